@@ -0,0 +1,59 @@
+package main
+
+import (
+	"crypto/subtle"
+	"expvar"
+	"fmt"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// apiController registers its routes in the api
+type apiController interface {
+	Register(*http.ServeMux)
+}
+
+// serveAPI of the bot in the background, if configured. The API allows us to operate the bot while it runs, so
+// don't expose it publicly: bind it to localhost (or a private network). It always requires a token.
+func serveAPI(conf *Config, controllers ...apiController) {
+	if len(conf.API.Address) == 0 {
+		return
+	}
+
+	// the api moves funds (eg. the panic sell) and changes the targets, so it's never served without auth
+	token := mustSecret(conf.API.Token)
+	if len(token) == 0 {
+		panic("api.address is set without an api.token, set one to serve the api")
+	}
+
+	mux := http.NewServeMux()
+	for _, c := range controllers {
+		c.Register(mux)
+	}
+	mux.Handle("/debug/vars", expvar.Handler()) // metrics
+
+	srv := &http.Server{
+		Addr:    conf.API.Address,
+		Handler: newAPIAuth(token, mux),
+	}
+	go func() {
+		log.Info(fmt.Sprintf("serving api at %s", conf.API.Address))
+		if err := srv.ListenAndServe(); err != nil {
+			panic(err)
+		}
+	}()
+}
+
+// newAPIAuth requires the token as a bearer in every request
+func newAPIAuth(token []byte, next http.Handler) http.Handler {
+	expected := append([]byte("Bearer "), token...)
+	wipe(token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
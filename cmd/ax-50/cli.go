@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/controller"
+	"github.com/saantiaguilera/liquidity-sniper/pkg/service"
+)
+
+const cliUsage = `usage: ax-50 target <command>
+       ax-50 tui [-interval 1s]
+       ax-50 log [level <level> | module <module> [level]]
+       ax-50 portfolio
+       ax-50 reserves <token> [-csv]
+       ax-50 panic [-y]
+       ax-50 secrets encrypt <env file> <encrypted file>
+       ax-50 config migrate
+       ax-50 validate
+
+commands:
+  list                    list the targets
+  get <name>              get a target
+  add [flags]             add a target for the instance with the given name
+  update [flags]          update the target of the instance with the given name
+  arm <name>              arm a target, so it gets sniped
+  disarm <name>           disarm a target, so it's ignored
+  delete <name>           delete a target, leaving its instance idle
+  profit <name> <amount>  realize a profit of a target (eg. after selling), reinvesting part of it into its budget
+
+portfolio lists the tokens held across the bees and the configured wallets, with their value and unrealized PnL.
+
+reserves exports the reserves recorded of a sniped token (see reserves in the config), oldest first. With -csv it's
+printed as csv, eg. to evaluate exit strategies in a spreadsheet.
+
+panic market sells every token held across the bees and the panic wallets right away, accepting any price and paying
+a multiple of the network gas (see panic in the config). It asks for confirmation unless -y is given. Wallets without
+keys (eg. portfolio wallets that aren't panic ones) are listed with an error, sell them by hand.
+
+log gets or sets the log level while the bot runs (crit, error, warn, info, debug or trace). A module (decoder, gas
+or execution) can log more verbosely than the rest, without a level it's reset to the level of the rest.
+
+tui shows the targets, positions (with their PnL), incoming candidates and latest decisions of the bot, refreshing
+them until interrupted.
+
+validate checks the config against the chain without sending anything (the nodes answer, the contracts and tokens
+have code, the trigger holds the order and the bees the gas of a snipe tx) and prints the plan of each instance: what
+it skips and how it snipes once armed. It fails if any check does, so it can gate a deployment.
+
+config migrate upgrades the config file to the latest version of its format, keeping the old one alongside (eg.
+local.json.v1.bak). Old configs are also migrated in memory on every start, with a warning.
+
+secrets encrypt encrypts an env file (NAME=value lines) with the passphrase in AX50_SECRETS_KEY, so config values can
+reference its secrets as env:NAME.
+
+flags of add / update (amounts in units of the paired token, eg 1.5, its decimals are asked to the snipe node):
+`
+
+// runCLI against the api of a running bot, configured in the same config file
+func runCLI(conf *Config, args []string) error {
+	if len(args) > 0 && args[0] == "secrets" {
+		if len(args) != 4 || args[1] != "encrypt" {
+			return usageError(nil)
+		}
+		return encryptSecretsFile(args[2], args[3])
+	}
+	if len(args) > 0 && args[0] == "config" {
+		if len(args) != 2 || args[1] != "migrate" {
+			return usageError(nil)
+		}
+		return runConfigMigrate(conf)
+	}
+	if len(args) == 1 && args[0] == "validate" {
+		return runValidate(conf)
+	}
+	if len(conf.API.Address) == 0 {
+		return fmt.Errorf("api isn't configured, set api.address in the config")
+	}
+	conf.API.Token = mustSecretString(conf.API.Token) // once, the tui calls the api on every refresh
+	if len(args) > 0 && args[0] == "tui" {
+		return runTUI(conf, args[1:])
+	}
+	if len(args) > 0 && args[0] == "log" {
+		return runLogCLI(conf, args[1:])
+	}
+	if len(args) == 1 && args[0] == "portfolio" {
+		return callAPI(conf, http.MethodGet, fmt.Sprintf("http://%s/portfolio", conf.API.Address), nil)
+	}
+	if len(args) > 0 && args[0] == "reserves" {
+		return runReservesCLI(conf, args[1:])
+	}
+	if len(args) > 0 && args[0] == "panic" {
+		return runPanicCLI(conf, args[1:])
+	}
+	if len(args) < 2 || args[0] != "target" {
+		return usageError(nil)
+	}
+
+	url := fmt.Sprintf("http://%s/targets", conf.API.Address)
+	cmd, args := args[1], args[2:]
+	switch cmd {
+	case "list":
+		return callAPI(conf, http.MethodGet, url, nil)
+	case "get", "arm", "disarm", "delete":
+		if len(args) != 1 {
+			return usageError(nil)
+		}
+		switch cmd {
+		case "get":
+			return callAPI(conf, http.MethodGet, url+"/"+args[0], nil)
+		case "delete":
+			return callAPI(conf, http.MethodDelete, url+"/"+args[0], nil)
+		default:
+			return callAPI(conf, http.MethodPost, url+"/"+args[0]+"/"+cmd, nil)
+		}
+	case "profit":
+		if len(args) != 2 {
+			return usageError(nil)
+		}
+		amount, err := parseEther(args[1])
+		if err != nil || amount == nil {
+			return fmt.Errorf("invalid amount %s: %v", args[1], err)
+		}
+		return callAPI(conf, http.MethodPost, url+"/"+args[0]+"/profit", controller.ProfitBody{Amount: amount})
+	case "add", "update":
+		fs := newTargetFlagSet()
+		if err := fs.Parse(args); err != nil {
+			return usageError(fs)
+		}
+		b, err := fs.body(conf)
+		if err != nil {
+			return err
+		}
+		if cmd == "add" {
+			return callAPI(conf, http.MethodPost, url, b)
+		}
+		return callAPI(conf, http.MethodPut, url+"/"+b.Name, b)
+	default:
+		return usageError(nil)
+	}
+}
+
+type (
+	targetFlagSet struct {
+		*flag.FlagSet
+
+		name, trigger, token, paired    *string
+		minLiquidity, orderSize, budget *string
+		gasLimit                        *uint64
+		armed                           *bool
+	}
+)
+
+func newTargetFlagSet() *targetFlagSet {
+	fs := flag.NewFlagSet("target", flag.ContinueOnError)
+	return &targetFlagSet{
+		FlagSet:      fs,
+		name:         fs.String("name", "", "name of the instance running the target"),
+		trigger:      fs.String("trigger", "", "address of the trigger contract"),
+		token:        fs.String("token", "", "address of the token to snipe"),
+		paired:       fs.String("paired", "", "address of the paired token in the LP, eg WBNB"),
+		minLiquidity: fs.String("min-liquidity", "0", "minimum liquidity of the paired token expected"),
+		orderSize:    fs.String("order-size", "", "order size of the paired token spent on each snipe"),
+		budget:       fs.String("budget", "", "max amount of the paired token spent across snipes"),
+		gasLimit:     fs.Uint64("gas-limit", 0, "gas limit of the snipe txs, 0 estimates it on each snipe"),
+		armed:        fs.Bool("armed", false, "arm the target right away (only on add)"),
+	}
+}
+
+func (fs *targetFlagSet) body(conf *Config) (*controller.TargetBody, error) {
+	if len(*fs.name) == 0 {
+		return nil, fmt.Errorf("name is required")
+	}
+	b := &controller.TargetBody{
+		Name:     *fs.name,
+		Trigger:  *fs.trigger,
+		Token:    *fs.token,
+		Paired:   *fs.paired,
+		GasLimit: *fs.gasLimit,
+		Armed:    *fs.armed,
+	}
+
+	// the min liquidity is an amount of the paired token, which may not have 18 decimals. The order is always spent
+	// in the native coin
+	var err error
+	decimals := uint8(18)
+	if len(b.Paired) > 0 && *fs.minLiquidity != "0" && len(*fs.minLiquidity) > 0 {
+		if decimals, err = tokenDecimals(conf, b.Paired); err != nil {
+			return nil, err
+		}
+	}
+	if b.MinLiquidity, err = parseAmount(*fs.minLiquidity, decimals); err != nil {
+		return nil, err
+	}
+	if b.OrderSize, err = parseEther(*fs.orderSize); err != nil {
+		return nil, err
+	}
+	if b.Budget, err = parseEther(*fs.budget); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// parseEther amount to wei. Empty amounts are nil.
+func parseEther(v string) (*big.Int, error) {
+	return parseAmount(v, 18)
+}
+
+// parseAmount to the smallest unit of a token with the given decimals. Empty amounts are nil.
+func parseAmount(v string, decimals uint8) (*big.Int, error) {
+	if len(v) == 0 {
+		return nil, nil
+	}
+	f, ok := new(big.Float).SetPrec(256).SetString(v)
+	if !ok || f.Sign() < 0 {
+		return nil, fmt.Errorf("invalid amount '%s'", v)
+	}
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	amount, _ := f.Mul(f, new(big.Float).SetPrec(256).SetInt(unit)).Int(nil)
+	return amount, nil
+}
+
+// tokenDecimals of the token, asking the snipe node
+func tokenDecimals(conf *Config, token string) (uint8, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c, err := dialRPC(ctx, conf, conf.Chains.Nodes.Snipe)
+	if err != nil {
+		return 0, fmt.Errorf("error dialing the snipe node: %s", err)
+	}
+	defer c.Close()
+	return service.NewTokenDecimals(ethclient.NewClient(c)).Of(ctx, common.HexToAddress(token))
+}
+
+func callAPI(conf *Config, method, url string, body interface{}) error {
+	b, res, err := requestAPI(conf, method, url, body)
+	if err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	if len(b) > 0 && json.Indent(&out, b, "", "  ") == nil {
+		b = out.Bytes()
+	}
+	fmt.Println(strings.TrimSpace(string(b)))
+	if res.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("api responded %s", res.Status)
+	}
+	return nil
+}
+
+// requestAPI returning the body of its response
+func requestAPI(conf *Config, method, url string, body interface{}) ([]byte, *http.Response, error) {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, nil, err
+		}
+		r = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, url, r)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(conf.API.Token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+conf.API.Token)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error calling the api, is the bot running? %s", err)
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b, res, nil
+}
+
+func runLogCLI(conf *Config, args []string) error {
+	url := fmt.Sprintf("http://%s/log", conf.API.Address)
+	switch {
+	case len(args) == 0:
+		return callAPI(conf, http.MethodGet, url, nil)
+	case len(args) == 2 && args[0] == "level":
+		return callAPI(conf, http.MethodPut, url, controller.LogBody{Level: args[1]})
+	case (len(args) == 2 || len(args) == 3) && args[0] == "module":
+		level := ""
+		if len(args) == 3 {
+			level = args[2]
+		}
+		return callAPI(conf, http.MethodPut, url, controller.LogBody{Modules: map[string]string{args[1]: level}})
+	default:
+		return usageError(nil)
+	}
+}
+
+// runReservesCLI exporting the reserve series of a token
+func runReservesCLI(conf *Config, args []string) error {
+	url := fmt.Sprintf("http://%s/reserves/", conf.API.Address)
+	switch {
+	case len(args) == 1:
+		return callAPI(conf, http.MethodGet, url+args[0], nil)
+	case len(args) == 2 && args[1] == "-csv":
+		return callAPI(conf, http.MethodGet, url+args[0]+"?format=csv", nil)
+	default:
+		return usageError(nil)
+	}
+}
+
+// runPanicCLI selling everything, once confirmed
+func runPanicCLI(conf *Config, args []string) error {
+	switch {
+	case len(args) == 1 && args[0] == "-y":
+	case len(args) == 0:
+		fmt.Print("sell EVERYTHING held at any price? [y/n]: ")
+		var answer string
+		if _, err := fmt.Scanln(&answer); err != nil || strings.ToLower(answer) != "y" {
+			return fmt.Errorf("panic sell cancelled")
+		}
+	default:
+		return usageError(nil)
+	}
+	return callAPI(conf, http.MethodPost, fmt.Sprintf("http://%s/panic", conf.API.Address), nil)
+}
+
+func usageError(fs *targetFlagSet) error {
+	fmt.Fprint(os.Stderr, cliUsage)
+	if fs == nil {
+		fs = newTargetFlagSet()
+	}
+	fs.SetOutput(os.Stderr)
+	fs.PrintDefaults()
+	return fmt.Errorf("invalid command")
+}
@@ -2,9 +2,12 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
+	"reflect"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
 )
 
 const (
@@ -21,32 +24,173 @@ const (
 	SniperModeBlockScan SniperMode = "new_blocks"
 )
 
+// defaultInstanceName of the sniper when there's a single one and it isn't named
+const defaultInstanceName = "default"
+
 type (
 	Address    string
 	SniperMode string
 
 	Config struct {
-		Chains    ChainContainer `json:"chain"`
-		Contracts Contracts      `json:"contract"`
-		Tokens    Tokens         `json:"token"`
-		Sniper    Sniper         `json:"sniper"`
+		// Version of the config format, see 'ax-50 config migrate'
+		Version   uint              `json:"version"`
+		Name      string            `json:"name"`
+		Chains    ChainContainer    `json:"chain"`
+		Order     Order             `json:"order"`
+		Contracts Contracts         `json:"contract"`
+		Tokens    Tokens            `json:"token"`
+		BeeBook   string            `json:"bee_book"`
+		Sniper    Sniper            `json:"sniper"`
+		Instances []json.RawMessage `json:"instances"`
+		API       API               `json:"api"`
+		Storage   Storage           `json:"storage"`
+		Alerts    Alerts            `json:"alerts"`
+		Portfolio Portfolio         `json:"portfolio"`
+		Reserves  Reserves          `json:"reserves"`
+		Markets   Markets           `json:"markets"`
+		Panic     Panic             `json:"panic"`
+		DeadMan   DeadMan           `json:"dead_man"`
+		Telegram  Telegram          `json:"telegram"`
+		// RateLimits of the external providers we call, by name (eg. the name of a relay)
+		RateLimits map[string]RateLimit `json:"rate_limits"`
+
+		raw  []byte
+		path string
+	}
+
+	RateLimit struct {
+		Rate  float64 `json:"rate"`
+		Burst int     `json:"burst"`
+		Quota uint    `json:"quota"`
+	}
+
+	Storage struct {
+		Postgres      string `json:"postgres"`
+		DecisionsPath string `json:"decisions_path"`
+	}
+
+	Portfolio struct {
+		Wallets []Address `json:"wallets"`
+	}
+
+	// Reserves of the sniped tokens recorded over time, see 'ax-50 reserves'
+	Reserves struct {
+		// Interval in seconds between samples
+		Interval uint `json:"interval"`
+		// After is how many hours a token is still recorded once we exited it
+		After uint `json:"after"`
+	}
+
+	// Markets of the sniped tokens from the pair trackers, for notifications and the dashboard
+	Markets struct {
+		// Dexscreener name of the chain (eg. bsc), empty disables them
+		Dexscreener string `json:"dexscreener"`
+		// Dextools name of the chain (eg. bnb) to link its charts, empty doesn't
+		Dextools string `json:"dextools"`
+		// Interval in seconds between refreshes
+		Interval uint `json:"interval"`
+	}
+
+	// Panic sells everything held at once, see 'ax-50 panic'
+	Panic struct {
+		// GasMultiplier of the network median gas price the sells pay
+		GasMultiplier float64 `json:"gas_multiplier"`
+		// Wallets to sell from besides the bees, as private keys (or references to them). eg. the admin
+		Wallets []string `json:"wallets"`
+	}
+
+	// Telegram bot the operators command the bot through (eg. the panic sell)
+	Telegram struct {
+		// Token of the bot, or a reference to it. Empty disables it
+		Token string `json:"token"`
+		// Chat whose commands are run, the others are ignored
+		Chat int64 `json:"chat"`
+	}
+
+	// DeadMan switch, for when our nodes stop answering
+	DeadMan struct {
+		Enabled bool `json:"enabled"`
+		// Threshold is how many seconds none of our nodes answers before it trips
+		Threshold uint `json:"threshold"`
+		// Fire the emergency sells of everything held through the rebroadcast nodes and the relays once it trips
+		Fire bool `json:"fire"`
+		// BlockTime of the chain in milliseconds, to guess the head without our nodes. Deprecated, see chain.block_time
+		BlockTime uint `json:"block_time"`
+	}
+
+	Alerts struct {
+		Webhook   string      `json:"webhook"`
+		Price     PriceAlerts `json:"price"`
+		Heartbeat Heartbeat   `json:"heartbeat"`
+	}
+
+	Heartbeat struct {
+		// Interval in seconds between heartbeats, zero disables them
+		Interval uint `json:"interval"`
+		// Check is the url (or secret reference) of an external check pinged on every heartbeat
+		Check string `json:"check"`
+	}
+
+	PriceAlerts struct {
+		Multiples []float64 `json:"multiples"`
+		Interval  uint      `json:"interval"`
+	}
+
+	API struct {
+		Address string `json:"address"`
+		Token   string `json:"token"`
 	}
 
 	ChainContainer struct {
 		Nodes ChainNodes `json:"nodes"`
 		ID    uint       `json:"id"`
 		Name  string     `json:"name"`
+		// BlockTime of the chain in milliseconds, the one of the chain id if we know it unless configured
+		BlockTime uint `json:"block_time"`
 	}
 
 	ChainNodes struct {
 		Stream string `json:"stream"`
 		Snipe  string `json:"snipe"`
+		// FullPendingTxs if the stream node notifies whole pending txs instead of their hashes
+		FullPendingTxs bool `json:"full_pending_txs"`
+		// Rebroadcast are alternate rpcs our own txs are sent again through if they vanish from the mempool
+		Rebroadcast []string `json:"rebroadcast"`
+		// Keys of the rpc providers by name, each node url has the name of its provider between braces where the key goes
+		Keys map[string][]string `json:"keys"`
+	}
+
+	Order struct {
+		// Mode of the buys, exact_in (spending the size for at least the expected tokens) or exact_out (buying the
+		// expected tokens for at most the size). Defaults to exact_in.
+		Mode           string  `json:"mode"`
+		Size           float64 `json:"size"`
+		ExpectedTokens float64 `json:"expected_tokens"`
+		// Jitter is the ± percentage the order size (and its expected tokens) vary each time the trigger is configured
+		Jitter float64 `json:"jitter"`
 	}
 
 	Contracts struct {
 		Trigger Address `json:"trigger"`
 		Factory Address `json:"factory"`
 		Router  Address `json:"router"`
+		// SwapFee % the v2 pairs of the router charge (eg. 0.3 for uniswap, 0.25 for pancake). Zero means pancake's.
+		SwapFee float64 `json:"swap_fee"`
+		// PositionManager of the v3 pools, only needed to follow liquidity migrations
+		PositionManager Address `json:"position_manager"`
+		// PriceFeed of the native coin in USD (a chainlink aggregator), only needed to report the trades in USD
+		PriceFeed Address `json:"price_feed"`
+		// Explorer the abi of the router is fetched from, only needed if the router isn't a uniswap v2 fork as is
+		Explorer Explorer `json:"explorer"`
+	}
+
+	// Explorer api, etherscan compatible (eg. https://api.bscscan.com/api)
+	Explorer struct {
+		API string `json:"api"`
+		// Key of the api, or a reference to it
+		Key string `json:"key"`
+		// Cache directory of the abis fetched, abi inside the config folder if missing
+		Cache string `json:"cache"`
 	}
 
 	Tokens struct {
@@ -56,9 +200,101 @@ type (
 	}
 
 	Sniper struct {
-		Mode         SniperMode `json:"mode"`
-		MinLiquidity float32    `json:"minimum_liquidity"`
-		Monitors     Monitors   `json:"monitors"`
+		Mode           SniperMode   `json:"mode"`
+		MinLiquidity   float32      `json:"minimum_liquidity"`
+		Budget         float64      `json:"budget"`
+		ValidateVictim bool         `json:"validate_victim"`
+		MaxDeadline    uint         `json:"max_deadline"`
+		FillTolerance  float64      `json:"fill_tolerance"`
+		ReentryBlocks  uint         `json:"reentry_blocks"`
+		ShedBacklog    uint         `json:"shed_backlog"`
+		Gas            Gas          `json:"gas"`
+		Submission     Submission   `json:"submission"`
+		CommitReveal   CommitReveal `json:"commit_reveal"`
+		QuietHours     QuietHours   `json:"quiet_hours"`
+		Reinvest       Reinvest     `json:"reinvest"`
+		Valuation      Valuation    `json:"valuation"`
+		Migration      Migration    `json:"migration"`
+		Slippage       Slippage     `json:"slippage"`
+		Sandwich       Sandwich     `json:"sandwich"`
+		Monitors       Monitors     `json:"monitors"`
+
+		// ConstructorLiquidity snipes the deploy of the token if its constructor adds the liquidity
+		ConstructorLiquidity bool `json:"constructor_liquidity"`
+	}
+
+	Slippage struct {
+		Adaptive bool `json:"adaptive"`
+		// Buffer is the % below the simulated amount out of each snipe we accept
+		Buffer float64 `json:"buffer"`
+		// Max is the % below the amount out of our buy alone we accept behind the competing buys. Zero means no max.
+		Max float64 `json:"max"`
+	}
+
+	Sandwich struct {
+		Enabled bool `json:"enabled"`
+		// MaxExposure of the paired token a sandwich may take from a snipe in the worst case. Zero means no max.
+		MaxExposure float64 `json:"max_exposure"`
+		// Action above the max exposure: private, reduce or empty to only warn
+		Action string `json:"action"`
+	}
+
+	Migration struct {
+		Enabled bool `json:"enabled"`
+		Enter   bool `json:"enter"`
+		// Window is how many seconds after a v2 removal a v3 mint is a migration
+		Window    uint `json:"window"`
+		PairedFee uint `json:"paired_fee"`
+	}
+
+	// Valuation bands of the launches, in units of the paired token
+	Valuation struct {
+		MinMarketCap float64 `json:"min_market_cap"`
+		MaxMarketCap float64 `json:"max_market_cap"`
+		MinFDV       float64 `json:"min_fdv"`
+		MaxFDV       float64 `json:"max_fdv"`
+		// MinLiquidityRatio is the min % of the fdv the liquidity added must be
+		MinLiquidityRatio float64 `json:"min_liquidity_ratio"`
+	}
+
+	Reinvest struct {
+		Percentage uint    `json:"percentage"`
+		MaxBudget  float64 `json:"max_budget"`
+	}
+
+	QuietHours struct {
+		Windows  []string `json:"windows"`
+		Timezone string   `json:"timezone"`
+	}
+
+	CommitReveal struct {
+		Enabled bool   `json:"enabled"`
+		Salt    string `json:"salt"`
+	}
+
+	Submission struct {
+		Relays          []Relay `json:"relays"`
+		FallbackGasBump uint    `json:"fallback_gas_bump"`
+		Concurrent      bool    `json:"concurrent"`
+		// StealthDelay is the most milliseconds each bee randomly waits before broadcasting when we aren't racing
+		StealthDelay uint `json:"stealth_delay"`
+		// NonceReconcile is every how many seconds the swarm nonces are checked against the chain
+		NonceReconcile uint `json:"nonce_reconcile"`
+	}
+
+	Relay struct {
+		Name    string `json:"name"`
+		URL     string `json:"url"`
+		AuthKey string `json:"auth_key"`
+	}
+
+	Gas struct {
+		MaxMultiplier float64   `json:"max_multiplier"`
+		MinMultiplier float64   `json:"min_multiplier"`
+		PresignLevels []float64 `json:"presign_levels"`
+		Limit         uint64    `json:"limit"`
+		LimitBuffer   uint      `json:"limit_buffer"`
+		AccessList    bool      `json:"access_list"`
 	}
 
 	Monitors struct {
@@ -87,13 +323,67 @@ func NewConfigFromFile(f string) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
-	c := &Config{}
-	if err = json.Unmarshal(b, c); err != nil {
+	// old configs are migrated in memory, so they keep working until they are upgraded for good
+	migrated, applied, err := migrateConfig(b)
+	if err != nil {
+		return nil, err
+	}
+	if len(applied) > 0 {
+		log.Warn(fmt.Sprintf(
+			"config %s is version %d, migrated it in memory to %d. Run 'ax-50 config migrate' to upgrade it",
+			f, configVersion-uint(len(applied)), configVersion,
+		))
+	}
+	c := &Config{raw: migrated, path: f}
+	if err = json.Unmarshal(migrated, c); err != nil {
 		return nil, err
 	}
 	return c, nil
 }
 
+// InstanceConfigs of the isolated snipers we should run in this process. Each instance inherits the whole configuration
+// and overrides whatever it defines (eg. its own trigger, target token, bee book and budget).
+// If no instances are defined, the configuration itself is the only one.
+func (c *Config) InstanceConfigs() ([]*Config, error) {
+	if len(c.Instances) == 0 {
+		if len(c.Name) == 0 {
+			c.Name = defaultInstanceName
+		}
+		return []*Config{c}, nil
+	}
+
+	res := make([]*Config, len(c.Instances))
+	for i, raw := range c.Instances {
+		ic := &Config{raw: c.raw, path: c.path}
+		if err := json.Unmarshal(c.raw, ic); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, ic); err != nil {
+			return nil, fmt.Errorf("error parsing instance %d: %s", i, err)
+		}
+		ic.Instances = nil
+		if len(ic.Name) == 0 {
+			ic.Name = fmt.Sprintf("instance-%d", i)
+		}
+		// they share the mempool stream, which is set up once for the chain, mode and router of the top level
+		switch {
+		case !reflect.DeepEqual(ic.Chains, c.Chains):
+			return nil, fmt.Errorf("instance %s can't override the chain", ic.Name)
+		case ic.Sniper.Mode != c.Sniper.Mode:
+			return nil, fmt.Errorf("instance %s can't override the sniper mode", ic.Name)
+		case ic.Contracts.Router != c.Contracts.Router || ic.Contracts.SwapFee != c.Contracts.SwapFee ||
+			ic.Contracts.Explorer != c.Contracts.Explorer:
+			return nil, fmt.Errorf("instance %s can't override the router", ic.Name)
+		case ic.Contracts.PositionManager != c.Contracts.PositionManager:
+			return nil, fmt.Errorf("instance %s can't override the position manager", ic.Name)
+		case ic.Contracts.PriceFeed != c.Contracts.PriceFeed:
+			return nil, fmt.Errorf("instance %s can't override the price feed", ic.Name)
+		}
+		res[i] = ic
+	}
+	return res, nil
+}
+
 func (a Address) Addr() common.Address {
 	if len(a) == 0 {
 		panic("empty address")
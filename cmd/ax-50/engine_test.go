@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/controller"
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+	"github.com/saantiaguilera/liquidity-sniper/pkg/service"
+	"github.com/saantiaguilera/liquidity-sniper/pkg/usecase"
+)
+
+// Benchmarks of the whole mempool pipeline (subscribe -> resolve -> decode -> filter), see PERFORMANCE.md.
+// The node is replaced by an in-process rpc server, so numbers only account for our own processing.
+
+const (
+	// benchmarkTxPool is the number of distinct txs we cycle through
+	benchmarkTxPool = 4096
+	// benchmarkRouterShare is 1/N of the txs that go to the router, the rest are unrelated txs
+	benchmarkRouterShare = 10
+	// benchmarkMaxInFlight txs notified and not yet processed, so we never overflow the client subscription buffer
+	benchmarkMaxInFlight = 5000
+)
+
+var (
+	benchmarkRouter = common.HexToAddress("0x10ED43C718714eb63d5aA57B78B54704E256024E")
+	benchmarkTarget = common.HexToAddress("0x8f5d3374373aDA8b2c201C5cAc4c384FD42d2390")
+	benchmarkWBNB   = common.HexToAddress("0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c")
+)
+
+type (
+	benchmarkEthAPI struct {
+		txs       []*types.Transaction
+		n         int
+		processed *int64
+	}
+
+	benchmarkResolver struct {
+		txs map[common.Hash]*types.Transaction
+	}
+)
+
+func (api *benchmarkEthAPI) NewPendingTransactions(ctx context.Context, fullTx *bool) (*rpc.Subscription, error) {
+	notifier, ok := rpc.NotifierFromContext(ctx)
+	if !ok {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	go func() {
+		for i := 0; i < api.n; i++ {
+			for int64(i)-atomic.LoadInt64(api.processed) > benchmarkMaxInFlight {
+				runtime.Gosched()
+			}
+			var v interface{} = api.txs[i%len(api.txs)].Hash()
+			if fullTx != nil && *fullTx {
+				v = api.txs[i%len(api.txs)]
+			}
+			if err := notifier.Notify(sub.ID, v); err != nil {
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
+
+func (r *benchmarkResolver) TransactionByHash(_ context.Context, h common.Hash) (*types.Transaction, bool, error) {
+	if tx, ok := r.txs[h]; ok {
+		return tx, true, nil
+	}
+	return nil, false, ethereum.NotFound
+}
+
+func newBenchmarkTxs(b *testing.B) map[common.Hash]*types.Transaction {
+	pk, err := crypto.GenerateKey()
+	if err != nil {
+		b.Fatal(err)
+	}
+	signer := types.NewEIP155Signer(big.NewInt(56))
+	e18, _ := new(big.Int).SetString("1000000000000000000", 10)
+
+	txs := make(map[common.Hash]*types.Transaction, benchmarkTxPool)
+	for i := 0; i < benchmarkTxPool; i++ {
+		to := common.BigToAddress(big.NewInt(int64(i + 1)))
+		var data []byte
+		if i%benchmarkRouterShare == 0 {
+			// addLiquidityETH of a token that isn't our target, it has to go through the whole filter chain
+			to = benchmarkRouter
+			data = append([]byte{0xf3, 0x05, 0xd7, 0x19}, common.LeftPadBytes(common.BigToAddress(big.NewInt(int64(i))).Bytes(), 32)...)
+			for _, w := range []*big.Int{e18, e18, e18, big.NewInt(0), big.NewInt(1700000000)} {
+				data = append(data, common.LeftPadBytes(w.Bytes(), 32)...)
+			}
+		}
+		tx, err := types.SignTx(types.NewTransaction(uint64(i), to, e18, 21000, big.NewInt(5000000000), data), signer, pk)
+		if err != nil {
+			b.Fatal(err)
+		}
+		txs[tx.Hash()] = tx
+	}
+	return txs
+}
+
+func newBenchmarkLanes(b *testing.B) *usecase.TransactionLanes {
+	sn := domain.NewSniper(benchmarkRouter.Hex(), benchmarkWBNB.Hex(), benchmarkTarget.Hex(), big.NewInt(1), big.NewInt(56))
+	sn.Armed = true
+	uni, err := service.NewUniswapLiquidity(service.NewEthClientCluster(), nil, service.NewSenderCache(senderCacheSize), service.NewDecisionHistory(decisionHistorySize, nil), sn, true, false, 0, domain.ValuationBands{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	strats := map[[4]byte]usecase.TransactionClassifierStrategy{
+		{0xf3, 0x05, 0xd7, 0x19}: uni.AddETH,
+		{0xe8, 0xe3, 0x37, 0x00}: uni.Add,
+	}
+	uc := usecase.NewTransactionClassifier(benchmarkRouter.Hex(), service.NewMonitorEngine().Monitor, strats)
+	return usecase.NewTransactionLanes(uc.Classify, uc.Classify, slowLaneWorkers, slowLaneQueue, benchmarkRouter.Hex())
+}
+
+func benchmarkPendingTxsPipeline(b *testing.B, workers int, full bool) {
+	txs := newBenchmarkTxs(b)
+	pool := make([]*types.Transaction, 0, len(txs))
+	for _, tx := range txs {
+		pool = append(pool, tx)
+	}
+
+	var processed int64
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", &benchmarkEthAPI{txs: pool, n: b.N, processed: &processed}); err != nil {
+		b.Fatal(err)
+	}
+	cli := rpc.DialInProc(server)
+	defer cli.Close()
+
+	ctx, canc := context.WithCancel(context.Background())
+	defer canc()
+
+	lanes := newBenchmarkLanes(b)
+	ctrl := controller.NewPendingTransaction(&benchmarkResolver{txs: txs}, lanes.Dispatch)
+	var sub controller.EngineSubscription = func(ctx context.Context, c *rpc.Client, ch chan<- interface{}) (*rpc.ClientSubscription, error) {
+		return c.EthSubscribe(ctx, ch, "newPendingTransactions")
+	}
+	if full {
+		sub = controller.SubscribeFullPendingTxs
+	}
+	engine := controller.NewEngine(
+		cli,
+		workers,
+		0,
+		sub,
+		func(ctx context.Context) context.Context { return ctx },
+		func(ctx context.Context, v interface{}) error {
+			var err error
+			if tx, ok := v.(*types.Transaction); ok {
+				err = lanes.Dispatch(ctx, tx)
+			} else {
+				err = ctrl.Snipe(ctx, common.HexToHash(v.(string)))
+			}
+			if atomic.AddInt64(&processed, 1) == int64(b.N) {
+				canc()
+			}
+			return err
+		},
+	)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	start := time.Now()
+	engine.Run(ctx)
+	b.StopTimer()
+	b.ReportMetric(float64(b.N)/time.Since(start).Seconds(), "txs/s")
+}
+
+func BenchmarkEngine_PendingTxsPipeline(b *testing.B) {
+	for _, w := range []int{1, 10, 100, 1000} {
+		b.Run(fmt.Sprintf("workers=%d", w), func(b *testing.B) {
+			benchmarkPendingTxsPipeline(b, w, false)
+		})
+	}
+}
+
+func BenchmarkEngine_FullPendingTxsPipeline(b *testing.B) {
+	for _, w := range []int{1, 10, 100, 1000} {
+		b.Run(fmt.Sprintf("workers=%d", w), func(b *testing.B) {
+			benchmarkPendingTxsPipeline(b, w, true)
+		})
+	}
+}
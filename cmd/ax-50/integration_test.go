@@ -0,0 +1,447 @@
+//go:build integration
+// +build integration
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+	"github.com/saantiaguilera/liquidity-sniper/third_party/erc20"
+	"github.com/saantiaguilera/liquidity-sniper/third_party/pancake"
+)
+
+// The integration suite runs a whole cycle of ax-50 on a testnet: it launches a throwaway token, deploys the trigger
+// and its router, starts the bot, adds the liquidity and checks the snipe landed, then panic sells it and checks
+// it's gone. It's opt-in, as it spends testnet coins and takes a few minutes:
+//
+//	npx truffle compile
+//	AX50_IT_RPC=wss://... AX50_IT_KEY=0x... go test -tags integration -count=1 -timeout 30m -run Integration ./cmd/ax-50
+//
+// See TESTNET_SETUP.md for the rest of the variables.
+const (
+	// itRPCEnv is the node of the testnet, a websocket one in pending_txs mode
+	itRPCEnv = "AX50_IT_RPC"
+	// itKeyEnv is the private key of the wallet paying for everything, it's the admin of the trigger
+	itKeyEnv = "AX50_IT_KEY"
+	// itFaucetEnv is an optional faucet api topping up the wallet, posted {"address": "0x..."}
+	itFaucetEnv = "AX50_IT_FAUCET"
+	// itModeEnv is the sniper mode, pending_txs unless set
+	itModeEnv = "AX50_IT_MODE"
+	// itFactoryEnv, itRouterEnv and itWETHEnv are the AMM fixtures, for testnets without defaults (eg. sepolia)
+	itFactoryEnv = "AX50_IT_FACTORY"
+	itRouterEnv  = "AX50_IT_ROUTER"
+	itWETHEnv    = "AX50_IT_WETH"
+
+	// itArtifacts of the contracts, compiled by truffle
+	itArtifacts = "../../build/contracts"
+	// itBlocks we wait for each step of the cycle
+	itBlocks = 20
+	// itFaucetTimeout we wait for the faucet to fund the wallet
+	itFaucetTimeout = 5 * time.Minute
+)
+
+var (
+	// itFixtures of the AMM on each testnet by chain id. The router of the trigger reads the init code hash of the
+	// pairs from the factory, so it must be a pancake like one (uniswap's doesn't expose it).
+	itFixtures = map[uint64]itFixture{
+		97: { // bsc testnet
+			factory: common.HexToAddress("0x6725F303b657a9451d8BA641348b6761A6CC7a17"),
+			router:  common.HexToAddress("0xD99D1c33F9fC3444f8101754aBC46c52416550D1"),
+			weth:    common.HexToAddress("0xae13d989daC2f0dEbFf460aC112a837C89BAa7cd"),
+		},
+	}
+
+	itOrderSize = big.NewInt(1e16) // 0.01
+	itLiquidity = big.NewInt(5e16) // 0.05
+	itBeeFunds  = big.NewInt(2e16) // 0.02, for the gas of its snipe
+	itMinFunds  = big.NewInt(2e17) // 0.2, everything the cycle spends with room for the gas
+	itSupply    = new(big.Int).Exp(big.NewInt(10), big.NewInt(24), nil)
+)
+
+type (
+	itFixture struct {
+		factory, router, weth common.Address
+	}
+
+	// itTestnet the cycle runs on, failing the test if any step does
+	itTestnet struct {
+		t       *testing.T
+		ctx     context.Context
+		rpc     string
+		client  *ethclient.Client
+		chainID *big.Int
+		timing  domain.Timing
+		admin   *ecdsa.PrivateKey
+		fixture itFixture
+	}
+
+	itArtifact struct {
+		ABI      json.RawMessage `json:"abi"`
+		Bytecode string          `json:"bytecode"`
+	}
+)
+
+func TestIntegration_SnipeCycle(t *testing.T) {
+	n := newITTestnet(t)
+	adminAddr := crypto.PubkeyToAddress(n.admin.PublicKey)
+	n.fund(adminAddr, itMinFunds)
+
+	// the launch and the trigger, holding the order
+	token, tokenContract := n.deploy("ThrowawayToken", "Throwaway", "THROW", itSupply)
+	router, _ := n.deploy("CustomRouter", n.fixture.factory, n.fixture.weth)
+	trigger, triggerContract := n.deploy("Trigger", n.fixture.weth)
+	n.transact(triggerContract, "setCustomRouter", router)
+	n.transfer(n.admin, trigger, itOrderSize) // wrapped by the trigger
+	n.transact(triggerContract, "configureSnipe", n.fixture.weth, itOrderSize, token, big.NewInt(1))
+
+	// a swarm of a single bee. ax-50 starts fresh bees at their second nonce, so its first one is spent
+	beeKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	beeAddr := crypto.PubkeyToAddress(beeKey.PublicKey)
+	n.transfer(n.admin, beeAddr, itBeeFunds)
+	n.transfer(beeKey, beeAddr, new(big.Int))
+
+	conf := n.config(token, trigger)
+	n.startBot(conf, []bee{{Address: beeAddr.Hex(), PK: hexutil.Encode(crypto.FromECDSA(beeKey))}})
+
+	// the launch, sniped by the bee into the admin
+	erc, err := erc20.NewErc20(token, n.client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.transact(tokenContract, "approve", n.fixture.router, itSupply)
+	pcs, err := pancake.NewIPancakeRouter02Transactor(n.fixture.router, n.client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := n.opts(n.admin)
+	opts.Value = itLiquidity
+	tx, err := pcs.AddLiquidityETH(opts, token, new(big.Int).Div(itSupply, big.NewInt(2)), big.NewInt(0), big.NewInt(0), adminAddr, big.NewInt(time.Now().Add(time.Hour).Unix()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.wait(tx)
+	launched := new(big.Int).Sub(itSupply, new(big.Int).Div(itSupply, big.NewInt(2)))
+
+	var sniped *big.Int
+	n.eventually("the snipe to land", func() (bool, error) {
+		held, err := erc.BalanceOf(&bind.CallOpts{Context: n.ctx}, adminAddr)
+		if err != nil {
+			return false, err
+		}
+		sniped = new(big.Int).Sub(held, launched)
+		return sniped.Sign() > 0, nil
+	})
+	wbnb, err := erc20.NewErc20(n.fixture.weth, n.client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if left, err := wbnb.BalanceOf(&bind.CallOpts{Context: n.ctx}, trigger); err != nil || left.Sign() != 0 {
+		t.Fatalf("expected the trigger to spend its whole order, it holds %v (%v)", left, err)
+	}
+	t.Logf("sniped %s of %s", sniped, token.Hex())
+
+	// the exit, selling everything held through the api of the bot
+	req, err := http.NewRequestWithContext(n.ctx, http.MethodPost, fmt.Sprintf("http://%s/panic", conf.API.Address), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+conf.API.Token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the panic sell to be accepted, got status %d", resp.StatusCode)
+	}
+	n.eventually("the panic sell to land", func() (bool, error) {
+		held, err := erc.BalanceOf(&bind.CallOpts{Context: n.ctx}, adminAddr)
+		return err == nil && held.Sign() == 0, err
+	})
+}
+
+// newITTestnet from the environment, skipping the test if it isn't set
+func newITTestnet(t *testing.T) *itTestnet {
+	rpc, key := os.Getenv(itRPCEnv), os.Getenv(itKeyEnv)
+	if len(rpc) == 0 || len(key) == 0 {
+		t.Skipf("set %s and %s to run the integration suite", itRPCEnv, itKeyEnv)
+	}
+	ctx := context.Background()
+	client, err := ethclient.DialContext(ctx, rpc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fixture, ok := itFixtures[chainID.Uint64()]
+	if f := os.Getenv(itFactoryEnv); len(f) > 0 {
+		fixture = itFixture{
+			factory: common.HexToAddress(f),
+			router:  common.HexToAddress(os.Getenv(itRouterEnv)),
+			weth:    common.HexToAddress(os.Getenv(itWETHEnv)),
+		}
+		ok = true
+	}
+	if !ok {
+		t.Skipf("chain %s has no amm fixtures, set %s, %s and %s", chainID, itFactoryEnv, itRouterEnv, itWETHEnv)
+	}
+
+	return &itTestnet{
+		t:       t,
+		ctx:     ctx,
+		rpc:     rpc,
+		client:  client,
+		chainID: chainID,
+		timing:  domain.NewTiming(chainID, 0),
+		admin:   mustSecretKey(key),
+		fixture: fixture,
+	}
+}
+
+func (n *itTestnet) opts(key *ecdsa.PrivateKey) *bind.TransactOpts {
+	opts, err := bind.NewKeyedTransactorWithChainID(key, n.chainID)
+	if err != nil {
+		n.t.Fatal(err)
+	}
+	opts.Context = n.ctx
+	return opts
+}
+
+// wait for the tx to be mined, failing if it reverted
+func (n *itTestnet) wait(tx *types.Transaction) *types.Receipt {
+	n.t.Helper()
+	ctx, canc := context.WithTimeout(n.ctx, n.timing.Blocks(itBlocks))
+	defer canc()
+	r, err := bind.WaitMined(ctx, n.client, tx)
+	if err != nil {
+		n.t.Fatalf("tx %s wasn't mined: %s", tx.Hash().Hex(), err)
+	}
+	if r.Status != types.ReceiptStatusSuccessful {
+		n.t.Fatalf("tx %s reverted", tx.Hash().Hex())
+	}
+	return r
+}
+
+// eventually the condition holds, within the blocks of a step
+func (n *itTestnet) eventually(step string, cond func() (bool, error)) {
+	n.t.Helper()
+	deadline := time.Now().Add(n.timing.Blocks(itBlocks))
+	for time.Now().Before(deadline) {
+		ok, err := cond()
+		if ok {
+			return
+		}
+		if err != nil {
+			n.t.Logf("waiting for %s: %s", step, err)
+		}
+		time.Sleep(n.timing.Poll())
+	}
+	n.t.Fatalf("timeout waiting for %s", step)
+}
+
+// fund the wallet up to the given balance from the faucet, skipping the test if there's no faucet to do so
+func (n *itTestnet) fund(addr common.Address, min *big.Int) {
+	n.t.Helper()
+	balance := func() (bool, error) {
+		b, err := n.client.BalanceAt(n.ctx, addr, nil)
+		return err == nil && b.Cmp(min) >= 0, err
+	}
+	if ok, err := balance(); err != nil || ok {
+		if err != nil {
+			n.t.Fatal(err)
+		}
+		return
+	}
+	faucet := os.Getenv(itFaucetEnv)
+	if len(faucet) == 0 {
+		n.t.Skipf("%s holds less than %s wei, fund it or set %s", addr.Hex(), min, itFaucetEnv)
+	}
+
+	body, _ := json.Marshal(map[string]string{"address": addr.Hex()})
+	resp, err := http.Post(faucet, "application/json", bytes.NewReader(body))
+	if err != nil {
+		n.t.Fatalf("error calling the faucet: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		n.t.Fatalf("faucet answered status %d", resp.StatusCode)
+	}
+
+	deadline := time.Now().Add(itFaucetTimeout)
+	for time.Now().Before(deadline) {
+		if ok, _ := balance(); ok {
+			return
+		}
+		time.Sleep(n.timing.BlockTime)
+	}
+	n.t.Fatalf("the faucet didn't fund %s with %s wei", addr.Hex(), min)
+}
+
+// transfer the native coin from the wallet
+func (n *itTestnet) transfer(from *ecdsa.PrivateKey, to common.Address, value *big.Int) {
+	n.t.Helper()
+	addr := crypto.PubkeyToAddress(from.PublicKey)
+	nonce, err := n.client.PendingNonceAt(n.ctx, addr)
+	if err != nil {
+		n.t.Fatal(err)
+	}
+	gasPrice, err := n.client.SuggestGasPrice(n.ctx)
+	if err != nil {
+		n.t.Fatal(err)
+	}
+	tx, err := types.SignNewTx(from, types.LatestSignerForChainID(n.chainID), &types.LegacyTx{
+		Nonce:    nonce,
+		GasPrice: gasPrice,
+		Gas:      21000,
+		To:       &to,
+		Value:    value,
+	})
+	if err != nil {
+		n.t.Fatal(err)
+	}
+	if err := n.client.SendTransaction(n.ctx, tx); err != nil {
+		n.t.Fatal(err)
+	}
+	n.wait(tx)
+}
+
+// deploy the contract compiled by truffle, from the admin
+func (n *itTestnet) deploy(name string, params ...interface{}) (common.Address, *bind.BoundContract) {
+	n.t.Helper()
+	raw, err := os.ReadFile(filepath.Join(itArtifacts, name+".json"))
+	if err != nil {
+		n.t.Fatalf("error reading the artifact of %s, run 'npx truffle compile' first: %s", name, err)
+	}
+	var art itArtifact
+	if err := json.Unmarshal(raw, &art); err != nil {
+		n.t.Fatal(err)
+	}
+	a, err := abi.JSON(bytes.NewReader(art.ABI))
+	if err != nil {
+		n.t.Fatal(err)
+	}
+
+	addr, tx, c, err := bind.DeployContract(n.opts(n.admin), a, common.FromHex(art.Bytecode), n.client, params...)
+	if err != nil {
+		n.t.Fatalf("error deploying %s: %s", name, err)
+	}
+	n.wait(tx)
+	n.t.Logf("deployed %s at %s", name, addr.Hex())
+	return addr, c
+}
+
+// transact with the contract from the admin
+func (n *itTestnet) transact(c *bind.BoundContract, method string, params ...interface{}) {
+	n.t.Helper()
+	tx, err := c.Transact(n.opts(n.admin), method, params...)
+	if err != nil {
+		n.t.Fatalf("error calling %s: %s", method, err)
+	}
+	n.wait(tx)
+}
+
+// config of the bot sniping the token with the trigger. The admin panic sells what it sniped.
+func (n *itTestnet) config(token, trigger common.Address) *Config {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		n.t.Fatal(err)
+	}
+	api := l.Addr().String()
+	l.Close()
+
+	conf := &Config{Version: configVersion, Name: "integration"}
+	conf.Chains.Nodes.Stream = n.rpc
+	conf.Chains.Nodes.Snipe = n.rpc
+	conf.Chains.ID = uint(n.chainID.Uint64())
+	conf.Chains.Name = "integration"
+	conf.Order.Size = 0.01
+	conf.Contracts.Trigger = Address(trigger.Hex())
+	conf.Contracts.Factory = Address(n.fixture.factory.Hex())
+	conf.Contracts.Router = Address(n.fixture.router.Hex())
+	conf.Tokens.SnipeA = Address(token.Hex())
+	conf.Tokens.SnipeB = Address(n.fixture.weth.Hex())
+	conf.Tokens.WBNB = Address(n.fixture.weth.Hex())
+	conf.Sniper.Mode = SniperMode(os.Getenv(itModeEnv))
+	conf.Sniper.MinLiquidity = 0.01
+	conf.Sniper.Gas.Limit = 500000
+	conf.API.Address = api
+	conf.API.Token = "integration"
+	conf.Panic.Wallets = []string{secretEnvPrefix + itKeyEnv}
+	return conf
+}
+
+// startBot with the config and bee book, until the test ends. Its logs are printed if the test fails.
+func (n *itTestnet) startBot(conf *Config, swarm []bee) {
+	n.t.Helper()
+	dir := n.t.TempDir()
+	write := func(name string, v interface{}) {
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			n.t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name+".json"), b, 0o600); err != nil {
+			n.t.Fatal(err)
+		}
+	}
+	write(configFile, conf)
+	write(beeBookFile, swarm)
+
+	bin := filepath.Join(dir, "ax-50")
+	if out, err := exec.Command("go", "build", "-o", bin, ".").CombinedOutput(); err != nil {
+		n.t.Fatalf("error building ax-50: %s\n%s", err, out)
+	}
+	logs := filepath.Join(dir, "ax-50.log")
+	f, err := os.Create(logs)
+	if err != nil {
+		n.t.Fatal(err)
+	}
+	cmd := exec.Command(bin)
+	cmd.Env = append(os.Environ(), configFolderEnv+"="+dir)
+	cmd.Stdout, cmd.Stderr = f, f
+	if err := cmd.Start(); err != nil {
+		n.t.Fatal(err)
+	}
+	n.t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		f.Close()
+		if n.t.Failed() {
+			b, _ := os.ReadFile(logs)
+			n.t.Logf("ax-50 logs:\n%s", b)
+		}
+	})
+
+	n.eventually("ax-50 to start", func() (bool, error) {
+		b, err := os.ReadFile(logs)
+		return strings.Contains(string(b), "igniting engine"), err
+	})
+}
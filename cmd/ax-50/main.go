@@ -4,14 +4,17 @@ import (
 	"context"
 	"fmt"
 	"math/big"
+	"math/rand"
 	"os"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rpc"
 
 	"github.com/saantiaguilera/liquidity-sniper/pkg/controller"
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
 	"github.com/saantiaguilera/liquidity-sniper/pkg/service"
 	"github.com/saantiaguilera/liquidity-sniper/pkg/usecase"
 )
@@ -24,6 +27,8 @@ const (
 	configFolderDefault = "config"
 	configFile          = "local"
 	beeBookFile         = "bee_book"
+	jitterFile          = "jitter" // written by configure-trigger, suffixed by the trigger address
+	routerABIsFolder    = "abi"    // abis of the routers fetched from the explorer, inside the config folder
 
 	// workers is the number of concurrent jobs consuming events from the pool,
 	// be careful not using something too low if the chain has high throughput for the specified mode
@@ -32,18 +37,74 @@ const (
 	// sweet spot where you're not the bottleneck of the stream nor you are wasting resources.
 	workers = 1000
 
+	// slowLaneWorkers is the number of concurrent jobs consuming txs that aren't snipe candidates (eg. for monitors).
+	// slowLaneQueue is how many of them we buffer before dropping them, so they never delay a candidate.
+	slowLaneWorkers = 100
+	slowLaneQueue   = 10000
+
+	// shedBacklog is the number of queued pending txs at which we shed everything but the router txs, unless configured.
+	shedBacklog = workers / 2
+
+	// senderCacheSize is the number of tx senders we keep recovered. It should be big enough to hold the txs of
+	// a few seconds of mempool activity, that's when we may see the same tx again.
+	senderCacheSize = 50000
+
+	// decisionHistorySize is the number of filter chain decisions we buffer while they are recorded.
+	decisionHistorySize = 10000
+
+	// priceAlertsInterval is how often positions are valued for price alerts, unless configured.
+	priceAlertsInterval = 30 * time.Second
+
+	// reserveSeriesInterval is how often the reserves of the sniped tokens are sampled, unless configured.
+	// reserveSeriesAfter is how long they are still sampled once we exited them, unless configured.
+	reserveSeriesInterval = time.Minute
+	reserveSeriesAfter    = 24 * time.Hour
+
+	// marketsInterval is how often the markets of the sniped tokens are refreshed from the trackers, unless configured.
+	marketsInterval = time.Minute
+
+	// decisionFeedSize is the number of latest decisions kept in memory for the api (eg. for the tui).
+	decisionFeedSize = 500
+
+	// nonceReconcileInterval is how often the swarm nonces are checked against the chain, unless configured.
+	nonceReconcileInterval = time.Minute
+
+	// migrationWindow is how long after a v2 removal a v3 mint is a liquidity migration, unless configured.
+	// migrationPairedFee is the fee tier of the v3 pool between wbnb and the paired token, unless configured.
+	migrationWindow    = time.Hour
+	migrationPairedFee = 500
+
+	// slippageBuffer is the % below the simulated amount out of a snipe we accept, unless configured.
+	// competingBuysBlocks is how long a pending buy of a target competes with our snipes, mined or not.
+	slippageBuffer      = 2
+	competingBuysBlocks = 10
+
+	// sandwichBotsBlocks is how long a pending call of a likely sandwich bot exposes our snipes, mined or not.
+	sandwichBotsBlocks = 10
+
+	// panicGasMultiplier of the network median gas price a panic sell pays, unless configured.
+	panicGasMultiplier = 3
+
+	// deadManThreshold is how long none of our nodes answers before the dead man switch trips, unless configured.
+	// deadManInterval is how often it probes them.
+	deadManThreshold = time.Minute
+	deadManInterval  = 5 * time.Second
+
+	// alertsFlushTimeout is how long we wait for the last alerts to be posted when exiting.
+	alertsFlushTimeout = 10 * time.Second
+
+	// maxStealthDelayBlocks a bee can wait before broadcasting. Any longer and the snipe would miss the next block.
+	maxStealthDelayBlocks = 1.0 / 3
+
 	// logLevel of the logs. Using DEBUG/INFO may suffice,
 	// if you want to check that everything works fine set LvlTrace (the lowest)
 	logLevel = log.LvlInfo
 )
 
 func main() {
-	if workers <= 0 {
-		panic("workers > 0")
-	}
-
-	configureLog(logLevel)
+	logLevels := service.NewLogLevels(configureLog(logLevel), logLevel)
 	ctx := context.Background()
+	rand.Seed(time.Now().UnixNano()) // stealth delays must differ between runs
 
 	dir := os.Getenv(configFolderEnv)
 	if len(dir) == 0 {
@@ -54,9 +115,15 @@ func main() {
 		panic(err)
 	}
 
-	log.Info(fmt.Sprintf("configurations parsed: %+v", conf))
+	if len(os.Args) > 1 {
+		if err := runCLI(conf, os.Args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	rpcClientStream := newRPCClient(ctx, conf.Chains.Nodes.Stream)
+	rpcClientStream := newRPCClient(ctx, conf, conf.Chains.Nodes.Stream)
 
 	/*
 	* Currently we only allow one write client because:
@@ -71,35 +138,212 @@ func main() {
 	* may have clusters through an ELB which internally are.. also different nodes.
 	* This doesn't matter much if it's a single instance self hosted node.
 	**/
-	var ecli *service.EthClientCluster
-	if conf.Chains.Nodes.Snipe == conf.Chains.Nodes.Stream {
-		ecli = service.NewEthClientCluster(ethclient.NewClient(rpcClientStream))
-	} else {
-		ecli = service.NewEthClientCluster(ethclient.NewClient(newRPCClient(ctx, conf.Chains.Nodes.Snipe)))
+	rpcClientSnipe := rpcClientStream
+	if conf.Chains.Nodes.Snipe != conf.Chains.Nodes.Stream {
+		rpcClientSnipe = newRPCClient(ctx, conf, conf.Chains.Nodes.Snipe)
 	}
+	ecli := service.NewEthClientCluster(service.NewRPCEthClient(rpcClientSnipe))
 	ctx = ecli.NewLoadBalancedContext(ctx)
 
-	sniper := newSniperEntity(ctx, conf, ecli)
-	monitors := newMonitors(conf, sniper)
-	factory := newFactory(conf, ecli)
-	swarm := newBees(ctx, ecli)
+	instances, err := conf.InstanceConfigs()
+	if err != nil {
+		panic(err)
+	}
+	log.Info(fmt.Sprintf("configuration parsed for chain %d with %d instances", conf.Chains.ID, len(instances)))
+
+	gasOracle := service.NewGasOracle(ecli)
+	senderCache := service.NewSenderCache(senderCacheSize)
+
+	chainID, err := ecli.NetworkID(ctx)
+	if err != nil {
+		panic(err)
+	}
+	dynamicFees, err := service.DetectDynamicFees(ctx, ecli)
+	if err != nil {
+		panic(err)
+	}
+	log.Info(fmt.Sprintf("chain %s has dynamic fees: %t", chainID.String(), dynamicFees))
+	timing := newTiming(conf, chainID)
+	log.Info(fmt.Sprintf("chain %s has blocks every %s", chainID.String(), timing.BlockTime))
+	repos := newRepositories(ctx, conf)
+	targetManager := usecase.NewTargetManager(repos.targets, chainID, newReinvestPolicy(conf))
+	decisionFeed := service.NewDecisionFeed(decisionFeedSize)
+	decisionStream := service.NewDecisionStream()
+	decisions := service.NewDecisionRecorders(
+		service.NewDecisionHistory(decisionHistorySize, repos.decisions),
+		service.NewDecisionMetrics(),
+		decisionFeed,
+		decisionStream,
+	)
+	rateLimits := newRateLimits(conf)
+	rebroadcaster := newRebroadcaster(ctx, conf)
+	alerts := service.NewAlerts(mustSecretString(conf.Alerts.Webhook))
+	tradeMetrics := service.NewTradeMetrics(repos.trades)
+	priceFeed := newPriceFeed(conf, ecli)
+
+	/*
+	* Each instance is an isolated sniper with its own trigger, target, bees and budget. Targets can be changed
+	* while the bot runs through the target manager. They all share the nodes,
+	* the mempool stream and the caches, so running many of them costs about the same as running one.
+	* A bee can't belong to more than one instance, else their nonces would collide.
+	**/
+	uniLiquidityClients := make([]*service.UniswapLiquidity, len(instances))
+	migrations := make([]*service.LiquidityMigration, 0)
+	competing := make([]*service.CompetingBuys, 0)
+	sandwiches := make([]*service.SandwichBots, 0)
+	deploys := make([]*service.UniswapLiquidity, 0)
+	snipers := make([]*service.Sniper, len(instances))
+	targets := make([]domain.Sniper, len(instances))
+	beeOwners := make(map[common.Address]string)
+	bees := make([]common.Address, 0)
+	var sniper domain.Sniper
+	for i, iconf := range instances {
+		sniper = newSniperEntity(ctx, iconf, ecli)
+		targets[i] = sniper
+		swarm, beeAddrs := newBees(ctx, iconf, ecli)
+		for _, addr := range beeAddrs {
+			if owner, ok := beeOwners[addr]; ok {
+				panic(fmt.Sprintf("bee %s is used by instances %s and %s", addr.Hex(), owner, iconf.Name))
+			}
+			beeOwners[addr] = iconf.Name
+			bees = append(bees, addr)
+		}
+
+		sniperClient := service.NewSniper(
+			ecli,
+			newFactory(iconf, ecli),
+			gasOracle,
+			newRelays(iconf, rateLimits),
+			rebroadcaster,
+			service.NewInclusionWatcher(ecli, timing),
+			tradeMetrics,
+			alerts,
+			newQuietHours(iconf),
+			swarm,
+			sniper,
+			iconf.Sniper.Gas.MaxMultiplier,
+			iconf.Sniper.Gas.MinMultiplier,
+			iconf.Sniper.FillTolerance,
+			iconf.Sniper.Submission.FallbackGasBump,
+			iconf.Sniper.ReentryBlocks,
+			iconf.Sniper.Gas.LimitBuffer,
+			newStealthDelay(iconf, timing),
+			timing,
+			iconf.Sniper.Mode == SniperModeBlockScan, // victims are already mined
+			iconf.Sniper.Submission.Concurrent,
+			iconf.Sniper.Gas.AccessList,
+			dynamicFees,
+		)
+		presign(iconf, sniperClient)
+		if priceFeed != nil {
+			sniperClient.ReportUSD(priceFeed)
+		}
+		if b := newCompetingBuys(iconf, sniperClient, sniper, timing); b != nil {
+			targetManager.Register(iconf.Name, b)
+			competing = append(competing, b)
+		}
+		if b := newSandwichBots(iconf, sniperClient, sniper, timing); b != nil {
+			targetManager.Register(iconf.Name, b)
+			sandwiches = append(sandwiches, b)
+		}
+		snipers[i] = sniperClient
+		go sniperClient.RunReconcile(ecli.NewLoadBalancedContext(ctx), newNonceReconcileInterval(iconf))
+		uniLiquidityClients[i] = newUniswapLiquidityClient(
+			ecli,
+			sniperClient,
+			senderCache,
+			decisions,
+			iconf,
+			sniper,
+			newValuationBands(ctx, iconf, ecli),
+			timing,
+		)
+		if iconf.Sniper.ConstructorLiquidity {
+			deploys = append(deploys, uniLiquidityClients[i])
+		}
+		targetManager.Register(iconf.Name, sniperClient, uniLiquidityClients[i])
+		if m := newLiquidityMigration(iconf, ecli, sniperClient, alerts, decisions, sniper); m != nil {
+			targetManager.Register(iconf.Name, m)
+			migrations = append(migrations, m)
+		}
+		if len(instances) > 1 {
+			log.Info(fmt.Sprintf("instance %s sniping %s", iconf.Name, iconf.Tokens.SnipeA.Hex()))
+		}
+	}
+
+	if err := targetManager.Restore(ctx, targets...); err != nil {
+		panic(err)
+	}
+	panicWallets, panicAddrs := newPanicWallets(conf, ecli, chainID, dynamicFees)
+	panicSeller := newPanicSeller(conf, ecli, gasOracle, panicWallets, snipers, timing)
+	portfolio := newPortfolio(conf, ecli, repos, targetManager, append(bees, panicAddrs...))
+	markets := newMarkets(conf, portfolio, rateLimits, alerts)
+	panicSell := newPanicSell(conf, portfolio, panicSeller, targetManager, alerts)
+	serveAPI(
+		conf,
+		controller.NewTarget(targetManager),
+		controller.NewDashboard(portfolio, decisionFeed, markets),
+		controller.NewStream(decisionStream),
+		controller.NewGraphQL(repos.trades),
+		controller.NewReserves(repos.reserves),
+		controller.NewLog(logLevels),
+		controller.NewPanic(panicSell),
+	)
+	if t := newTelegram(conf, panicSell); t != nil {
+		go t.Run(ctx)
+	}
+	if dm := newDeadManSwitch(conf, ecli, portfolio, panicSeller, service.NewBackupRoute(rebroadcaster, newRelays(conf, rateLimits)), alerts, timing); dm != nil {
+		go dm.Run(ecli.NewLoadBalancedContext(ctx))
+	}
+	go newPriceAlerts(conf, portfolio, alerts).Run(ctx)
+	go newReserveSeries(conf, ecli, portfolio, repos).Run(ctx)
+	if len(conf.Markets.Dexscreener) > 0 {
+		go markets.Run(ctx)
+	}
+	if hb := newHeartbeat(conf, targetManager, alerts); hb != nil {
+		go hb.Run(ctx)
+		defer func() {
+			err := recover()
+			hb.Stop(fmt.Sprintf("the engine stopped (%v)", err))
+			alerts.Flush(alertsFlushTimeout)
+			if err != nil {
+				panic(err)
+			}
+		}()
+	}
+	routerMethods := newRouterMethods(ctx, conf, rateLimits)
+	wipeSecrets() // everything holding a secret is wired
+
+	monitors := newMonitors(instances, sniper, gasOracle, senderCache)
 	monitorEngine := service.NewMonitorEngine(monitors...)
-	sniperClient := service.NewSniper(ecli, factory, swarm, sniper)
-	uniLiquidityClient := newUniswapLiquidityClient(ecli, sniperClient, sniper)
 
-	txClassifierUseCase := newTxClassifierUseCase(conf, monitorEngine, uniLiquidityClient)
+	txClassifierUseCase := newTxClassifierUseCase(conf, routerMethods, monitorEngine, uniLiquidityClients, deploys, migrations, competing, sandwiches)
+	txLanesUseCase := newTxLanesUseCase(ctx, conf, instances, targetManager, txClassifierUseCase, len(migrations) > 0, len(deploys) > 0)
 
 	log.Info("igniting engine")
-	newEngine(conf, rpcClientStream, ecli, ecli.NewLoadBalancedContext, txClassifierUseCase).Run(ctx)
+	newEngine(conf, rpcClientStream, ecli, ecli.NewLoadBalancedContext, txLanesUseCase).Run(ctx)
+}
+
+// newTelegram bot commands, nil if there's no bot
+func newTelegram(conf *Config, panicSell *usecase.PanicSell) *controller.Telegram {
+	token := mustSecretString(conf.Telegram.Token)
+	if len(token) == 0 {
+		return nil
+	}
+	if conf.Telegram.Chat == 0 {
+		panic("telegram.token is set without a telegram.chat, set the chat whose commands are run")
+	}
+	log.Info(fmt.Sprintf("running the telegram commands of chat %d", conf.Telegram.Chat))
+	return controller.NewTelegram(service.NewTelegramBot(token), conf.Telegram.Chat, panicSell)
 }
 
 func newEngine(
 	conf *Config,
 	cli *rpc.Client,
 	ecli *service.EthClientCluster,
-	mid engineMid,
-	uc *usecase.TransactionClassifier,
-) *Engine {
+	mid controller.EngineMiddleware,
+	uc *usecase.TransactionLanes,
+) *controller.Engine {
 
 	mode := conf.Sniper.Mode
 	if len(mode) == 0 {
@@ -107,11 +351,31 @@ func newEngine(
 	}
 	log.Info(fmt.Sprintf("using mode %s", mode))
 
+	shed := int(conf.Sniper.ShedBacklog)
+	if shed == 0 {
+		shed = shedBacklog
+	}
+
 	switch mode {
 	case SniperModePendingTxs:
-		ctrl := controller.NewPendingTransaction(ecli, uc.Classify)
-		return NewEngine(
+		if conf.Chains.Nodes.FullPendingTxs {
+			// no fetch by hash, so every tx reaches its lane right away
+			return controller.NewEngine(
+				cli,
+				workers,
+				shed,
+				controller.SubscribeFullPendingTxs,
+				mid,
+				func(ctx context.Context, v interface{}) error {
+					return uc.Dispatch(ctx, v.(*types.Transaction))
+				},
+			)
+		}
+		ctrl := controller.NewPendingTransaction(ecli, uc.Dispatch)
+		return controller.NewEngine(
 			cli,
+			workers,
+			shed,
 			func(ctx context.Context, c *rpc.Client, ch chan<- interface{}) (*rpc.ClientSubscription, error) {
 				return c.EthSubscribe(ctx, ch, "newPendingTransactions")
 			},
@@ -121,9 +385,11 @@ func newEngine(
 			},
 		)
 	case SniperModeBlockScan:
-		ctrl := controller.NewBlock(ecli, uc.Classify)
-		return NewEngine(
+		ctrl := controller.NewBlock(ecli, uc.Dispatch)
+		return controller.NewEngine(
 			cli,
+			workers,
+			shed,
 			func(ctx context.Context, c *rpc.Client, ch chan<- interface{}) (*rpc.ClientSubscription, error) {
 				return c.EthSubscribe(ctx, ch, "newHeads")
 			},
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// configVersionUnversioned of the configs without a version, the ones written before the format was versioned
+const configVersionUnversioned = 1
+
+type (
+	// configMigration upgrades a config (and each of its instances, which are partial configs) from the version of its
+	// index in configMigrations (plus the unversioned one) to the next one.
+	configMigration struct {
+		Description string
+		Up          func(c map[string]interface{})
+	}
+)
+
+// configMigrations of the format, in order. Whenever a change of the format would break the configs already deployed
+// (eg. a key is renamed, moved or its unit changes) append the migration upgrading them. There are none yet, the first
+// version is the unversioned one.
+var configMigrations []configMigration
+
+// configVersion of the config format this build reads
+var configVersion = configVersionOf(configMigrations)
+
+// configVersionOf the format after applying the given migrations to an unversioned config
+func configVersionOf(migrations []configMigration) uint {
+	return configVersionUnversioned + uint(len(migrations))
+}
+
+// migrateConfig to the latest version, returning it along the descriptions of the migrations applied. Configs of a
+// newer version than this build reads are rejected, they could mean anything.
+func migrateConfig(b []byte) ([]byte, []string, error) {
+	return migrateConfigWith(b, configMigrations)
+}
+
+// migrateConfigWith the given migrations, up to the version after the last of them
+func migrateConfigWith(b []byte, migrations []configMigration) ([]byte, []string, error) {
+	latest := configVersionOf(migrations)
+
+	var c map[string]interface{}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, nil, err
+	}
+	v := uint(configVersionUnversioned)
+	if raw, ok := c["version"]; ok {
+		f, ok := raw.(float64)
+		if !ok || f < configVersionUnversioned || f != float64(uint(f)) {
+			return nil, nil, fmt.Errorf("invalid config version %v", raw)
+		}
+		v = uint(f)
+	}
+	switch {
+	case v > latest:
+		return nil, nil, fmt.Errorf("config version %d is newer than the %d this build reads, upgrade ax-50", v, latest)
+	case v == latest:
+		return b, nil, nil
+	}
+
+	applied := make([]string, 0, latest-v)
+	for ; v < latest; v++ {
+		m := migrations[v-configVersionUnversioned]
+		m.Up(c)
+		if instances, ok := c["instances"].([]interface{}); ok {
+			for _, i := range instances {
+				if ic, ok := i.(map[string]interface{}); ok {
+					m.Up(ic)
+				}
+			}
+		}
+		applied = append(applied, fmt.Sprintf("%d -> %d: %s", v, v+1, m.Description))
+	}
+	c["version"] = latest
+
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(c); err != nil {
+		return nil, nil, err
+	}
+	return out.Bytes(), applied, nil
+}
+
+// runConfigMigrate rewriting the config file in the latest version. The old one is kept alongside, suffixed by its
+// version.
+func runConfigMigrate(conf *Config) error {
+	b, err := os.ReadFile(conf.path)
+	if err != nil {
+		return err
+	}
+	migrated, applied, err := migrateConfig(b)
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		fmt.Printf("%s is already in version %d\n", conf.path, configVersion)
+		return nil
+	}
+
+	backup := fmt.Sprintf("%s.v%d.bak", conf.path, configVersion-uint(len(applied)))
+	if err := os.WriteFile(backup, b, 0600); err != nil {
+		return err
+	}
+	if err := os.WriteFile(conf.path, migrated, 0600); err != nil {
+		return err
+	}
+	for _, a := range applied {
+		fmt.Println(a)
+	}
+	fmt.Printf("%s migrated to version %d, the old one is at %s\n", conf.path, configVersion, backup)
+	return nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// testConfigMigrations renaming sniper.quiet_hours.windows to sniper.quiet_hours.hours, and then dropping
+// sniper.quiet_hours.enabled
+var testConfigMigrations = []configMigration{
+	{
+		Description: "rename sniper.quiet_hours.windows to hours",
+		Up: func(c map[string]interface{}) {
+			if qh, ok := jsonObject(c, "sniper", "quiet_hours"); ok {
+				if w, ok := qh["windows"]; ok {
+					qh["hours"] = w
+					delete(qh, "windows")
+				}
+			}
+		},
+	},
+	{
+		Description: "drop sniper.quiet_hours.enabled",
+		Up: func(c map[string]interface{}) {
+			if qh, ok := jsonObject(c, "sniper", "quiet_hours"); ok {
+				delete(qh, "enabled")
+			}
+		},
+	},
+}
+
+func TestMigrateConfig(t *testing.T) {
+	b := []byte(`{"sniper": {"quiet_hours": {"windows": ["23:30-07:00"]}}}`)
+	migrated, applied, err := migrateConfig(b)
+	if err != nil || len(applied) != len(configMigrations) {
+		t.Fatalf("expected the %d migrations applied, got %v %v", len(configMigrations), applied, err)
+	}
+	c := &Config{}
+	if err := json.Unmarshal(migrated, c); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Sniper.QuietHours.Windows) != 1 {
+		t.Fatalf("unexpected migrated config %s", migrated)
+	}
+}
+
+func TestMigrateConfigWith(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        string
+		expectApplied int
+	}{
+		{"unversioned", `{"sniper": {"quiet_hours": {"windows": ["23:30-07:00"], "enabled": true}}}`, 2},
+		{"version 2", `{"version": 2, "sniper": {"quiet_hours": {"hours": ["23:30-07:00"], "enabled": true}}}`, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, applied, err := migrateConfigWith([]byte(tt.config), testConfigMigrations)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(applied) != tt.expectApplied {
+				t.Fatalf("expected %d migrations applied, got %v", tt.expectApplied, applied)
+			}
+
+			var m map[string]interface{}
+			if err := json.Unmarshal(b, &m); err != nil {
+				t.Fatal(err)
+			}
+			qh, _ := jsonObject(m, "sniper", "quiet_hours")
+			if _, ok := qh["hours"]; !ok || qh["windows"] != nil || qh["enabled"] != nil {
+				t.Fatalf("unexpected migrated config %s", b)
+			}
+			if m["version"] != float64(3) {
+				t.Fatalf("expected version 3, got %v", m["version"])
+			}
+
+			again, applied, err := migrateConfigWith(b, testConfigMigrations)
+			if err != nil || len(applied) != 0 || string(again) != string(b) {
+				t.Fatalf("expected a migrated config to be left as is, got %v %v", applied, err)
+			}
+		})
+	}
+}
+
+func TestMigrateConfigWith_Instances(t *testing.T) {
+	b, _, err := migrateConfigWith([]byte(`{
+		"instances": [{"name": "alice", "sniper": {"quiet_hours": {"windows": ["23:30-07:00"]}}}]
+	}`), testConfigMigrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	qh, _ := jsonObject(m["instances"].([]interface{})[0].(map[string]interface{}), "sniper", "quiet_hours")
+	if _, ok := qh["hours"]; !ok {
+		t.Fatalf("expected the instances to be migrated, got %s", b)
+	}
+}
+
+func TestMigrateConfig_Newer(t *testing.T) {
+	if _, _, err := migrateConfig([]byte(`{"version": 1000}`)); err == nil {
+		t.Fatal("expected a config newer than the build to be rejected")
+	}
+	if _, _, err := migrateConfig([]byte(`{"version": "1"}`)); err == nil {
+		t.Fatal("expected an invalid version to be rejected")
+	}
+	if _, _, err := migrateConfig([]byte(`{"version": 0}`)); err == nil {
+		t.Fatal("expected a version older than the first one to be rejected")
+	}
+}
+
+// jsonObject nested in c at the given keys, if it's there
+func jsonObject(c map[string]interface{}, keys ...string) (map[string]interface{}, bool) {
+	for _, k := range keys {
+		next, ok := c[k].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		c = next
+	}
+	return c, true
+}
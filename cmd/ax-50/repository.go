@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	_ "github.com/lib/pq" // postgres driver
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+	"github.com/saantiaguilera/liquidity-sniper/pkg/repository"
+)
+
+type (
+	targetRepository interface {
+		Get(context.Context, string) (domain.Sniper, error)
+		List(context.Context) ([]domain.Sniper, error)
+		Save(context.Context, domain.Sniper) error
+		Delete(context.Context, string) error
+	}
+
+	tradeRepository interface {
+		Save(context.Context, domain.Trade) error
+		Trades(context.Context, string) ([]domain.Trade, error)
+		Find(context.Context, domain.TradeFilter) ([]domain.Trade, error)
+		Positions(context.Context) ([]domain.Position, error)
+	}
+
+	decisionRepository interface {
+		Append(context.Context, domain.Decision) error
+	}
+
+	reserveRepository interface {
+		Save(context.Context, domain.ReserveSample) error
+		Series(ctx context.Context, token string, from, to time.Time) ([]domain.ReserveSample, error)
+	}
+
+	repositories struct {
+		targets   targetRepository
+		trades    tradeRepository
+		decisions decisionRepository
+		reserves  reserveRepository
+	}
+)
+
+// newRepositories of targets, trades, decisions and reserve series. They are kept in postgres if configured (so many operators and
+// dashboards share them), else in memory. Without postgres, decisions are only kept if a file for them is configured.
+func newRepositories(ctx context.Context, conf *Config) repositories {
+	if len(conf.Storage.Postgres) == 0 {
+		var decisions decisionRepository
+		if len(conf.Storage.DecisionsPath) > 0 {
+			r, err := repository.NewFileDecision(conf.Storage.DecisionsPath)
+			if err != nil {
+				panic(err)
+			}
+			decisions = r
+		}
+
+		return repositories{
+			targets:   repository.NewMemoryTarget(),
+			trades:    repository.NewMemoryTrade(),
+			decisions: decisions,
+			reserves:  repository.NewMemoryReserves(),
+		}
+	}
+
+	db, err := sql.Open("postgres", mustSecretString(conf.Storage.Postgres))
+	if err != nil {
+		panic(err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		panic(fmt.Sprintf("error connecting to postgres: %s", err))
+	}
+
+	applied, err := repository.MigratePostgres(ctx, db)
+	if err != nil {
+		panic(err)
+	}
+	for _, m := range applied {
+		log.Info(fmt.Sprintf("applied postgres migration %s", m))
+	}
+	return repositories{
+		targets:   repository.NewPostgresTarget(db),
+		trades:    repository.NewPostgresTrade(db),
+		decisions: repository.NewPostgresDecision(db),
+		reserves:  repository.NewPostgresReserves(db),
+	}
+}
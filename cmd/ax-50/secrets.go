@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Secrets (private keys, api tokens, webhooks, dsns) can be referenced from the config instead of written in it:
+//
+//	vault:<path>#<key>  the key of a secret in HashiCorp Vault (kv v1 or v2), eg vault:secret/data/ax50#api_token.
+//	                    VAULT_ADDR and VAULT_TOKEN must be set.
+//	env:<name>          a variable of the encrypted env file at AX50_SECRETS_FILE, decrypted with the passphrase
+//	                    in AX50_SECRETS_KEY. Create it with 'ax-50 secrets encrypt'.
+//
+// Any other value is taken as the plain secret. Resolved secrets are handed out as bytes so they can be zeroed
+// once used (eg. private keys after parsing them), the decrypted env file is zeroed as soon as it's parsed.
+const (
+	secretVaultPrefix = "vault:"
+	secretEnvPrefix   = "env:"
+
+	secretsFileEnv = "AX50_SECRETS_FILE"
+	secretsKeyEnv  = "AX50_SECRETS_KEY"
+	vaultAddrEnv   = "VAULT_ADDR"
+	vaultTokenEnv  = "VAULT_TOKEN"
+
+	secretsSaltSize = 16
+	secretsTimeout  = 10 * time.Second
+)
+
+var (
+	secretsOnce sync.Once
+	secretsEnv  map[string][]byte
+	secretsErr  error
+)
+
+// mustSecret resolves the referenced secret, panicking if it can't. Zero it once used.
+func mustSecret(ref string) []byte {
+	s, err := resolveSecret(ref)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// mustSecretString for secrets that are consumed as strings (so they can't be zeroed anyway)
+func mustSecretString(ref string) string {
+	s := mustSecret(ref)
+	defer wipe(s)
+	return string(s)
+}
+
+// mustSecretKey resolves a hex private key, zeroing its intermediate copies.
+func mustSecretKey(ref string) *ecdsa.PrivateKey {
+	s := mustSecret(ref)
+	defer wipe(s)
+
+	h := bytes.TrimPrefix(bytes.TrimSpace(s), []byte("0x"))
+	raw := make([]byte, hex.DecodedLen(len(h)))
+	defer wipe(raw)
+	if _, err := hex.Decode(raw, h); err != nil {
+		panic(fmt.Sprintf("invalid private key: %s", err))
+	}
+
+	k, err := crypto.ToECDSA(raw)
+	if err != nil {
+		panic(fmt.Sprintf("invalid private key: %s", err))
+	}
+	return k
+}
+
+func resolveSecret(ref string) ([]byte, error) {
+	switch {
+	case strings.HasPrefix(ref, secretVaultPrefix):
+		return vaultSecret(strings.TrimPrefix(ref, secretVaultPrefix))
+	case strings.HasPrefix(ref, secretEnvPrefix):
+		return envSecret(strings.TrimPrefix(ref, secretEnvPrefix))
+	default:
+		return []byte(ref), nil
+	}
+}
+
+func vaultSecret(ref string) ([]byte, error) {
+	i := strings.LastIndex(ref, "#")
+	if i < 0 {
+		return nil, fmt.Errorf("vault secret %s has no key, use vault:<path>#<key>", ref)
+	}
+	path, key := ref[:i], ref[i+1:]
+
+	addr, token := os.Getenv(vaultAddrEnv), os.Getenv(vaultTokenEnv)
+	if len(addr) == 0 || len(token) == 0 {
+		return nil, fmt.Errorf("vault secret %s requires %s and %s", ref, vaultAddrEnv, vaultTokenEnv)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(addr, "/")+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+
+	res, err := (&http.Client{Timeout: secretsTimeout}).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error reading vault secret %s: %s", ref, err)
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	defer wipe(b)
+	if err != nil {
+		return nil, fmt.Errorf("error reading vault secret %s: %s", ref, err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error reading vault secret %s: status %d", ref, res.StatusCode)
+	}
+
+	// kv v2 nests the secret in data.data, kv v1 has it in data
+	var body struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(b, &body); err != nil {
+		return nil, fmt.Errorf("error decoding vault secret %s: %s", ref, err)
+	}
+	data := body.Data
+	if nested, ok := data["data"]; ok {
+		data = nil
+		if err := json.Unmarshal(nested, &data); err != nil {
+			return nil, fmt.Errorf("error decoding vault secret %s: %s", ref, err)
+		}
+	}
+
+	v, ok := data[key]
+	if !ok {
+		return nil, fmt.Errorf("vault secret %s not found", ref)
+	}
+	defer wipe(v)
+	return unquoteSecret(v)
+}
+
+// unquoteSecret of a json string, without going through a string (so the result can be zeroed)
+func unquoteSecret(v []byte) ([]byte, error) {
+	if len(v) < 2 || v[0] != '"' || v[len(v)-1] != '"' {
+		return nil, fmt.Errorf("secret isn't a string")
+	}
+	v = v[1 : len(v)-1]
+	if bytes.IndexByte(v, '\\') >= 0 {
+		return nil, fmt.Errorf("secret has escaped characters")
+	}
+	return append([]byte(nil), v...), nil
+}
+
+func envSecret(name string) ([]byte, error) {
+	secretsOnce.Do(func() {
+		secretsEnv, secretsErr = loadSecretsFile()
+	})
+	if secretsErr != nil {
+		return nil, secretsErr
+	}
+
+	v, ok := secretsEnv[name]
+	if !ok {
+		return nil, fmt.Errorf("secret %s not found in %s", name, os.Getenv(secretsFileEnv))
+	}
+	return append([]byte(nil), v...), nil
+}
+
+// loadSecretsFile decrypts and parses the encrypted env file. The passphrase is removed from the environment.
+func loadSecretsFile() (map[string][]byte, error) {
+	path, pass := os.Getenv(secretsFileEnv), []byte(os.Getenv(secretsKeyEnv))
+	defer wipe(pass)
+	os.Unsetenv(secretsKeyEnv)
+	if len(path) == 0 || len(pass) == 0 {
+		return nil, fmt.Errorf("env secrets require %s and %s", secretsFileEnv, secretsKeyEnv)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := decryptSecrets(b, pass)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting %s: %s", path, err)
+	}
+	defer wipe(plain)
+
+	env := make(map[string][]byte)
+	sc := bufio.NewScanner(bytes.NewReader(plain))
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		i := bytes.IndexByte(line, '=')
+		if i < 0 {
+			return nil, fmt.Errorf("invalid line in %s, expected NAME=value", path)
+		}
+		env[string(bytes.TrimSpace(line[:i]))] = append([]byte(nil), bytes.TrimSpace(line[i+1:])...)
+	}
+	return env, sc.Err()
+}
+
+// wipeSecrets of the env file kept in memory. Call it once everything is wired.
+func wipeSecrets() {
+	for _, v := range secretsEnv {
+		wipe(v)
+	}
+	secretsEnv = nil
+}
+
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// secretsCipher derives an AES-256-GCM cipher from the passphrase with scrypt
+func secretsCipher(pass, salt []byte) (cipher.AEAD, error) {
+	k, err := scrypt.Key(pass, salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	defer wipe(k)
+
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptSecrets as salt | nonce | ciphertext
+func encryptSecrets(plain, pass []byte) ([]byte, error) {
+	salt := make([]byte, secretsSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := secretsCipher(pass, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(append(salt, nonce...), nonce, plain, nil), nil
+}
+
+func decryptSecrets(b, pass []byte) ([]byte, error) {
+	if len(b) < secretsSaltSize {
+		return nil, fmt.Errorf("file too short")
+	}
+	aead, err := secretsCipher(pass, b[:secretsSaltSize])
+	if err != nil {
+		return nil, err
+	}
+	b = b[secretsSaltSize:]
+	if len(b) < aead.NonceSize() {
+		return nil, fmt.Errorf("file too short")
+	}
+	plain, err := aead.Open(nil, b[:aead.NonceSize()], b[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("wrong passphrase or corrupted file")
+	}
+	return plain, nil
+}
+
+// encryptSecretsFile for 'ax-50 secrets encrypt <env file> <encrypted file>', with the passphrase in AX50_SECRETS_KEY
+func encryptSecretsFile(in, out string) error {
+	pass := []byte(os.Getenv(secretsKeyEnv))
+	defer wipe(pass)
+	if len(pass) == 0 {
+		return fmt.Errorf("set the passphrase in %s", secretsKeyEnv)
+	}
+
+	plain, err := os.ReadFile(in)
+	if err != nil {
+		return err
+	}
+	defer wipe(plain)
+
+	b, err := encryptSecrets(plain, pass)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(out, b, 0600)
+}
@@ -2,12 +2,18 @@ package main
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"encoding/json"
 	"fmt"
+	"math"
 	"math/big"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -33,90 +39,278 @@ func (l *logHandler) Log(r *log.Record) error {
 	return nil
 }
 
-func configureLog(level log.Lvl) {
+func configureLog(level log.Lvl) *log.GlogHandler {
 	glog := log.NewGlogHandler(&logHandler{
 		format: log.TerminalFormat(true),
 	})
 	glog.Verbosity(level)
 	log.Root().SetHandler(glog)
+	return glog
 }
 
-func newRPCClient(ctx context.Context, rpcURL string) *rpc.Client {
-	rpcClient, err := rpc.DialContext(ctx, rpcURL)
+func newRPCClient(ctx context.Context, conf *Config, rpcURL string) *rpc.Client {
+	rpcClient, err := dialRPC(ctx, conf, rpcURL)
 	if err != nil {
 		panic(err)
 	}
 	return rpcClient
 }
 
+// dialRPC of the url, rotating the keys of its provider if we have many
+func dialRPC(ctx context.Context, conf *Config, rpcURL string) (*rpc.Client, error) {
+	for provider, keys := range conf.Chains.Nodes.Keys {
+		placeholder := fmt.Sprintf("{%s}", provider)
+		if strings.Contains(rpcURL, placeholder) {
+			return dialRotatingRPC(ctx, rpcURL, placeholder, keys)
+		}
+	}
+	return rpc.DialContext(ctx, rpcURL)
+}
+
+// dialRotatingRPC of a provider we have many keys of. Over http every request moves to the next key while they
+// are rate limited, over websockets a connection is bound to its key so we can only pick one that works when dialing.
+func dialRotatingRPC(ctx context.Context, rpcURL, placeholder string, keys []string) (*rpc.Client, error) {
+	if strings.HasPrefix(rpcURL, "http") {
+		t, err := service.NewKeyRotator(rpcURL, placeholder, keys, http.DefaultTransport)
+		if err != nil {
+			return nil, err
+		}
+		log.Info(fmt.Sprintf("rotating %d keys of %s", len(keys), placeholder))
+		return rpc.DialHTTPWithClient(rpcURL, &http.Client{Transport: t})
+	}
+
+	err := fmt.Errorf("no keys for %s", placeholder)
+	for i, k := range keys {
+		var rpcClient *rpc.Client
+		if rpcClient, err = rpc.DialContext(ctx, strings.ReplaceAll(rpcURL, placeholder, k)); err == nil {
+			log.Info(fmt.Sprintf("dialed %s with key %d", placeholder, i))
+			return rpcClient, nil
+		}
+		log.Warn(fmt.Sprintf("error dialing %s with key %d: %s", placeholder, i, err))
+	}
+	return nil, err
+}
+
 func newSniperEntity(ctx context.Context, conf *Config, ethClient *service.EthClientCluster) domain.Sniper {
 	chainID, err := ethClient.NetworkID(ctx)
 	if err != nil {
 		panic(err)
 	}
 
-	mul10pow14, _ := new(big.Int).SetString("100000000000000", 10)
-	ml := big.NewInt(int64(10000 * conf.Sniper.MinLiquidity))
-	ml.Mul(ml, mul10pow14)
+	decimals := service.NewTokenDecimals(ethClient)
+	pairedDecimals, err := decimals.Of(ctx, conf.Tokens.SnipeB.Addr())
+	if err != nil {
+		panic(err)
+	}
+	// min liquidity can have up to 4 decimal places
+	ml := parseUnits(float64(conf.Sniper.MinLiquidity), 4, pairedDecimals)
 
-	return domain.NewSniper(
+	sn := domain.NewSniper(
 		conf.Contracts.Trigger.Hex(),
 		conf.Tokens.SnipeB.Hex(),
 		conf.Tokens.SnipeA.Hex(),
 		ml,
 		chainID,
 	)
-}
 
-func newMonitors(conf *Config, sniper domain.Sniper) []service.Monitor {
-	monitors := make([]service.Monitor, 0, 2)
+	sn.Name = conf.Name
+	sn.Armed = true
+	sn.GasLimit = conf.Sniper.Gas.Limit
+	sn.BuyMode = domain.BuyMode(conf.Order.Mode)
+	if !sn.BuyMode.Valid() {
+		panic(fmt.Sprintf("order mode '%s' must be %s or %s", sn.BuyMode, domain.BuyModeExactIn, domain.BuyModeExactOut))
+	}
 
-	if conf.Sniper.Monitors.AddressListMonitor.Enabled {
-		l := make([]domain.NamedAddress, len(conf.Sniper.Monitors.AddressListMonitor.List))
-		for i, v := range conf.Sniper.Monitors.AddressListMonitor.List {
-			l[i] = domain.NewNamedAddress(v.Name, v.Addr.Hex())
-		}
+	// order amounts can have up to 3 decimal places, same as the trigger configurer
+	mul10pow15, _ := new(big.Int).SetString("1000000000000000", 10)
+	if conf.Order.Jitter < 0 || conf.Order.Jitter >= 100 {
+		panic(fmt.Sprintf("order jitter %.2f must be between 0 and 100", conf.Order.Jitter))
+	}
+	if conf.Order.Size > 0 {
+		sn.OrderSize = new(big.Int).Mul(big.NewInt(int64(math.Round(1000*conf.Order.Size))), mul10pow15)
+	}
+	if conf.Sniper.Budget > 0 {
+		sn.Budget = new(big.Int).Mul(big.NewInt(int64(math.Round(1000*conf.Sniper.Budget))), mul10pow15)
+	}
 
-		monitors = append(monitors, service.NewAddressMonitor(sniper, l...).Monitor)
+	// expected tokens are in units of the token, which may not have 18 decimals. Same as the trigger configurer
+	tokenDecimals, err := decimals.Of(ctx, conf.Tokens.SnipeA.Addr())
+	if err != nil {
+		panic(err)
 	}
+	expectedTokens := parseUnits(conf.Order.ExpectedTokens, 3, tokenDecimals)
 
-	if conf.Sniper.Monitors.WhaleMonitor.Enabled {
-		min, _ := new(big.Int).SetString(conf.Sniper.Monitors.WhaleMonitor.Min, 10)
-		monitors = append(monitors, service.NewWhaleMonitor(min).Monitor)
+	var jitter []*big.Int
+	if conf.Order.Jitter > 0 {
+		// configure-trigger picks a new seed each time, it's the only way to know which order it configured
+		seed := readJitterSeed(conf)
+		jitter = domain.OrderJitter{Percentage: conf.Order.Jitter}.Apply(
+			seed,
+			new(big.Int).Mul(big.NewInt(int64(math.Round(1000*conf.Order.Size))), mul10pow15),
+			expectedTokens,
+		)
+		sn.OrderSize = jitter[0]
 	}
 
-	return monitors
+	if conf.Sniper.CommitReveal.Enabled {
+		salt, err := hexutil.Decode(conf.Sniper.CommitReveal.Salt)
+		if err != nil || len(salt) != common.HashLength {
+			panic(fmt.Sprintf("commit reveal salt must be a 32 bytes hex: %v", err))
+		}
+		sn.Reveal = &domain.SniperReveal{
+			AmountIn:     new(big.Int).Mul(big.NewInt(int64(math.Round(1000*conf.Order.Size))), mul10pow15),
+			AmountOutMin: expectedTokens,
+			Salt:         common.BytesToHash(salt),
+		}
+		if jitter != nil {
+			sn.Reveal.AmountIn, sn.Reveal.AmountOutMin = jitter[0], jitter[1]
+		}
+	}
+	return sn
 }
 
-func newFactory(conf *Config, ethClient *service.EthClientCluster) *uniswap.IUniswapV2Factory {
-	factoryAddr := conf.Contracts.Factory.Addr()
-	factory, err := uniswap.NewIUniswapV2Factory(factoryAddr, ethClient)
+// newValuationBands of the launches, in the smallest unit of the paired token. Bounds can have up to 4 decimal
+// places, same as the min liquidity.
+func newValuationBands(ctx context.Context, conf *Config, ethClient *service.EthClientCluster) domain.ValuationBands {
+	v := conf.Sniper.Valuation
+	if v.MinLiquidityRatio < 0 || v.MinLiquidityRatio > 100 {
+		panic(fmt.Sprintf("min liquidity ratio %.2f must be between 0 and 100", v.MinLiquidityRatio))
+	}
+	bands := domain.ValuationBands{
+		MinLiquidityRatio: uint64(math.Round(100 * v.MinLiquidityRatio)),
+	}
+	if v.MinMarketCap <= 0 && v.MaxMarketCap <= 0 && v.MinFDV <= 0 && v.MaxFDV <= 0 {
+		return bands
+	}
+	pairedDecimals, err := service.NewTokenDecimals(ethClient).Of(ctx, conf.Tokens.SnipeB.Addr())
 	if err != nil {
 		panic(err)
 	}
+	bound := func(v float64) *big.Int {
+		if v <= 0 {
+			return nil
+		}
+		return parseUnits(v, 4, pairedDecimals)
+	}
+	bands.MinMarketCap = bound(v.MinMarketCap)
+	bands.MaxMarketCap = bound(v.MaxMarketCap)
+	bands.MinFDV = bound(v.MinFDV)
+	bands.MaxFDV = bound(v.MaxFDV)
+	return bands
+}
 
-	return factory
+// parseUnits of an amount with up to the given decimal places, into the smallest unit of a token with the given
+// decimals. eg. 1.5 with 6 decimals is 1500000.
+func parseUnits(v float64, places int, decimals uint8) *big.Int {
+	scale := math.Pow10(places)
+	amount := new(big.Int).Mul(big.NewInt(int64(math.Round(scale*v))), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
+	return amount.Div(amount, big.NewInt(int64(scale)))
 }
 
-func newBees(ctx context.Context, ethClient *service.EthClientCluster) []*service.Bee {
+// readJitterSeed configure-trigger picked for the order of the trigger of the instance
+func readJitterSeed(conf *Config) common.Hash {
 	dir := os.Getenv(configFolderEnv)
 	if len(dir) == 0 {
 		dir = configFolderDefault
 	}
-	b, err := os.ReadFile(fmt.Sprintf("%s/%s.json", dir, beeBookFile))
+	name := fmt.Sprintf("%s/%s_%s.json", dir, jitterFile, strings.ToLower(conf.Contracts.Trigger.Hex()))
+	b, err := os.ReadFile(name)
 	if err != nil {
+		panic(fmt.Sprintf("order jitter is set but there's no seed (run configure-trigger first): %s", err))
+	}
+
+	var j struct {
+		Seed string `json:"seed"`
+	}
+	if err := json.Unmarshal(b, &j); err != nil {
 		panic(err)
 	}
+	seed, err := hexutil.Decode(j.Seed)
+	if err != nil || len(seed) != common.HashLength {
+		panic(fmt.Sprintf("jitter seed of %s must be a 32 bytes hex: %v", name, err))
+	}
+	return common.BytesToHash(seed)
+}
 
-	var swarm []bee
-	err = json.Unmarshal(b, &swarm)
+// newReinvestPolicy of the realized profits of the targets. It's the same for all of them.
+func newReinvestPolicy(conf *Config) domain.ReinvestPolicy {
+	if conf.Sniper.Reinvest.Percentage > 100 {
+		panic(fmt.Sprintf("reinvest percentage %d is above 100", conf.Sniper.Reinvest.Percentage))
+	}
+	p := domain.ReinvestPolicy{Percentage: conf.Sniper.Reinvest.Percentage}
+	if conf.Sniper.Reinvest.MaxBudget > 0 {
+		mul10pow15, _ := new(big.Int).SetString("1000000000000000", 10)
+		p.MaxBudget = new(big.Int).Mul(big.NewInt(int64(math.Round(1000*conf.Sniper.Reinvest.MaxBudget))), mul10pow15)
+	}
+	return p
+}
+
+// newMonitors of the mempool for all the instances. They share the stream, so each monitor runs once no matter how
+// many instances enable it: the watched addresses are merged and each distinct whale minimum is watched once.
+func newMonitors(
+	instances []*Config,
+	sniper domain.Sniper,
+	gasOracle *service.GasOracle,
+	senderCache *service.SenderCache,
+) []service.Monitor {
+
+	monitors := make([]service.Monitor, 0, 3)
+
+	observeGas := false
+	addrs := make([]domain.NamedAddress, 0)
+	seenAddrs := make(map[common.Address]bool)
+	whales := make(map[string]bool)
+	for _, conf := range instances {
+		if conf.Sniper.Gas.MaxMultiplier > 0 || conf.Sniper.Gas.MinMultiplier > 0 {
+			observeGas = true
+		}
+
+		if conf.Sniper.Monitors.AddressListMonitor.Enabled {
+			for _, v := range conf.Sniper.Monitors.AddressListMonitor.List {
+				if !seenAddrs[v.Addr.Addr()] {
+					seenAddrs[v.Addr.Addr()] = true
+					addrs = append(addrs, domain.NewNamedAddress(v.Name, v.Addr.Hex()))
+				}
+			}
+		}
+
+		if conf.Sniper.Monitors.WhaleMonitor.Enabled && !whales[conf.Sniper.Monitors.WhaleMonitor.Min] {
+			whales[conf.Sniper.Monitors.WhaleMonitor.Min] = true
+			min, _ := new(big.Int).SetString(conf.Sniper.Monitors.WhaleMonitor.Min, 10)
+			monitors = append(monitors, service.NewWhaleMonitor(min).Monitor)
+		}
+	}
+
+	if observeGas {
+		monitors = append(monitors, gasOracle.Observe)
+	}
+	if len(addrs) > 0 {
+		monitors = append(monitors, service.NewAddressMonitor(sniper, senderCache, addrs...).Monitor)
+	}
+	return monitors
+}
+
+func newFactory(conf *Config, ethClient *service.EthClientCluster) *uniswap.IUniswapV2Factory {
+	factoryAddr := conf.Contracts.Factory.Addr()
+	factory, err := uniswap.NewIUniswapV2Factory(factoryAddr, ethClient)
+	if err != nil {
+		panic(err)
+	}
+
+	return factory
+}
+
+func newBees(ctx context.Context, conf *Config, ethClient *service.EthClientCluster) ([]*service.Bee, []common.Address) {
+	swarm, err := readBeeBook(conf)
 	if err != nil {
 		panic(err)
 	}
 
 	res := make([]*service.Bee, len(swarm))
+	addrs := make([]common.Address, len(swarm))
 	for i, bee := range swarm {
 		addr := common.HexToAddress(bee.Address)
+		addrs[i] = addr
 		pn, err := ethClient.PendingNonceAt(ctx, addr)
 		if err != nil {
 			panic(err)
@@ -125,27 +319,362 @@ func newBees(ctx context.Context, ethClient *service.EthClientCluster) []*servic
 			pn++
 		}
 
-		rawPK, err := crypto.HexToECDSA(bee.PK[2:])
+		log.Info(fmt.Sprintf("creating bee %s with nonce: %d", bee.Address, pn))
+		res[i] = service.NewBee(mustSecretKey(bee.PK), pn)
+	}
+
+	return res, addrs
+}
+
+// readBeeBook of the instance, with the swarm sniping for it
+func readBeeBook(conf *Config) ([]bee, error) {
+	dir := os.Getenv(configFolderEnv)
+	if len(dir) == 0 {
+		dir = configFolderDefault
+	}
+	book := conf.BeeBook
+	if len(book) == 0 {
+		book = beeBookFile
+	}
+	b, err := os.ReadFile(fmt.Sprintf("%s/%s.json", dir, book))
+	if err != nil {
+		return nil, err
+	}
+
+	var swarm []bee
+	if err := json.Unmarshal(b, &swarm); err != nil {
+		return nil, fmt.Errorf("error parsing bee book %s: %s", book, err)
+	}
+	return swarm, nil
+}
+
+// newPanicWallets to panic sell from besides the bees, with their addresses
+func newPanicWallets(
+	conf *Config,
+	ethClient *service.EthClientCluster,
+	chainID *big.Int,
+	dynamicFees bool,
+) (*service.PanicWallets, []common.Address) {
+
+	keys := make([]*ecdsa.PrivateKey, len(conf.Panic.Wallets))
+	addrs := make([]common.Address, len(conf.Panic.Wallets))
+	for i, w := range conf.Panic.Wallets {
+		keys[i] = mustSecretKey(w)
+		addrs[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+		log.Info(fmt.Sprintf("panic sells can sell from %s", addrs[i].Hex()))
+	}
+	return service.NewPanicWallets(ethClient, chainID, dynamicFees, keys...), addrs
+}
+
+// newPanicSeller of everything held by the bees of every instance and the panic wallets
+func newPanicSeller(
+	conf *Config,
+	ethClient *service.EthClientCluster,
+	gasOracle *service.GasOracle,
+	wallets *service.PanicWallets,
+	snipers []*service.Sniper,
+	timing domain.Timing,
+) *service.PanicSeller {
+
+	gm := conf.Panic.GasMultiplier
+	if gm == 0 {
+		gm = panicGasMultiplier
+	}
+	if gm < 1 {
+		panic(fmt.Sprintf("panic gas multiplier %.2f must be at least 1", gm))
+	}
+	return service.NewPanicSeller(gasOracle, ethClient, wallets, snipers, conf.Contracts.Router.Addr(), conf.Tokens.WBNB.Addr(), gm, timing)
+}
+
+// newPriceFeed of the native coin in USD the trades are reported with, nil if it isn't configured
+func newPriceFeed(conf *Config, ethClient *service.EthClientCluster) *service.PriceFeed {
+	if len(conf.Contracts.PriceFeed) == 0 {
+		return nil
+	}
+	log.Info(fmt.Sprintf("reporting trades in usd with the price feed %s", conf.Contracts.PriceFeed.Hex()))
+	return service.NewPriceFeed(conf.Contracts.PriceFeed.Addr(), ethClient)
+}
+
+// newRouterMethods adding liquidity to the router, read from its abi if an explorer is configured. Else (or if it
+// can't be read) they are the uniswap ones.
+func newRouterMethods(ctx context.Context, conf *Config, limits *service.RateLimits) service.RouterMethods {
+	ex := conf.Contracts.Explorer
+	if len(ex.API) == 0 {
+		return service.UniswapRouterMethods
+	}
+	cache := ex.Cache
+	if len(cache) == 0 {
+		dir := os.Getenv(configFolderEnv)
+		if len(dir) == 0 {
+			dir = configFolderDefault
+		}
+		cache = fmt.Sprintf("%s/%s", dir, routerABIsFolder)
+	}
+	var key string
+	if len(ex.Key) > 0 {
+		key = mustSecretString(ex.Key)
+	}
+	a, err := service.NewRouterABIs(ex.API, key, cache, limits).ABI(ctx, conf.Contracts.Router.Addr())
+	switch {
+	case err != nil && len(a.Methods) == 0:
+		log.Warn(fmt.Sprintf("decoding router %s as uniswap's, its abi couldn't be read: %s", conf.Contracts.Router.Hex(), err))
+		return service.UniswapRouterMethods
+	case err != nil:
+		log.Warn(err.Error()) // fetched but not cached, it's fetched again next time
+	}
+	m := service.NewRouterMethods(a)
+	if m.Empty() {
+		log.Warn(fmt.Sprintf("decoding router %s as uniswap's, its abi has no method adding liquidity we know", conf.Contracts.Router.Hex()))
+		return service.UniswapRouterMethods
+	}
+	log.Info(fmt.Sprintf("decoding router %s with its abi, adding liquidity with %x and the native coin with %x",
+		conf.Contracts.Router.Hex(), m.AddLiquidity, m.AddLiquidityETH))
+	return m
+}
+
+// newCompetingBuys of the target of the instance, nil if its slippage isn't adaptive. The sniper adapts its slippage
+// to them.
+func newCompetingBuys(conf *Config, s *service.Sniper, sn domain.Sniper, timing domain.Timing) *service.CompetingBuys {
+	if !conf.Sniper.Slippage.Adaptive {
+		return nil
+	}
+	b := service.NewCompetingBuys(timing.Blocks(competingBuysBlocks), sn)
+	sl := newSlippage(conf)
+	s.AdaptSlippage(conf.Tokens.WBNB.Addr(), sl, b)
+	log.Info(fmt.Sprintf("%s adapts its slippage to the competing buys, accepting %.2f%% below the simulation", conf.Name, float64(sl.Buffer)/100))
+	return b
+}
+
+// newSandwichBots around the target of the instance, nil if its snipes aren't guarded from them. The sniper guards its
+// snipes while they are pending.
+func newSandwichBots(conf *Config, s *service.Sniper, sn domain.Sniper, timing domain.Timing) *service.SandwichBots {
+	if !conf.Sniper.Sandwich.Enabled {
+		return nil
+	}
+	action := domain.SandwichAction(conf.Sniper.Sandwich.Action)
+	switch {
+	case !action.Valid():
+		panic(fmt.Sprintf("unknown sandwich action %s", action))
+	case !conf.Sniper.Slippage.Adaptive:
+		panic("the sandwich guard needs sniper.slippage.adaptive, as it estimates the exposure from the simulation")
+	case conf.Sniper.Sandwich.MaxExposure < 0:
+		panic(fmt.Sprintf("sandwich max exposure %f can't be negative", conf.Sniper.Sandwich.MaxExposure))
+	case action == domain.SandwichActionPrivate && len(conf.Sniper.Submission.Relays) == 0:
+		panic("the sandwich guard can't keep snipes private without sniper.submission.relays")
+	case action == domain.SandwichActionReduce && conf.Sniper.CommitReveal.Enabled:
+		panic("the sandwich guard can't reduce a committed order, see sniper.commit_reveal")
+	}
+
+	g := domain.SandwichGuard{Action: action}
+	if conf.Sniper.Sandwich.MaxExposure > 0 {
+		mul10pow15, _ := new(big.Int).SetString("1000000000000000", 10)
+		g.MaxExposure = new(big.Int).Mul(big.NewInt(int64(math.Round(1000*conf.Sniper.Sandwich.MaxExposure))), mul10pow15)
+	}
+	b := service.NewSandwichBots(timing.Blocks(sandwichBotsBlocks), conf.Contracts.Router.Addr(), sn)
+	s.GuardSandwiches(g, b)
+	log.Info(fmt.Sprintf("%s guards its snipes from sandwich bots (max exposure %.3f, action %q)", conf.Name, conf.Sniper.Sandwich.MaxExposure, action))
+	return b
+}
+
+// newSlippage of the snipes of the instance, in basis points
+func newSlippage(conf *Config) domain.Slippage {
+	buffer := conf.Sniper.Slippage.Buffer
+	if buffer == 0 {
+		buffer = slippageBuffer
+	}
+	if buffer < 0 || buffer >= 100 || conf.Sniper.Slippage.Max < 0 || conf.Sniper.Slippage.Max >= 100 {
+		panic(fmt.Sprintf("slippage buffer %.2f and max %.2f must be between 0 and 100", buffer, conf.Sniper.Slippage.Max))
+	}
+	return domain.Slippage{
+		Buffer: uint64(math.Round(buffer * 100)),
+		Max:    uint64(math.Round(conf.Sniper.Slippage.Max * 100)),
+		Fee:    newSwapFee(conf),
+	}
+}
+
+// newSwapFee of the v2 pairs of the router, in basis points. Pancake's unless configured.
+func newSwapFee(conf *Config) domain.SwapFee {
+	if conf.Contracts.SwapFee == 0 {
+		return domain.SwapFeePancake
+	}
+	fee := domain.SwapFee(math.Round(conf.Contracts.SwapFee * 100))
+	if conf.Contracts.SwapFee < 0 || !fee.Valid() {
+		panic(fmt.Sprintf("swap fee %.2f must be between 0 and 100", conf.Contracts.SwapFee))
+	}
+	return fee
+}
+
+// newLiquidityMigration of the instance, nil if it doesn't follow migrations
+func newLiquidityMigration(
+	conf *Config,
+	e *service.EthClientCluster,
+	s *service.Sniper,
+	a *service.Alerts,
+	d service.DecisionRecorders,
+	sn domain.Sniper,
+) *service.LiquidityMigration {
+
+	if !conf.Sniper.Migration.Enabled {
+		return nil
+	}
+	if len(conf.Contracts.PositionManager) == 0 {
+		panic(fmt.Sprintf("instance %s follows liquidity migrations without the v3 position manager", conf.Name))
+	}
+	window := time.Duration(conf.Sniper.Migration.Window) * time.Second
+	if window == 0 {
+		window = migrationWindow
+	}
+	pairedFee := uint32(conf.Sniper.Migration.PairedFee)
+	if pairedFee == 0 {
+		pairedFee = migrationPairedFee
+	}
+	log.Info(fmt.Sprintf("%s follows liquidity migrations within %s (entering: %t)", conf.Name, window, conf.Sniper.Migration.Enter))
+	return service.NewLiquidityMigration(
+		e,
+		s,
+		a,
+		d,
+		sn,
+		conf.Contracts.PositionManager.Addr(),
+		conf.Sniper.Migration.Enter,
+		window,
+		pairedFee,
+	)
+}
+
+// newNonceReconcileInterval of the swarm, the default one if not configured
+func newNonceReconcileInterval(conf *Config) time.Duration {
+	if conf.Sniper.Submission.NonceReconcile == 0 {
+		return nonceReconcileInterval
+	}
+	return time.Duration(conf.Sniper.Submission.NonceReconcile) * time.Second
+}
+
+// newStealthDelay of the bees, up to a third of a block. Zero disables it.
+func newStealthDelay(conf *Config, timing domain.Timing) time.Duration {
+	d := time.Duration(conf.Sniper.Submission.StealthDelay) * time.Millisecond
+	if max := time.Duration(float64(timing.BlockTime) * maxStealthDelayBlocks); d > max {
+		panic(fmt.Sprintf("stealth delay %s is above %s", d, max))
+	}
+	return d
+}
+
+// newTiming of the chain, from its configured block time or the one of its id if we know it
+func newTiming(conf *Config, chainID *big.Int) domain.Timing {
+	return domain.NewTiming(chainID, time.Duration(conf.Chains.BlockTime)*time.Millisecond)
+}
+
+func newQuietHours(conf *Config) *service.QuietHours {
+	windows := make([]service.QuietWindow, len(conf.Sniper.QuietHours.Windows))
+	for i, w := range conf.Sniper.QuietHours.Windows {
+		qw, err := service.ParseQuietWindow(w)
 		if err != nil {
 			panic(err)
 		}
+		windows[i] = qw
+	}
 
-		log.Info(fmt.Sprintf("creating bee %s with nonce: %d", bee.Address, pn))
-		res[i] = service.NewBee(rawPK, pn)
+	loc := time.Local
+	if len(conf.Sniper.QuietHours.Timezone) > 0 {
+		l, err := time.LoadLocation(conf.Sniper.QuietHours.Timezone)
+		if err != nil {
+			panic(err)
+		}
+		loc = l
+	}
+	if len(windows) > 0 {
+		log.Info(fmt.Sprintf("quiet hours %v (%s)", conf.Sniper.QuietHours.Windows, loc))
 	}
+	return service.NewQuietHours(windows, loc)
+}
 
-	return res
+func newRelays(conf *Config, limits *service.RateLimits) *service.RelayCluster {
+	relays := make([]*service.Relay, len(conf.Sniper.Submission.Relays))
+	for i, r := range conf.Sniper.Submission.Relays {
+		var authKey *ecdsa.PrivateKey
+		if len(r.AuthKey) > 0 {
+			authKey = mustSecretKey(r.AuthKey)
+		}
+		log.Info(fmt.Sprintf("using bundle relay %s", r.Name))
+		relays[i] = service.NewRelay(r.Name, r.URL, authKey, limits)
+	}
+	return service.NewRelayCluster(relays...)
+}
+
+// newRebroadcaster of our own txs through the alternate nodes, if any
+func newRebroadcaster(ctx context.Context, conf *Config) *service.Rebroadcaster {
+	nodes := make([]*service.RPCEthClient, len(conf.Chains.Nodes.Rebroadcast))
+	for i, url := range conf.Chains.Nodes.Rebroadcast {
+		nodes[i] = service.NewRPCEthClient(newRPCClient(ctx, conf, url))
+	}
+	if len(nodes) > 0 {
+		log.Info(fmt.Sprintf("rebroadcasting vanished txs through %d alternate nodes", len(nodes)))
+	}
+	return service.NewRebroadcaster(nodes...)
+}
+
+// newRateLimits of the external providers, by name (eg. the name of a relay)
+func newRateLimits(conf *Config) *service.RateLimits {
+	limits := make(map[string]service.RateLimit, len(conf.RateLimits))
+	for name, l := range conf.RateLimits {
+		if l.Rate < 0 {
+			panic(fmt.Sprintf("rate limit of %s has a negative rate", name))
+		}
+		limits[name] = service.RateLimit{Rate: l.Rate, Burst: l.Burst, Quota: l.Quota}
+		log.Info(fmt.Sprintf("rate limiting %s to %.2f calls/s (burst %d) and %d calls/day", name, l.Rate, l.Burst, l.Quota))
+	}
+	return service.NewRateLimits(limits)
+}
+
+func presign(conf *Config, s *service.Sniper) {
+	if len(conf.Sniper.Gas.PresignLevels) == 0 {
+		return
+	}
+
+	mul10pow6 := big.NewInt(1000000)
+	levels := make([]*big.Int, len(conf.Sniper.Gas.PresignLevels))
+	for i, gwei := range conf.Sniper.Gas.PresignLevels {
+		// gwei can have up to 3 decimal places
+		levels[i] = new(big.Int).Mul(big.NewInt(int64(math.Round(1000*gwei))), mul10pow6)
+	}
+	if err := s.Presign(levels...); err != nil {
+		panic(err)
+	}
+	log.Info(fmt.Sprintf("presigned snipe txs at gas levels (gwei) %v", conf.Sniper.Gas.PresignLevels))
 }
 
 func newUniswapLiquidityClient(
 	e *service.EthClientCluster,
 	s *service.Sniper,
+	c *service.SenderCache,
+	d service.DecisionRecorders,
+	conf *Config,
 	sn domain.Sniper,
+	vb domain.ValuationBands,
+	timing domain.Timing,
 ) *service.UniswapLiquidity {
 
-	v, err := service.NewUniswapLiquidity(e, s, sn)
+	v, err := service.NewUniswapLiquidity(
+		e,
+		s,
+		c,
+		d,
+		sn,
+		conf.Sniper.Mode != SniperModeBlockScan, // in blocks they are no longer pending
+		conf.Sniper.ValidateVictim,
+		time.Duration(conf.Sniper.MaxDeadline)*time.Second,
+		vb,
+	)
 	if err != nil {
 		panic(err)
 	}
+	if conf.Sniper.ConstructorLiquidity {
+		factory, err := uniswap.NewIUniswapV2FactoryCaller(conf.Contracts.Factory.Addr(), e)
+		if err != nil {
+			panic(err)
+		}
+		v.DetectConstructorLiquidity(service.NewPairReserves(factory, e), e, timing)
+		log.Info(fmt.Sprintf("%s snipes %s if its deploy adds liquidity", conf.Name, conf.Tokens.SnipeA.Hex()))
+	}
 	return v
 }
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseUnits(t *testing.T) {
+	tests := []struct {
+		name     string
+		v        float64
+		places   int
+		decimals uint8
+		expect   string
+	}{
+		{"18 decimals", 1.5, 3, 18, "1500000000000000000"},
+		{"6 decimals", 5000, 4, 6, "5000000000"},
+		{"9 decimals", 0.0125, 4, 9, "12500000"},
+		{"fewer decimals than places", 1.234, 3, 2, "123"},
+		{"rounds to places", 0.00016, 4, 18, "200000000000000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseUnits(tt.v, tt.places, tt.decimals); got.String() != tt.expect {
+				t.Fatalf("expected %s, got %s", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		v         string
+		decimals  uint8
+		expect    string
+		expectErr bool
+	}{
+		{"1.5", 18, "1500000000000000000", false},
+		{"5000", 6, "5000000000", false},
+		{"0.5", 9, "500000000", false},
+		{"-1", 18, "", true},
+		{"abc", 18, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.v, func(t *testing.T) {
+			got, err := parseAmount(tt.v, tt.decimals)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if err == nil && got.String() != tt.expect {
+				t.Fatalf("expected %s, got %s", tt.expect, got)
+			}
+		})
+	}
+	if got, err := parseAmount("", 18); got != nil || err != nil {
+		t.Fatalf("expected no amount, got %v (%v)", got, err)
+	}
+}
+
+func TestServeAPIRequiresToken(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected the api not to be served without a token")
+		}
+	}()
+	serveAPI(&Config{API: API{Address: "127.0.0.1:0"}})
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/controller"
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+const (
+	tuiCandidates = 8
+	tuiDecisions  = 15
+	// tuiFeed is how many decisions we fetch to fill both the candidates and the decisions panels
+	tuiFeed = 200
+
+	ansiClear = "\033[H\033[2J"
+	ansiBold  = "\033[1m"
+	ansiReset = "\033[0m"
+	// colors have the same length, so columns painted with them stay aligned by the tabwriter
+	ansiNone  = "\033[39m"
+	ansiRed   = "\033[31m"
+	ansiGreen = "\033[32m"
+	ansiYell  = "\033[33m"
+	ansiGrey  = "\033[90m"
+)
+
+type tuiState struct {
+	targets   []controller.TargetBody
+	positions []controller.PositionBody
+	decisions []controller.DecisionBody
+	err       error
+}
+
+// runTUI refreshes a dashboard of the running bot in the terminal until interrupted. It only needs the api, so it can
+// run over SSH next to the bot (or through a tunnel to it).
+func runTUI(conf *Config, args []string) error {
+	fs := flag.NewFlagSet("tui", flag.ContinueOnError)
+	interval := fs.Duration("interval", time.Second, "refresh interval")
+	if err := fs.Parse(args); err != nil {
+		return usageError(nil)
+	}
+	if *interval <= 0 {
+		return fmt.Errorf("invalid interval %s", *interval)
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	t := time.NewTicker(*interval)
+	defer t.Stop()
+	for {
+		var buf bytes.Buffer
+		renderTUI(&buf, conf, fetchTUI(conf))
+		os.Stdout.Write(buf.Bytes())
+
+		select {
+		case <-t.C:
+		case <-stop:
+			fmt.Println()
+			return nil
+		}
+	}
+}
+
+func fetchTUI(conf *Config) tuiState {
+	var s tuiState
+	base := fmt.Sprintf("http://%s", conf.API.Address)
+	for _, v := range []struct {
+		path string
+		dst  interface{}
+	}{
+		{"/targets", &s.targets},
+		{"/positions", &s.positions},
+		{fmt.Sprintf("/decisions?limit=%d", tuiFeed), &s.decisions},
+	} {
+		b, res, err := requestAPI(conf, http.MethodGet, base+v.path, nil)
+		if err == nil && res.StatusCode >= http.StatusBadRequest {
+			err = fmt.Errorf("api responded %s to %s", res.Status, v.path)
+		}
+		if err == nil {
+			err = json.Unmarshal(b, v.dst)
+		}
+		if err != nil {
+			s.err = err
+			return s
+		}
+	}
+	return s
+}
+
+func renderTUI(w io.Writer, conf *Config, s tuiState) {
+	fmt.Fprint(w, ansiClear)
+	fmt.Fprintf(w, "%sax-50%s  %s  %s\n\n",
+		ansiBold, ansiReset, conf.API.Address, paint(ansiGrey, time.Now().Format("2006-01-02 15:04:05")+"  (ctrl+c to quit)"))
+	if s.err != nil {
+		fmt.Fprintln(w, paint(ansiRed, s.err.Error()))
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%sTARGETS%s\n", ansiBold, ansiReset)
+	fmt.Fprintf(tw, "NAME\t%s\tTOKEN\tMIN LIQ\tORDER\tBUDGET\t\n", paint(ansiNone, "ARMED"))
+	for _, t := range s.targets {
+		armed := paint(ansiGrey, "no")
+		if t.Armed {
+			armed = paint(ansiGreen, "yes")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t\n",
+			t.Name, armed, shortHex(t.Token), formatWei(t.MinLiquidity), formatWei(t.OrderSize), formatWei(t.Budget))
+	}
+
+	fmt.Fprintf(tw, "\n%sPOSITIONS%s\n", ansiBold, ansiReset)
+	fmt.Fprintf(tw, "TARGET\tTOKEN\tAMOUNT\tCOST\tVALUE\t%s\tTRADES\t\n", paint(ansiNone, "PNL"))
+	for _, p := range s.positions {
+		color := ansiNone
+		if p.PnL != nil && p.PnL.Sign() < 0 {
+			color = ansiRed
+		} else if p.PnL != nil {
+			color = ansiGreen
+		}
+		pnl := paint(color, formatWei(p.PnL))
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t\n",
+			p.Target, shortHex(p.Token), formatWei(p.Amount), formatWei(p.Cost), formatWei(p.Value), pnl, p.Trades)
+	}
+
+	candidates, decisions := splitTUIDecisions(s.decisions)
+	fmt.Fprintf(tw, "\n%sCANDIDATES%s\n", ansiBold, ansiReset)
+	fmt.Fprintln(tw, "TIME\tTARGET\tTX\t")
+	for _, d := range candidates {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t\n", d.Time.Local().Format("15:04:05"), d.Target, d.Tx)
+	}
+
+	fmt.Fprintf(tw, "\n%sDECISIONS%s\n", ansiBold, ansiReset)
+	fmt.Fprintf(tw, "TIME\tTARGET\t%s\tTX\tDETAIL\t\n", paint(ansiNone, "DECISION"))
+	for _, d := range decisions {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t\n",
+			d.Time.Local().Format("15:04:05"), d.Target, colorDecision(d), shortHex(d.Tx), truncate(d.Detail, 80))
+	}
+	tw.Flush()
+}
+
+// splitTUIDecisions into the latest candidates seen and the latest decisions about them
+func splitTUIDecisions(all []controller.DecisionBody) ([]controller.DecisionBody, []controller.DecisionBody) {
+	var candidates, decisions []controller.DecisionBody
+	for _, d := range all {
+		if d.Kind == domain.DecisionCandidateSeen {
+			if len(candidates) < tuiCandidates {
+				candidates = append(candidates, d)
+			}
+		} else if len(decisions) < tuiDecisions {
+			decisions = append(decisions, d)
+		}
+	}
+	return candidates, decisions
+}
+
+func colorDecision(d controller.DecisionBody) string {
+	switch d.Kind {
+	case domain.DecisionSniped:
+		return paint(ansiGreen, string(d.Kind))
+	case domain.DecisionSnipeFailed:
+		return paint(ansiRed, string(d.Kind))
+	case domain.DecisionRejected:
+		return paint(ansiYell, string(d.Reason))
+	default:
+		return paint(ansiNone, string(d.Kind))
+	}
+}
+
+func paint(color, s string) string {
+	return color + s + ansiReset
+}
+
+// formatWei in ether units, or - if unknown
+func formatWei(v *big.Int) string {
+	if v == nil {
+		return "-"
+	}
+	return new(big.Float).Quo(new(big.Float).SetInt(v), big.NewFloat(1e18)).Text('f', 4)
+}
+
+func shortHex(s string) string {
+	if len(s) <= 14 {
+		return s
+	}
+	return s[:8] + ".." + s[len(s)-4:]
+}
+
+func truncate(s string, n int) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-2] + ".."
+}
@@ -1,21 +1,302 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
 	"github.com/saantiaguilera/liquidity-sniper/pkg/service"
 	"github.com/saantiaguilera/liquidity-sniper/pkg/usecase"
+	"github.com/saantiaguilera/liquidity-sniper/third_party/uniswap"
 )
 
 func newTxClassifierUseCase(
 	conf *Config,
+	routerMethods service.RouterMethods,
 	monitorEngine *service.MonitorEngine,
-	uniLiqClient *service.UniswapLiquidity,
+	uniLiqClients []*service.UniswapLiquidity,
+	deploys []*service.UniswapLiquidity,
+	migrations []*service.LiquidityMigration,
+	competing []*service.CompetingBuys,
+	sandwiches []*service.SandwichBots,
 ) *usecase.TransactionClassifier {
 
+	addETH := make([]usecase.TransactionClassifierStrategy, len(uniLiqClients))
+	add := make([]usecase.TransactionClassifierStrategy, len(uniLiqClients))
+	for i, c := range uniLiqClients {
+		addETH[i] = c.AddETH
+		add[i] = c.Add
+	}
+
 	routerAddr := conf.Contracts.Router.Addr()
 	strats := make(map[[4]byte]usecase.TransactionClassifierStrategy)
 	// Put the 4 bytes of each contract signature mapped to the strategy
-	strats[[...]byte{0xf3, 0x05, 0xd7, 0x19}] = uniLiqClient.AddETH
-	strats[[...]byte{0xe8, 0xe3, 0x37, 0x00}] = uniLiqClient.Add
+	for _, sel := range routerMethods.AddLiquidityETH {
+		strats[sel] = usecase.FanOut(addETH...)
+	}
+	for _, sel := range routerMethods.AddLiquidity {
+		strats[sel] = usecase.FanOut(add...)
+	}
+
+	if len(competing) > 0 {
+		buy := make([]usecase.TransactionClassifierStrategy, len(competing))
+		for i, b := range competing {
+			buy[i] = b.Buy
+		}
+		// swapExactETHForTokens / swapETHForExactTokens / swapExactTokensForTokens and their
+		// SupportingFeeOnTransferTokens variants
+		strats[[...]byte{0x7f, 0xf3, 0x6a, 0xb5}] = usecase.FanOut(buy...)
+		strats[[...]byte{0xb6, 0xf9, 0xde, 0x95}] = usecase.FanOut(buy...)
+		strats[[...]byte{0xfb, 0x3b, 0xdb, 0x41}] = usecase.FanOut(buy...)
+		strats[[...]byte{0x38, 0xed, 0x17, 0x39}] = usecase.FanOut(buy...)
+		strats[[...]byte{0x5c, 0x11, 0xd7, 0x95}] = usecase.FanOut(buy...)
+	}
+
+	// sandwich bots call their own contracts, so they are looked for in every tx before the monitors
+	monitor := monitorEngine.Monitor
+	if len(sandwiches) > 0 {
+		monitor = func(ctx context.Context, tx *types.Transaction) {
+			for _, b := range sandwiches {
+				b.Observe(ctx, tx)
+			}
+			monitorEngine.Monitor(ctx, tx)
+		}
+	}
+
+	uc := usecase.NewTransactionClassifier(routerAddr.Hex(), monitor, strats)
+	if len(deploys) > 0 {
+		deploy := make([]usecase.TransactionClassifierStrategy, len(deploys))
+		for i, c := range deploys {
+			deploy[i] = c.Deploy
+		}
+		uc.Deploys(usecase.FanOut(deploy...))
+	}
+	if len(migrations) == 0 {
+		return uc
+	}
+
+	remove := make([]usecase.TransactionClassifierStrategy, len(migrations))
+	removeETH := make([]usecase.TransactionClassifierStrategy, len(migrations))
+	manage := make([]usecase.TransactionClassifierStrategy, len(migrations))
+	for i, m := range migrations {
+		remove[i] = m.Remove
+		removeETH[i] = m.RemoveETH
+		manage[i] = m.Manage
+	}
+	// removeLiquidity / removeLiquidityWithPermit
+	strats[[...]byte{0xba, 0xa2, 0xab, 0xde}] = usecase.FanOut(remove...)
+	strats[[...]byte{0x21, 0x95, 0x99, 0x5c}] = usecase.FanOut(remove...)
+	// removeLiquidityETH / removeLiquidityETHWithPermit and their SupportingFeeOnTransferTokens variants
+	strats[[...]byte{0x02, 0x75, 0x1c, 0xec}] = usecase.FanOut(removeETH...)
+	strats[[...]byte{0xde, 0xd9, 0x38, 0x2a}] = usecase.FanOut(removeETH...)
+	strats[[...]byte{0xaf, 0x29, 0x79, 0xeb}] = usecase.FanOut(removeETH...)
+	strats[[...]byte{0x5b, 0x0d, 0x59, 0x84}] = usecase.FanOut(removeETH...)
+
+	// mint / decreaseLiquidity / multicall of the v3 position manager
+	pm := make(map[[4]byte]usecase.TransactionClassifierStrategy)
+	pm[[...]byte{0x88, 0x31, 0x64, 0x56}] = usecase.FanOut(manage...)
+	pm[[...]byte{0x0c, 0x49, 0xcc, 0xbe}] = usecase.FanOut(manage...)
+	pm[[...]byte{0xac, 0x96, 0x50, 0xd8}] = usecase.FanOut(manage...)
+	uc.Route(conf.Contracts.PositionManager.Addr().Hex(), pm)
+	return uc
+}
+
+// newTxLanesUseCase watching the token of the target of each instance, following its changes
+func newTxLanesUseCase(
+	ctx context.Context,
+	conf *Config,
+	instances []*Config,
+	targetManager *usecase.TargetManager,
+	uc *usecase.TransactionClassifier,
+	migrations, deploys bool,
+) *usecase.TransactionLanes {
+
+	var watched []string
+	if migrations {
+		watched = append(watched, conf.Contracts.PositionManager.Hex())
+	}
+
+	l := usecase.NewTransactionLanes(
+		uc.Classify,
+		uc.Classify,
+		slowLaneWorkers,
+		slowLaneQueue,
+		conf.Contracts.Router.Hex(),
+		watched...,
+	)
+	for _, i := range instances {
+		t := l.Target(i.Name)
+		targetManager.Register(i.Name, t)
+		sn, err := targetManager.Get(ctx, i.Name)
+		if err != nil {
+			panic(fmt.Sprintf("error getting target of %s: %s", i.Name, err))
+		}
+		if err := t.SetTarget(sn); err != nil {
+			panic(err)
+		}
+	}
+	if deploys {
+		l.WatchDeploys()
+	}
+	return l
+}
+
+// newPortfolio across the bees of every instance, the panic wallets and the configured wallets (eg. the admin getting
+// the sniped tokens). A wallet is only counted once, even if it's more than one of them.
+func newPortfolio(
+	conf *Config,
+	ethClient *service.EthClientCluster,
+	repos repositories,
+	targets *usecase.TargetManager,
+	bees []common.Address,
+) *usecase.Portfolio {
+
+	router, err := uniswap.NewIUniswapV2Router02Caller(conf.Contracts.Router.Addr(), ethClient)
+	if err != nil {
+		panic(err)
+	}
+	wallets := make([]common.Address, 0, len(bees)+len(conf.Portfolio.Wallets))
+	seen := make(map[common.Address]bool)
+	add := func(w common.Address) {
+		if !seen[w] {
+			seen[w] = true
+			wallets = append(wallets, w)
+		}
+	}
+	for _, w := range bees {
+		add(w)
+	}
+	for _, w := range conf.Portfolio.Wallets {
+		add(w.Addr())
+	}
+	return usecase.NewPortfolio(repos.trades, targets, service.NewRouterQuoter(router), service.NewTokenBalances(ethClient), wallets)
+}
+
+// newPanicSell of everything held, reinvesting the profits of the targets it sells if there's a reinvest policy
+func newPanicSell(
+	conf *Config,
+	portfolio *usecase.Portfolio,
+	seller *service.PanicSeller,
+	targetManager *usecase.TargetManager,
+	alerts *service.Alerts,
+) *usecase.PanicSell {
+
+	p := usecase.NewPanicSell(portfolio, seller, alerts)
+	if conf.Sniper.Reinvest.Percentage > 0 {
+		p.ReinvestProfits(targetManager)
+	}
+	return p
+}
+
+// newDeadManSwitch of our nodes, nil if it's disabled
+func newDeadManSwitch(
+	conf *Config,
+	ethClient *service.EthClientCluster,
+	portfolio *usecase.Portfolio,
+	seller *service.PanicSeller,
+	backup *service.BackupRoute,
+	alerts *service.Alerts,
+	timing domain.Timing,
+) *usecase.DeadManSwitch {
+
+	if !conf.DeadMan.Enabled {
+		return nil
+	}
+	threshold := time.Duration(conf.DeadMan.Threshold) * time.Second
+	if threshold == 0 {
+		threshold = deadManThreshold
+	}
+	if threshold < 2*deadManInterval {
+		panic(fmt.Sprintf("dead man threshold %s must be at least %s", threshold, 2*deadManInterval))
+	}
+	blockTime := time.Duration(conf.DeadMan.BlockTime) * time.Millisecond
+	if blockTime == 0 {
+		blockTime = timing.BlockTime
+	}
+	if conf.DeadMan.Fire && !backup.Enabled() {
+		log.Warn("the dead man switch fires without rebroadcast nodes nor relays, it will only alert")
+	}
+	log.Info(fmt.Sprintf("dead man switch trips after %s without our nodes (firing emergency sells: %t)", threshold, conf.DeadMan.Fire))
+	return usecase.NewDeadManSwitch(ethClient, portfolio, seller, backup, alerts, threshold, deadManInterval, blockTime, conf.DeadMan.Fire)
+}
+
+// newHeartbeat of the bot, nil if it's disabled
+func newHeartbeat(conf *Config, targetManager *usecase.TargetManager, alerts *service.Alerts) *usecase.Heartbeat {
+	if conf.Alerts.Heartbeat.Interval == 0 {
+		return nil
+	}
+	interval := time.Duration(conf.Alerts.Heartbeat.Interval) * time.Second
+	check := service.NewHealthCheck(mustSecretString(conf.Alerts.Heartbeat.Check))
+	if !check.Enabled() {
+		log.Warn("heartbeats without an external check, a crash won't be alerted")
+	}
+	log.Info(fmt.Sprintf("heartbeat every %s", interval))
+	return usecase.NewHeartbeat(targetManager, alerts, check, interval)
+}
+
+func newPriceAlerts(conf *Config, portfolio *usecase.Portfolio, alerts *service.Alerts) *usecase.PriceAlerts {
+	for _, m := range conf.Alerts.Price.Multiples {
+		if m <= 0 || m == 1 {
+			panic(fmt.Sprintf("invalid price alert multiple %g, it must be above 1 (gains) or between 0 and 1 (losses)", m))
+		}
+	}
+	interval := time.Duration(conf.Alerts.Price.Interval) * time.Second
+	if interval == 0 {
+		interval = priceAlertsInterval
+	}
+	if len(conf.Alerts.Price.Multiples) > 0 {
+		log.Info(fmt.Sprintf("price alerts at %v of the cost of positions, every %s", conf.Alerts.Price.Multiples, interval))
+	}
+	return usecase.NewPriceAlerts(portfolio, alerts, conf.Alerts.Price.Multiples, interval)
+}
+
+// newMarkets of the sniped tokens, from Dexscreener. They are only refreshed if it's configured.
+func newMarkets(conf *Config, portfolio *usecase.Portfolio, limits *service.RateLimits, alerts *service.Alerts) *usecase.Markets {
+	interval := time.Duration(conf.Markets.Interval) * time.Second
+	if interval == 0 {
+		interval = marketsInterval
+	}
+	if len(conf.Markets.Dexscreener) > 0 {
+		log.Info(fmt.Sprintf("refreshing the markets of the sniped tokens from dexscreener every %s", interval))
+	}
+	return usecase.NewMarkets(
+		portfolio,
+		service.NewDexscreener(conf.Markets.Dexscreener, conf.Markets.Dextools, limits),
+		alerts,
+		interval,
+	)
+}
+
+// newReserveSeries recording the reserves of the sniped tokens, held by the portfolio
+func newReserveSeries(
+	conf *Config,
+	ethClient *service.EthClientCluster,
+	portfolio *usecase.Portfolio,
+	repos repositories,
+) *usecase.ReserveSeries {
 
-	return usecase.NewTransactionClassifier(routerAddr.Hex(), monitorEngine.Monitor, strats)
+	factory, err := uniswap.NewIUniswapV2FactoryCaller(conf.Contracts.Factory.Addr(), ethClient)
+	if err != nil {
+		panic(err)
+	}
+	interval := time.Duration(conf.Reserves.Interval) * time.Second
+	if interval == 0 {
+		interval = reserveSeriesInterval
+	}
+	after := time.Duration(conf.Reserves.After) * time.Hour
+	if after == 0 {
+		after = reserveSeriesAfter
+	}
+	return usecase.NewReserveSeries(
+		portfolio,
+		service.NewPairReserves(factory, ethClient),
+		repos.reserves,
+		interval,
+		after,
+	)
 }
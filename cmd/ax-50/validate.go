@@ -0,0 +1,365 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+	"github.com/saantiaguilera/liquidity-sniper/pkg/service"
+	"github.com/saantiaguilera/liquidity-sniper/third_party/uniswap"
+)
+
+const (
+	// validateTimeout of the whole validation, it makes a few calls per instance and bee
+	validateTimeout = time.Minute
+
+	// validateGasLimit the bees are funded for when the gas limit is estimated on each snipe. It's the limit snipes of
+	// pending liquidity use, since they can't be estimated.
+	validateGasLimit = 500000
+)
+
+type (
+	// validation of a config against the chain. Checks are printed as they are made, and every failed one counted.
+	validation struct {
+		ctx     context.Context
+		ecli    *service.RPCEthClient
+		cluster *service.EthClientCluster
+
+		gasPrice *big.Int
+		timing   domain.Timing
+		failed   int
+	}
+)
+
+// runValidate the config without sending anything: the addresses it references are resolved on-chain and the plan of
+// each instance is printed, as the bot runs it once armed. It fails if any check does.
+func runValidate(conf *Config) error {
+	ctx, cancel := context.WithTimeout(context.Background(), validateTimeout)
+	defer cancel()
+
+	instances, err := conf.InstanceConfigs()
+	if err != nil {
+		return err
+	}
+	c, err := dialRPC(ctx, conf, conf.Chains.Nodes.Snipe)
+	if err != nil {
+		return fmt.Errorf("error dialing the snipe node: %s", err)
+	}
+	defer c.Close()
+
+	ecli := service.NewRPCEthClient(c)
+	v := &validation{
+		ctx:     ctx,
+		ecli:    ecli,
+		cluster: service.NewEthClientCluster(ecli),
+	}
+	v.chain(conf)
+	for _, iconf := range instances {
+		v.instance(iconf)
+	}
+
+	fmt.Println()
+	if v.failed > 0 {
+		return fmt.Errorf("%d checks failed, fix them before arming", v.failed)
+	}
+	fmt.Println("all checks passed, nothing was sent")
+	return nil
+}
+
+// chain checks of what the instances share: the nodes and the network
+func (v *validation) chain(conf *Config) {
+	fmt.Printf("chain %s\n", conf.Chains.Name)
+
+	id, err := v.ecli.NetworkID(v.ctx)
+	if v.check(err, "snipe node answers") && conf.Chains.ID != 0 {
+		v.assert(id.Uint64() == uint64(conf.Chains.ID), "chain id of the snipe node is %s, configured %d", id, conf.Chains.ID)
+	}
+	v.timing = newTiming(conf, id)
+	fmt.Printf("  blocks every %s\n", v.timing.BlockTime)
+	if conf.Chains.Nodes.Stream != conf.Chains.Nodes.Snipe {
+		v.dial("stream node", conf, conf.Chains.Nodes.Stream)
+	}
+	for i, url := range conf.Chains.Nodes.Rebroadcast {
+		v.dial(fmt.Sprintf("rebroadcast node %d", i), conf, url)
+	}
+
+	v.gasPrice, err = v.ecli.SuggestGasPrice(v.ctx)
+	if !v.check(err, "gas price of the network") {
+		v.gasPrice = new(big.Int)
+	}
+	dynamicFees, err := service.DetectDynamicFees(v.ctx, v.ecli)
+	v.check(err, "fee market detected (dynamic fees: %t)", dynamicFees)
+
+	mode := conf.Sniper.Mode
+	if len(mode) == 0 {
+		mode = SniperModePendingTxs
+	}
+	v.assert(
+		mode == SniperModePendingTxs || mode == SniperModeBlockScan,
+		"sniper mode %s is %s or %s", mode, SniperModePendingTxs, SniperModeBlockScan,
+	)
+	v.try("rate limits", func() { newRateLimits(conf) })
+	v.try("reinvest policy", func() { newReinvestPolicy(conf) })
+	v.try("panic gas multiplier", func() {
+		if gm := conf.Panic.GasMultiplier; gm != 0 && gm < 1 {
+			panic(fmt.Sprintf("panic gas multiplier %.2f must be at least 1", gm))
+		}
+	})
+}
+
+// instance checks of the addresses and funds the instance uses, printing its plan if they are there
+func (v *validation) instance(conf *Config) {
+	fmt.Printf("\ninstance %s\n", conf.Name)
+
+	failed := v.failed
+	v.code("trigger", conf.Contracts.Trigger)
+	v.code("factory", conf.Contracts.Factory)
+	v.code("router", conf.Contracts.Router)
+	v.code("wbnb", conf.Tokens.WBNB)
+	v.code("token", conf.Tokens.SnipeA)
+	v.code("paired token", conf.Tokens.SnipeB)
+	if conf.Sniper.Migration.Enabled {
+		v.code("position manager", conf.Contracts.PositionManager)
+	}
+	if len(conf.Contracts.PriceFeed) > 0 {
+		v.code("price feed", conf.Contracts.PriceFeed)
+	}
+	if v.failed > failed {
+		fmt.Println("  can't plan without the contracts")
+		return
+	}
+
+	var sn domain.Sniper
+	var slippage domain.Slippage
+	var quiet *service.QuietHours
+	var bands domain.ValuationBands
+	v.try("target", func() { sn = newSniperEntity(v.ctx, conf, v.cluster) })
+	v.try("valuation bands", func() { bands = newValuationBands(v.ctx, conf, v.cluster) })
+	v.try("quiet hours", func() { quiet = newQuietHours(conf) })
+	v.try("stealth delay", func() { newStealthDelay(conf, v.timing) })
+	v.try("slippage", func() { slippage = newSlippage(conf) })
+	if v.failed > failed {
+		fmt.Println("  can't plan without the target")
+		return
+	}
+
+	v.funds(conf, sn)
+	v.pair(conf)
+	v.plan(conf, sn, slippage, quiet, bands)
+}
+
+// funds of the trigger for the order, and of each bee for its snipe tx
+func (v *validation) funds(conf *Config, sn domain.Sniper) {
+	wbnb := service.NewTokenBalances(v.ecli)
+	held, err := wbnb.BalanceOf(v.ctx, conf.Tokens.WBNB.Addr(), conf.Contracts.Trigger.Addr())
+	if v.check(err, "wbnb balance of the trigger") && sn.OrderSize != nil {
+		v.assert(
+			held.Cmp(sn.OrderSize) >= 0,
+			"trigger holds %s wbnb, the order spends %s", formatWei(held), formatWei(sn.OrderSize),
+		)
+	}
+
+	swarm, err := readBeeBook(conf)
+	if !v.check(err, "bee book") || !v.assert(len(swarm) > 0, "bee book has %d bees", len(swarm)) {
+		return
+	}
+	gasLimit := conf.Sniper.Gas.Limit
+	if gasLimit == 0 {
+		gasLimit = validateGasLimit
+	}
+	// they pay the gas of the addLiquidity, which may be up to the max multiple of the network one
+	gasPrice := new(big.Float).SetInt(v.gasPrice)
+	if m := conf.Sniper.Gas.MaxMultiplier; m > 1 {
+		gasPrice.Mul(gasPrice, big.NewFloat(m))
+	}
+	cost, _ := gasPrice.Mul(gasPrice, new(big.Float).SetUint64(gasLimit)).Int(nil)
+
+	funded := 0
+	for _, b := range swarm {
+		balance, err := v.ecli.BalanceAt(v.ctx, common.HexToAddress(b.Address), nil)
+		switch {
+		case err != nil:
+			v.check(err, "balance of bee %s", b.Address)
+		case balance.Cmp(cost) < 0:
+			v.assert(false, "bee %s holds %s, a snipe tx costs up to %s", b.Address, formatWei(balance), formatWei(cost))
+		default:
+			funded++
+		}
+	}
+	if funded == len(swarm) {
+		v.assert(true, "all %d bees hold at least the %s a snipe tx costs", funded, formatWei(cost))
+	}
+}
+
+// pair of the target, which shouldn't have liquidity yet. It's a note, re-additions are sniped too.
+func (v *validation) pair(conf *Config) {
+	opts := &bind.CallOpts{Context: v.ctx}
+	factory, err := uniswap.NewIUniswapV2FactoryCaller(conf.Contracts.Factory.Addr(), v.ecli)
+	if !v.check(err, "factory binding") {
+		return
+	}
+	pair, err := factory.GetPair(opts, conf.Tokens.SnipeA.Addr(), conf.Tokens.SnipeB.Addr())
+	if !v.check(err, "pair lookup") {
+		return
+	}
+	if pair == (common.Address{}) {
+		fmt.Println("  note: the pair isn't created yet, it's usually created along the liquidity")
+		return
+	}
+	caller, err := uniswap.NewIUniswapV2PairCaller(pair, v.ecli)
+	if !v.check(err, "pair binding") {
+		return
+	}
+	reserves, err := caller.GetReserves(opts)
+	if !v.check(err, "reserves of pair %s", pair.Hex()) {
+		return
+	}
+	if reserves.Reserve0.Sign() > 0 || reserves.Reserve1.Sign() > 0 {
+		fmt.Printf("  note: pair %s already has liquidity, only a new addition is sniped\n", pair.Hex())
+	} else {
+		fmt.Printf("  note: pair %s exists without liquidity\n", pair.Hex())
+	}
+}
+
+// plan the instance follows once armed, as the filter chain and the execution run it
+func (v *validation) plan(
+	conf *Config,
+	sn domain.Sniper,
+	slippage domain.Slippage,
+	quiet *service.QuietHours,
+	bands domain.ValuationBands,
+) {
+	decimals := service.NewTokenDecimals(v.ecli)
+	paired := func(a *big.Int) string {
+		if a == nil {
+			return "-"
+		}
+		return fmt.Sprintf("%g", decimals.Format(v.ctx, conf.Tokens.SnipeB.Addr(), a))
+	}
+	gwei := func(m float64) string {
+		f, _ := new(big.Float).Mul(new(big.Float).SetInt(v.gasPrice), big.NewFloat(m/1e9)).Float64()
+		return fmt.Sprintf("%.2f gwei", f)
+	}
+	mode := conf.Sniper.Mode
+	if len(mode) == 0 {
+		mode = SniperModePendingTxs
+	}
+
+	fmt.Println("  plan:")
+	fmt.Printf("    watch %s for liquidity added to %s / %s through the router\n", mode, sn.AddressTargetToken, sn.AddressTargetPaired)
+	fmt.Printf("    skip it if it adds less than %s of the paired token\n", paired(sn.MinimumLiquidity))
+	if m := conf.Sniper.Gas.MinMultiplier; m > 0 {
+		fmt.Printf("    skip it if it pays less than %.2fx the network gas (now %s)\n", m, gwei(m))
+	}
+	if m := conf.Sniper.Gas.MaxMultiplier; m > 0 {
+		fmt.Printf("    skip it if it pays more than %.2fx the network gas (now %s)\n", m, gwei(m))
+	}
+	if conf.Sniper.ValidateVictim && mode == SniperModePendingTxs {
+		fmt.Println("    skip it if it reverts against the pending state")
+	}
+	if conf.Sniper.MaxDeadline > 0 {
+		fmt.Printf("    skip it if its deadline is more than %s ahead\n", time.Duration(conf.Sniper.MaxDeadline)*time.Second)
+	}
+	if bands.MinMarketCap != nil || bands.MaxMarketCap != nil || bands.MinFDV != nil || bands.MaxFDV != nil {
+		fmt.Printf(
+			"    skip it if its market cap isn't within [%s, %s] or its fdv within [%s, %s]\n",
+			paired(bands.MinMarketCap), paired(bands.MaxMarketCap), paired(bands.MinFDV), paired(bands.MaxFDV),
+		)
+	}
+	if bands.MinLiquidityRatio > 0 {
+		fmt.Printf("    skip it if the liquidity is less than %.2f%% of its fdv\n", float64(bands.MinLiquidityRatio)/100)
+	}
+	if quiet.Check(time.Now()) != nil {
+		fmt.Printf("    skip it while in quiet hours %v, like now\n", conf.Sniper.QuietHours.Windows)
+	} else if len(conf.Sniper.QuietHours.Windows) > 0 {
+		fmt.Printf("    skip it while in quiet hours %v\n", conf.Sniper.QuietHours.Windows)
+	}
+	if sn.Budget != nil {
+		fmt.Printf("    skip it once %s wbnb are spent\n", formatWei(sn.Budget))
+	}
+
+	buy := fmt.Sprintf("spend %s wbnb for at least the configured tokens", formatWei(sn.OrderSize))
+	if sn.BuyMode.ExactOut() {
+		buy = fmt.Sprintf("buy the configured tokens for at most %s wbnb", formatWei(sn.OrderSize))
+	}
+	if sn.Reveal != nil {
+		buy += ", revealing the order in the snipe tx"
+	}
+	if conf.Sniper.Slippage.Adaptive {
+		buy += fmt.Sprintf(", asking for %.2f%% below the simulation (on pairs charging %.2f%%)", float64(slippage.Buffer)/100, float64(slippage.Fee)/100)
+	}
+	fmt.Printf("    else %s\n", buy)
+
+	submission := "the public mempool"
+	if relays := conf.Sniper.Submission.Relays; len(relays) > 0 {
+		names := make([]string, len(relays))
+		for i, r := range relays {
+			names[i] = r.Name
+		}
+		submission = fmt.Sprintf("bundles to %s, falling back to the public mempool", strings.Join(names, ", "))
+		if conf.Sniper.Submission.Concurrent {
+			submission = fmt.Sprintf("bundles to %s and the public mempool at once", strings.Join(names, ", "))
+		}
+	}
+	fmt.Printf("    with a tx from each bee at the gas of the addition, through %s\n", submission)
+	if conf.Sniper.ReentryBlocks > 0 {
+		fmt.Printf("    and if it reverts, again on each of the next %d blocks where it would succeed\n", conf.Sniper.ReentryBlocks)
+	}
+	if conf.Sniper.Migration.Enabled {
+		fmt.Printf("    follow the liquidity if it migrates to v3 (entering it: %t)\n", conf.Sniper.Migration.Enter)
+	}
+}
+
+// dial a node, closing it right away
+func (v *validation) dial(name string, conf *Config, url string) {
+	c, err := dialRPC(v.ctx, conf, url)
+	if v.check(err, "%s answers", name) {
+		c.Close()
+	}
+}
+
+// code deployed at the address, so it's the contract we expect
+func (v *validation) code(name string, a Address) {
+	if !v.assert(len(a) > 0 && common.IsHexAddress(string(a)), "%s address '%s' is valid", name, a) {
+		return
+	}
+	code, err := v.ecli.CodeAt(v.ctx, a.Addr(), nil)
+	if v.check(err, "code of %s %s", name, a) {
+		v.assert(len(code) > 0, "%s %s has code", name, a)
+	}
+}
+
+// try what the bot would build, which panics on an invalid config
+func (v *validation) try(name string, fn func()) {
+	defer func() {
+		if err := recover(); err != nil {
+			v.assert(false, "%s: %v", name, err)
+		}
+	}()
+	fn()
+}
+
+// check of a call, which passes if it didn't error
+func (v *validation) check(err error, format string, args ...interface{}) bool {
+	if err != nil {
+		return v.assert(false, "%s: %s", fmt.Sprintf(format, args...), err)
+	}
+	return v.assert(true, format, args...)
+}
+
+// assert printing it, returning if it passed
+func (v *validation) assert(ok bool, format string, args ...interface{}) bool {
+	if !ok {
+		v.failed++
+		fmt.Printf("  FAIL  %s\n", fmt.Sprintf(format, args...))
+		return false
+	}
+	fmt.Printf("  ok    %s\n", fmt.Sprintf(format, args...))
+	return true
+}
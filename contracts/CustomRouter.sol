@@ -75,6 +75,22 @@ contract CustomRouter is Ownable {
         route(amounts, path, to);
     }
 
+    function swapTokensForExactTokens(
+        uint amountOut,
+        uint amountInMax,
+        address[] calldata path,
+        address to,
+        uint deadline
+    ) external virtual ensure(deadline) returns (uint[] memory amounts) {
+        amounts = UniSwapV2Library.getAmountsIn(factory, amountOut, path, creationCode);
+        require(amounts[0] <= amountInMax, 'Router: EXCESSIVE_INPUT_AMOUNT');
+        TransferHelper.safeTransferFrom(
+            path[0], msg.sender, UniSwapV2Library.pairFor(factory, path[0], path[1], creationCode), amounts[0]
+        );
+
+        route(amounts, path, to);
+    }
+
     function route(uint[] memory amounts, address[] memory path, address _to) private {
         for (uint i; i < path.length - 1; i++) {
             (address input, address output) = (path[i], path[i + 1]);
@@ -1,5 +1,6 @@
 // SPDX-License-Identifier: GPL-3.0
 pragma solidity >=0.6.0 <0.8.0;
+pragma experimental ABIEncoderV2;
 
 import "@openzeppelin/contracts/utils/Context.sol";
 import "@openzeppelin/contracts/access/Ownable.sol";
@@ -18,6 +19,36 @@ interface ICustomRouter {
         address to,
         uint deadline
     ) external returns (uint[] memory amounts);
+
+    function swapTokensForExactTokens(
+        uint amountOut,
+        uint amountInMax,
+        address[] calldata path,
+        address to,
+        uint deadline
+    ) external returns (uint[] memory amounts);
+}
+
+interface ISwapRouterV3 {
+    struct ExactInputParams {
+        bytes path;
+        address recipient;
+        uint256 deadline;
+        uint256 amountIn;
+        uint256 amountOutMinimum;
+    }
+
+    struct ExactOutputParams {
+        bytes path;
+        address recipient;
+        uint256 deadline;
+        uint256 amountOut;
+        uint256 amountInMaximum;
+    }
+
+    function exactInput(ExactInputParams calldata params) external payable returns (uint256 amountOut);
+
+    function exactOutput(ExactOutputParams calldata params) external payable returns (uint256 amountIn);
 }
 
 contract Trigger is Ownable {
@@ -26,15 +57,26 @@ contract Trigger is Ownable {
 
     address payable private administrator;
     address private customRouter;
+    // uniswap v3 like router, for tokens whose liquidity migrated to a v3 pool
+    address private v3Router;
 
+    // in exact out mode wbnbIn is the most we spend and minTknOut the exact amount of tokens we buy
     uint private wbnbIn;
     uint private minTknOut;
+    bool private exactOut;
 
     address private tokenToBuy;
     address private tokenPaired;
 
     bool private snipeLock;
 
+    bytes32 private orderCommitment;
+
+    // wallets the order is split across, for tokens enforcing a max wallet. Empty means everything goes to the administrator.
+    address[] private recipients;
+    // most tokens a recipient may get. Zero means no max wallet.
+    uint private maxWallet;
+
     constructor(address _wbnb) public {
         administrator = payable(msg.sender);
         wbnb = _wbnb;
@@ -51,11 +93,96 @@ contract Trigger is Ownable {
     
     // perform the liquidity sniping
     function snipeListing() external returns(bool success) {
+        require(orderCommitment == bytes32(0), "snipe: order is committed. See revealAndSnipe");
+        return snipe(0, 0);
+    }
+
+    // same as snipeListing, raising the min amount out to _amountOutMin if it's above the configured one. ax-50 uses it
+    // to tighten each snipe from its simulation, the configured min is always the floor. Exact out snipes ignore it.
+    function snipeListingMin(uint _amountOutMin) external returns(bool success) {
+        require(orderCommitment == bytes32(0), "snipe: order is committed. See revealAndSnipeMin");
+        raiseMinOut(_amountOutMin);
+        return snipe(0, 0);
+    }
+
+    // same as snipeListingMin, lowering the amount in to _amountIn if it's below the configured one. ax-50 uses it to
+    // shrink a snipe exposed to sandwich bots, the configured amount is always the cap. Exact out snipes ignore it.
+    function snipeListingReduced(uint _amountIn, uint _amountOutMin) external returns(bool success) {
+        require(orderCommitment == bytes32(0), "snipe: order is committed. See revealAndSnipeMin");
+        if (!exactOut && _amountIn > 0 && _amountIn < wbnbIn) {
+            wbnbIn = _amountIn;
+        }
+        raiseMinOut(_amountOutMin);
+        return snipe(0, 0);
+    }
+
+    // perform the liquidity sniping on the v3 pool of the given fee tier, for liquidity migrated from v2.
+    // _pairedFee is the fee tier of the wbnb / paired pool, only used when the paired token isn't wbnb.
+    function snipeListingV3(uint24 _fee, uint24 _pairedFee) external returns(bool success) {
+        require(orderCommitment == bytes32(0), "snipe: order is committed. See revealAndSnipeV3");
+        require(_fee > 0, "snipe: no v3 fee tier");
+        return snipe(_fee, _pairedFee);
+    }
+
+    // perform the liquidity sniping of a committed order, revealing it in the same tx.
+    // this way the order parameters are never visible (neither in the mempool nor in the contract storage) until the snipe
+    // itself, so sandwich bots can't tailor their attack to our amounts.
+    function revealAndSnipe(address _tokenPaired, uint _amountIn, address _tknToBuy, uint _amountOutMin, bytes32 _salt) external returns(bool success) {
+        require(orderCommitment != bytes32(0), "snipe: no order committed. See commitSnipe");
+        require(keccak256(abi.encodePacked(_tokenPaired, _amountIn, _tknToBuy, _amountOutMin, _salt)) == orderCommitment, "snipe: order doesn't match commitment");
+        tokenPaired = _tokenPaired;
+        wbnbIn = _amountIn;
+        tokenToBuy = _tknToBuy;
+        minTknOut = _amountOutMin;
+        return snipe(0, 0);
+    }
+
+    // same as revealAndSnipe, raising the revealed min amount out to _raisedMin if it's above it. See snipeListingMin
+    function revealAndSnipeMin(address _tokenPaired, uint _amountIn, address _tknToBuy, uint _amountOutMin, bytes32 _salt, uint _raisedMin) external returns(bool success) {
+        require(orderCommitment != bytes32(0), "snipe: no order committed. See commitSnipe");
+        require(keccak256(abi.encodePacked(_tokenPaired, _amountIn, _tknToBuy, _amountOutMin, _salt)) == orderCommitment, "snipe: order doesn't match commitment");
+        tokenPaired = _tokenPaired;
+        wbnbIn = _amountIn;
+        tokenToBuy = _tknToBuy;
+        minTknOut = _amountOutMin;
+        raiseMinOut(_raisedMin);
+        return snipe(0, 0);
+    }
+
+    // same as revealAndSnipe, on the v3 pool of the given fee tier. See snipeListingV3
+    function revealAndSnipeV3(address _tokenPaired, uint _amountIn, address _tknToBuy, uint _amountOutMin, bytes32 _salt, uint24 _fee, uint24 _pairedFee) external returns(bool success) {
+        require(orderCommitment != bytes32(0), "snipe: no order committed. See commitSnipe");
+        require(keccak256(abi.encodePacked(_tokenPaired, _amountIn, _tknToBuy, _amountOutMin, _salt)) == orderCommitment, "snipe: order doesn't match commitment");
+        require(_fee > 0, "snipe: no v3 fee tier");
+        tokenPaired = _tokenPaired;
+        wbnbIn = _amountIn;
+        tokenToBuy = _tknToBuy;
+        minTknOut = _amountOutMin;
+        return snipe(_fee, _pairedFee);
+    }
+
+    // raiseMinOut of an exact in snipe, never lowering it. In exact out mode minTknOut is what we buy, so it's kept.
+    function raiseMinOut(uint _amountOutMin) private {
+        if (!exactOut && _amountOutMin > minTknOut) {
+            minTknOut = _amountOutMin;
+        }
+    }
+
+    // snipe through the custom router (v2) if _fee is zero, else through the v3 router on the pool of that fee tier
+    function snipe(uint24 _fee, uint24 _pairedFee) private returns(bool success) {
         require(IERC20(wbnb).balanceOf(address(this)) >= wbnbIn, "snipe: not enough wbnb on the contract");
-        IERC20(wbnb).approve(customRouter, wbnbIn);
+        require(_fee == 0 || v3Router != address(0), "snipe: no v3 router. See setV3Router");
+        IERC20(wbnb).approve(_fee == 0 ? customRouter : v3Router, wbnbIn);
         require(snipeLock == false, "snipe: sniping is locked. See configure");
         snipeLock = true;
-        
+
+        if (_fee > 0) {
+            return snipeV3(_fee, _pairedFee);
+        }
+        if (exactOut) {
+            return snipeExactOut();
+        }
+
         address[] memory path;
         if (tokenPaired != wbnb) {
             path = new address[](3);
@@ -68,13 +195,118 @@ contract Trigger is Ownable {
             path[1] = tokenToBuy;
         }
 
-        ICustomRouter(customRouter).swapExactTokensForTokens(
-              wbnbIn,
-              minTknOut,
-              path, 
-              administrator,
-              block.timestamp + 120
-        );
+        if (recipients.length == 0) {
+            ICustomRouter(customRouter).swapExactTokensForTokens(
+                  wbnbIn,
+                  minTknOut,
+                  path, 
+                  administrator,
+                  block.timestamp + 120
+            );
+            return true;
+        }
+
+        // one swap per recipient, each getting an equal share of the order (the last one the remainder).
+        // later legs buy at a worse price, so the minimum is checked against what all of them bought. A leg above the
+        // max wallet would get stuck (or revert deep inside the token), so we revert the whole snipe instead.
+        uint legIn = wbnbIn / recipients.length;
+        uint bought;
+        for (uint i = 0; i < recipients.length; i++) {
+            uint amountIn = legIn;
+            if (i == recipients.length - 1) {
+                amountIn = wbnbIn - legIn * (recipients.length - 1);
+            }
+            uint[] memory amounts = ICustomRouter(customRouter).swapExactTokensForTokens(
+                  amountIn,
+                  0,
+                  path,
+                  recipients[i],
+                  block.timestamp + 120
+            );
+            uint legOut = amounts[amounts.length - 1];
+            require(maxWallet == 0 || legOut <= maxWallet, "snipe: leg above max wallet");
+            bought += legOut;
+        }
+        require(bought >= minTknOut, "snipe: insufficient output amount");
+        return true;
+    }
+
+    // same as the v2 snipe, buying exactly minTknOut tokens for at most wbnbIn. Recipients get an equal share of the
+    // tokens (the last one the remainder), and all the legs together can't spend more than wbnbIn.
+    function snipeExactOut() private returns(bool success) {
+        address[] memory path;
+        if (tokenPaired != wbnb) {
+            path = new address[](3);
+            path[0] = wbnb;
+            path[1] = tokenPaired;
+            path[2] = tokenToBuy;
+        } else {
+            path = new address[](2);
+            path[0] = wbnb;
+            path[1] = tokenToBuy;
+        }
+
+        if (recipients.length == 0) {
+            ICustomRouter(customRouter).swapTokensForExactTokens(
+                  minTknOut,
+                  wbnbIn,
+                  path,
+                  administrator,
+                  block.timestamp + 120
+            );
+            return true;
+        }
+
+        uint legOut = minTknOut / recipients.length;
+        uint spent;
+        for (uint i = 0; i < recipients.length; i++) {
+            uint amountOut = legOut;
+            if (i == recipients.length - 1) {
+                amountOut = minTknOut - legOut * (recipients.length - 1);
+            }
+            require(maxWallet == 0 || amountOut <= maxWallet, "snipe: leg above max wallet");
+            uint[] memory amounts = ICustomRouter(customRouter).swapTokensForExactTokens(
+                  amountOut,
+                  wbnbIn - spent,
+                  path,
+                  recipients[i],
+                  block.timestamp + 120
+            );
+            spent += amounts[0];
+        }
+        return true;
+    }
+
+    // same as the v2 snipe, on v3 pools. The path is packed with the fee tier of each pool in between its tokens.
+    function snipeV3(uint24 _fee, uint24 _pairedFee) private returns(bool success) {
+        if (exactOut) {
+            return snipeV3ExactOut(_fee, _pairedFee);
+        }
+
+        bytes memory path;
+        if (tokenPaired != wbnb) {
+            path = abi.encodePacked(wbnb, _pairedFee, tokenPaired, _fee, tokenToBuy);
+        } else {
+            path = abi.encodePacked(wbnb, _fee, tokenToBuy);
+        }
+
+        if (recipients.length == 0) {
+            ISwapRouterV3(v3Router).exactInput(ISwapRouterV3.ExactInputParams(path, administrator, block.timestamp + 120, wbnbIn, minTknOut));
+            return true;
+        }
+
+        uint legIn = wbnbIn / recipients.length;
+        uint bought;
+        for (uint i = 0; i < recipients.length; i++) {
+            uint amountIn = legIn;
+            if (i == recipients.length - 1) {
+                amountIn = wbnbIn - legIn * (recipients.length - 1);
+            }
+            uint legOut = ISwapRouterV3(v3Router).exactInput(ISwapRouterV3.ExactInputParams(path, recipients[i], block.timestamp + 120, amountIn, 0));
+            require(maxWallet == 0 || legOut <= maxWallet, "snipe: leg above max wallet");
+            bought += legOut;
+        }
+        require(bought >= minTknOut, "snipe: insufficient output amount");
         return true;
     }
     
@@ -96,6 +328,42 @@ contract Trigger is Ownable {
         return true;
     }
 
+    // same as snipeExactOut, on v3 pools. Exact output paths go backwards: from the token we buy to wbnb.
+    function snipeV3ExactOut(uint24 _fee, uint24 _pairedFee) private returns(bool success) {
+        bytes memory path;
+        if (tokenPaired != wbnb) {
+            path = abi.encodePacked(tokenToBuy, _fee, tokenPaired, _pairedFee, wbnb);
+        } else {
+            path = abi.encodePacked(tokenToBuy, _fee, wbnb);
+        }
+
+        if (recipients.length == 0) {
+            ISwapRouterV3(v3Router).exactOutput(ISwapRouterV3.ExactOutputParams(path, administrator, block.timestamp + 120, minTknOut, wbnbIn));
+            return true;
+        }
+
+        uint legOut = minTknOut / recipients.length;
+        uint spent;
+        for (uint i = 0; i < recipients.length; i++) {
+            uint amountOut = legOut;
+            if (i == recipients.length - 1) {
+                amountOut = minTknOut - legOut * (recipients.length - 1);
+            }
+            require(maxWallet == 0 || amountOut <= maxWallet, "snipe: leg above max wallet");
+            spent += ISwapRouterV3(v3Router).exactOutput(ISwapRouterV3.ExactOutputParams(path, recipients[i], block.timestamp + 120, amountOut, wbnbIn - spent));
+        }
+        return true;
+    }
+
+    function getV3Router() external view onlyOwner returns(address) {
+        return v3Router;
+    }
+
+    function setV3Router(address _newRouter) external onlyOwner returns(bool success) {
+        v3Router = _newRouter;
+        return true;
+    }
+
     function setWBNBAddress(address _wbnb) external onlyOwner returns(bool success) {
         wbnb = _wbnb;
         return true;
@@ -111,9 +379,62 @@ contract Trigger is Ownable {
         wbnbIn = _amountIn;
         tokenToBuy = _tknToBuy;
         minTknOut = _amountOutMin;
+        exactOut = false;
+        orderCommitment = bytes32(0);
         snipeLock = false;
         return true;
     }
+
+    // alternative to configureSnipe, buying exactly _amountOut tokens for at most _amountInMax
+    function configureSnipeExactOut(address _tokenPaired, uint _amountInMax, address _tknToBuy, uint _amountOut) external onlyOwner returns(bool success) {
+        tokenPaired = _tokenPaired;
+        wbnbIn = _amountInMax;
+        tokenToBuy = _tknToBuy;
+        minTknOut = _amountOut;
+        exactOut = true;
+        orderCommitment = bytes32(0);
+        snipeLock = false;
+        return true;
+    }
+
+    // alternative to configureSnipe, where only the hash of the order is stored. The order must be revealed when sniping.
+    // commitment = keccak256(abi.encodePacked(tokenPaired, amountIn, tknToBuy, amountOutMin, salt))
+    function commitSnipe(bytes32 _commitment) external onlyOwner returns(bool success) {
+        require(_commitment != bytes32(0), "commit: empty commitment");
+        orderCommitment = _commitment;
+        exactOut = false;
+        snipeLock = false;
+        return true;
+    }
+
+    // same as commitSnipe, for an order buying exactly the tokens of the commitment for at most its amount in
+    function commitSnipeExactOut(bytes32 _commitment) external onlyOwner returns(bool success) {
+        require(_commitment != bytes32(0), "commit: empty commitment");
+        orderCommitment = _commitment;
+        exactOut = true;
+        snipeLock = false;
+        return true;
+    }
+
+    // split the order across the given wallets (eg. the swarm), so none of them holds more than the max wallet of the token.
+    // an empty list sends the whole order to the administrator again.
+    function configureRecipients(address[] calldata _recipients, uint _maxWallet) external onlyOwner returns(bool success) {
+        recipients = _recipients;
+        maxWallet = _maxWallet;
+        return true;
+    }
+
+    function getRecipients() external view onlyOwner returns(address[] memory, uint) {
+        return (recipients, maxWallet);
+    }
+
+    function getSnipeMode() external view onlyOwner returns(bool) {
+        return exactOut;
+    }
+
+    function getSnipeCommitment() external view onlyOwner returns(bytes32) {
+        return orderCommitment;
+    }
     
     function getSnipeConfiguration() external view onlyOwner returns(address, uint, address, uint, bool) {
         return (tokenPaired, wbnbIn, tokenToBuy, minTknOut, snipeLock);
@@ -0,0 +1,13 @@
+// SPDX-License-Identifier: GPL-3.0
+pragma solidity >=0.6.0 <0.8.0;
+
+import "@openzeppelin/contracts/token/ERC20/ERC20.sol";
+
+// ThrowawayToken is a plain token launched by the integration tests on a testnet, to be sniped and sold by ax-50.
+// It's never deployed by the migrations.
+contract ThrowawayToken is ERC20 {
+
+    constructor(string memory _name, string memory _symbol, uint _supply) public ERC20(_name, _symbol) {
+        _mint(msg.sender, _supply);
+    }
+}
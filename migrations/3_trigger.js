@@ -4,7 +4,7 @@ const CustomRouter = artifacts.require("CustomRouter");
 
 const Trigger = artifacts.require("Trigger");
 
-const { token } = require('./../config/local.json')
+const { token, contract } = require('./../config/local.json')
 
 module.exports = async function (deployer, network) {
   const router = await CustomRouter.deployed();
@@ -14,4 +14,8 @@ module.exports = async function (deployer, network) {
   const trigger = await Trigger.deployed();
   
   await trigger.setCustomRouter(router.address)
+  if (contract.v3_router) {
+    // only used if the liquidity of a target migrates to a v3 pool
+    await trigger.setV3Router(contract.v3_router)
+  }
 };
@@ -0,0 +1,271 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+const (
+	positionsPath = "/positions"
+	portfolioPath = "/portfolio"
+	decisionsPath = "/decisions"
+
+	defaultDecisionsLimit = 100
+)
+
+type (
+	// Dashboard controller exposes what the bot is doing through HTTP:
+	//   GET /positions            lists the positions of the targets, valued at the current reserves
+	//   GET /portfolio            lists the tokens held across all our wallets, valued at the current reserves
+	// Both carry the market of each token as reported by the pair trackers, once known.
+	//   GET /decisions?limit={n}  lists the latest decisions of the filter chain, newest first
+	Dashboard struct {
+		portfolio dashboardPortfolio
+		feed      dashboardFeed
+		markets   dashboardMarkets
+	}
+
+	dashboardPortfolio interface {
+		Positions(context.Context) ([]domain.PositionValue, error)
+		Holdings(context.Context) ([]domain.Holding, error)
+	}
+
+	dashboardFeed interface {
+		Recent(int) []domain.Decision
+	}
+
+	dashboardMarkets interface {
+		Market(token string) (domain.Market, bool)
+	}
+
+	// PositionBody is the representation of a position in the API. Amounts are in wei.
+	PositionBody struct {
+		Target    string      `json:"target"`
+		Token     string      `json:"token"`
+		Paired    string      `json:"paired,omitempty"`
+		Amount    *big.Int    `json:"amount"`
+		Cost      *big.Int    `json:"cost,omitempty"`
+		Value     *big.Int    `json:"value,omitempty"`
+		PnL       *big.Int    `json:"pnl,omitempty"`
+		Trades    uint        `json:"trades"`
+		UpdatedAt time.Time   `json:"updated_at"`
+		Market    *MarketBody `json:"market,omitempty"`
+	}
+
+	// HoldingBody is the representation of a token held across our wallets in the API. Amounts are in wei.
+	HoldingBody struct {
+		Token    string              `json:"token"`
+		Paired   string              `json:"paired,omitempty"`
+		Balance  *big.Int            `json:"balance"`
+		Balances map[string]*big.Int `json:"balances"`
+		Cost     *big.Int            `json:"cost,omitempty"`
+		Value    *big.Int            `json:"value,omitempty"`
+		PnL      *big.Int            `json:"pnl,omitempty"`
+		Market   *MarketBody         `json:"market,omitempty"`
+	}
+
+	// MarketBody is the representation of the market of a token in the API, as reported by the pair trackers.
+	// Amounts are in USD.
+	MarketBody struct {
+		Pair           string    `json:"pair"`
+		DexscreenerURL string    `json:"dexscreener_url,omitempty"`
+		DextoolsURL    string    `json:"dextools_url,omitempty"`
+		PriceUSD       float64   `json:"price_usd"`
+		LiquidityUSD   float64   `json:"liquidity_usd"`
+		FDV            float64   `json:"fdv"`
+		VolumeH1       float64   `json:"volume_h1"`
+		VolumeH24      float64   `json:"volume_h24"`
+		BuysH24        uint      `json:"buys_h24"`
+		SellsH24       uint      `json:"sells_h24"`
+		UpdatedAt      time.Time `json:"updated_at"`
+	}
+
+	// DecisionBody is the representation of a decision in the API
+	DecisionBody struct {
+		Kind      domain.DecisionKind `json:"kind"`
+		Target    string              `json:"target"`
+		Tx        string              `json:"tx"`
+		Reason    domain.SkipReason   `json:"reason,omitempty"`
+		Detail    string              `json:"detail,omitempty"`
+		Candidate *CandidateBody      `json:"candidate,omitempty"`
+		Time      time.Time           `json:"time"`
+	}
+
+	// CandidateBody is the representation of a decoded liquidity addition in the API. Amounts are in the smallest unit
+	// of their token, the paired one is empty for the native coin.
+	CandidateBody struct {
+		Method       string   `json:"method"`
+		Token        string   `json:"token"`
+		Paired       string   `json:"paired,omitempty"`
+		AmountToken  *big.Int `json:"amount_token"`
+		AmountPaired *big.Int `json:"amount_paired"`
+		GasPrice     *big.Int `json:"gas_price"`
+		Deadline     *big.Int `json:"deadline"`
+	}
+)
+
+func NewDashboard(p dashboardPortfolio, f dashboardFeed, m dashboardMarkets) *Dashboard {
+	return &Dashboard{
+		portfolio: p,
+		feed:      f,
+		markets:   m,
+	}
+}
+
+// Register the routes of the controller in the mux
+func (c *Dashboard) Register(mux *http.ServeMux) {
+	mux.HandleFunc(positionsPath, c.servePositions)
+	mux.HandleFunc(portfolioPath, c.servePortfolio)
+	mux.HandleFunc(decisionsPath, c.serveDecisions)
+}
+
+func (c *Dashboard) servePositions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		c.writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	positions, err := c.portfolio.Positions(r.Context())
+	if err != nil {
+		c.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	res := make([]PositionBody, len(positions))
+	for i, p := range positions {
+		res[i] = NewPositionBody(p)
+		res[i].Market = c.market(p.Token)
+	}
+	c.write(w, http.StatusOK, res)
+}
+
+func (c *Dashboard) servePortfolio(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		c.writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	holdings, err := c.portfolio.Holdings(r.Context())
+	if err != nil {
+		c.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	res := make([]HoldingBody, len(holdings))
+	for i, h := range holdings {
+		res[i] = NewHoldingBody(h)
+		res[i].Market = c.market(h.Token)
+	}
+	c.write(w, http.StatusOK, res)
+}
+
+func (c *Dashboard) serveDecisions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		c.writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	limit := defaultDecisionsLimit
+	if v := r.URL.Query().Get("limit"); len(v) > 0 {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			c.writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit %s", v))
+			return
+		}
+		limit = n
+	}
+
+	decisions := c.feed.Recent(limit)
+	res := make([]DecisionBody, len(decisions))
+	for i, d := range decisions {
+		res[i] = NewDecisionBody(d)
+	}
+	c.write(w, http.StatusOK, res)
+}
+
+// market of the token, nil if it's not known
+func (c *Dashboard) market(token string) *MarketBody {
+	m, ok := c.markets.Market(token)
+	if !ok {
+		return nil
+	}
+	return &MarketBody{
+		Pair:           m.Pair,
+		DexscreenerURL: m.DexscreenerURL,
+		DextoolsURL:    m.DextoolsURL,
+		PriceUSD:       m.PriceUSD,
+		LiquidityUSD:   m.LiquidityUSD,
+		FDV:            m.FDV,
+		VolumeH1:       m.VolumeH1,
+		VolumeH24:      m.VolumeH24,
+		BuysH24:        m.BuysH24,
+		SellsH24:       m.SellsH24,
+		UpdatedAt:      m.UpdatedAt,
+	}
+}
+
+func (c *Dashboard) writeError(w http.ResponseWriter, status int, err error) {
+	log.Warn(fmt.Sprintf("dashboard api error: %s", err))
+	c.write(w, status, targetError{Error: err.Error()})
+}
+
+func (c *Dashboard) write(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error(fmt.Sprintf("error writing dashboard api response: %s", err))
+	}
+}
+
+func NewPositionBody(p domain.PositionValue) PositionBody {
+	return PositionBody{
+		Target:    p.Target,
+		Token:     p.Token,
+		Paired:    p.Paired,
+		Amount:    p.Amount,
+		Cost:      p.Cost,
+		Value:     p.Value,
+		PnL:       p.PnL(),
+		Trades:    p.Trades,
+		UpdatedAt: p.UpdatedAt,
+	}
+}
+
+func NewHoldingBody(h domain.Holding) HoldingBody {
+	return HoldingBody{
+		Token:    h.Token,
+		Paired:   h.Paired,
+		Balance:  h.Balance,
+		Balances: h.Balances,
+		Cost:     h.Cost,
+		Value:    h.Value,
+		PnL:      h.PnL(),
+	}
+}
+
+func NewDecisionBody(d domain.Decision) DecisionBody {
+	b := DecisionBody{
+		Kind:   d.Kind,
+		Target: d.Target,
+		Tx:     d.Tx,
+		Reason: d.Reason,
+		Detail: d.Detail,
+		Time:   d.Time,
+	}
+	if c := d.Candidate; c != nil {
+		b.Candidate = &CandidateBody{
+			Method:       c.Method,
+			Token:        c.Token,
+			Paired:       c.Paired,
+			AmountToken:  c.AmountToken,
+			AmountPaired: c.AmountPaired,
+			GasPrice:     c.GasPrice,
+			Deadline:     c.Deadline,
+		}
+	}
+	return b
+}
@@ -0,0 +1,4 @@
+// Package controller is the entry point of the stimuli: the Engine subscribes to the node and hands what it notifies
+// (pending txs, blocks) to their controllers, and the api controllers (Target, Dashboard, Stream, GraphQL, Log, Panic)
+// register their routes in a mux. The Telegram controller runs the commands sent to our bot.
+package controller
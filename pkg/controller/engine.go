@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/usecase"
+)
+
+type (
+	// Engine subscribes to the node and hands each notified value (eg. a pending tx hash, a full tx or a block header)
+	// to the handler, from a pool of workers. If the subscription errors it subscribes again, and if a worker panics
+	// it stops. While the queue is backed up it marks the contexts as shedding (see usecase.Shedding).
+	Engine struct {
+		client  *rpc.Client
+		workers int
+
+		// shedAt is the backlog of queued values at which we start shedding load, until it drains to half of it.
+		// Zero means we never shed.
+		shedAt   int
+		shedding *int32
+
+		sub    EngineSubscription
+		middle EngineMiddleware
+		ctrl   EngineHandler
+	}
+
+	// EngineSubscription to the node, piping what it notifies to the channel
+	EngineSubscription func(ctx context.Context, c *rpc.Client, ch chan<- interface{}) (*rpc.ClientSubscription, error)
+	// EngineMiddleware of the context of each value (eg. to load balance the nodes it calls)
+	EngineMiddleware func(context.Context) context.Context
+	// EngineHandler of each value notified
+	EngineHandler func(ctx context.Context, v interface{}) error
+)
+
+// NewEngine with w workers, which starts shedding load once shed values are queued (zero never sheds)
+func NewEngine(cl *rpc.Client, w, shed int, sub EngineSubscription, mid EngineMiddleware, ctrl EngineHandler) *Engine {
+	if w <= 0 {
+		panic("workers > 0")
+	}
+	return &Engine{
+		client:   cl,
+		workers:  w,
+		shedAt:   shed,
+		shedding: new(int32),
+		sub:      sub,
+		middle:   mid,
+		ctrl:     ctrl,
+	}
+}
+
+// Run the engine until the context is done or a worker panics
+func (e *Engine) Run(ctx context.Context) {
+	var canc func()
+	ctx, canc = context.WithCancel(ctx)
+
+	// Go channel to pipe data from client subscription
+	ch := make(chan interface{}, e.workers)
+
+	// Consume in workers the new txs
+	wg := new(sync.WaitGroup)
+	wg.Add(e.workers)
+	for i := 0; i < e.workers; i++ {
+		go func(ctx context.Context, ch <-chan interface{}, wg *sync.WaitGroup) {
+			defer wg.Done()
+			defer recovery(canc) // if a worker panics we stop everything
+			e.consumeBlocking(ctx, ch)
+		}(ctx, ch, wg)
+	}
+
+	// Subscribe to receive one time events for new txs
+	e.subscribeSafe(ctx, ch, canc)
+
+	// Block forever (or until the ctx gets cancelled / an error occurs)
+	wg.Wait()
+}
+
+func (e *Engine) subscribeSafe(ctx context.Context, ch chan<- interface{}, canc func()) {
+	s, err := e.sub(ctx, e.client, ch)
+	if err != nil {
+		panic(err)
+	}
+	go func() { // on error try subscribing again, if it panics we fail gracefully
+		defer recovery(canc)
+		var once sync.Once
+		for range s.Err() {
+			once.Do(func() {
+				s.Unsubscribe()
+				e.subscribeSafe(ctx, ch, canc)
+			})
+		}
+	}()
+}
+
+func (e *Engine) consumeBlocking(ctx context.Context, ch <-chan interface{}) {
+	for {
+		select {
+		case v := <-ch:
+			vctx := e.middle(ctx)
+			if e.shed(len(ch)) {
+				vctx = usecase.Shedding(vctx)
+			}
+			if err := e.ctrl(vctx, v); err != nil {
+				log.Error(err.Error())
+			}
+		case <-ctx.Done():
+			return // break here.
+		}
+	}
+}
+
+// shed reports if we are shedding load with the given backlog. Once we start, we keep shedding until the backlog
+// drains to half the threshold, so we don't flip on every value.
+func (e *Engine) shed(backlog int) bool {
+	if e.shedAt <= 0 {
+		return false
+	}
+
+	shedding := atomic.LoadInt32(e.shedding) == 1
+	switch {
+	case !shedding && backlog >= e.shedAt:
+		if atomic.CompareAndSwapInt32(e.shedding, 0, 1) {
+			log.Warn(fmt.Sprintf("backlog of %d values, shedding everything but the router txs", backlog))
+		}
+		return true
+	case shedding && backlog < e.shedAt/2:
+		if atomic.CompareAndSwapInt32(e.shedding, 1, 0) {
+			log.Info(fmt.Sprintf("backlog drained to %d values, no longer shedding load", backlog))
+		}
+		return false
+	}
+	return shedding
+}
+
+func recovery(fn func()) {
+	if err := recover(); err != nil {
+		log.Error(fmt.Sprintf("panic recovered: %s %s", fmt.Errorf("%s", err), debug.Stack()))
+		if fn != nil {
+			fn()
+		}
+	}
+}
+
+// SubscribeFullPendingTxs of the mempool, piping them to the engine as they are notified. Txs are decoded by the rpc
+// client straight into their type, so they aren't decoded twice.
+func SubscribeFullPendingTxs(ctx context.Context, c *rpc.Client, ch chan<- interface{}) (*rpc.ClientSubscription, error) {
+	txs := make(chan *types.Transaction, cap(ch))
+	s, err := c.EthSubscribe(ctx, txs, "newPendingTransactions", true)
+	if err != nil {
+		return nil, err
+	}
+	// the engine owns the errors of the subscription (and subscribes again on them), so this one only stops with the
+	// context. Once the subscription errors it simply idles
+	go func() {
+		for {
+			select {
+			case tx := <-txs:
+				ch <- tx
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return s, nil
+}
@@ -0,0 +1,28 @@
+package controller
+
+import "testing"
+
+func TestEngine_Shed(t *testing.T) {
+	e := NewEngine(nil, 1, 100, nil, nil, nil)
+	backlogs := []struct {
+		backlog int
+		expect  bool
+	}{
+		{10, false},
+		{99, false},
+		{100, true},
+		{60, true}, // keeps shedding until it drains to half
+		{50, true},
+		{49, false},
+		{99, false},
+	}
+	for _, b := range backlogs {
+		if got := e.shed(b.backlog); got != b.expect {
+			t.Fatalf("expected shedding %t with a backlog of %d, got %t", b.expect, b.backlog, got)
+		}
+	}
+
+	if NewEngine(nil, 1, 0, nil, nil, nil).shed(1 << 20) {
+		t.Fatal("expected an engine without threshold to never shed")
+	}
+}
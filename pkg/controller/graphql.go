@@ -0,0 +1,228 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+const (
+	graphQLPath = "/graphql"
+
+	// graphQLSchema over the trade journal and the positions. Amounts are strings in the smallest unit of their token,
+	// as they don't fit a GraphQL Int.
+	graphQLSchema = `
+		schema {
+			query: Query
+		}
+
+		type Query {
+			# trades passing all the given filters, oldest first. from and to are both inclusive
+			trades(target: String, token: String, outcome: TradeOutcome, from: Time, to: Time, limit: Int): [Trade!]!
+			# positions of the targets, sorted by target and token
+			positions(target: String, token: String): [Position!]!
+		}
+
+		scalar Time
+
+		enum TradeOutcome {
+			filled
+			short_fill
+			zero_fill
+		}
+
+		type Trade {
+			target: String!
+			hash: String!
+			token: String!
+			paired: String!
+			amountIn: String
+			amountOut: String!
+			block: Float!
+			time: Time!
+			outcome: TradeOutcome!
+			simulatedGasUsed: Float
+			simulatedCoinbaseDiff: String
+			gasUsed: Float
+			gasCost: String
+			nativeUsd: Float
+			amountInUsd: Float
+			gasCostUsd: Float
+		}
+
+		type Position {
+			target: String!
+			token: String!
+			amount: String!
+			cost: String!
+			trades: Int!
+			updatedAt: Time!
+		}
+	`
+)
+
+type (
+	// GraphQL controller answers filtered queries over the trade journal and the positions, for reporting built on
+	// top of the bot:
+	//   POST /graphql  runs the query of the body ({"query": ..., "variables": {...}}), see graphQLSchema
+	GraphQL struct {
+		handler http.Handler
+	}
+
+	graphQLTrades interface {
+		Find(context.Context, domain.TradeFilter) ([]domain.Trade, error)
+		Positions(context.Context) ([]domain.Position, error)
+	}
+
+	graphQLQuery struct {
+		trades graphQLTrades
+	}
+
+	graphQLTrade struct {
+		t domain.Trade
+	}
+
+	graphQLPosition struct {
+		p domain.Position
+	}
+)
+
+func NewGraphQL(t graphQLTrades) *GraphQL {
+	return &GraphQL{
+		handler: &relay.Handler{
+			Schema: graphql.MustParseSchema(graphQLSchema, &graphQLQuery{trades: t}),
+		},
+	}
+}
+
+// Register the routes of the controller in the mux
+func (c *GraphQL) Register(mux *http.ServeMux) {
+	mux.HandleFunc(graphQLPath, c.serve)
+}
+
+func (c *GraphQL) serve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, fmt.Sprintf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+		return
+	}
+	c.handler.ServeHTTP(w, r)
+}
+
+func (q *graphQLQuery) Trades(ctx context.Context, args struct {
+	Target  *string
+	Token   *string
+	Outcome *string
+	From    *graphql.Time
+	To      *graphql.Time
+	Limit   *int32
+}) ([]*graphQLTrade, error) {
+	var f domain.TradeFilter
+	if args.Target != nil {
+		f.Target = *args.Target
+	}
+	if args.Token != nil {
+		f.Token = *args.Token
+	}
+	if args.Outcome != nil {
+		f.Outcome = domain.TradeOutcome(*args.Outcome)
+	}
+	if args.From != nil {
+		f.From = args.From.Time
+	}
+	if args.To != nil {
+		f.To = args.To.Time
+	}
+	if args.Limit != nil {
+		if *args.Limit <= 0 {
+			return nil, fmt.Errorf("invalid limit %d", *args.Limit)
+		}
+		f.Limit = int(*args.Limit)
+	}
+
+	trades, err := q.trades.Find(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*graphQLTrade, len(trades))
+	for i, t := range trades {
+		res[i] = &graphQLTrade{t: t}
+	}
+	return res, nil
+}
+
+func (q *graphQLQuery) Positions(ctx context.Context, args struct {
+	Target *string
+	Token  *string
+}) ([]*graphQLPosition, error) {
+	positions, err := q.trades.Positions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*graphQLPosition, 0, len(positions))
+	for _, p := range positions {
+		if args.Target != nil && p.Target != *args.Target {
+			continue
+		}
+		if args.Token != nil && !strings.EqualFold(p.Token, *args.Token) {
+			continue
+		}
+		res = append(res, &graphQLPosition{p: p})
+	}
+	return res, nil
+}
+
+func (r *graphQLTrade) Target() string     { return r.t.Target }
+func (r *graphQLTrade) Hash() string       { return r.t.Hash }
+func (r *graphQLTrade) Token() string      { return r.t.Token }
+func (r *graphQLTrade) Paired() string     { return r.t.Paired }
+func (r *graphQLTrade) AmountIn() *string  { return graphQLAmount(r.t.AmountIn) }
+func (r *graphQLTrade) AmountOut() string  { return r.t.AmountOut.String() }
+func (r *graphQLTrade) Block() float64     { return float64(r.t.Block) }
+func (r *graphQLTrade) Time() graphql.Time { return graphql.Time{Time: r.t.Time} }
+func (r *graphQLTrade) Outcome() string    { return string(r.t.Outcome) }
+func (r *graphQLTrade) SimulatedGasUsed() *float64 {
+	if r.t.SimulatedGasUsed == 0 {
+		return nil
+	}
+	g := float64(r.t.SimulatedGasUsed)
+	return &g
+}
+func (r *graphQLTrade) SimulatedCoinbaseDiff() *string {
+	return graphQLAmount(r.t.SimulatedCoinbaseDiff)
+}
+func (r *graphQLTrade) GasUsed() *float64     { return graphQLFloat(float64(r.t.GasUsed)) }
+func (r *graphQLTrade) GasCost() *string      { return graphQLAmount(r.t.GasCost) }
+func (r *graphQLTrade) NativeUsd() *float64   { return graphQLFloat(r.t.NativeUSD) }
+func (r *graphQLTrade) AmountInUsd() *float64 { return graphQLFloat(r.t.AmountInUSD()) }
+func (r *graphQLTrade) GasCostUsd() *float64  { return graphQLFloat(r.t.GasCostUSD()) }
+
+func (r *graphQLPosition) Target() string          { return r.p.Target }
+func (r *graphQLPosition) Token() string           { return r.p.Token }
+func (r *graphQLPosition) Amount() string          { return r.p.Amount.String() }
+func (r *graphQLPosition) Cost() string            { return r.p.Cost.String() }
+func (r *graphQLPosition) Trades() int32           { return int32(r.p.Trades) }
+func (r *graphQLPosition) UpdatedAt() graphql.Time { return graphql.Time{Time: r.p.UpdatedAt} }
+
+// graphQLAmount of a nullable amount
+func graphQLAmount(a *big.Int) *string {
+	if a == nil {
+		return nil
+	}
+	s := a.String()
+	return &s
+}
+
+// graphQLFloat of a value that is zero when unknown
+func graphQLFloat(v float64) *float64 {
+	if v == 0 {
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+	"github.com/saantiaguilera/liquidity-sniper/pkg/repository"
+)
+
+func TestGraphQL_Trades(t *testing.T) {
+	trades := repository.NewMemoryTrade()
+	day := time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC)
+	for i, tr := range []domain.Trade{
+		{Target: "a", Hash: "0x1", Token: "0xAA", AmountOut: big.NewInt(10), Time: day, Outcome: domain.TradeFilled},
+		{Target: "a", Hash: "0x2", Token: "0xBB", AmountOut: big.NewInt(20), Time: day.Add(time.Hour), Outcome: domain.TradeShortFill},
+		{Target: "b", Hash: "0x3", Token: "0xAA", AmountOut: big.NewInt(30), Time: day.Add(48 * time.Hour), Outcome: domain.TradeFilled},
+		{Target: "b", Hash: "0x4", Token: "0xAA", AmountOut: big.NewInt(0), Time: day.Add(72 * time.Hour), Outcome: domain.TradeZeroFill},
+	} {
+		tr.AmountIn = big.NewInt(int64(i + 1))
+		if err := trades.Save(context.Background(), tr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mux := http.NewServeMux()
+	NewGraphQL(trades).Register(mux)
+
+	tests := []struct {
+		name   string
+		query  string
+		expect []string
+	}{
+		{
+			name:   "all",
+			query:  `{ trades { hash } }`,
+			expect: []string{"0x1", "0x2", "0x3", "0x4"},
+		},
+		{
+			name:   "token",
+			query:  `{ trades(token: "0xaa") { hash } }`,
+			expect: []string{"0x1", "0x3", "0x4"},
+		},
+		{
+			name:   "outcome",
+			query:  `{ trades(token: "0xAA", outcome: filled) { hash } }`,
+			expect: []string{"0x1", "0x3"},
+		},
+		{
+			name:   "date range",
+			query:  `{ trades(from: "2021-11-01T01:00:00Z", to: "2021-11-03T00:00:00Z") { hash } }`,
+			expect: []string{"0x2", "0x3"},
+		},
+		{
+			name:   "limit",
+			query:  `{ trades(target: "b", limit: 1) { hash } }`,
+			expect: []string{"0x3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var res struct {
+				Data struct {
+					Trades []struct{ Hash string }
+				}
+				Errors []interface{}
+			}
+			serveGraphQL(t, mux, tt.query, &res)
+			if len(res.Errors) > 0 {
+				t.Fatalf("expected no errors, got %v", res.Errors)
+			}
+			got := make([]string, len(res.Data.Trades))
+			for i, tr := range res.Data.Trades {
+				got[i] = tr.Hash
+			}
+			if strings.Join(got, ",") != strings.Join(tt.expect, ",") {
+				t.Fatalf("expected trades %v, got %v", tt.expect, got)
+			}
+		})
+	}
+
+	var res struct {
+		Data struct {
+			Positions []struct {
+				Target string
+				Amount string
+				Cost   string
+				Trades int
+			}
+		}
+	}
+	serveGraphQL(t, mux, `{ positions(token: "0xAA") { target amount cost trades } }`, &res)
+	if len(res.Data.Positions) != 2 {
+		t.Fatalf("expected the positions of both targets in the token, got %+v", res.Data.Positions)
+	}
+	if p := res.Data.Positions[1]; p.Target != "b" || p.Amount != "30" || p.Cost != "7" || p.Trades != 2 {
+		t.Fatalf("unexpected position %+v", p)
+	}
+}
+
+func serveGraphQL(t *testing.T, mux *http.ServeMux, query string, res interface{}) {
+	body, err := json.Marshal(map[string]string{"query": query})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, graphQLPath, strings.NewReader(string(body))))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), res); err != nil {
+		t.Fatal(err)
+	}
+}
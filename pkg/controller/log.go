@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const logPath = "/log"
+
+type (
+	// Log controller exposes the log levels through HTTP, to change them while the bot runs:
+	//   GET /log  gets the level and the modules with their own one
+	//   PUT /log  sets the level and / or the level of modules (an empty one resets the module)
+	Log struct {
+		levels logLevels
+	}
+
+	logLevels interface {
+		Level() log.Lvl
+		Modules() map[string]log.Lvl
+		SetLevel(log.Lvl)
+		SetModule(string, log.Lvl) error
+		ResetModule(string) error
+	}
+
+	// LogBody is the representation of the log levels in the API, eg. {"level": "info", "modules": {"gas": "debug"}}
+	LogBody struct {
+		Level   string            `json:"level,omitempty"`
+		Modules map[string]string `json:"modules,omitempty"`
+	}
+)
+
+func NewLog(l logLevels) *Log {
+	return &Log{
+		levels: l,
+	}
+}
+
+// Register the routes of the controller in the mux
+func (c *Log) Register(mux *http.ServeMux) {
+	mux.HandleFunc(logPath, c.serve)
+}
+
+func (c *Log) serve(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var b LogBody
+		if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+			c.writeError(w, http.StatusBadRequest, fmt.Errorf("malformed log levels: %s", err))
+			return
+		}
+		if err := c.set(b); err != nil {
+			c.writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	default:
+		c.writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	b := LogBody{Level: logLevelName(c.levels.Level()), Modules: make(map[string]string)}
+	for m, l := range c.levels.Modules() {
+		b.Modules[m] = logLevelName(l)
+	}
+	c.write(w, http.StatusOK, b)
+}
+
+// set the levels of the body, parsing all of them first so a malformed one changes nothing
+func (c *Log) set(b LogBody) error {
+	var level log.Lvl
+	if len(b.Level) > 0 {
+		l, err := log.LvlFromString(b.Level)
+		if err != nil {
+			return err
+		}
+		level = l
+	}
+	modules := make(map[string]log.Lvl, len(b.Modules))
+	for m, v := range b.Modules {
+		if len(v) == 0 {
+			continue
+		}
+		l, err := log.LvlFromString(v)
+		if err != nil {
+			return fmt.Errorf("module %s: %s", m, err)
+		}
+		modules[m] = l
+	}
+
+	if len(b.Level) > 0 {
+		c.levels.SetLevel(level)
+	}
+	for m, v := range b.Modules {
+		var err error
+		if l, ok := modules[m]; ok {
+			err = c.levels.SetModule(m, l)
+		} else if len(v) == 0 {
+			err = c.levels.ResetModule(m)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Log) writeError(w http.ResponseWriter, status int, err error) {
+	log.Warn(fmt.Sprintf("log api error: %s", err))
+	c.write(w, status, targetError{Error: err.Error()})
+}
+
+func (c *Log) write(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error(fmt.Sprintf("error writing log api response: %s", err))
+	}
+}
+
+func logLevelName(l log.Lvl) string {
+	switch l {
+	case log.LvlCrit:
+		return "crit"
+	case log.LvlError:
+		return "error"
+	case log.LvlWarn:
+		return "warn"
+	case log.LvlInfo:
+		return "info"
+	case log.LvlDebug:
+		return "debug"
+	default:
+		return "trace"
+	}
+}
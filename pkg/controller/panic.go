@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+const panicPath = "/panic"
+
+type (
+	// Panic controller sells everything we hold through HTTP, for "everything is rugging" moments:
+	//   POST /panic  market sells every token held across our wallets with aggressive gas, listing the sales
+	Panic struct {
+		seller panicSeller
+	}
+
+	panicSeller interface {
+		Run(context.Context) ([]domain.Sale, error)
+	}
+
+	// SaleBody is the representation of a sale in the API. Amounts are in wei.
+	SaleBody struct {
+		Token  string   `json:"token"`
+		Wallet string   `json:"wallet"`
+		Amount *big.Int `json:"amount"`
+		Tx     string   `json:"tx,omitempty"`
+		Error  string   `json:"error,omitempty"`
+	}
+)
+
+func NewPanic(s panicSeller) *Panic {
+	return &Panic{
+		seller: s,
+	}
+}
+
+// Register the routes of the controller in the mux
+func (c *Panic) Register(mux *http.ServeMux) {
+	mux.HandleFunc(panicPath, c.serve)
+}
+
+func (c *Panic) serve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		c.writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	log.Warn("panic sell requested through the api")
+	sales, err := c.seller.Run(r.Context())
+	if err != nil {
+		c.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	res := make([]SaleBody, len(sales))
+	for i, s := range sales {
+		res[i] = NewSaleBody(s)
+	}
+	c.write(w, http.StatusOK, res)
+}
+
+func (c *Panic) writeError(w http.ResponseWriter, status int, err error) {
+	log.Warn(fmt.Sprintf("panic api error: %s", err))
+	c.write(w, status, targetError{Error: err.Error()})
+}
+
+func (c *Panic) write(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error(fmt.Sprintf("error writing panic api response: %s", err))
+	}
+}
+
+func NewSaleBody(s domain.Sale) SaleBody {
+	return SaleBody{
+		Token:  s.Token,
+		Wallet: s.Wallet,
+		Amount: s.Amount,
+		Tx:     s.Tx,
+		Error:  s.Error,
+	}
+}
@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+const reservesPath = "/reserves"
+
+type (
+	// Reserves controller exports the reserve series recorded for the sniped tokens:
+	//   GET /reserves/{token}?from={rfc3339}&to={rfc3339}&format={json|csv}  lists the samples of the token, oldest
+	//                                                                       first. Both bounds are optional
+	Reserves struct {
+		series reservesSeries
+	}
+
+	reservesSeries interface {
+		Series(ctx context.Context, token string, from, to time.Time) ([]domain.ReserveSample, error)
+	}
+
+	// ReserveSampleBody is the representation of a reserve sample in the API. Reserves are in the smallest unit of
+	// their token, and so is the price (paired per token).
+	ReserveSampleBody struct {
+		Token         string    `json:"token"`
+		Paired        string    `json:"paired"`
+		Pair          string    `json:"pair"`
+		ReserveToken  *big.Int  `json:"reserve_token"`
+		ReservePaired *big.Int  `json:"reserve_paired"`
+		Price         string    `json:"price,omitempty"`
+		Held          *big.Int  `json:"held"`
+		Time          time.Time `json:"time"`
+	}
+)
+
+func NewReserves(s reservesSeries) *Reserves {
+	return &Reserves{
+		series: s,
+	}
+}
+
+// Register the routes of the controller in the mux
+func (c *Reserves) Register(mux *http.ServeMux) {
+	mux.HandleFunc(reservesPath+"/", c.serve)
+}
+
+func (c *Reserves) serve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		c.writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	token := strings.TrimPrefix(r.URL.Path, reservesPath+"/")
+	if len(token) == 0 || strings.Contains(token, "/") {
+		c.writeError(w, http.StatusNotFound, fmt.Errorf("unknown path %s", r.URL.Path))
+		return
+	}
+	var from, to time.Time
+	for _, b := range []struct {
+		name string
+		t    *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		v := r.URL.Query().Get(b.name)
+		if len(v) == 0 {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.writeError(w, http.StatusBadRequest, fmt.Errorf("invalid %s %s, expected an rfc3339 time", b.name, v))
+			return
+		}
+		*b.t = t
+	}
+
+	samples, err := c.series.Series(r.Context(), token, from, to)
+	if err != nil {
+		c.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	res := make([]ReserveSampleBody, len(samples))
+	for i, s := range samples {
+		res[i] = NewReserveSampleBody(s)
+	}
+
+	switch f := r.URL.Query().Get("format"); f {
+	case "", "json":
+		c.write(w, http.StatusOK, res)
+	case "csv":
+		c.writeCSV(w, res)
+	default:
+		c.writeError(w, http.StatusBadRequest, fmt.Errorf("unknown format %s", f))
+	}
+}
+
+func (c *Reserves) writeCSV(w http.ResponseWriter, samples []ReserveSampleBody) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.WriteHeader(http.StatusOK)
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"time", "token", "paired", "pair", "reserve_token", "reserve_paired", "price", "held"})
+	for _, s := range samples {
+		_ = cw.Write([]string{
+			s.Time.Format(time.RFC3339),
+			s.Token,
+			s.Paired,
+			s.Pair,
+			s.ReserveToken.String(),
+			s.ReservePaired.String(),
+			s.Price,
+			s.Held.String(),
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		log.Error(fmt.Sprintf("error writing reserves api response: %s", err))
+	}
+}
+
+func (c *Reserves) writeError(w http.ResponseWriter, status int, err error) {
+	log.Warn(fmt.Sprintf("reserves api error: %s", err))
+	c.write(w, status, targetError{Error: err.Error()})
+}
+
+func (c *Reserves) write(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error(fmt.Sprintf("error writing reserves api response: %s", err))
+	}
+}
+
+func NewReserveSampleBody(s domain.ReserveSample) ReserveSampleBody {
+	b := ReserveSampleBody{
+		Token:         s.Token,
+		Paired:        s.Paired,
+		Pair:          s.Pair,
+		ReserveToken:  s.ReserveToken,
+		ReservePaired: s.ReservePaired,
+		Held:          s.Held,
+		Time:          s.Time,
+	}
+	if p := s.Price(); p != nil {
+		b.Price = p.Text('g', 10)
+	}
+	return b
+}
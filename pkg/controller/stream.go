@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/gorilla/websocket"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+const (
+	streamPath = "/stream"
+
+	// streamBuffer of decisions of each consumer, beyond it a slow one misses them
+	streamBuffer = 1000
+	// streamPingInterval keeps idle connections (eg. between launches) alive through proxies, and detects dead ones
+	streamPingInterval = 30 * time.Second
+	streamWriteTimeout = 10 * time.Second
+)
+
+type (
+	// Stream controller streams the decisions of the filter chain through a WebSocket as they are made, so external
+	// tools can follow what the bot sees in the mempool:
+	//   GET /stream?target={name}&kinds={kind,...}  upgrades to a WebSocket sending each decision as a JSON message
+	//                                               (a DecisionBody, candidates seen carry their decoded addition)
+	// Both filters are optional. Clients are only sent decisions, anything they send is discarded.
+	Stream struct {
+		stream   streamDecisions
+		upgrader websocket.Upgrader
+	}
+
+	streamDecisions interface {
+		Subscribe(int) (<-chan domain.Decision, func())
+	}
+
+	// streamFilter of the decisions a consumer wants
+	streamFilter struct {
+		target string
+		kinds  map[domain.DecisionKind]bool
+	}
+)
+
+func NewStream(s streamDecisions) *Stream {
+	return &Stream{
+		stream: s,
+	}
+}
+
+// Register the routes of the controller in the mux
+func (c *Stream) Register(mux *http.ServeMux) {
+	mux.HandleFunc(streamPath, c.serve)
+}
+
+func (c *Stream) serve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, fmt.Sprintf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+		return
+	}
+	f := streamFilter{target: r.URL.Query().Get("target")}
+	if kinds := r.URL.Query().Get("kinds"); len(kinds) > 0 {
+		f.kinds = make(map[domain.DecisionKind]bool)
+		for _, k := range strings.Split(kinds, ",") {
+			f.kinds[domain.DecisionKind(k)] = true
+		}
+	}
+
+	conn, err := c.upgrader.Upgrade(w, r, nil) // it already responded if it failed
+	if err != nil {
+		log.Warn(fmt.Sprintf("stream api error: %s", err))
+		return
+	}
+	defer conn.Close()
+
+	decisions, unsubscribe := c.stream.Subscribe(streamBuffer)
+	defer unsubscribe()
+	log.Info(fmt.Sprintf("streaming decisions to %s", r.RemoteAddr))
+
+	// reading handles the pongs and the close of the client, which stops the stream
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(streamPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case d := <-decisions:
+			if !f.match(d) {
+				continue
+			}
+			err := conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			if err == nil {
+				err = conn.WriteJSON(NewDecisionBody(d))
+			}
+			if err != nil {
+				log.Info(fmt.Sprintf("stopped streaming decisions to %s: %s", r.RemoteAddr, err))
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout)); err != nil {
+				log.Info(fmt.Sprintf("stopped streaming decisions to %s: %s", r.RemoteAddr, err))
+				return
+			}
+		case <-closed:
+			log.Info(fmt.Sprintf("stopped streaming decisions to %s", r.RemoteAddr))
+			return
+		}
+	}
+}
+
+func (f streamFilter) match(d domain.Decision) bool {
+	if len(f.target) > 0 && d.Target != f.target {
+		return false
+	}
+	return f.kinds == nil || f.kinds[d.Kind]
+}
@@ -0,0 +1,233 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+const targetPath = "/targets"
+
+type (
+	// Target controller exposes the management of targets through HTTP:
+	//   GET    /targets              lists them
+	//   GET    /targets/{name}       gets one
+	//   POST   /targets              adds one
+	//   PUT    /targets/{name}       updates one
+	//   DELETE /targets/{name}       deletes one
+	//   POST   /targets/{name}/arm    arms one
+	//   POST   /targets/{name}/disarm disarms one
+	//   POST   /targets/{name}/profit realizes a profit of one, reinvesting part of it into its budget
+	Target struct {
+		manager targetManager
+	}
+
+	targetManager interface {
+		Get(context.Context, string) (domain.Sniper, error)
+		List(context.Context) ([]domain.Sniper, error)
+		Add(context.Context, domain.Sniper) error
+		Update(context.Context, domain.Sniper) error
+		Arm(context.Context, string) error
+		Disarm(context.Context, string) error
+		Delete(context.Context, string) error
+		RealizeProfit(context.Context, string, *big.Int) (*big.Int, error)
+	}
+
+	// TargetBody is the representation of a target in the API. Amounts are in wei.
+	TargetBody struct {
+		Name         string   `json:"name"`
+		Trigger      string   `json:"trigger"`
+		Token        string   `json:"token"`
+		Paired       string   `json:"paired"`
+		MinLiquidity *big.Int `json:"min_liquidity"`
+		OrderSize    *big.Int `json:"order_size,omitempty"`
+		Budget       *big.Int `json:"budget,omitempty"`
+		GasLimit     uint64   `json:"gas_limit,omitempty"`
+		BuyMode      string   `json:"buy_mode,omitempty"`
+		Armed        bool     `json:"armed"`
+		CommitReveal bool     `json:"commit_reveal"`
+	}
+
+	// ProfitBody is a profit realized by a target, in wei. Reinvested is how much of it was added to its budget.
+	ProfitBody struct {
+		Amount     *big.Int `json:"amount"`
+		Reinvested *big.Int `json:"reinvested,omitempty"`
+	}
+
+	targetError struct {
+		Error string `json:"error"`
+	}
+)
+
+func NewTarget(m targetManager) *Target {
+	return &Target{
+		manager: m,
+	}
+}
+
+// Register the routes of the controller in the mux
+func (c *Target) Register(mux *http.ServeMux) {
+	mux.HandleFunc(targetPath, c.serveCollection)
+	mux.HandleFunc(targetPath+"/", c.serveTarget)
+}
+
+func (c *Target) serveCollection(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		targets, err := c.manager.List(r.Context())
+		if err != nil {
+			c.writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		res := make([]TargetBody, len(targets))
+		for i, sn := range targets {
+			res[i] = NewTargetBody(sn)
+		}
+		c.write(w, http.StatusOK, res)
+	case http.MethodPost:
+		var b TargetBody
+		if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+			c.writeError(w, http.StatusBadRequest, fmt.Errorf("malformed target: %s", err))
+			return
+		}
+		if err := c.manager.Add(r.Context(), b.Sniper()); err != nil {
+			c.writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		c.writeTarget(w, r, b.Name, http.StatusCreated)
+	default:
+		c.writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	}
+}
+
+func (c *Target) serveTarget(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, targetPath+"/"), "/")
+	name := parts[0]
+	if len(name) == 0 || len(parts) > 2 {
+		c.writeError(w, http.StatusNotFound, fmt.Errorf("unknown path %s", r.URL.Path))
+		return
+	}
+
+	if len(parts) == 2 {
+		if r.Method != http.MethodPost {
+			c.writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+			return
+		}
+		var err error
+		switch parts[1] {
+		case "profit":
+			c.serveProfit(w, r, name)
+			return
+		case "arm":
+			err = c.manager.Arm(r.Context(), name)
+		case "disarm":
+			err = c.manager.Disarm(r.Context(), name)
+		default:
+			c.writeError(w, http.StatusNotFound, fmt.Errorf("unknown path %s", r.URL.Path))
+			return
+		}
+		if err != nil {
+			c.writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		c.writeTarget(w, r, name, http.StatusOK)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		c.writeTarget(w, r, name, http.StatusOK)
+	case http.MethodPut:
+		var b TargetBody
+		if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+			c.writeError(w, http.StatusBadRequest, fmt.Errorf("malformed target: %s", err))
+			return
+		}
+		b.Name = name
+		if err := c.manager.Update(r.Context(), b.Sniper()); err != nil {
+			c.writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		c.writeTarget(w, r, name, http.StatusOK)
+	case http.MethodDelete:
+		if err := c.manager.Delete(r.Context(), name); err != nil {
+			c.writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		c.writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	}
+}
+
+func (c *Target) serveProfit(w http.ResponseWriter, r *http.Request, name string) {
+	var b ProfitBody
+	if err := json.NewDecoder(r.Body).Decode(&b); err != nil || b.Amount == nil {
+		c.writeError(w, http.StatusBadRequest, fmt.Errorf("malformed profit: %v", err))
+		return
+	}
+	reinvested, err := c.manager.RealizeProfit(r.Context(), name, b.Amount)
+	if err != nil {
+		c.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	b.Reinvested = reinvested
+	c.write(w, http.StatusOK, b)
+}
+
+func (c *Target) writeTarget(w http.ResponseWriter, r *http.Request, name string, status int) {
+	sn, err := c.manager.Get(r.Context(), name)
+	if err != nil {
+		c.writeError(w, http.StatusNotFound, fmt.Errorf("error getting target %s: %s", name, err))
+		return
+	}
+	c.write(w, status, NewTargetBody(sn))
+}
+
+func (c *Target) writeError(w http.ResponseWriter, status int, err error) {
+	log.Warn(fmt.Sprintf("target api error: %s", err))
+	c.write(w, status, targetError{Error: err.Error()})
+}
+
+func (c *Target) write(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error(fmt.Sprintf("error writing target api response: %s", err))
+	}
+}
+
+func NewTargetBody(sn domain.Sniper) TargetBody {
+	return TargetBody{
+		Name:         sn.Name,
+		Trigger:      sn.AddressTrigger,
+		Token:        sn.AddressTargetToken,
+		Paired:       sn.AddressTargetPaired,
+		MinLiquidity: sn.MinimumLiquidity,
+		OrderSize:    sn.OrderSize,
+		Budget:       sn.Budget,
+		GasLimit:     sn.GasLimit,
+		BuyMode:      string(sn.BuyMode),
+		Armed:        sn.Armed,
+		CommitReveal: sn.Reveal != nil,
+	}
+}
+
+// Sniper of the body. Its chain and committed order (if any) are kept by the manager, and updates don't change if it's armed.
+func (b TargetBody) Sniper() domain.Sniper {
+	sn := domain.NewSniper(b.Trigger, b.Paired, b.Token, b.MinLiquidity, nil)
+	sn.Name = b.Name
+	sn.OrderSize = b.OrderSize
+	sn.Budget = b.Budget
+	sn.GasLimit = b.GasLimit
+	sn.BuyMode = domain.BuyMode(b.BuyMode)
+	sn.Armed = b.Armed
+	return sn
+}
@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+const (
+	telegramPanicCommand = "/panic"
+	telegramPanicConfirm = "/panic confirm"
+
+	// telegramCommandTTL is how old a command can be to be run. Commands sent while the bot was down are delivered
+	// once it's back, and a stale panic is never what the operator wants by then.
+	telegramCommandTTL    = time.Minute
+	telegramRetryDuration = 5 * time.Second
+)
+
+type (
+	// Telegram controller runs the commands an operator sends to our bot, from the configured chat only:
+	//   /panic          explains what it does, asking to confirm it
+	//   /panic confirm  market sells every token held across our wallets with aggressive gas, listing the sales
+	Telegram struct {
+		bot    telegramBot
+		chat   int64
+		seller panicSeller
+	}
+
+	telegramBot interface {
+		Messages(ctx context.Context, after int64) ([]domain.ChatMessage, error)
+		Send(ctx context.Context, chat int64, text string) error
+	}
+)
+
+func NewTelegram(b telegramBot, chat int64, s panicSeller) *Telegram {
+	return &Telegram{
+		bot:    b,
+		chat:   chat,
+		seller: s,
+	}
+}
+
+// Run the commands sent to the bot until the context is done
+func (c *Telegram) Run(ctx context.Context) {
+	var last int64
+	for ctx.Err() == nil {
+		msgs, err := c.bot.Messages(ctx, last)
+		if err != nil {
+			log.Warn(fmt.Sprintf("error getting telegram messages: %s", err))
+			select {
+			case <-ctx.Done():
+			case <-time.After(telegramRetryDuration):
+			}
+			continue
+		}
+		for _, m := range msgs {
+			last = m.ID
+			c.handle(ctx, m)
+		}
+	}
+}
+
+func (c *Telegram) handle(ctx context.Context, m domain.ChatMessage) {
+	if m.Chat != c.chat {
+		if len(m.Text) > 0 {
+			log.Warn(fmt.Sprintf("ignoring telegram message from chat %d, it's not ours", m.Chat))
+		}
+		return
+	}
+
+	switch strings.TrimSpace(m.Text) {
+	case telegramPanicCommand:
+		c.reply(ctx, fmt.Sprintf(
+			"this market sells everything held across our wallets right away, at any price and with aggressive gas. Send %s to go on",
+			telegramPanicConfirm,
+		))
+	case telegramPanicConfirm:
+		if time.Since(m.Time) > telegramCommandTTL {
+			c.reply(ctx, fmt.Sprintf("ignoring the panic sell sent at %s, send it again", m.Time.Format(time.RFC3339)))
+			return
+		}
+		log.Warn("panic sell requested through telegram")
+		c.reply(ctx, "panic selling everything")
+		sales, err := c.seller.Run(ctx)
+		if err != nil {
+			c.reply(ctx, fmt.Sprintf("error panic selling: %s", err))
+			return
+		}
+		c.reply(ctx, c.formatSales(sales))
+	}
+}
+
+func (c *Telegram) formatSales(sales []domain.Sale) string {
+	if len(sales) == 0 {
+		return "there was nothing to sell"
+	}
+	lines := make([]string, 0, len(sales)+1)
+	lines = append(lines, fmt.Sprintf("%d sells:", len(sales)))
+	for _, s := range sales {
+		l := fmt.Sprintf("%s of %s: %s wei", s.Token, s.Wallet, s.Amount.String())
+		if len(s.Error) > 0 {
+			l = fmt.Sprintf("%s, error: %s", l, s.Error)
+		} else {
+			l = fmt.Sprintf("%s, tx %s", l, s.Tx)
+		}
+		lines = append(lines, l)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (c *Telegram) reply(ctx context.Context, text string) {
+	if err := c.bot.Send(ctx, c.chat, text); err != nil {
+		log.Error(fmt.Sprintf("error replying through telegram: %s", err))
+	}
+}
@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type (
+	fakeTelegramBot struct {
+		sent []string
+	}
+
+	fakeTelegramSeller struct {
+		runs int
+	}
+)
+
+func (f *fakeTelegramBot) Messages(context.Context, int64) ([]domain.ChatMessage, error) {
+	return nil, nil
+}
+
+func (f *fakeTelegramBot) Send(_ context.Context, _ int64, text string) error {
+	f.sent = append(f.sent, text)
+	return nil
+}
+
+func (f *fakeTelegramSeller) Run(context.Context) ([]domain.Sale, error) {
+	f.runs++
+	return []domain.Sale{{Token: "0xAA", Wallet: "0x01", Amount: big.NewInt(10), Tx: "0x1"}}, nil
+}
+
+func TestTelegram_Panic(t *testing.T) {
+	const chat = 42
+
+	tests := []struct {
+		name        string
+		msg         domain.ChatMessage
+		runs        int
+		expectReply string
+	}{
+		{"asks to confirm", domain.ChatMessage{Chat: chat, Text: "/panic", Time: time.Now()}, 0, "/panic confirm"},
+		{"confirmed", domain.ChatMessage{Chat: chat, Text: "/panic confirm", Time: time.Now()}, 1, "0xAA of 0x01: 10 wei, tx 0x1"},
+		{"stale", domain.ChatMessage{Chat: chat, Text: "/panic confirm", Time: time.Now().Add(-time.Hour)}, 0, "send it again"},
+		{"other chat", domain.ChatMessage{Chat: 7, Text: "/panic confirm", Time: time.Now()}, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bot, seller := &fakeTelegramBot{}, &fakeTelegramSeller{}
+			NewTelegram(bot, chat, seller).handle(context.Background(), tt.msg)
+
+			if seller.runs != tt.runs {
+				t.Fatalf("expected %d panic sells, got %d", tt.runs, seller.runs)
+			}
+			replies := strings.Join(bot.sent, "\n")
+			if len(tt.expectReply) == 0 && len(replies) > 0 {
+				t.Fatalf("expected no replies, got %q", replies)
+			}
+			if !strings.Contains(replies, tt.expectReply) {
+				t.Fatalf("expected a reply with %q, got %q", tt.expectReply, replies)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package domain
+
+import "time"
+
+const (
+	// AlertZeroFill is a snipe that was mined successfully but bought nothing
+	AlertZeroFill AlertKind = "ZERO_FILL"
+	// AlertShortFill is a snipe that received way less tokens than the pair sent, usually a transfer tax surprise
+	AlertShortFill AlertKind = "SHORT_FILL"
+	// AlertSnipeReverted is a snipe whose txs were mined but all of them reverted
+	AlertSnipeReverted AlertKind = "SNIPE_REVERTED"
+	// AlertSnipeMarket is the market of a token we just sniped (price, liquidity, volume, charts), for a quick human
+	// assessment of the position. It's a notification.
+	AlertSnipeMarket AlertKind = "SNIPE_MARKET"
+	// AlertPrice is a held position whose value crossed one of the multiples of its cost we watch (eg. 3x / 0.5x)
+	AlertPrice AlertKind = "PRICE"
+	// AlertMigration is the liquidity of a target moving between pool versions (eg. from its v2 pair to a v3 pool)
+	AlertMigration AlertKind = "MIGRATION"
+	// AlertPanicSell is everything we hold being market sold at once, by an operator
+	AlertPanicSell AlertKind = "PANIC_SELL"
+	// AlertConnectivityLost is none of our nodes answering for too long, which may fire the emergency sells
+	AlertConnectivityLost AlertKind = "CONNECTIVITY_LOST"
+	// AlertConnectivityRestored is our nodes answering again after a connectivity loss
+	AlertConnectivityRestored AlertKind = "CONNECTIVITY_RESTORED"
+	// AlertHeartbeat is the bot telling it's alive and what it watches. It's a notification, nothing to act on.
+	AlertHeartbeat AlertKind = "HEARTBEAT"
+	// AlertHeartbeatStopped is the bot exiting, so the heartbeats stop and nothing is watched anymore
+	AlertHeartbeatStopped AlertKind = "HEARTBEAT_STOPPED"
+)
+
+type (
+	AlertKind string
+
+	// Alert of something an operator must look at right away
+	Alert struct {
+		Kind AlertKind
+		// Target (the sniper name) alerting
+		Target string
+		// Tx the alert is about, if any
+		Tx      string
+		Message string
+		Time    time.Time
+	}
+)
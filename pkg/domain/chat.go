@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+type (
+	// ChatMessage received by our bot in a chat (eg. a Telegram command of an operator)
+	ChatMessage struct {
+		// ID of the update that delivered it, increasing
+		ID int64
+		// Chat the message was sent in
+		Chat int64
+		Text string
+		// Time it was sent at
+		Time time.Time
+	}
+)
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"math/big"
+	"time"
+)
+
+const (
+	// DecisionCandidateSeen is a liquidity addition of our target that enters the filter chain
+	DecisionCandidateSeen DecisionKind = "candidate_seen"
+	// DecisionRejected is a candidate that a gate of the filter chain rejected
+	DecisionRejected DecisionKind = "rejected"
+	// DecisionSniped is a candidate we sniped
+	DecisionSniped DecisionKind = "sniped"
+	// DecisionSnipeFailed is a candidate that passed the filter chain but whose snipe failed
+	DecisionSnipeFailed DecisionKind = "snipe_failed"
+)
+
+type (
+	DecisionKind string
+
+	// Decision made by the filter chain about a candidate. Decisions are never modified once made, the history of
+	// a candidate is the sequence of decisions about its tx.
+	Decision struct {
+		Kind DecisionKind
+		// Target (the sniper name) deciding
+		Target string
+		// Tx of the candidate (eg. the addLiquidity one)
+		Tx string
+		// Reason the candidate was rejected, only for rejections
+		Reason SkipReason
+		// Detail of the decision, eg. the amounts that made a gate reject it
+		Detail string
+		// Candidate decoded from the tx, only for candidates seen
+		Candidate *Candidate
+		Time      time.Time
+	}
+
+	// Candidate is a liquidity addition of our target, as decoded from its tx
+	Candidate struct {
+		// Method of the router it calls, eg. addLiquidityETH
+		Method string
+		Token  string
+		// Paired token of the addition, empty for the native coin
+		Paired string
+		// AmountToken and AmountPaired are the desired amounts, in their smallest unit
+		AmountToken  *big.Int
+		AmountPaired *big.Int
+		GasPrice     *big.Int
+		// Deadline of the addition, as a unix timestamp
+		Deadline *big.Int
+	}
+)
@@ -0,0 +1,4 @@
+// Package domain holds the entities and the pure rules of the sniper, without any I/O: the targets (Sniper, BuyMode,
+// SniperReveal), their safety checks (ValuationBands, Slippage, ReinvestPolicy, OrderJitter), the outcome of what we
+// do (Trade, Decision, SkipError, RevertError) and the alerts we raise. Everything else builds on it.
+package domain
@@ -0,0 +1,24 @@
+package domain_test
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+func ExampleSlippage_MinOut() {
+	s := domain.Slippage{Buffer: 200, Max: 2000} // 2% buffer, skip if competing buys take more than 20%
+
+	// 10 wbnb into a launch adding 1000 wbnb and 1M tokens, behind 100 wbnb of competing buys
+	minOut, err := s.MinOut(big.NewInt(10), big.NewInt(1000), big.NewInt(1000000), big.NewInt(100))
+	fmt.Println(minOut, err)
+	// Output: 8007 <nil>
+}
+
+func ExampleNewValuation() {
+	// a launch adding 10% of a 1M supply (400k of it burned) against 50 wbnb
+	v := domain.NewValuation(big.NewInt(1000000), big.NewInt(400000), big.NewInt(100000), big.NewInt(50))
+	fmt.Println(v.MarketCap, v.FDV, v.LiquidityRatio())
+	// Output: 300 500 1000
+}
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"math/big"
+)
+
+type (
+	// OrderJitter varies the size of an order within ±Percentage, so our buys don't carry the same amount every
+	// time (a fingerprint anti-bot code and other snipers key on).
+	OrderJitter struct {
+		// Percentage the order may vary up or down, with 0.01 resolution. Zero disables it
+		Percentage float64
+	}
+)
+
+// Apply the jitter picked by the seed to the amounts. Every amount is scaled by the same factor, so an order keeps
+// its price (eg. its minimum out is scaled alongside its size). The same seed always picks the same jitter, which
+// lets us and configure-trigger agree on the configured order without sharing anything but the seed.
+func (j OrderJitter) Apply(seed [32]byte, amounts ...*big.Int) []*big.Int {
+	bps := j.bps()
+	res := make([]*big.Int, len(amounts))
+	if bps == 0 {
+		for i, a := range amounts {
+			res[i] = a
+		}
+		return res
+	}
+
+	// seed hash mod (2*bps+1) - bps, in basis points
+	h := sha256.Sum256(seed[:])
+	r := new(big.Int).SetBytes(h[:])
+	r.Mod(r, big.NewInt(2*bps+1))
+	r.Add(r, big.NewInt(10000-bps))
+	for i, a := range amounts {
+		if a == nil {
+			continue
+		}
+		res[i] = new(big.Int).Mul(a, r)
+		res[i].Div(res[i], big.NewInt(10000))
+	}
+	return res
+}
+
+func (j OrderJitter) bps() int64 {
+	if j.Percentage <= 0 {
+		return 0
+	}
+	if j.Percentage >= 100 {
+		return 9999
+	}
+	return int64(j.Percentage*100 + 0.5)
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestOrderJitter_Apply(t *testing.T) {
+	size, _ := new(big.Int).SetString("2000000000000000000", 10)
+	tests := []struct {
+		name       string
+		percentage float64
+		min, max   int64 // in basis points of the amount
+	}{
+		{"disabled", 0, 10000, 10000},
+		{"negative", -5, 10000, 10000},
+		{"small", 0.5, 9950, 10050},
+		{"common", 7, 9300, 10700},
+		{"capped", 150, 1, 19999},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := OrderJitter{Percentage: tt.percentage}
+			min := new(big.Int).Div(new(big.Int).Mul(size, big.NewInt(tt.min)), big.NewInt(10000))
+			max := new(big.Int).Div(new(big.Int).Mul(size, big.NewInt(tt.max)), big.NewInt(10000))
+			for i := 0; i < 256; i++ {
+				var seed [32]byte
+				seed[0], seed[31] = byte(i), byte(i*7)
+				res := j.Apply(seed, size)
+				if res[0].Cmp(min) < 0 || res[0].Cmp(max) > 0 {
+					t.Fatalf("jittered %s out of [%s, %s]", res[0], min, max)
+				}
+			}
+		})
+	}
+}
+
+func TestOrderJitter_Apply_Deterministic(t *testing.T) {
+	j := OrderJitter{Percentage: 10}
+	seed := [32]byte{1, 2, 3}
+	a, b := j.Apply(seed, big.NewInt(1000000), big.NewInt(50000)), j.Apply(seed, big.NewInt(1000000), big.NewInt(50000))
+	if a[0].Cmp(b[0]) != 0 || a[1].Cmp(b[1]) != 0 {
+		t.Fatalf("same seed jittered %v and %v", a, b)
+	}
+
+	// both amounts are scaled by the same factor, so the order keeps its price
+	if new(big.Int).Mul(a[0], big.NewInt(50000)).Cmp(new(big.Int).Mul(a[1], big.NewInt(1000000))) != 0 {
+		t.Fatalf("amounts %v weren't scaled alike", a)
+	}
+
+	other := j.Apply([32]byte{3, 2, 1}, big.NewInt(1000000))
+	if other[0].Cmp(a[0]) == 0 {
+		t.Fatalf("different seeds jittered the same %s", a[0])
+	}
+}
+
+func TestOrderJitter_Apply_Nil(t *testing.T) {
+	res := OrderJitter{Percentage: 5}.Apply([32]byte{1}, nil, big.NewInt(100))
+	if res[0] != nil || res[1] == nil {
+		t.Fatalf("unexpected %v", res)
+	}
+}
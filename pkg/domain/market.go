@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+type (
+	// Market of a token as seen by the pair trackers (eg. Dexscreener), for a quick human assessment of a position.
+	// Amounts are in USD, as they report them.
+	Market struct {
+		Token string
+		Pair  string
+		// DexscreenerURL and DextoolsURL are the charts of the pair, empty if the tracker isn't enabled
+		DexscreenerURL string
+		DextoolsURL    string
+		PriceUSD       float64
+		LiquidityUSD   float64
+		FDV            float64
+		VolumeH1       float64
+		VolumeH24      float64
+		// BuysH24 and SellsH24 are the txns of the pair in the last 24 hours
+		BuysH24   uint
+		SellsH24  uint
+		UpdatedAt time.Time
+	}
+)
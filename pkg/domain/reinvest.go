@@ -0,0 +1,35 @@
+package domain
+
+import "math/big"
+
+type (
+	// ReinvestPolicy allocates a share of the realized profits of a target back into its budget, so it compounds
+	// without manual top-ups
+	ReinvestPolicy struct {
+		// Percentage of each realized profit added to the budget. Zero disables reinvesting
+		Percentage uint
+		// MaxBudget is the most the budget can grow to by reinvesting. Nil means no cap
+		MaxBudget *big.Int
+	}
+)
+
+// Reinvest the share of the profit into the budget, returning the new budget and how much was added to it.
+// Losses and targets without budget (they have nothing to top up) are left as they are.
+func (p ReinvestPolicy) Reinvest(budget, profit *big.Int) (*big.Int, *big.Int) {
+	added := new(big.Int)
+	if p.Percentage == 0 || budget == nil || profit == nil || profit.Sign() <= 0 {
+		return budget, added
+	}
+
+	added.Mul(profit, big.NewInt(int64(p.Percentage)))
+	added.Div(added, big.NewInt(100))
+	res := new(big.Int).Add(budget, added)
+	if p.MaxBudget != nil && res.Cmp(p.MaxBudget) > 0 {
+		res.Set(p.MaxBudget)
+		if budget.Cmp(res) > 0 {
+			res.Set(budget) // it was already above the cap, we never shrink it
+		}
+		added.Sub(res, budget)
+	}
+	return res, added
+}
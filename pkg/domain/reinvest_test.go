@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestReinvestPolicy_Reinvest(t *testing.T) {
+	tests := []struct {
+		name         string
+		policy       ReinvestPolicy
+		budget       *big.Int
+		profit       *big.Int
+		expectBudget *big.Int
+		expectAdded  int64
+	}{
+		{"disabled", ReinvestPolicy{}, big.NewInt(100), big.NewInt(50), big.NewInt(100), 0},
+		{"no budget", ReinvestPolicy{Percentage: 50}, nil, big.NewInt(50), nil, 0},
+		{"no profit", ReinvestPolicy{Percentage: 50}, big.NewInt(100), nil, big.NewInt(100), 0},
+		{"loss", ReinvestPolicy{Percentage: 50}, big.NewInt(100), big.NewInt(-50), big.NewInt(100), 0},
+		{"share", ReinvestPolicy{Percentage: 50}, big.NewInt(100), big.NewInt(50), big.NewInt(125), 25},
+		{"all of it", ReinvestPolicy{Percentage: 100}, big.NewInt(100), big.NewInt(50), big.NewInt(150), 50},
+		{"rounds down", ReinvestPolicy{Percentage: 33}, big.NewInt(100), big.NewInt(10), big.NewInt(103), 3},
+		{"below cap", ReinvestPolicy{Percentage: 50, MaxBudget: big.NewInt(200)}, big.NewInt(100), big.NewInt(50), big.NewInt(125), 25},
+		{"capped", ReinvestPolicy{Percentage: 50, MaxBudget: big.NewInt(110)}, big.NewInt(100), big.NewInt(50), big.NewInt(110), 10},
+		{"already above cap", ReinvestPolicy{Percentage: 50, MaxBudget: big.NewInt(80)}, big.NewInt(100), big.NewInt(50), big.NewInt(100), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget, added := tt.policy.Reinvest(tt.budget, tt.profit)
+			if (budget == nil) != (tt.expectBudget == nil) || (budget != nil && budget.Cmp(tt.expectBudget) != 0) {
+				t.Fatalf("expected budget %v, got %v", tt.expectBudget, budget)
+			}
+			if added.Cmp(big.NewInt(tt.expectAdded)) != 0 {
+				t.Fatalf("expected %d added, got %s", tt.expectAdded, added)
+			}
+		})
+	}
+}
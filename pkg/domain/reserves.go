@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"math/big"
+	"time"
+)
+
+type (
+	// ReserveSample of the v2 pair of a sniped token at some point in time. Series of them tell what the price actually
+	// did after a snipe, to evaluate exit strategies against it.
+	ReserveSample struct {
+		Token  string
+		Paired string
+		Pair   string
+		// ReserveToken and ReservePaired in the pair, in their smallest unit
+		ReserveToken  *big.Int
+		ReservePaired *big.Int
+		// Held of the token across our wallets when sampled, zero once we exited
+		Held *big.Int
+		Time time.Time
+	}
+)
+
+// Price of the token in the paired one (both in their smallest unit), nil if the pair holds none of the token
+// (eg. the liquidity was pulled)
+func (s ReserveSample) Price() *big.Float {
+	if s.ReserveToken == nil || s.ReservePaired == nil || s.ReserveToken.Sign() == 0 {
+		return nil
+	}
+	return new(big.Float).Quo(new(big.Float).SetInt(s.ReservePaired), new(big.Float).SetInt(s.ReserveToken))
+}
@@ -0,0 +1,37 @@
+package domain
+
+const (
+	// RevertTransferFromFailed is a token that couldn't be pulled, eg. missing allowance or balance
+	RevertTransferFromFailed RevertCategory = "TRANSFER_FROM_FAILED"
+	// RevertTradingNotOpen is a token whose trading isn't enabled yet
+	RevertTradingNotOpen RevertCategory = "TRADING_NOT_OPEN"
+	// RevertMaxTx is a buy above the max tx / wallet amount of the token
+	RevertMaxTx RevertCategory = "MAX_TX"
+	// RevertSlippage is a buy that would receive less than the min amount out
+	RevertSlippage RevertCategory = "SLIPPAGE"
+	// RevertUnknown is any other revert
+	RevertUnknown RevertCategory = "UNKNOWN"
+)
+
+type (
+	// RevertCategory groups revert reasons that mean the same, since every token words them its own way
+	RevertCategory string
+
+	// RevertError is returned when a tx we sent reverted
+	RevertError struct {
+		Category RevertCategory
+		// Reason decoded from the revert, eg. the require message or the custom error
+		Reason string
+	}
+)
+
+func NewRevertError(c RevertCategory, reason string) *RevertError {
+	return &RevertError{
+		Category: c,
+		Reason:   reason,
+	}
+}
+
+func (e *RevertError) Error() string {
+	return "reverted with " + string(e.Category) + ": " + e.Reason
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"fmt"
+	"math/big"
+)
+
+const (
+	// SandwichActionWarn only logs the exposure of the snipe, sending it as any other
+	SandwichActionWarn SandwichAction = ""
+	// SandwichActionPrivate sends the snipe only through the private relays, never falling back to the public mempool
+	SandwichActionPrivate SandwichAction = "private"
+	// SandwichActionReduce shrinks the order until its exposure is within the max
+	SandwichActionReduce SandwichAction = "reduce"
+)
+
+type (
+	// SandwichAction taken on a snipe whose exposure to the sandwich bots around is above the max
+	SandwichAction string
+
+	// SandwichGuard of the snipes sent while sandwich bots are pending around the target
+	SandwichGuard struct {
+		// MaxExposure of the paired token a sandwich may take from a snipe in the worst case
+		MaxExposure *big.Int
+		Action      SandwichAction
+	}
+
+	// SandwichExposure of a buy into a v2 pool. A sandwich bot can buy before us until we get just our min amount out,
+	// and sell right after, so the worst case execution is the min out and the tokens below the expected ones are
+	// what it takes from us.
+	SandwichExposure struct {
+		AmountIn *big.Int
+		// Expected tokens of the buy if nobody buys before it, Worst the ones we get when sandwiched
+		Expected *big.Int
+		Worst    *big.Int
+		// Exposure is the share of the amount in lost in the worst case, in the paired token
+		Exposure *big.Int
+	}
+)
+
+// Valid if it's a known action
+func (a SandwichAction) Valid() bool {
+	return a == SandwichActionWarn || a == SandwichActionPrivate || a == SandwichActionReduce
+}
+
+// EstimateSandwich of a buy of amountIn into a v2 pool charging the fee and holding the given reserves, accepting
+// minOut tokens
+func EstimateSandwich(fee SwapFee, amountIn, reserveIn, reserveOut, minOut *big.Int) (SandwichExposure, error) {
+	if reserveIn.Sign() <= 0 || reserveOut.Sign() <= 0 {
+		return SandwichExposure{}, fmt.Errorf("can't simulate a buy on empty reserves %s / %s", reserveIn, reserveOut)
+	}
+	e := SandwichExposure{
+		AmountIn: amountIn,
+		Expected: fee.AmountOut(amountIn, reserveIn, reserveOut),
+		Worst:    minOut,
+		Exposure: new(big.Int),
+	}
+	if e.Expected.Sign() > 0 && e.Worst.Cmp(e.Expected) < 0 {
+		e.Exposure.Mul(amountIn, new(big.Int).Sub(e.Expected, e.Worst))
+		e.Exposure.Quo(e.Exposure, e.Expected)
+	}
+	return e, nil
+}
+
+// Exceeds reports whether the exposure is above the max of the guard
+func (g SandwichGuard) Exceeds(e SandwichExposure) bool {
+	return g.MaxExposure != nil && e.Exposure.Cmp(g.MaxExposure) > 0
+}
+
+// Reduced amount in of the exposed buy so its exposure is within the max. The exposure grows with the amount in, as
+// the share of it we may lose is the slippage we accept.
+func (g SandwichGuard) Reduced(e SandwichExposure) *big.Int {
+	if !g.Exceeds(e) {
+		return e.AmountIn
+	}
+	r := new(big.Int).Mul(e.AmountIn, g.MaxExposure)
+	return r.Quo(r, e.Exposure)
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestEstimateSandwich(t *testing.T) {
+	tests := []struct {
+		name           string
+		minOut         int64
+		max            *big.Int
+		expectExposure int64
+		expectReduced  int64
+	}{
+		{"no slippage", 987647, big.NewInt(10), 0, 1000},
+		{"within the max", 967894, big.NewInt(20), 20, 1000},
+		{"above the max", 967894, big.NewInt(10), 20, 500},
+		{"no max", 967894, nil, 20, 1000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := EstimateSandwich(SwapFeePancake, big.NewInt(1000), big.NewInt(100000), big.NewInt(100000000), big.NewInt(tt.minOut))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if e.Exposure.Cmp(big.NewInt(tt.expectExposure)) != 0 {
+				t.Fatalf("expected exposure %d, got %s", tt.expectExposure, e.Exposure)
+			}
+			g := SandwichGuard{MaxExposure: tt.max, Action: SandwichActionReduce}
+			if r := g.Reduced(e); r.Cmp(big.NewInt(tt.expectReduced)) != 0 {
+				t.Fatalf("expected amount in %d, got %s", tt.expectReduced, r)
+			}
+		})
+	}
+}
+
+func TestEstimateSandwich_EmptyReserves(t *testing.T) {
+	if _, err := EstimateSandwich(SwapFeePancake, big.NewInt(10), new(big.Int), big.NewInt(1000000), big.NewInt(1)); err == nil {
+		t.Fatal("expected an error simulating on empty reserves")
+	}
+}
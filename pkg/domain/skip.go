@@ -0,0 +1,71 @@
+package domain
+
+const (
+	// SkipReasonDeadlineExpired is a liquidity addition whose deadline already passed, it will revert
+	SkipReasonDeadlineExpired SkipReason = "DEADLINE_EXPIRED"
+	// SkipReasonDeadlineTooFar is a liquidity addition whose deadline is suspiciously far in the future
+	SkipReasonDeadlineTooFar SkipReason = "DEADLINE_TOO_FAR"
+	// SkipReasonWrongPair is a liquidity addition of our token but paired with another one
+	SkipReasonWrongPair SkipReason = "WRONG_PAIR"
+	// SkipReasonLiqBelowMin is a liquidity addition with less liquidity than the minimum we expect
+	SkipReasonLiqBelowMin SkipReason = "LIQ_BELOW_MIN"
+	// SkipReasonSenderUnknown is a liquidity addition whose sender can't be recovered
+	SkipReasonSenderUnknown SkipReason = "SENDER_UNKNOWN"
+	// SkipReasonSenderBalanceUnknown is a liquidity addition whose sender balance can't be queried
+	SkipReasonSenderBalanceUnknown SkipReason = "SENDER_BALANCE_UNKNOWN"
+	// SkipReasonSenderBalanceInsufficient is a liquidity addition whose sender doesn't hold the tokens being added,
+	// usually a fake addition made by another bot to lure us
+	SkipReasonSenderBalanceInsufficient SkipReason = "SENDER_BALANCE_INSUFFICIENT"
+	// SkipReasonSenderAllowanceUnknown is a liquidity addition whose sender allowance to the router can't be queried
+	SkipReasonSenderAllowanceUnknown SkipReason = "SENDER_ALLOWANCE_UNKNOWN"
+	// SkipReasonSenderAllowanceInsufficient is a liquidity addition whose sender didn't allow the router to move the
+	// tokens being added. It holds them, but the addition can't happen: a classic bait
+	SkipReasonSenderAllowanceInsufficient SkipReason = "SENDER_ALLOWANCE_INSUFFICIENT"
+	// SkipReasonVictimReverts is a liquidity addition that reverts when simulated against the pending state
+	// (eg. missing allowance / expired deadline), so it won't add any liquidity
+	SkipReasonVictimReverts SkipReason = "VICTIM_REVERTS"
+	// SkipReasonGasAboveCap is a liquidity addition with a gas price higher than the cap relative to the network
+	SkipReasonGasAboveCap SkipReason = "GAS_ABOVE_CAP"
+	// SkipReasonGasBelowMin is a liquidity addition with a gas price far below the network one, it won't confirm soon
+	SkipReasonGasBelowMin SkipReason = "GAS_BELOW_MIN"
+	// SkipReasonBudgetExceeded is a snipe that would spend more than the budget of the sniper
+	SkipReasonBudgetExceeded SkipReason = "BUDGET_EXCEEDED"
+	// SkipReasonPoolUnfunded is a snipe into a pair that doesn't hold the min liquidity, even though it should by now
+	SkipReasonPoolUnfunded SkipReason = "POOL_UNFUNDED"
+	// SkipReasonQuietHours is a snipe within the quiet hours of the operator, when we only observe
+	SkipReasonQuietHours SkipReason = "QUIET_HOURS"
+	// SkipReasonValuationUnknown is a liquidity addition whose token supply can't be queried to value the launch
+	SkipReasonValuationUnknown SkipReason = "VALUATION_UNKNOWN"
+	// SkipReasonValuationBelowMin is a launch whose implied market cap or FDV is too tiny to exit it
+	SkipReasonValuationBelowMin SkipReason = "VALUATION_BELOW_MIN"
+	// SkipReasonValuationAboveMax is a launch whose implied market cap or FDV is absurdly pre-valued
+	SkipReasonValuationAboveMax SkipReason = "VALUATION_ABOVE_MAX"
+	// SkipReasonLiqRatioBelowMin is a launch with too little liquidity for its FDV, an instant dump setup
+	SkipReasonLiqRatioBelowMin SkipReason = "LIQ_RATIO_BELOW_MIN"
+	// SkipReasonSlippageAboveMax is a snipe behind competing buys that take more of the price than we accept
+	SkipReasonSlippageAboveMax SkipReason = "SLIPPAGE_ABOVE_MAX"
+	// SkipReasonSandwichExposure is a snipe so exposed to the sandwich bots around that even reduced it's above the max
+	SkipReasonSandwichExposure SkipReason = "SANDWICH_EXPOSURE"
+)
+
+type (
+	// SkipReason is the code of why we don't snipe a candidate
+	SkipReason string
+
+	// SkipError is returned when we deliberately don't snipe, as opposed to failing to do so
+	SkipError struct {
+		Reason SkipReason
+		Detail string
+	}
+)
+
+func NewSkipError(r SkipReason, detail string) *SkipError {
+	return &SkipError{
+		Reason: r,
+		Detail: detail,
+	}
+}
+
+func (e *SkipError) Error() string {
+	return string(e.Reason) + ": " + e.Detail
+}
@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"fmt"
+	"math/big"
+)
+
+const (
+	// SwapFeeUniswap of the uniswap v2 pairs (and most of its forks), in basis points
+	SwapFeeUniswap SwapFee = 30
+	// SwapFeePancake of the pancake v2 pairs, in basis points
+	SwapFeePancake SwapFee = 25
+)
+
+type (
+	// SwapFee the v2 pairs of a router charge on the amount in, in basis points. It differs between forks, so it's
+	// configured with the router. Zero is the pancake one.
+	SwapFee uint64
+
+	// Slippage of each snipe derived from its simulation, instead of a static min amount out. The buy is simulated
+	// against the reserves the pool will hold, after the competing buys we saw pending land before it.
+	Slippage struct {
+		// Buffer below the simulated amount out we accept, in basis points. It covers the competing buys we didn't see.
+		Buffer uint64
+		// Max we accept getting below what our buy alone would get, in basis points. Beyond it the competing buys
+		// already took the price and we would be their exit. Zero means no max.
+		Max uint64
+		// Fee of the pairs the buys are simulated on
+		Fee SwapFee
+	}
+)
+
+// MinOut of a buy of amountIn into a v2 pool holding the given reserves, once the competing buys (their total amount
+// in) land before it. It's the simulated amount out minus the buffer. If the competing buys take more than the max
+// from what we would get alone, it's a SkipError.
+func (s Slippage) MinOut(amountIn, reserveIn, reserveOut, competing *big.Int) (*big.Int, error) {
+	if reserveIn.Sign() <= 0 || reserveOut.Sign() <= 0 {
+		return nil, fmt.Errorf("can't simulate a buy on empty reserves %s / %s", reserveIn, reserveOut)
+	}
+
+	alone := s.Fee.AmountOut(amountIn, reserveIn, reserveOut)
+	out := alone
+	if competing != nil && competing.Sign() > 0 {
+		taken := s.Fee.AmountOut(competing, reserveIn, reserveOut)
+		out = s.Fee.AmountOut(amountIn, new(big.Int).Add(reserveIn, competing), new(big.Int).Sub(reserveOut, taken))
+	}
+
+	if s.Max > 0 && alone.Sign() > 0 {
+		floor := bpsBelow(alone, s.Max)
+		if out.Cmp(floor) < 0 {
+			return nil, NewSkipError(SkipReasonSlippageAboveMax, fmt.Sprintf(
+				"competing buys of %s leave us %s of the %s tokens we would get alone", competing, out, alone,
+			))
+		}
+	}
+	return bpsBelow(out, s.Buffer), nil
+}
+
+// Valid if it's below 100%
+func (f SwapFee) Valid() bool {
+	return f < 10000
+}
+
+// AmountOut of a swap of amountIn into a v2 pool charging the fee with the given reserves, as the pair computes it
+func (f SwapFee) AmountOut(amountIn, reserveIn, reserveOut *big.Int) *big.Int {
+	if f == 0 {
+		f = SwapFeePancake
+	}
+	in := new(big.Int).Mul(amountIn, big.NewInt(int64(10000-f)))
+	num := new(big.Int).Mul(in, reserveOut)
+	den := new(big.Int).Mul(reserveIn, big.NewInt(10000))
+	den.Add(den, in)
+	return num.Quo(num, den)
+}
+
+// bpsBelow the amount by the given basis points
+func bpsBelow(amount *big.Int, bps uint64) *big.Int {
+	if bps >= 10000 {
+		return new(big.Int)
+	}
+	r := new(big.Int).Mul(amount, new(big.Int).SetUint64(10000-bps))
+	return r.Quo(r, big.NewInt(10000))
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestSlippage_MinOut(t *testing.T) {
+	tests := []struct {
+		name      string
+		slippage  Slippage
+		competing int64
+		expect    int64
+		skip      bool
+	}{
+		{"alone", Slippage{}, 0, 9876, false},
+		{"buffer", Slippage{Buffer: 200}, 0, 9678, false},
+		{"behind competing buys", Slippage{Buffer: 200}, 100, 8007, false},
+		{"competing buys within the max", Slippage{Buffer: 200, Max: 2000}, 100, 8007, false},
+		{"competing buys above the max", Slippage{Buffer: 200, Max: 1000}, 100, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			minOut, err := tt.slippage.MinOut(big.NewInt(10), big.NewInt(1000), big.NewInt(1000000), big.NewInt(tt.competing))
+			var skip *SkipError
+			if tt.skip {
+				if !errors.As(err, &skip) || skip.Reason != SkipReasonSlippageAboveMax {
+					t.Fatalf("expected a slippage skip, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if minOut.Cmp(big.NewInt(tt.expect)) != 0 {
+				t.Fatalf("expected min out %d, got %s", tt.expect, minOut)
+			}
+		})
+	}
+}
+
+func TestSlippage_MinOut_EmptyReserves(t *testing.T) {
+	if _, err := (Slippage{}).MinOut(big.NewInt(10), new(big.Int), big.NewInt(1000000), nil); err == nil {
+		t.Fatal("expected an error simulating on empty reserves")
+	}
+}
+
+func TestSwapFee_AmountOut(t *testing.T) {
+	tests := []struct {
+		name   string
+		fee    SwapFee
+		expect int64
+	}{
+		{"pancake", SwapFeePancake, 9876},
+		{"uniswap", SwapFeeUniswap, 9871},
+		{"unset is pancake", 0, 9876},
+		{"custom fork", 10, 9891},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if out := tt.fee.AmountOut(big.NewInt(10), big.NewInt(1000), big.NewInt(1000000)); out.Cmp(big.NewInt(tt.expect)) != 0 {
+				t.Fatalf("expected %d, got %s", tt.expect, out)
+			}
+		})
+	}
+}
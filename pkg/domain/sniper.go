@@ -2,8 +2,22 @@ package domain
 
 import "math/big"
 
+const (
+	// BuyModeExactIn orders spend a fixed amount of the paired token, buying at least the expected tokens
+	// (swapExactETHForTokens style)
+	BuyModeExactIn BuyMode = "exact_in"
+	// BuyModeExactOut orders buy a fixed amount of tokens, spending at most the order size
+	// (swapETHForExactTokens style)
+	BuyModeExactOut BuyMode = "exact_out"
+)
+
 type (
+	// BuyMode of the snipe orders, it must match how the trigger was configured
+	BuyMode string
+
 	Sniper struct {
+		// Name of the sniper instance, useful when running many of them
+		Name string
 		// AddressTrigger of the smart contract
 		AddressTrigger string
 		// AddressTargetPaired of the token. WBNB's address probably.
@@ -15,12 +29,45 @@ type (
 		// It's an important question to solve in the telegram of the project.
 		// You can also monitor bscscan and see the repartition of WBNB among the address that holds the targeted token
 		// and deduce the WBNB liq that will be added.
+		// It's in the smallest unit of the paired token, which isn't always 18 decimals (eg. USDC).
 		MinimumLiquidity *big.Int
 		// ChainID of the network
 		ChainID *big.Int
+		// OrderSize of the paired token the trigger spends on each snipe. Buying exact out, it's the most it spends.
+		OrderSize *big.Int
+		// BuyMode of the orders of the trigger. Empty means exact in.
+		BuyMode BuyMode
+		// Budget of the paired token this sniper is allowed to spend across snipes. Nil means no budget.
+		Budget *big.Int
+		// GasLimit of the snipe txs. Zero means estimating it on each snipe.
+		GasLimit uint64
+		// Armed snipers are the only ones that snipe. A disarmed sniper keeps its target but ignores its liquidity additions.
+		Armed bool
+		// Reveal of the order committed to the trigger contract. Only present when using the commit-reveal flow,
+		// where the buy parameters are kept off-chain until the snipe itself.
+		Reveal *SniperReveal
+	}
+
+	SniperReveal struct {
+		// AmountIn of the paired token we are spending
+		AmountIn *big.Int
+		// AmountOutMin of the target token we expect to receive
+		AmountOutMin *big.Int
+		// Salt used when committing the order, so it can't be bruteforced from the commitment
+		Salt [32]byte
 	}
 )
 
+// Valid if it's a known mode or empty (exact in)
+func (m BuyMode) Valid() bool {
+	return m == "" || m == BuyModeExactIn || m == BuyModeExactOut
+}
+
+// ExactOut if the orders buy a fixed amount of tokens
+func (m BuyMode) ExactOut() bool {
+	return m == BuyModeExactOut
+}
+
 func NewSniper(
 	at, atp, att string,
 	ml, ci *big.Int,
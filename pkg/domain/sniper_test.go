@@ -0,0 +1,26 @@
+package domain
+
+import "testing"
+
+func TestBuyMode_Valid(t *testing.T) {
+	tests := []struct {
+		mode     BuyMode
+		expect   bool
+		exactOut bool
+	}{
+		{"", true, false},
+		{BuyModeExactIn, true, false},
+		{BuyModeExactOut, true, true},
+		{"exact", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			if v := tt.mode.Valid(); v != tt.expect {
+				t.Fatalf("expected valid %t, got %t", tt.expect, v)
+			}
+			if e := tt.mode.ExactOut(); e != tt.exactOut {
+				t.Fatalf("expected exact out %t, got %t", tt.exactOut, e)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"math/big"
+	"time"
+)
+
+const (
+	// DefaultBlockTime of the chains we don't know, BSC's
+	DefaultBlockTime = 3 * time.Second
+
+	// minPoll of anything landing within a block, so chains with sub-second blocks don't flood our nodes
+	minPoll = 50 * time.Millisecond
+)
+
+// blockTimes of the chains we know, by chain id
+var blockTimes = map[uint64]time.Duration{
+	1:        12 * time.Second,       // ethereum
+	5:        12 * time.Second,       // goerli
+	11155111: 12 * time.Second,       // sepolia
+	56:       3 * time.Second,        // bsc
+	97:       3 * time.Second,        // bsc testnet
+	137:      2 * time.Second,        // polygon
+	80001:    2 * time.Second,        // mumbai
+	10:       2 * time.Second,        // optimism
+	8453:     2 * time.Second,        // base
+	43114:    2 * time.Second,        // avalanche
+	250:      time.Second,            // fantom
+	42161:    250 * time.Millisecond, // arbitrum
+	421613:   250 * time.Millisecond, // arbitrum goerli
+}
+
+type (
+	// Timing of a chain, so the windows of the strategies span the same blocks on any of them (eg. ten blocks are
+	// 30s on BSC but 2 minutes on Ethereum)
+	Timing struct {
+		BlockTime time.Duration
+	}
+)
+
+// NewTiming of the chain, with the given block time if it's known (eg. configured) or the one of the chain if we know
+// it. Else it's BSC's.
+func NewTiming(chainID *big.Int, blockTime time.Duration) Timing {
+	if blockTime > 0 {
+		return Timing{BlockTime: blockTime}
+	}
+	if chainID != nil && chainID.IsUint64() {
+		if bt, ok := blockTimes[chainID.Uint64()]; ok {
+			return Timing{BlockTime: bt}
+		}
+	}
+	return Timing{BlockTime: DefaultBlockTime}
+}
+
+// Blocks is how long the given blocks take
+func (t Timing) Blocks(n uint64) time.Duration {
+	return time.Duration(n) * t.BlockTime
+}
+
+// BlocksIn is how many whole blocks are mined in the given time
+func (t Timing) BlocksIn(d time.Duration) uint64 {
+	if d <= 0 || t.BlockTime <= 0 {
+		return 0
+	}
+	return uint64(d / t.BlockTime)
+}
+
+// Poll interval of anything landing within a block (eg. our txs), a sixth of it
+func (t Timing) Poll() time.Duration {
+	if p := t.BlockTime / 6; p > minPoll {
+		return p
+	}
+	return minPoll
+}
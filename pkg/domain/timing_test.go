@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestNewTiming(t *testing.T) {
+	tests := []struct {
+		name      string
+		chainID   *big.Int
+		blockTime time.Duration
+		expect    time.Duration
+	}{
+		{"bsc", big.NewInt(56), 0, 3 * time.Second},
+		{"ethereum", big.NewInt(1), 0, 12 * time.Second},
+		{"configured", big.NewInt(1), 5 * time.Second, 5 * time.Second},
+		{"unknown chain", big.NewInt(999999), 0, DefaultBlockTime},
+		{"no chain", nil, 0, DefaultBlockTime},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewTiming(tt.chainID, tt.blockTime); got.BlockTime != tt.expect {
+				t.Fatalf("expected block time %s, got %s", tt.expect, got.BlockTime)
+			}
+		})
+	}
+}
+
+func TestTiming(t *testing.T) {
+	bsc := Timing{BlockTime: 3 * time.Second}
+	if d := bsc.Blocks(10); d != 30*time.Second {
+		t.Fatalf("expected 10 blocks in 30s, got %s", d)
+	}
+	if n := bsc.BlocksIn(10 * time.Second); n != 3 {
+		t.Fatalf("expected 3 whole blocks in 10s, got %d", n)
+	}
+	if p := bsc.Poll(); p != 500*time.Millisecond {
+		t.Fatalf("expected to poll every 500ms, got %s", p)
+	}
+	if p := (Timing{BlockTime: 250 * time.Millisecond}).Poll(); p != minPoll {
+		t.Fatalf("expected to poll at most every %s, got %s", minPoll, p)
+	}
+}
@@ -0,0 +1,164 @@
+package domain
+
+import (
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	// TradeFilled is a snipe that received what the pair sent, within the tolerated loss
+	TradeFilled TradeOutcome = "filled"
+	// TradeShortFill is a snipe that received way less than what the pair sent (eg. a token tax)
+	TradeShortFill TradeOutcome = "short_fill"
+	// TradeZeroFill is a snipe that was mined but received none of the token
+	TradeZeroFill TradeOutcome = "zero_fill"
+)
+
+type (
+	TradeOutcome string
+
+	// Trade made by a sniper once a snipe fills
+	Trade struct {
+		// Target (the sniper name) that made the trade
+		Target string
+		// Hash of the snipe tx
+		Hash string
+		// Token bought
+		Token string
+		// Paired token spent
+		Paired string
+		// AmountIn of the paired token spent. Nil if unknown (eg. there's no order size configured)
+		AmountIn *big.Int
+		// AmountOut of the token bought
+		AmountOut *big.Int
+		// Block the snipe was included in
+		Block uint64
+		Time  time.Time
+		// SimulatedGasUsed by the snipe tx when its bundle was simulated. Zero if it wasn't (eg. sent to the mempool)
+		SimulatedGasUsed uint64
+		// SimulatedCoinbaseDiff paid to the builder by the simulated bundle. Nil if it wasn't simulated
+		SimulatedCoinbaseDiff *big.Int
+		// Outcome of the fill of the snipe
+		Outcome TradeOutcome
+		// GasUsed by the snipe tx, and GasCost it paid in wei of the native coin. Nil if unknown
+		GasUsed uint64
+		GasCost *big.Int
+		// NativeUSD is the price of the native coin in USD when the trade was made, zero if unknown
+		NativeUSD float64
+	}
+
+	// TradeFilter of the trades to query. Zero fields don't filter.
+	TradeFilter struct {
+		Target  string
+		Token   string
+		Outcome TradeOutcome
+		// From and To bound the time of the trades, both inclusive
+		From time.Time
+		To   time.Time
+		// Limit of trades to return, the oldest ones first
+		Limit int
+	}
+
+	// Position of a target in a token, aggregated from its trades
+	Position struct {
+		Target string
+		Token  string
+		// Amount of the token held
+		Amount *big.Int
+		// Cost of the paired token spent for the amount held
+		Cost *big.Int
+		// Trades that built the position
+		Trades    uint
+		UpdatedAt time.Time
+	}
+
+	// PositionValue is a position valued in the paired token it was bought with
+	PositionValue struct {
+		Position
+		Paired string
+		// Value of the amount held if sold right now. Nil if it can't be quoted (eg. the liquidity was pulled)
+		Value *big.Int
+	}
+
+	// Holding of a token across all our wallets, valued in the paired token it was bought with
+	Holding struct {
+		Token  string
+		Paired string
+		// Balances of the wallets holding the token
+		Balances map[string]*big.Int
+		// Balance across all the wallets
+		Balance *big.Int
+		// Cost of the positions of all the targets in the token. Nil if we never bought it
+		Cost *big.Int
+		// Value of the balance if sold right now. Nil if it can't be quoted
+		Value *big.Int
+	}
+
+	// Sale of a token held by one of our wallets at market price (eg. panic selling everything)
+	Sale struct {
+		Token  string
+		Wallet string
+		// Amount of the token sold
+		Amount *big.Int
+		// Tx selling it, empty if it couldn't be sent
+		Tx string
+		// Error sending the sale, if any
+		Error string
+	}
+)
+
+// PnL of the position if sold right now, nil if it can't be valued or its cost is unknown
+func (p PositionValue) PnL() *big.Int {
+	if p.Value == nil || p.Cost == nil {
+		return nil
+	}
+	return new(big.Int).Sub(p.Value, p.Cost)
+}
+
+// PnL of the holding if sold right now, nil if it can't be valued or its cost is unknown
+func (h Holding) PnL() *big.Int {
+	if h.Value == nil || h.Cost == nil {
+		return nil
+	}
+	return new(big.Int).Sub(h.Value, h.Cost)
+}
+
+// AmountInUSD spent by the trade, as orders are in the native coin. Zero if unknown
+func (t Trade) AmountInUSD() float64 {
+	return usdOf(t.AmountIn, t.NativeUSD)
+}
+
+// GasCostUSD of the trade, zero if unknown
+func (t Trade) GasCostUSD() float64 {
+	return usdOf(t.GasCost, t.NativeUSD)
+}
+
+// usdOf an amount of wei of the native coin at the given price
+func usdOf(wei *big.Int, price float64) float64 {
+	if wei == nil || price == 0 {
+		return 0
+	}
+	v, _ := new(big.Float).Mul(
+		new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e18)),
+		big.NewFloat(price),
+	).Float64()
+	return v
+}
+
+// Match reports whether the trade passes the filter (ignoring its limit)
+func (f TradeFilter) Match(t Trade) bool {
+	switch {
+	case len(f.Target) > 0 && t.Target != f.Target:
+		return false
+	case len(f.Token) > 0 && !strings.EqualFold(t.Token, f.Token):
+		return false
+	case len(f.Outcome) > 0 && t.Outcome != f.Outcome:
+		return false
+	case !f.From.IsZero() && t.Time.Before(f.From):
+		return false
+	case !f.To.IsZero() && t.Time.After(f.To):
+		return false
+	}
+	return true
+}
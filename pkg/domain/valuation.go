@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"math"
+	"math/big"
+)
+
+type (
+	// Valuation of a launch implied by the price of its liquidity addition (paired amount / token amount). Both are in
+	// the smallest unit of the paired token.
+	Valuation struct {
+		// MarketCap of the circulating supply, which is the total supply minus the burned one
+		MarketCap *big.Int
+		// FDV (fully diluted valuation) of the total supply
+		FDV *big.Int
+		// Liquidity of the paired token added
+		Liquidity *big.Int
+	}
+
+	// ValuationBands a launch must be within so we snipe it. Too tiny ones can't absorb our exit and absurdly
+	// pre-valued ones have nowhere to go but down. Nil bounds aren't checked.
+	ValuationBands struct {
+		MinMarketCap *big.Int
+		MaxMarketCap *big.Int
+		MinFDV       *big.Int
+		MaxFDV       *big.Int
+		// MinLiquidityRatio of the liquidity to the FDV, in basis points. Zero isn't checked.
+		MinLiquidityRatio uint64
+	}
+)
+
+// NewValuation of a token with the given total and burned supply, launched adding the given amounts of it and of the
+// paired token. A launch adding no tokens has no price, so it's valued at zero.
+func NewValuation(supply, burned, tokenAmount, pairedAmount *big.Int) Valuation {
+	if tokenAmount.Sign() <= 0 {
+		return Valuation{MarketCap: new(big.Int), FDV: new(big.Int), Liquidity: new(big.Int).Set(pairedAmount)}
+	}
+	circulating := new(big.Int).Sub(supply, burned)
+	if circulating.Sign() < 0 {
+		circulating.SetInt64(0)
+	}
+	return Valuation{
+		MarketCap: impliedValue(circulating, tokenAmount, pairedAmount),
+		FDV:       impliedValue(supply, tokenAmount, pairedAmount),
+		Liquidity: new(big.Int).Set(pairedAmount),
+	}
+}
+
+// LiquidityRatio of the liquidity to the FDV, in basis points. At the launch price it's the share of the supply added
+// to the pool: a low one means most of it sits in some wallet, ready to be dumped on whoever buys. A launch without
+// valuation has no ratio.
+func (v Valuation) LiquidityRatio() uint64 {
+	if v.FDV.Sign() <= 0 {
+		return 0
+	}
+	r := new(big.Int).Mul(v.Liquidity, big.NewInt(10000))
+	r.Quo(r, v.FDV)
+	if !r.IsUint64() {
+		return math.MaxUint64
+	}
+	return r.Uint64()
+}
+
+// Enabled if any of the bounds is set
+func (b ValuationBands) Enabled() bool {
+	return b.MinMarketCap != nil || b.MaxMarketCap != nil || b.MinFDV != nil || b.MaxFDV != nil || b.MinLiquidityRatio > 0
+}
+
+// impliedValue of the amount of the token at the price of the addition
+func impliedValue(amount, tokenAmount, pairedAmount *big.Int) *big.Int {
+	v := new(big.Int).Mul(amount, pairedAmount)
+	return v.Quo(v, tokenAmount)
+}
@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestNewValuation(t *testing.T) {
+	tests := []struct {
+		name            string
+		supply          int64
+		burned          int64
+		tokenAmount     int64
+		pairedAmount    int64
+		expectMarketCap int64
+		expectFDV       int64
+	}{
+		{"whole supply added", 1000, 0, 1000, 10, 10, 10},
+		{"part of the supply added", 1000, 0, 100, 10, 100, 100},
+		{"burned supply", 1000, 400, 100, 10, 60, 100},
+		{"all burned", 1000, 1500, 100, 10, 0, 100},
+		{"rounds down", 1000, 0, 300, 1, 3, 3},
+		{"no tokens added", 1000, 0, 0, 10, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewValuation(big.NewInt(tt.supply), big.NewInt(tt.burned), big.NewInt(tt.tokenAmount), big.NewInt(tt.pairedAmount))
+			if v.MarketCap.Cmp(big.NewInt(tt.expectMarketCap)) != 0 {
+				t.Fatalf("expected market cap %d, got %s", tt.expectMarketCap, v.MarketCap)
+			}
+			if v.FDV.Cmp(big.NewInt(tt.expectFDV)) != 0 {
+				t.Fatalf("expected fdv %d, got %s", tt.expectFDV, v.FDV)
+			}
+		})
+	}
+}
+
+func TestValuation_LiquidityRatio(t *testing.T) {
+	tests := []struct {
+		name         string
+		supply       int64
+		tokenAmount  int64
+		pairedAmount int64
+		expect       uint64
+	}{
+		{"whole supply added", 1000, 1000, 10, 10000},
+		{"a third added", 900, 300, 10, 3333},
+		{"a tiny share added", 1000000, 1, 1000, 0},
+		{"no tokens added", 1000, 0, 10, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewValuation(big.NewInt(tt.supply), new(big.Int), big.NewInt(tt.tokenAmount), big.NewInt(tt.pairedAmount))
+			if r := v.LiquidityRatio(); r != tt.expect {
+				t.Fatalf("expected ratio %d, got %d", tt.expect, r)
+			}
+		})
+	}
+}
+
+func TestValuationBands_Enabled(t *testing.T) {
+	if (ValuationBands{}).Enabled() {
+		t.Fatal("expected bands without bounds to be disabled")
+	}
+	if !(ValuationBands{MaxFDV: big.NewInt(1)}).Enabled() {
+		t.Fatal("expected bands with a bound to be enabled")
+	}
+	if !(ValuationBands{MinLiquidityRatio: 1}).Enabled() {
+		t.Fatal("expected bands with a min liquidity ratio to be enabled")
+	}
+}
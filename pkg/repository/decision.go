@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type (
+	// FileDecision repository appends decisions as JSON lines to a file, so they can be inspected with the usual tools
+	// (eg. jq / grep) after a launch.
+	FileDecision struct {
+		f   *os.File
+		enc *json.Encoder
+		mut *sync.Mutex
+	}
+
+	fileDecisionLine struct {
+		Kind   domain.DecisionKind `json:"kind"`
+		Target string              `json:"target"`
+		Tx     string              `json:"tx"`
+		Reason domain.SkipReason   `json:"reason,omitempty"`
+		Detail string              `json:"detail,omitempty"`
+		Time   time.Time           `json:"time"`
+	}
+)
+
+func NewFileDecision(path string) (*FileDecision, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileDecision{
+		f:   f,
+		enc: json.NewEncoder(f),
+		mut: new(sync.Mutex),
+	}, nil
+}
+
+func (r *FileDecision) Append(_ context.Context, d domain.Decision) error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	return r.enc.Encode(fileDecisionLine{
+		Kind:   d.Kind,
+		Target: d.Target,
+		Tx:     d.Tx,
+		Reason: d.Reason,
+		Detail: d.Detail,
+		Time:   d.Time,
+	})
+}
@@ -0,0 +1,4 @@
+// Package repository stores the targets, the trades, the decisions and the reserve series: in memory (MemoryTarget,
+// MemoryTrade, MemoryReserves), in a file (FileDecision) or in postgres (PostgresTarget, PostgresTrade,
+// PostgresDecision, PostgresReserves, whose schema MigratePostgres applies).
+package repository
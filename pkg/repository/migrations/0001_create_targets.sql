@@ -0,0 +1,15 @@
+CREATE TABLE targets (
+    name                  TEXT PRIMARY KEY,
+    address_trigger       TEXT NOT NULL,
+    address_target_paired TEXT NOT NULL,
+    address_target_token  TEXT NOT NULL,
+    minimum_liquidity     NUMERIC(78, 0) NOT NULL,
+    chain_id              NUMERIC(78, 0) NOT NULL,
+    order_size            NUMERIC(78, 0),
+    budget                NUMERIC(78, 0),
+    armed                 BOOLEAN NOT NULL DEFAULT FALSE,
+    reveal_amount_in      NUMERIC(78, 0),
+    reveal_amount_out_min NUMERIC(78, 0),
+    reveal_salt           BYTEA,
+    updated_at            TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,22 @@
+CREATE TABLE trades (
+    hash       TEXT PRIMARY KEY,
+    target     TEXT NOT NULL,
+    token      TEXT NOT NULL,
+    paired     TEXT NOT NULL,
+    amount_in  NUMERIC(78, 0),
+    amount_out NUMERIC(78, 0) NOT NULL,
+    block      BIGINT NOT NULL,
+    time       TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX trades_target_time_idx ON trades (target, time);
+
+CREATE TABLE positions (
+    target     TEXT NOT NULL,
+    token      TEXT NOT NULL,
+    amount     NUMERIC(78, 0) NOT NULL,
+    cost       NUMERIC(78, 0) NOT NULL,
+    trades     INTEGER NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (target, token)
+);
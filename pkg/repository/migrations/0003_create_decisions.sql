@@ -0,0 +1,13 @@
+CREATE TABLE decisions (
+    id     BIGSERIAL PRIMARY KEY,
+    kind   TEXT NOT NULL,
+    target TEXT NOT NULL,
+    tx     TEXT NOT NULL,
+    reason TEXT,
+    detail TEXT,
+    time   TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX decisions_tx_idx ON decisions (tx);
+CREATE INDEX decisions_target_time_idx ON decisions (target, time);
+CREATE INDEX decisions_reason_time_idx ON decisions (reason, time) WHERE reason IS NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE targets ADD COLUMN gas_limit BIGINT;
@@ -0,0 +1,2 @@
+ALTER TABLE trades ADD COLUMN simulated_gas_used BIGINT;
+ALTER TABLE trades ADD COLUMN simulated_coinbase_diff NUMERIC(78, 0);
@@ -0,0 +1 @@
+ALTER TABLE targets ADD COLUMN buy_mode TEXT NOT NULL DEFAULT '';
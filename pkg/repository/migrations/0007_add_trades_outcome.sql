@@ -0,0 +1,4 @@
+-- trades saved before outcomes were journaled are taken as filled
+ALTER TABLE trades ADD COLUMN outcome TEXT NOT NULL DEFAULT 'filled';
+
+CREATE INDEX trades_token_time_idx ON trades (lower(token), time);
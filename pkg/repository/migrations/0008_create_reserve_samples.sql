@@ -0,0 +1,12 @@
+CREATE TABLE reserve_samples (
+    id             BIGSERIAL PRIMARY KEY,
+    token          TEXT NOT NULL,
+    paired         TEXT NOT NULL,
+    pair           TEXT NOT NULL,
+    reserve_token  NUMERIC(78, 0) NOT NULL,
+    reserve_paired NUMERIC(78, 0) NOT NULL,
+    held           NUMERIC(78, 0) NOT NULL,
+    time           TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX reserve_samples_token_time_idx ON reserve_samples (lower(token), time);
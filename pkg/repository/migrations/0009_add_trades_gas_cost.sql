@@ -0,0 +1,4 @@
+-- gas costs are in wei of the native coin, and native_usd its price when the trade was saved (null if unknown)
+ALTER TABLE trades ADD COLUMN gas_used BIGINT;
+ALTER TABLE trades ADD COLUMN gas_cost NUMERIC(78, 0);
+ALTER TABLE trades ADD COLUMN native_usd DOUBLE PRECISION;
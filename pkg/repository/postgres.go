@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"math/big"
+	"path"
+	"sort"
+	"strings"
+)
+
+// postgresMigrationLock is the key of the advisory lock taken while migrating, so operators starting at the same
+// time don't race each other
+const postgresMigrationLock = 725416
+
+//go:embed migrations/*.sql
+var postgresMigrations embed.FS
+
+// MigratePostgres applies the pending migrations of the repo (see ./migrations) in order.
+// Migrations are never modified once released, new ones are added instead.
+func MigratePostgres(ctx context.Context, db *sql.DB) ([]string, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", postgresMigrationLock); err != nil {
+		return nil, fmt.Errorf("error locking migrations: %s", err)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", postgresMigrationLock) // nolint
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    TEXT PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`); err != nil {
+		return nil, fmt.Errorf("error creating migrations table: %s", err)
+	}
+
+	files, err := postgresMigrations.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(files))
+	for i, f := range files {
+		names[i] = f.Name()
+	}
+	sort.Strings(names)
+
+	applied := make([]string, 0)
+	for _, n := range names {
+		version := strings.TrimSuffix(n, ".sql")
+		var exists bool
+		if err := conn.QueryRowContext(
+			ctx, "SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)", version,
+		).Scan(&exists); err != nil {
+			return applied, fmt.Errorf("error checking migration %s: %s", version, err)
+		}
+		if exists {
+			continue
+		}
+
+		q, err := postgresMigrations.ReadFile(path.Join("migrations", n))
+		if err != nil {
+			return applied, err
+		}
+		if err := migratePostgres(ctx, conn, version, string(q)); err != nil {
+			return applied, fmt.Errorf("error applying migration %s: %s", version, err)
+		}
+		applied = append(applied, version)
+	}
+	return applied, nil
+}
+
+func migratePostgres(ctx context.Context, conn *sql.Conn, version, q string) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // nolint
+
+	if _, err := tx.ExecContext(ctx, q); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version) VALUES ($1)", version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// numeric value of the big int for a NUMERIC column
+func numeric(v *big.Int) interface{} {
+	if v == nil {
+		return nil
+	}
+	return v.String()
+}
+
+// parseNumeric of a NUMERIC column. Null is nil.
+func parseNumeric(v sql.NullString) (*big.Int, error) {
+	if !v.Valid {
+		return nil, nil
+	}
+	n, ok := new(big.Int).SetString(v.String, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid numeric '%s'", v.String)
+	}
+	return n, nil
+}
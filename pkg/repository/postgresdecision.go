@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type (
+	// PostgresDecision repository. Decisions are only inserted, never updated nor deleted.
+	PostgresDecision struct {
+		db *sql.DB
+	}
+)
+
+func NewPostgresDecision(db *sql.DB) *PostgresDecision {
+	return &PostgresDecision{
+		db: db,
+	}
+}
+
+func (r *PostgresDecision) Append(ctx context.Context, d domain.Decision) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO decisions (kind, target, tx, reason, detail, time)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6)`,
+		string(d.Kind), d.Target, d.Tx, string(d.Reason), d.Detail, d.Time,
+	)
+	return err
+}
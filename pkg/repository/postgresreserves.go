@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"math/big"
+	"time"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type (
+	// PostgresReserves repository of the reserve series of the sniped tokens. Samples are only inserted.
+	PostgresReserves struct {
+		db *sql.DB
+	}
+)
+
+func NewPostgresReserves(db *sql.DB) *PostgresReserves {
+	return &PostgresReserves{
+		db: db,
+	}
+}
+
+// Save the sample at the end of the series of its token
+func (r *PostgresReserves) Save(ctx context.Context, s domain.ReserveSample) error {
+	held := s.Held
+	if held == nil {
+		held = new(big.Int)
+	}
+	_, err := r.db.ExecContext(ctx, `INSERT INTO reserve_samples (token, paired, pair, reserve_token, reserve_paired,
+			held, time)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		s.Token, s.Paired, s.Pair, numeric(s.ReserveToken), numeric(s.ReservePaired), numeric(held), s.Time,
+	)
+	return err
+}
+
+// Series of the token sampled between from and to (both inclusive, zero ones don't bound it), oldest first
+func (r *PostgresReserves) Series(ctx context.Context, token string, from, to time.Time) ([]domain.ReserveSample, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT token, paired, pair, reserve_token, reserve_paired, held, time
+		FROM reserve_samples
+		WHERE lower(token) = lower($1) AND ($2::TIMESTAMPTZ IS NULL OR time >= $2) AND ($3::TIMESTAMPTZ IS NULL OR time <= $3)
+		ORDER BY time`, token, nullTime(from), nullTime(to))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]domain.ReserveSample, 0)
+	for rows.Next() {
+		var (
+			s                   domain.ReserveSample
+			rToken, rPaired, hd sql.NullString
+		)
+		if err := rows.Scan(&s.Token, &s.Paired, &s.Pair, &rToken, &rPaired, &hd, &s.Time); err != nil {
+			return nil, err
+		}
+		if s.ReserveToken, err = parseNumeric(rToken); err != nil {
+			return nil, err
+		}
+		if s.ReservePaired, err = parseNumeric(rPaired); err != nil {
+			return nil, err
+		}
+		if s.Held, err = parseNumeric(hd); err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+	return res, rows.Err()
+}
+
+// nullTime as a nullable column, as zero times don't bound a query
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/big"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+const postgresTargetColumns = `name, address_trigger, address_target_paired, address_target_token, minimum_liquidity,
+	chain_id, order_size, budget, armed, reveal_amount_in, reveal_amount_out_min, reveal_salt, gas_limit, buy_mode`
+
+type (
+	// PostgresTarget repository, so many operators / dashboards share the same targets
+	PostgresTarget struct {
+		db *sql.DB
+	}
+
+	postgresRow interface {
+		Scan(...interface{}) error
+	}
+)
+
+func NewPostgresTarget(db *sql.DB) *PostgresTarget {
+	return &PostgresTarget{
+		db: db,
+	}
+}
+
+func (r *PostgresTarget) Get(ctx context.Context, name string) (domain.Sniper, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT "+postgresTargetColumns+" FROM targets WHERE name = $1", name)
+	sn, err := scanTarget(row)
+	if err == sql.ErrNoRows {
+		return domain.Sniper{}, ErrTargetNotFound
+	}
+	return sn, err
+}
+
+// List all the targets, sorted by name
+func (r *PostgresTarget) List(ctx context.Context) ([]domain.Sniper, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+postgresTargetColumns+" FROM targets ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]domain.Sniper, 0)
+	for rows.Next() {
+		sn, err := scanTarget(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, sn)
+	}
+	return res, rows.Err()
+}
+
+// Save the target, replacing the one with the same name if any
+func (r *PostgresTarget) Save(ctx context.Context, sn domain.Sniper) error {
+	var revealIn, revealOutMin, revealSalt, gasLimit interface{}
+	if sn.GasLimit > 0 {
+		gasLimit = int64(sn.GasLimit)
+	}
+	if sn.Reveal != nil {
+		revealIn = numeric(sn.Reveal.AmountIn)
+		revealOutMin = numeric(sn.Reveal.AmountOutMin)
+		revealSalt = sn.Reveal.Salt[:]
+	}
+
+	_, err := r.db.ExecContext(ctx, `INSERT INTO targets (`+postgresTargetColumns+`, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, now())
+		ON CONFLICT (name) DO UPDATE SET
+			address_trigger = EXCLUDED.address_trigger,
+			address_target_paired = EXCLUDED.address_target_paired,
+			address_target_token = EXCLUDED.address_target_token,
+			minimum_liquidity = EXCLUDED.minimum_liquidity,
+			chain_id = EXCLUDED.chain_id,
+			order_size = EXCLUDED.order_size,
+			budget = EXCLUDED.budget,
+			armed = EXCLUDED.armed,
+			reveal_amount_in = EXCLUDED.reveal_amount_in,
+			reveal_amount_out_min = EXCLUDED.reveal_amount_out_min,
+			reveal_salt = EXCLUDED.reveal_salt,
+			gas_limit = EXCLUDED.gas_limit,
+			buy_mode = EXCLUDED.buy_mode,
+			updated_at = EXCLUDED.updated_at`,
+		sn.Name, sn.AddressTrigger, sn.AddressTargetPaired, sn.AddressTargetToken, numeric(sn.MinimumLiquidity),
+		numeric(sn.ChainID), numeric(sn.OrderSize), numeric(sn.Budget), sn.Armed, revealIn, revealOutMin, revealSalt,
+		gasLimit, string(sn.BuyMode),
+	)
+	return err
+}
+
+func (r *PostgresTarget) Delete(ctx context.Context, name string) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM targets WHERE name = $1", name)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrTargetNotFound
+	}
+	return nil
+}
+
+func scanTarget(row postgresRow) (domain.Sniper, error) {
+	var (
+		sn                                 domain.Sniper
+		minLiq, chainID, orderSize, budget sql.NullString
+		revealIn, revealOutMin             sql.NullString
+		revealSalt                         []byte
+		gasLimit                           sql.NullInt64
+		buyMode                            string
+	)
+	err := row.Scan(
+		&sn.Name, &sn.AddressTrigger, &sn.AddressTargetPaired, &sn.AddressTargetToken, &minLiq,
+		&chainID, &orderSize, &budget, &sn.Armed, &revealIn, &revealOutMin, &revealSalt, &gasLimit, &buyMode,
+	)
+	if err != nil {
+		return domain.Sniper{}, err
+	}
+	if gasLimit.Valid {
+		sn.GasLimit = uint64(gasLimit.Int64)
+	}
+	sn.BuyMode = domain.BuyMode(buyMode)
+
+	for _, v := range []struct {
+		dst **big.Int
+		src sql.NullString
+	}{
+		{&sn.MinimumLiquidity, minLiq},
+		{&sn.ChainID, chainID},
+		{&sn.OrderSize, orderSize},
+		{&sn.Budget, budget},
+	} {
+		if *v.dst, err = parseNumeric(v.src); err != nil {
+			return domain.Sniper{}, fmt.Errorf("error parsing target %s: %s", sn.Name, err)
+		}
+	}
+
+	if revealIn.Valid {
+		in, err := parseNumeric(revealIn)
+		if err != nil {
+			return domain.Sniper{}, err
+		}
+		outMin, err := parseNumeric(revealOutMin)
+		if err != nil {
+			return domain.Sniper{}, err
+		}
+		sn.Reveal = &domain.SniperReveal{
+			AmountIn:     in,
+			AmountOutMin: outMin,
+		}
+		copy(sn.Reveal.Salt[:], revealSalt)
+	}
+	return sn, nil
+}
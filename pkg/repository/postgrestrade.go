@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type (
+	// PostgresTrade repository of trades and the positions they build, so dashboards can follow them
+	PostgresTrade struct {
+		db *sql.DB
+	}
+)
+
+func NewPostgresTrade(db *sql.DB) *PostgresTrade {
+	return &PostgresTrade{
+		db: db,
+	}
+}
+
+// Save the trade, adding it to the position of its target. Saving the same trade twice is a noop.
+func (r *PostgresTrade) Save(ctx context.Context, t domain.Trade) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // nolint
+
+	res, err := tx.ExecContext(ctx, `INSERT INTO trades (hash, target, token, paired, amount_in, amount_out, block, time,
+			simulated_gas_used, simulated_coinbase_diff, outcome, gas_used, gas_cost, native_usd)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (hash) DO NOTHING`,
+		t.Hash, t.Target, t.Token, t.Paired, numeric(t.AmountIn), numeric(t.AmountOut), int64(t.Block), t.Time,
+		simulatedGasUsed(t.SimulatedGasUsed), numeric(t.SimulatedCoinbaseDiff), tradeOutcome(t.Outcome),
+		simulatedGasUsed(t.GasUsed), numeric(t.GasCost), sql.NullFloat64{Float64: t.NativeUSD, Valid: t.NativeUSD > 0},
+	)
+	if err != nil {
+		return fmt.Errorf("error saving trade %s: %s", t.Hash, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return nil // already saved
+	}
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO positions (target, token, amount, cost, trades, updated_at)
+		VALUES ($1, $2, $3, COALESCE($4::NUMERIC, 0), 1, $5)
+		ON CONFLICT (target, token) DO UPDATE SET
+			amount = positions.amount + EXCLUDED.amount,
+			cost = positions.cost + EXCLUDED.cost,
+			trades = positions.trades + 1,
+			updated_at = EXCLUDED.updated_at`,
+		t.Target, t.Token, numeric(t.AmountOut), numeric(t.AmountIn), t.Time,
+	); err != nil {
+		return fmt.Errorf("error updating position of trade %s: %s", t.Hash, err)
+	}
+	return tx.Commit()
+}
+
+// Trades of the target, oldest first
+func (r *PostgresTrade) Trades(ctx context.Context, target string) ([]domain.Trade, error) {
+	return r.Find(ctx, domain.TradeFilter{Target: target})
+}
+
+// Find the trades passing the filter, oldest first
+func (r *PostgresTrade) Find(ctx context.Context, f domain.TradeFilter) ([]domain.Trade, error) {
+	var (
+		where []string
+		args  []interface{}
+	)
+	cond := func(c string, v interface{}) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(c, len(args)))
+	}
+	if len(f.Target) > 0 {
+		cond("target = $%d", f.Target)
+	}
+	if len(f.Token) > 0 {
+		cond("lower(token) = lower($%d)", f.Token)
+	}
+	if len(f.Outcome) > 0 {
+		cond("outcome = $%d", string(f.Outcome))
+	}
+	if !f.From.IsZero() {
+		cond("time >= $%d", f.From)
+	}
+	if !f.To.IsZero() {
+		cond("time <= $%d", f.To)
+	}
+	q := `SELECT hash, target, token, paired, amount_in, amount_out, block, time,
+			simulated_gas_used, simulated_coinbase_diff, outcome, gas_used, gas_cost, native_usd
+		FROM trades`
+	if len(where) > 0 {
+		q += " WHERE " + strings.Join(where, " AND ")
+	}
+	q += " ORDER BY time"
+	if f.Limit > 0 {
+		q += fmt.Sprintf(" LIMIT %d", f.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]domain.Trade, 0)
+	for rows.Next() {
+		var (
+			t        domain.Trade
+			in, out  sql.NullString
+			block    int64
+			simGas   sql.NullInt64
+			coinbase sql.NullString
+			gasUsed  sql.NullInt64
+			gasCost  sql.NullString
+			usd      sql.NullFloat64
+		)
+		if err := rows.Scan(&t.Hash, &t.Target, &t.Token, &t.Paired, &in, &out, &block, &t.Time, &simGas, &coinbase,
+			&t.Outcome, &gasUsed, &gasCost, &usd); err != nil {
+			return nil, err
+		}
+		if t.AmountIn, err = parseNumeric(in); err != nil {
+			return nil, err
+		}
+		if t.AmountOut, err = parseNumeric(out); err != nil {
+			return nil, err
+		}
+		if t.SimulatedCoinbaseDiff, err = parseNumeric(coinbase); err != nil {
+			return nil, err
+		}
+		if t.GasCost, err = parseNumeric(gasCost); err != nil {
+			return nil, err
+		}
+		t.Block = uint64(block)
+		t.SimulatedGasUsed = uint64(simGas.Int64)
+		t.GasUsed = uint64(gasUsed.Int64)
+		t.NativeUSD = usd.Float64
+		res = append(res, t)
+	}
+	return res, rows.Err()
+}
+
+// Positions of all the targets, sorted by target and token
+func (r *PostgresTrade) Positions(ctx context.Context) ([]domain.Position, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT target, token, amount, cost, trades, updated_at
+		FROM positions ORDER BY target, token`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]domain.Position, 0)
+	for rows.Next() {
+		var (
+			p            domain.Position
+			amount, cost sql.NullString
+		)
+		if err := rows.Scan(&p.Target, &p.Token, &amount, &cost, &p.Trades, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if p.Amount, err = parseNumeric(amount); err != nil {
+			return nil, err
+		}
+		if p.Cost, err = parseNumeric(cost); err != nil {
+			return nil, err
+		}
+		res = append(res, p)
+	}
+	return res, rows.Err()
+}
+
+// simulatedGasUsed as a nullable column, as zero means the trade wasn't simulated (or the gas isn't known)
+func simulatedGasUsed(g uint64) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(g), Valid: g > 0}
+}
+
+// tradeOutcome to save, trades that don't know it (eg. saved by tools) filled
+func tradeOutcome(o domain.TradeOutcome) string {
+	if len(o) == 0 {
+		return string(domain.TradeFilled)
+	}
+	return string(o)
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type (
+	// MemoryReserves repository of the reserve series of the sniped tokens. They live as long as the process does.
+	MemoryReserves struct {
+		series map[string][]domain.ReserveSample
+		mut    *sync.RWMutex
+	}
+)
+
+func NewMemoryReserves() *MemoryReserves {
+	return &MemoryReserves{
+		series: make(map[string][]domain.ReserveSample),
+		mut:    new(sync.RWMutex),
+	}
+}
+
+// Save the sample at the end of the series of its token
+func (r *MemoryReserves) Save(_ context.Context, s domain.ReserveSample) error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	k := strings.ToLower(s.Token)
+	r.series[k] = append(r.series[k], s)
+	return nil
+}
+
+// Series of the token sampled between from and to (both inclusive, zero ones don't bound it), oldest first
+func (r *MemoryReserves) Series(_ context.Context, token string, from, to time.Time) ([]domain.ReserveSample, error) {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+	res := make([]domain.ReserveSample, 0)
+	for _, s := range r.series[strings.ToLower(token)] {
+		if (from.IsZero() || !s.Time.Before(from)) && (to.IsZero() || !s.Time.After(to)) {
+			res = append(res, s)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+// ErrTargetNotFound is returned when there's no target with the given name
+var ErrTargetNotFound = errors.New("target not found")
+
+type (
+	// MemoryTarget repository. Targets live as long as the process does.
+	MemoryTarget struct {
+		targets map[string]domain.Sniper
+		mut     *sync.RWMutex
+	}
+)
+
+func NewMemoryTarget() *MemoryTarget {
+	return &MemoryTarget{
+		targets: make(map[string]domain.Sniper),
+		mut:     new(sync.RWMutex),
+	}
+}
+
+func (r *MemoryTarget) Get(_ context.Context, name string) (domain.Sniper, error) {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+	sn, ok := r.targets[name]
+	if !ok {
+		return domain.Sniper{}, ErrTargetNotFound
+	}
+	return sn, nil
+}
+
+// List all the targets, sorted by name
+func (r *MemoryTarget) List(_ context.Context) ([]domain.Sniper, error) {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+	res := make([]domain.Sniper, 0, len(r.targets))
+	for _, sn := range r.targets {
+		res = append(res, sn)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res, nil
+}
+
+// Save the target, replacing the one with the same name if any
+func (r *MemoryTarget) Save(_ context.Context, sn domain.Sniper) error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.targets[sn.Name] = sn
+	return nil
+}
+
+func (r *MemoryTarget) Delete(_ context.Context, name string) error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	if _, ok := r.targets[name]; !ok {
+		return ErrTargetNotFound
+	}
+	delete(r.targets, name)
+	return nil
+}
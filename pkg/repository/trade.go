@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type (
+	// MemoryTrade repository. Trades and positions live as long as the process does.
+	MemoryTrade struct {
+		trades    []domain.Trade
+		positions map[positionKey]domain.Position
+		mut       *sync.RWMutex
+	}
+
+	positionKey struct {
+		target string
+		token  string
+	}
+)
+
+func NewMemoryTrade() *MemoryTrade {
+	return &MemoryTrade{
+		positions: make(map[positionKey]domain.Position),
+		mut:       new(sync.RWMutex),
+	}
+}
+
+// Save the trade, adding it to the position of its target
+func (r *MemoryTrade) Save(_ context.Context, t domain.Trade) error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.trades = append(r.trades, t)
+
+	k := positionKey{target: t.Target, token: t.Token}
+	p, ok := r.positions[k]
+	if !ok {
+		p = domain.Position{Target: t.Target, Token: t.Token, Amount: new(big.Int), Cost: new(big.Int)}
+	}
+	p.Amount = new(big.Int).Add(p.Amount, t.AmountOut)
+	if t.AmountIn != nil {
+		p.Cost = new(big.Int).Add(p.Cost, t.AmountIn)
+	}
+	p.Trades++
+	p.UpdatedAt = t.Time
+	r.positions[k] = p
+	return nil
+}
+
+// Trades of the target, oldest first
+func (r *MemoryTrade) Trades(ctx context.Context, target string) ([]domain.Trade, error) {
+	return r.Find(ctx, domain.TradeFilter{Target: target})
+}
+
+// Find the trades passing the filter, oldest first
+func (r *MemoryTrade) Find(_ context.Context, f domain.TradeFilter) ([]domain.Trade, error) {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+	res := make([]domain.Trade, 0)
+	for _, t := range r.trades {
+		if f.Limit > 0 && len(res) == f.Limit {
+			break
+		}
+		if f.Match(t) {
+			res = append(res, t)
+		}
+	}
+	return res, nil
+}
+
+// Positions of all the targets, sorted by target and token
+func (r *MemoryTrade) Positions(_ context.Context) ([]domain.Position, error) {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+	res := make([]domain.Position, 0, len(r.positions))
+	for _, p := range r.positions {
+		res = append(res, p)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Target != res[j].Target {
+			return res[i].Target < res[j].Target
+		}
+		return res[i].Token < res[j].Token
+	})
+	return res, nil
+}
@@ -18,29 +18,34 @@ const (
 
 type (
 	AddressMonitor struct {
+		senders       addressMonitorSenderResolver
 		sniperChainID *big.Int
 		watchedAddrs  map[common.Address]domain.NamedAddress
 	}
+
+	addressMonitorSenderResolver interface {
+		Sender(*big.Int, *types.Transaction) (common.Address, error)
+	}
 )
 
-func NewAddressMonitor(sn domain.Sniper, addrs ...domain.NamedAddress) *AddressMonitor {
+func NewAddressMonitor(sn domain.Sniper, s addressMonitorSenderResolver, addrs ...domain.NamedAddress) *AddressMonitor {
 	m := make(map[common.Address]domain.NamedAddress)
 	for _, v := range addrs {
 		m[common.HexToAddress(v.Addr)] = v
 	}
 	return &AddressMonitor{
+		senders:       s,
 		sniperChainID: sn.ChainID,
 		watchedAddrs:  m,
 	}
 }
 
 func (m *AddressMonitor) Monitor(ctx context.Context, tx *types.Transaction) {
-	msg, err := tx.AsMessage(types.NewEIP155Signer(m.sniperChainID), nil)
+	owner, err := m.senders.Sender(m.sniperChainID, tx)
 	if err != nil {
-		log.Error(fmt.Sprintf("error getting tx as message %s: %s", tx.Hash().String(), err.Error()))
+		log.Error(fmt.Sprintf("error getting tx sender %s: %s", tx.Hash().String(), err.Error()))
 		return
 	}
-	owner := msg.From()
 
 	if na, ok := m.watchedAddrs[owner]; ok {
 		log.Info(fmt.Sprintf(
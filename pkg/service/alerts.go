@@ -0,0 +1,123 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+const (
+	alertTimeout = 5 * time.Second
+)
+
+type (
+	// Alerts are always logged, and posted to a webhook if there's one (eg. a slack / discord / mattermost incoming
+	// webhook), so someone notices them while the bot runs unattended.
+	Alerts struct {
+		url    string // optional
+		client *http.Client
+
+		// posting tracks the posts in flight, so they can be flushed before exiting
+		posting sync.WaitGroup
+	}
+
+	alertPayload struct {
+		// Text is the field chat webhooks display
+		Text   string           `json:"text"`
+		Kind   domain.AlertKind `json:"kind"`
+		Target string           `json:"target"`
+		Tx     string           `json:"tx,omitempty"`
+		Time   time.Time        `json:"time"`
+	}
+)
+
+func NewAlerts(url string) *Alerts {
+	return &Alerts{
+		url: url,
+		client: &http.Client{
+			Timeout: alertTimeout,
+		},
+	}
+}
+
+// Alert without blocking the caller. Posting it to the webhook is best effort.
+func (a *Alerts) Alert(al domain.Alert) {
+	al, text := a.format(al)
+	log.Error(fmt.Sprintf("ALERT %s", text))
+	a.send(al, text)
+}
+
+// Notify of something that needs no action (eg. a heartbeat) the same way as an alert, without logging it as an error
+func (a *Alerts) Notify(al domain.Alert) {
+	al, text := a.format(al)
+	log.Info(fmt.Sprintf("NOTIFY %s", text))
+	a.send(al, text)
+}
+
+// Flush waits for the posts in flight, up to the timeout. Call it before exiting, else the last alerts are lost.
+func (a *Alerts) Flush(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		a.posting.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		log.Warn("timed out flushing alerts")
+	}
+}
+
+func (a *Alerts) format(al domain.Alert) (domain.Alert, string) {
+	if al.Time.IsZero() {
+		al.Time = time.Now()
+	}
+	text := fmt.Sprintf("[%s] %s: %s", al.Kind, al.Target, al.Message)
+	if len(al.Tx) > 0 {
+		text = fmt.Sprintf("%s (tx %s)", text, al.Tx)
+	}
+	return al, text
+}
+
+func (a *Alerts) send(al domain.Alert, text string) {
+	if len(a.url) == 0 {
+		return
+	}
+	a.posting.Add(1)
+	go func() {
+		defer a.posting.Done()
+		defer recovery()
+		if err := a.post(alertPayload{
+			Text:   text,
+			Kind:   al.Kind,
+			Target: al.Target,
+			Tx:     al.Tx,
+			Time:   al.Time,
+		}); err != nil {
+			log.Error(fmt.Sprintf("error posting alert to webhook: %s", err))
+		}
+	}()
+}
+
+func (a *Alerts) post(p alertPayload) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	resp, err := a.client.Post(a.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
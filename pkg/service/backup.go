@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// backupBundleBlocks is how many blocks in a row a bundle sent through a backup route targets, as we can only
+	// guess the head without our nodes
+	backupBundleBlocks = 3
+)
+
+type (
+	// BackupRoute sends our txs without our nodes: through the alternate nodes we rebroadcast to and the bundle
+	// relays. It's for when our nodes are unreachable, so it never reads the chain.
+	BackupRoute struct {
+		rebroadcaster backupRebroadcaster
+		relays        backupRelays
+	}
+
+	backupRebroadcaster interface {
+		Enabled() bool
+		Rebroadcast(context.Context, *types.Transaction) error
+	}
+
+	backupRelays interface {
+		Enabled() bool
+		SendBundle(context.Context, []*types.Transaction, uint64) error
+	}
+)
+
+func NewBackupRoute(rb backupRebroadcaster, r backupRelays) *BackupRoute {
+	return &BackupRoute{
+		rebroadcaster: rb,
+		relays:        r,
+	}
+}
+
+// Enabled if there's any alternate node or relay to send through
+func (b *BackupRoute) Enabled() bool {
+	return b.rebroadcaster.Enabled() || b.relays.Enabled()
+}
+
+// Send the txs, which must be sent in order (eg. an approval and the sell using it), through every route we have. The
+// relays get them as a bundle for each of the blocks following the guessed head. It only fails if no route took them.
+func (b *BackupRoute) Send(ctx context.Context, txs []*types.Transaction, head uint64) error {
+	var err error
+	sent := false
+	if b.rebroadcaster.Enabled() {
+		if err = b.rebroadcastAll(ctx, txs); err == nil {
+			sent = true
+		}
+	}
+	if b.relays.Enabled() {
+		for block := head + 1; block <= head+backupBundleBlocks; block++ {
+			if e := b.relays.SendBundle(ctx, txs, block); e != nil {
+				log.Warn(fmt.Sprintf("error sending backup bundle for block %d: %s", block, e))
+				err = e
+				continue
+			}
+			sent = true
+		}
+	}
+	if !sent {
+		return fmt.Errorf("no backup route took the txs: %v", err)
+	}
+	return nil
+}
+
+func (b *BackupRoute) rebroadcastAll(ctx context.Context, txs []*types.Transaction) error {
+	for _, tx := range txs {
+		if err := b.rebroadcaster.Rebroadcast(ctx, tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/saantiaguilera/liquidity-sniper/third_party/erc20"
+)
+
+type (
+	// TokenBalances of ERC20 tokens held by any address
+	TokenBalances struct {
+		caller bind.ContractCaller
+	}
+)
+
+func NewTokenBalances(c bind.ContractCaller) *TokenBalances {
+	return &TokenBalances{
+		caller: c,
+	}
+}
+
+// BalanceOf the token held by the owner
+func (b *TokenBalances) BalanceOf(ctx context.Context, token, owner common.Address) (*big.Int, error) {
+	tkn, err := erc20.NewErc20Caller(token, b.caller)
+	if err != nil {
+		return nil, err
+	}
+	v, err := tkn.BalanceOf(&bind.CallOpts{Context: ctx}, owner)
+	if err != nil {
+		return nil, fmt.Errorf("error getting balance of %s in %s: %s", owner.Hex(), token.Hex(), err)
+	}
+	return v, nil
+}
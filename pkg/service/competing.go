@@ -0,0 +1,131 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type (
+	// CompetingBuys of the target seen pending on the router, so a snipe can tell how much of the price they will take
+	// before it. Only direct buys from the paired token count (path paired -> token), as we can't tell how much of the
+	// paired token a longer path reaches the pair with. Buys are forgotten after the ttl, mined or not.
+	CompetingBuys struct {
+		ttl    time.Duration
+		target *atomic.Value // competingBuysTarget
+
+		buys []competingBuy
+		mut  *sync.Mutex
+	}
+
+	competingBuysTarget struct {
+		token, paired common.Address
+	}
+
+	competingBuy struct {
+		hash     common.Hash
+		in       *big.Int
+		gasPrice *big.Int
+		seen     time.Time
+	}
+)
+
+func NewCompetingBuys(ttl time.Duration, sn domain.Sniper) *CompetingBuys {
+	b := &CompetingBuys{
+		ttl:    ttl,
+		target: new(atomic.Value),
+		mut:    new(sync.Mutex),
+	}
+	_ = b.SetTarget(sn)
+	return b
+}
+
+// SetTarget whose buys we watch, forgetting the ones of the previous target
+func (b *CompetingBuys) SetTarget(sn domain.Sniper) error {
+	b.target.Store(competingBuysTarget{
+		token:  common.HexToAddress(sn.AddressTargetToken),
+		paired: common.HexToAddress(sn.AddressTargetPaired),
+	})
+	b.mut.Lock()
+	defer b.mut.Unlock()
+	b.buys = nil
+	return nil
+}
+
+// Buy is the strategy of the swaps of the router buying tokens (exact eth / tokens in, or eth for exact tokens),
+// remembering the ones buying our target.
+func (b *CompetingBuys) Buy(_ context.Context, tx *types.Transaction) error {
+	t := b.target.Load().(competingBuysTarget)
+	data := tx.Data()
+	// most swaps aren't of our target, this is way cheaper than decoding them
+	if !bytes.Contains(data, t.token.Bytes()) {
+		return nil
+	}
+
+	m, err := routerABI.MethodById(data[:4])
+	if err != nil {
+		return fmt.Errorf("unknown swap %s: %s", tx.Hash().Hex(), err)
+	}
+	args := make(map[string]interface{})
+	if err := m.Inputs.UnpackIntoMap(args, data[4:]); err != nil {
+		return fmt.Errorf("malformed swap %s: %s", tx.Hash().Hex(), err)
+	}
+	path, ok := args["path"].([]common.Address)
+	if !ok || len(path) != 2 || path[0] != t.paired || path[1] != t.token {
+		return nil
+	}
+	in, ok := args["amountIn"].(*big.Int)
+	if !ok {
+		in = tx.Value() // buys with eth, for eth for exact tokens it's the most they spend
+	}
+
+	b.add(competingBuy{hash: tx.Hash(), in: in, gasPrice: tx.GasPrice(), seen: time.Now()})
+	return nil
+}
+
+// Competing is the total amount in of the buys seen within the ttl that land before a tx with the given gas price.
+// Those paying the same are counted too, as we can't tell their order.
+func (b *CompetingBuys) Competing(gasPrice *big.Int) *big.Int {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	b.expire(time.Now())
+	total := new(big.Int)
+	for _, buy := range b.buys {
+		if buy.gasPrice.Cmp(gasPrice) >= 0 {
+			total.Add(total, buy.in)
+		}
+	}
+	return total
+}
+
+func (b *CompetingBuys) add(buy competingBuy) {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	b.expire(buy.seen)
+	for _, prev := range b.buys {
+		if prev.hash == buy.hash {
+			return // the node delivered it twice
+		}
+	}
+	b.buys = append(b.buys, buy)
+}
+
+// expire the buys older than the ttl. Buys are kept in the order we saw them. Must be called holding the lock.
+func (b *CompetingBuys) expire(now time.Time) {
+	i := 0
+	for i < len(b.buys) && now.Sub(b.buys[i].seen) > b.ttl {
+		i++
+	}
+	b.buys = b.buys[i:]
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+func newCompetingBuyTx(t *testing.T, nonce uint64, gasPrice int64, method string, args ...interface{}) *types.Transaction {
+	data, err := routerABI.Pack(method, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return types.NewTransaction(nonce, benchTo, big.NewInt(7), 300000, big.NewInt(gasPrice), data)
+}
+
+func TestCompetingBuys_Competing(t *testing.T) {
+	paired, token := fillPair, benchTokenA
+	b := NewCompetingBuys(time.Minute, domain.Sniper{AddressTargetToken: token.Hex(), AddressTargetPaired: paired.Hex()})
+	deadline := big.NewInt(1700000000)
+
+	txs := []*types.Transaction{
+		// counted, the amount in of the tokens
+		newCompetingBuyTx(t, 0, 10, "swapExactTokensForTokens", big.NewInt(100), big.NewInt(0), []common.Address{paired, token}, benchTo, deadline),
+		// counted, the value of the eth
+		newCompetingBuyTx(t, 1, 5, "swapExactETHForTokens", big.NewInt(0), []common.Address{paired, token}, benchTo, deadline),
+		// paying less gas than us
+		newCompetingBuyTx(t, 2, 1, "swapExactTokensForTokens", big.NewInt(1000), big.NewInt(0), []common.Address{paired, token}, benchTo, deadline),
+		// through another path
+		newCompetingBuyTx(t, 3, 10, "swapExactTokensForTokens", big.NewInt(1000), big.NewInt(0), []common.Address{benchTokenB, paired, token}, benchTo, deadline),
+		// selling the target
+		newCompetingBuyTx(t, 4, 10, "swapExactTokensForTokens", big.NewInt(1000), big.NewInt(0), []common.Address{token, paired}, benchTo, deadline),
+		// of another token
+		newCompetingBuyTx(t, 5, 10, "swapExactTokensForTokens", big.NewInt(1000), big.NewInt(0), []common.Address{paired, benchTokenB}, benchTo, deadline),
+	}
+	for _, tx := range append(txs, txs[0]) { // delivered twice
+		if err := b.Buy(context.Background(), tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if c := b.Competing(big.NewInt(5)); c.Cmp(big.NewInt(107)) != 0 {
+		t.Fatalf("expected 107 competing, got %s", c)
+	}
+	if c := b.Competing(big.NewInt(6)); c.Cmp(big.NewInt(100)) != 0 {
+		t.Fatalf("expected 100 competing above our gas, got %s", c)
+	}
+
+	b.buys[0].seen = time.Now().Add(-2 * time.Minute)
+	if c := b.Competing(big.NewInt(5)); c.Cmp(big.NewInt(7)) != 0 {
+		t.Fatalf("expected the expired buy forgotten, got %s", c)
+	}
+
+	if err := b.SetTarget(domain.Sniper{AddressTargetToken: benchTokenB.Hex(), AddressTargetPaired: paired.Hex()}); err != nil {
+		t.Fatal(err)
+	}
+	if c := b.Competing(big.NewInt(0)); c.Sign() != 0 {
+		t.Fatalf("expected the buys of the previous target forgotten, got %s", c)
+	}
+}
+
+func TestNewTriggerMinOutCalldata(t *testing.T) {
+	reveal := &domain.SniperReveal{AmountIn: big.NewInt(1), AmountOutMin: big.NewInt(2)}
+	tests := []struct {
+		name     string
+		reveal   *domain.SniperReveal
+		selector []byte
+		words    int
+	}{
+		{"v2", nil, triggerMinSmartContract, 1},
+		{"v2 revealed", reveal, triggerRevealMinSmartContract, 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := newTriggerMinOutCalldata(tt.reveal, benchTokenB, benchTokenA, big.NewInt(42))
+			if string(data[:4]) != string(tt.selector) || len(data) != 4+tt.words*common.HashLength {
+				t.Fatalf("expected %x with %d words, got %x", tt.selector, tt.words, data)
+			}
+			if new(big.Int).SetBytes(data[len(data)-common.HashLength:]).Int64() != 42 {
+				t.Fatalf("expected the min amount out last in %x", data)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+const (
+	// constructorMaxBlocks we wait for a pending deploy of the target to be mined before giving up on it
+	constructorMaxBlocks = 5
+)
+
+type (
+	// uniswapConstructors inspect the pair of the target once it's deployed, for tokens adding their liquidity in
+	// their constructor (deploy and fund in one tx)
+	uniswapConstructors struct {
+		reserves uniswapConstructorReserves
+		receipts uniswapConstructorReceipts
+		timing   domain.Timing
+	}
+
+	uniswapConstructorReserves interface {
+		Sample(ctx context.Context, token, paired common.Address) (domain.ReserveSample, error)
+	}
+
+	uniswapConstructorReceipts interface {
+		TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	}
+)
+
+// DetectConstructorLiquidity of the target, sniping its deploy if it adds liquidity in the constructor. It must be
+// done before classifying any tx.
+func (u *UniswapLiquidity) DetectConstructorLiquidity(r uniswapConstructorReserves, rc uniswapConstructorReceipts, tm domain.Timing) {
+	u.constructors = &uniswapConstructors{
+		reserves: r,
+		receipts: rc,
+		timing:   tm,
+	}
+}
+
+// Deploy of a contract, sniped if it creates the target token and its constructor adds liquidity to the pair. The
+// creation is simulated while pending, and once mined the pair it funded is inspected to snipe the very next block.
+// The pair state after a pending creation can't be read, so we never race it within its own block.
+func (u *UniswapLiquidity) Deploy(ctx context.Context, tx *types.Transaction) error {
+	t := u.target.Load().(*uniswapLiquidityTarget)
+	if u.constructors == nil || !t.armed || tx.To() != nil {
+		return nil
+	}
+
+	sender, err := u.getTxSenderAddressQuick(t, tx)
+	if err != nil {
+		return nil // anyone deploys, we only care about the creation of our token
+	}
+	if crypto.CreateAddress(sender, tx.Nonce()) != t.sniperTTBAddr {
+		return nil
+	}
+	u.see(t, tx, &domain.Candidate{
+		Method:       "constructor",
+		Token:        t.sniperTTBAddr.Hex(),
+		Paired:       t.sniperTokenPaired.Hex(),
+		AmountPaired: tx.Value(),
+		GasPrice:     tx.GasPrice(),
+	})
+	log.Info(fmt.Sprintf("found the deploy of %s in tx %s", t.sniperTTBAddr.Hex(), tx.Hash().Hex()))
+
+	if u.pending {
+		if !u.checkCreation(ctx, t, tx, sender) || !u.awaitCreation(ctx, t, tx) {
+			return nil
+		}
+	}
+
+	s, err := u.constructors.reserves.Sample(ctx, t.sniperTTBAddr, t.sniperTokenPaired)
+	if err != nil {
+		u.reject(t, tx, domain.SkipReasonPoolUnfunded, fmt.Sprintf("deployed without liquidity: %s", err))
+		return nil
+	}
+	if s.ReservePaired.Cmp(t.sniperMinLiq) != 1 {
+		u.reject(t, tx, domain.SkipReasonLiqBelowMin, fmt.Sprintf(
+			"%.4f vs %.4f expected",
+			u.decimals.Format(ctx, t.sniperTokenPaired, s.ReservePaired),
+			u.decimals.Format(ctx, t.sniperTokenPaired, t.sniperMinLiq),
+		))
+		return nil
+	}
+	if ok, err := u.checkValuation(ctx, t, tx, s.ReserveToken, s.ReservePaired); !ok {
+		return err
+	}
+	return u.snipe(withLaunchReserves(ctx, s.ReservePaired, s.ReserveToken), t, tx, sender)
+}
+
+// checkCreation simulating the deploy against the pending state, we should only wait for it if it's ok. A failed
+// simulation (eg. the node is down) doesn't block it, as the pair is inspected anyway.
+func (u *UniswapLiquidity) checkCreation(ctx context.Context, t *uniswapLiquidityTarget, tx *types.Transaction, sender common.Address) bool {
+	_, err := u.ethClient.PendingCallContract(ctx, ethereum.CallMsg{
+		From:     sender,
+		Gas:      tx.Gas(),
+		GasPrice: tx.GasPrice(),
+		Value:    tx.Value(),
+		Data:     tx.Data(),
+	})
+	if err == nil {
+		return true
+	}
+
+	if !isRevert(err) {
+		log.Warn(fmt.Sprintf("couldn't simulate deploy %s, waiting for it anyway: %s", tx.Hash().Hex(), err))
+		return true
+	}
+	u.reject(t, tx, domain.SkipReasonVictimReverts, err.Error())
+	return false
+}
+
+// awaitCreation of the pending deploy, we should only inspect the pair if it's mined and succeeded
+func (u *UniswapLiquidity) awaitCreation(ctx context.Context, t *uniswapLiquidityTarget, tx *types.Transaction) bool {
+	c := u.constructors
+	ctx, canc := context.WithTimeout(ctx, c.timing.Blocks(constructorMaxBlocks))
+	defer canc()
+
+	tk := time.NewTicker(c.timing.Poll())
+	defer tk.Stop()
+
+	for {
+		r, err := c.receipts.TransactionReceipt(ctx, tx.Hash())
+		switch {
+		case err == nil && r.Status == types.ReceiptStatusFailed:
+			u.reject(t, tx, domain.SkipReasonVictimReverts, fmt.Sprintf("deploy reverted in block %s", r.BlockNumber))
+			return false
+		case err == nil:
+			return true
+		case !errors.Is(err, ethereum.NotFound):
+			log.Warn(fmt.Sprintf("error getting the receipt of deploy %s: %s", tx.Hash().Hex(), err))
+		}
+
+		select {
+		case <-tk.C:
+		case <-ctx.Done():
+			u.decide(t, tx, domain.DecisionSnipeFailed, "", fmt.Sprintf("deploy not mined: %s", ctx.Err()))
+			return false
+		}
+	}
+}
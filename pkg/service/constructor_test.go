@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type (
+	fakeConstructorReserves struct {
+		sample domain.ReserveSample
+		err    error
+	}
+
+	fakeConstructorSniper struct {
+		sniped []common.Hash
+	}
+
+	fakeConstructorDecisions struct {
+		decisions []domain.Decision
+	}
+)
+
+func (f fakeConstructorReserves) Sample(context.Context, common.Address, common.Address) (domain.ReserveSample, error) {
+	return f.sample, f.err
+}
+
+func (f *fakeConstructorSniper) Snipe(_ context.Context, tx *types.Transaction) error {
+	f.sniped = append(f.sniped, tx.Hash())
+	return nil
+}
+
+func (f *fakeConstructorSniper) Replace(ctx context.Context, _, tx *types.Transaction) error {
+	return f.Snipe(ctx, tx)
+}
+
+func (f *fakeConstructorDecisions) Record(d domain.Decision) {
+	f.decisions = append(f.decisions, d)
+}
+
+func TestUniswapLiquidity_Deploy(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainID := big.NewInt(56)
+	deployer := crypto.PubkeyToAddress(key.PublicKey)
+	deploy := func(nonce uint64) *types.Transaction {
+		tx, err := types.SignNewTx(key, types.LatestSignerForChainID(chainID), &types.LegacyTx{
+			Nonce:    nonce,
+			GasPrice: big.NewInt(5000000000),
+			Gas:      3000000,
+			Value:    big.NewInt(10),
+			Data:     []byte{0x60, 0x80},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+	funded := domain.ReserveSample{ReserveToken: big.NewInt(1000), ReservePaired: big.NewInt(10)}
+
+	tests := []struct {
+		name     string
+		nonce    uint64
+		reserves fakeConstructorReserves
+		sniped   bool
+		reason   domain.SkipReason
+	}{
+		{name: "another contract", nonce: 2, reserves: fakeConstructorReserves{sample: funded}},
+		{name: "funded in the constructor", nonce: 3, reserves: fakeConstructorReserves{sample: funded}, sniped: true},
+		{name: "deployed without a pair", nonce: 3, reserves: fakeConstructorReserves{err: errors.New("there's no pair")}, reason: domain.SkipReasonPoolUnfunded},
+		{name: "funded below the min", nonce: 3, reserves: fakeConstructorReserves{sample: domain.ReserveSample{
+			ReserveToken: big.NewInt(1000), ReservePaired: big.NewInt(5),
+		}}, reason: domain.SkipReasonLiqBelowMin},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sniper := &fakeConstructorSniper{}
+			decisions := &fakeConstructorDecisions{}
+			u := &UniswapLiquidity{
+				sniperClient: sniper,
+				senders:      NewSenderCache(10),
+				decisions:    decisions,
+				victims:      newVictimTracker(victimTrackerSize),
+				target:       new(atomic.Value),
+				decimals:     NewTokenDecimals(nil),
+			}
+			u.decimals.cache.Store(benchTokenB, uint8(6))
+			u.target.Store(&uniswapLiquidityTarget{
+				sniperTTBAddr:     crypto.CreateAddress(deployer, 3),
+				sniperTokenPaired: benchTokenB,
+				sniperMinLiq:      big.NewInt(5),
+				sniperChainID:     chainID,
+				armed:             true,
+			})
+			u.DetectConstructorLiquidity(tt.reserves, nil, domain.NewTiming(chainID, 0))
+
+			if err := u.Deploy(context.Background(), deploy(tt.nonce)); err != nil {
+				t.Fatal(err)
+			}
+			if sniped := len(sniper.sniped) > 0; sniped != tt.sniped {
+				t.Fatalf("expected sniped %t, got %t", tt.sniped, sniped)
+			}
+			var reason domain.SkipReason
+			for _, d := range decisions.decisions {
+				if d.Kind == domain.DecisionRejected {
+					reason = d.Reason
+				}
+			}
+			if reason != tt.reason {
+				t.Fatalf("expected rejection %q, got %q", tt.reason, reason)
+			}
+		})
+	}
+}
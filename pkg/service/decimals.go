@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/saantiaguilera/liquidity-sniper/third_party/erc20"
+)
+
+type (
+	// TokenDecimals of the erc20 tokens, so amounts are compared and shown in their own units instead of assuming
+	// 18 decimals (eg. USDC has 6 on most chains). They never change, so each token is asked once.
+	TokenDecimals struct {
+		caller bind.ContractCaller
+		cache  *sync.Map // common.Address -> uint8
+	}
+)
+
+func NewTokenDecimals(c bind.ContractCaller) *TokenDecimals {
+	return &TokenDecimals{
+		caller: c,
+		cache:  new(sync.Map),
+	}
+}
+
+// Of the token
+func (d *TokenDecimals) Of(ctx context.Context, token common.Address) (uint8, error) {
+	if v, ok := d.cache.Load(token); ok {
+		return v.(uint8), nil
+	}
+
+	tkn, err := erc20.NewErc20Caller(token, d.caller)
+	if err != nil {
+		return 0, fmt.Errorf("error binding token %s: %s", token.Hex(), err)
+	}
+	dec, err := tkn.Decimals(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return 0, fmt.Errorf("error getting decimals of token %s: %s", token.Hex(), err)
+	}
+	d.cache.Store(token, dec)
+	return dec, nil
+}
+
+// Format the amount of the token in its units, eg. 1500000 of a 6 decimals token is 1.5. If its decimals are unknown
+// 18 are assumed, it's only meant for humans.
+func (d *TokenDecimals) Format(ctx context.Context, token common.Address, amount *big.Int) float64 {
+	dec, err := d.Of(ctx, token)
+	if err != nil {
+		dec = 18
+	}
+	return formatUnits(amount, dec)
+}
+
+// formatUnits of an amount with the given decimals
+func formatUnits(amount *big.Int, decimals uint8) float64 {
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	v, _ := new(big.Float).Quo(new(big.Float).SetInt(amount), new(big.Float).SetInt(unit)).Float64()
+	return v
+}
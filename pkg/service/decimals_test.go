@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type decimalsTestCaller struct {
+	decimals uint8
+	err      error
+	calls    *int
+}
+
+func (c decimalsTestCaller) CodeAt(context.Context, common.Address, *big.Int) ([]byte, error) {
+	return []byte{0x1}, nil
+}
+
+func (c decimalsTestCaller) CallContract(context.Context, ethereum.CallMsg, *big.Int) ([]byte, error) {
+	*c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return common.LeftPadBytes([]byte{c.decimals}, common.HashLength), nil
+}
+
+func TestTokenDecimals_Of(t *testing.T) {
+	token := common.HexToAddress("0x8AC76a51cc950d9822D68b83fE1Ad97B32Cd580d")
+	calls := 0
+	d := NewTokenDecimals(decimalsTestCaller{decimals: 6, calls: &calls})
+
+	for i := 0; i < 2; i++ {
+		dec, err := d.Of(context.Background(), token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dec != 6 {
+			t.Fatalf("expected 6 decimals, got %d", dec)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected the decimals to be asked once, got %d calls", calls)
+	}
+}
+
+func TestTokenDecimals_Format(t *testing.T) {
+	token := common.HexToAddress("0x8AC76a51cc950d9822D68b83fE1Ad97B32Cd580d")
+	tests := []struct {
+		name   string
+		caller decimalsTestCaller
+		amount *big.Int
+		expect float64
+	}{
+		{"6 decimals", decimalsTestCaller{decimals: 6}, big.NewInt(1500000), 1.5},
+		{"9 decimals", decimalsTestCaller{decimals: 9}, big.NewInt(2500000000), 2.5},
+		{"18 decimals", decimalsTestCaller{decimals: 18}, new(big.Int).Mul(big.NewInt(3), big.NewInt(1e18)), 3},
+		{"unknown decimals", decimalsTestCaller{err: errors.New("boom")}, new(big.Int).Mul(big.NewInt(3), big.NewInt(1e18)), 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			tt.caller.calls = &calls
+			if got := NewTokenDecimals(tt.caller).Format(context.Background(), token, tt.amount); got != tt.expect {
+				t.Fatalf("expected %g, got %g", tt.expect, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type (
+	// DecisionFeed keeps the latest decisions of the filter chain in memory, so they can be watched live (eg. from
+	// the tui) without a decision repository.
+	DecisionFeed struct {
+		ring []domain.Decision
+		next int
+		full bool
+
+		mut *sync.RWMutex
+	}
+)
+
+func NewDecisionFeed(size int) *DecisionFeed {
+	return &DecisionFeed{
+		ring: make([]domain.Decision, size),
+		mut:  new(sync.RWMutex),
+	}
+}
+
+func (f *DecisionFeed) Record(d domain.Decision) {
+	if len(f.ring) == 0 {
+		return
+	}
+	if d.Time.IsZero() {
+		d.Time = time.Now()
+	}
+
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	f.ring[f.next] = d
+	f.next = (f.next + 1) % len(f.ring)
+	if f.next == 0 {
+		f.full = true
+	}
+}
+
+// Recent decisions, up to n (or all of them if n is zero) and newest first
+func (f *DecisionFeed) Recent(n int) []domain.Decision {
+	f.mut.RLock()
+	defer f.mut.RUnlock()
+
+	size := f.next
+	if f.full {
+		size = len(f.ring)
+	}
+	if n <= 0 || n > size {
+		n = size
+	}
+
+	res := make([]domain.Decision, n)
+	for i := range res {
+		res[i] = f.ring[(f.next-1-i+len(f.ring))%len(f.ring)]
+	}
+	return res
+}
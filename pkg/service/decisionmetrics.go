@@ -0,0 +1,54 @@
+package service
+
+import (
+	"expvar"
+	"sync"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type (
+	// DecisionMetrics counts the decisions of the filter chain per target, so we can see at a glance why we aren't
+	// sniping (eg. most candidates rejected because of their liquidity). Counters are exported through expvar as:
+	//   decisions:    { target: { kind: n } }
+	//   skip_reasons: { target: { reason: n } }
+	DecisionMetrics struct {
+		decisions *expvar.Map
+		skips     *expvar.Map
+
+		mut *sync.Mutex
+	}
+
+	// DecisionRecorders records each decision in all of them
+	DecisionRecorders []decisionRecorder
+
+	decisionRecorder interface {
+		Record(domain.Decision)
+	}
+)
+
+// NewDecisionMetrics publishes the counters, hence it can be created only once.
+func NewDecisionMetrics() *DecisionMetrics {
+	return &DecisionMetrics{
+		decisions: expvar.NewMap("decisions"),
+		skips:     expvar.NewMap("skip_reasons"),
+		mut:       new(sync.Mutex),
+	}
+}
+
+func NewDecisionRecorders(r ...decisionRecorder) DecisionRecorders {
+	return r
+}
+
+func (m *DecisionMetrics) Record(d domain.Decision) {
+	childMap(m.mut, m.decisions, d.Target).Add(string(d.Kind), 1)
+	if len(d.Reason) > 0 {
+		childMap(m.mut, m.skips, d.Target).Add(string(d.Reason), 1)
+	}
+}
+
+func (r DecisionRecorders) Record(d domain.Decision) {
+	for _, v := range r {
+		v.Record(d)
+	}
+}
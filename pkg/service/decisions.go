@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type (
+	// DecisionHistory records the decisions of the filter chain in the background, so recording never delays a snipe.
+	// Without a repository it records nothing.
+	DecisionHistory struct {
+		repository decisionHistoryRepository
+
+		queue chan domain.Decision
+	}
+
+	decisionHistoryRepository interface {
+		Append(context.Context, domain.Decision) error
+	}
+)
+
+func NewDecisionHistory(size int, r decisionHistoryRepository) *DecisionHistory {
+	h := &DecisionHistory{
+		repository: r,
+	}
+	if r != nil {
+		h.queue = make(chan domain.Decision, size)
+		go h.consume()
+	}
+	return h
+}
+
+// Record the decision. If the history can't keep up with the decisions being made they are dropped.
+func (h *DecisionHistory) Record(d domain.Decision) {
+	if h.queue == nil {
+		return
+	}
+	if d.Time.IsZero() {
+		d.Time = time.Now()
+	}
+
+	select {
+	case h.queue <- d:
+	default:
+		log.Warn(fmt.Sprintf("decision history full, dropping %s decision of tx %s", d.Kind, d.Tx))
+	}
+}
+
+func (h *DecisionHistory) consume() {
+	for d := range h.queue {
+		h.append(d)
+	}
+}
+
+func (h *DecisionHistory) append(d domain.Decision) {
+	defer recovery()
+	if err := h.repository.Append(context.Background(), d); err != nil {
+		log.Error(fmt.Sprintf("error appending %s decision of tx %s: %s", d.Kind, d.Tx, err))
+	}
+}
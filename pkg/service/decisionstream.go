@@ -0,0 +1,77 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type (
+	// DecisionStream fans the decisions of the filter chain out to its subscribers as they are made (eg. external
+	// consumers of the api). A subscriber that can't keep up misses decisions, recording never waits for it.
+	DecisionStream struct {
+		subs map[uint64]*decisionSubscription
+		next uint64
+
+		mut *sync.RWMutex
+	}
+
+	decisionSubscription struct {
+		ch      chan domain.Decision
+		dropped uint64
+	}
+)
+
+func NewDecisionStream() *DecisionStream {
+	return &DecisionStream{
+		subs: make(map[uint64]*decisionSubscription),
+		mut:  new(sync.RWMutex),
+	}
+}
+
+func (s *DecisionStream) Record(d domain.Decision) {
+	if d.Time.IsZero() {
+		d.Time = time.Now()
+	}
+
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	for id, sub := range s.subs {
+		select {
+		case sub.ch <- d:
+		default:
+			if n := atomic.AddUint64(&sub.dropped, 1); n == 1 || n%1000 == 0 {
+				log.Warn(fmt.Sprintf("decision stream subscriber %d can't keep up, dropped %d decisions", id, n))
+			}
+		}
+	}
+}
+
+// Subscribe to the decisions made from now on, buffering up to size of them. The returned func unsubscribes,
+// closing the channel.
+func (s *DecisionStream) Subscribe(size int) (<-chan domain.Decision, func()) {
+	sub := &decisionSubscription{
+		ch: make(chan domain.Decision, size),
+	}
+
+	s.mut.Lock()
+	id := s.next
+	s.next++
+	s.subs[id] = sub
+	s.mut.Unlock()
+
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			s.mut.Lock()
+			delete(s.subs, id)
+			s.mut.Unlock()
+			close(sub.ch)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+func TestDecisionStream_Record(t *testing.T) {
+	s := NewDecisionStream()
+	s.Record(domain.Decision{Kind: domain.DecisionCandidateSeen, Tx: "0x0"}) // nobody listening yet
+
+	fast, unsubscribeFast := s.Subscribe(10)
+	slow, unsubscribeSlow := s.Subscribe(1)
+	defer unsubscribeSlow()
+
+	s.Record(domain.Decision{Kind: domain.DecisionCandidateSeen, Tx: "0x1"})
+	s.Record(domain.Decision{Kind: domain.DecisionSniped, Tx: "0x1"})
+
+	if d := <-fast; d.Tx != "0x1" || d.Kind != domain.DecisionCandidateSeen || d.Time.IsZero() {
+		t.Fatalf("unexpected first decision %+v", d)
+	}
+	if d := <-fast; d.Kind != domain.DecisionSniped {
+		t.Fatalf("unexpected second decision %+v", d)
+	}
+	if d := <-slow; d.Kind != domain.DecisionCandidateSeen {
+		t.Fatalf("unexpected decision of the slow subscriber %+v", d)
+	}
+	select {
+	case d := <-slow:
+		t.Fatalf("expected the slow subscriber to miss the decision beyond its buffer, got %+v", d)
+	default:
+	}
+
+	unsubscribeFast()
+	unsubscribeFast() // twice is fine
+	if _, ok := <-fast; ok {
+		t.Fatal("expected the channel to be closed once unsubscribed")
+	}
+	s.Record(domain.Decision{Kind: domain.DecisionRejected, Tx: "0x2"})
+	if d := <-slow; d.Kind != domain.DecisionRejected {
+		t.Fatalf("expected the remaining subscriber to keep receiving, got %+v", d)
+	}
+}
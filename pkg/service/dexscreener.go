@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+const (
+	dexscreenerURL      = "https://api.dexscreener.com/latest/dex"
+	dexscreenerProvider = "dexscreener"
+	dexscreenerTimeout  = 10 * time.Second
+
+	dextoolsChartURL = "https://www.dextools.io/app/en/%s/pair-explorer/%s"
+)
+
+type (
+	// Dexscreener reports the market of the tokens through the public Dexscreener api, linking their chart in Dextools
+	// too if its chain is known (its api isn't public, so only the link). Calls are rate limited as the 'dexscreener'
+	// provider.
+	Dexscreener struct {
+		url   string
+		chain string
+		// dextoolsChain is the name of the chain in Dextools (eg. bnb or ether), empty doesn't link it
+		dextoolsChain string
+		limits        dexscreenerLimits
+		client        *http.Client
+	}
+
+	dexscreenerLimits interface {
+		Allow(provider string) bool
+		Exhausted(provider string)
+	}
+
+	dexscreenerTokens struct {
+		Pairs []dexscreenerPair `json:"pairs"`
+	}
+
+	dexscreenerPair struct {
+		ChainID     string `json:"chainId"`
+		URL         string `json:"url"`
+		PairAddress string `json:"pairAddress"`
+		BaseToken   struct {
+			Address string `json:"address"`
+		} `json:"baseToken"`
+		QuoteToken struct {
+			Address string `json:"address"`
+		} `json:"quoteToken"`
+		PriceUSD string `json:"priceUsd"`
+		Txns     struct {
+			H24 struct {
+				Buys  uint `json:"buys"`
+				Sells uint `json:"sells"`
+			} `json:"h24"`
+		} `json:"txns"`
+		Volume struct {
+			H1  float64 `json:"h1"`
+			H24 float64 `json:"h24"`
+		} `json:"volume"`
+		Liquidity struct {
+			USD float64 `json:"usd"`
+		} `json:"liquidity"`
+		FDV float64 `json:"fdv"`
+	}
+)
+
+// NewDexscreener of the given chain, as named by Dexscreener (eg. bsc or ethereum)
+func NewDexscreener(chain, dextoolsChain string, l dexscreenerLimits) *Dexscreener {
+	return &Dexscreener{
+		url:           dexscreenerURL,
+		chain:         chain,
+		dextoolsChain: dextoolsChain,
+		limits:        l,
+		client: &http.Client{
+			Timeout: dexscreenerTimeout,
+		},
+	}
+}
+
+// Market of the token in its pair with the paired one. It's not ok if Dexscreener doesn't track the pair yet (it takes
+// a while after a launch) or we are over its rate limits.
+func (d *Dexscreener) Market(ctx context.Context, token, paired common.Address) (domain.Market, bool, error) {
+	if !d.limits.Allow(dexscreenerProvider) {
+		return domain.Market{}, false, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/tokens/%s", d.url, token.Hex()), nil)
+	if err != nil {
+		return domain.Market{}, false, err
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return domain.Market{}, false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusTooManyRequests {
+		d.limits.Exhausted(dexscreenerProvider)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return domain.Market{}, false, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var tokens dexscreenerTokens
+	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
+		return domain.Market{}, false, fmt.Errorf("malformed dexscreener response: %s", err)
+	}
+	for _, p := range tokens.Pairs {
+		if p.ChainID != d.chain ||
+			!strings.EqualFold(p.BaseToken.Address, token.Hex()) ||
+			!strings.EqualFold(p.QuoteToken.Address, paired.Hex()) {
+			continue
+		}
+		return d.market(token, p), true, nil
+	}
+	return domain.Market{}, false, nil
+}
+
+func (d *Dexscreener) market(token common.Address, p dexscreenerPair) domain.Market {
+	m := domain.Market{
+		Token:          token.Hex(),
+		Pair:           common.HexToAddress(p.PairAddress).Hex(),
+		DexscreenerURL: p.URL,
+		LiquidityUSD:   p.Liquidity.USD,
+		FDV:            p.FDV,
+		VolumeH1:       p.Volume.H1,
+		VolumeH24:      p.Volume.H24,
+		BuysH24:        p.Txns.H24.Buys,
+		SellsH24:       p.Txns.H24.Sells,
+		UpdatedAt:      time.Now(),
+	}
+	m.PriceUSD, _ = strconv.ParseFloat(p.PriceUSD, 64) // zero if it has no usd price yet
+	if len(d.dextoolsChain) > 0 {
+		m.DextoolsURL = fmt.Sprintf(dextoolsChartURL, d.dextoolsChain, strings.ToLower(m.Pair))
+	}
+	return m
+}
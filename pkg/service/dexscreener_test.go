@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type fakeDexscreenerLimits struct {
+	allow     bool
+	exhausted bool
+}
+
+func (f *fakeDexscreenerLimits) Allow(string) bool { return f.allow }
+func (f *fakeDexscreenerLimits) Exhausted(string)  { f.exhausted = true }
+
+func TestDexscreener_Market(t *testing.T) {
+	token := common.HexToAddress("0x8f5d3374373aDA8b2c201C5cAc4c384FD42d2390")
+	wbnb := common.HexToAddress("0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c")
+	busd := common.HexToAddress("0xe9e7CEA3DedcA5984780Bafc599bD69ADd087D56")
+	pair := common.HexToAddress("0x58F876857a02D6762E0101bb5C46A8c1ED44Dc16")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/tokens/"+token.Hex() {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"pairs": [
+			{"chainId": "ethereum", "pairAddress": "0x01", "baseToken": {"address": "%[1]s"}, "quoteToken": {"address": "%[2]s"}},
+			{"chainId": "bsc", "pairAddress": "0x02", "baseToken": {"address": "%[1]s"}, "quoteToken": {"address": "%[3]s"}},
+			{"chainId": "bsc", "url": "https://dexscreener.com/bsc/%[4]s", "pairAddress": "%[4]s",
+				"baseToken": {"address": "%[1]s"}, "quoteToken": {"address": "%[2]s"}, "priceUsd": "0.0012",
+				"txns": {"h24": {"buys": 30, "sells": 5}}, "volume": {"h1": 100, "h24": 1200},
+				"liquidity": {"usd": 50000}, "fdv": 200000}
+		]}`, token.Hex(), wbnb.Hex(), busd.Hex(), pair.Hex())
+	}))
+	defer srv.Close()
+
+	limits := &fakeDexscreenerLimits{allow: true}
+	d := NewDexscreener("bsc", "bnb", limits)
+	d.url = srv.URL
+	m, ok, err := d.Market(context.Background(), token, wbnb)
+	if err != nil || !ok {
+		t.Fatalf("expected the market, got %v %v", ok, err)
+	}
+	if m.Pair != pair.Hex() || m.PriceUSD != 0.0012 || m.LiquidityUSD != 50000 || m.FDV != 200000 ||
+		m.VolumeH1 != 100 || m.VolumeH24 != 1200 || m.BuysH24 != 30 || m.SellsH24 != 5 {
+		t.Fatalf("unexpected market %+v", m)
+	}
+	if expected := "https://www.dextools.io/app/en/bnb/pair-explorer/0x58f876857a02d6762e0101bb5c46a8c1ed44dc16"; m.DextoolsURL != expected {
+		t.Fatalf("expected dextools chart %s, got %s", expected, m.DextoolsURL)
+	}
+
+	if _, ok, err := d.Market(context.Background(), token, common.HexToAddress("0x01")); ok || err != nil {
+		t.Fatalf("expected a pair not tracked yet not to be ok, got %v %v", ok, err)
+	}
+
+	if _, _, err := d.Market(context.Background(), pair, wbnb); err == nil {
+		t.Fatal("expected an error answering with an unexpected status")
+	}
+
+	limits.allow = false
+	if _, ok, err := d.Market(context.Background(), token, wbnb); ok || err != nil {
+		t.Fatalf("expected the call over the rate limits to be skipped, got %v %v", ok, err)
+	}
+}
@@ -0,0 +1,10 @@
+// Package service holds the components talking to the chain and the outside world.
+//
+// Detection: UniswapLiquidity (liquidity additions of a target, with its safety checks), LiquidityMigration and
+// CompetingBuys are strategies for the txs of the router. Execution: Sniper fires the trigger with a swarm of Bees,
+// through the mempool, the relays (RelayCluster) and the alternate nodes (Rebroadcaster), and PanicSeller and BackupRoute
+// get us out. The rest are their dependencies (eg. GasOracle, EthClientCluster, TokenDecimals) and notifications
+// (Alerts, HealthCheck, Dexscreener).
+//
+// Constructors take the dependencies they use as small interfaces, so any of them can be replaced.
+package service
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type (
+	// RPCEthClient is an eth client that also speaks the rpc methods the ethclient package lacks
+	// (eg. eth_createAccessList)
+	RPCEthClient struct {
+		*ethclient.Client
+
+		rpc *rpc.Client
+	}
+
+	accessListResult struct {
+		AccessList *types.AccessList `json:"accessList"`
+		Error      string            `json:"error,omitempty"`
+		GasUsed    hexutil.Uint64    `json:"gasUsed"`
+	}
+)
+
+func NewRPCEthClient(c *rpc.Client) *RPCEthClient {
+	return &RPCEthClient{
+		Client: ethclient.NewClient(c),
+		rpc:    c,
+	}
+}
+
+// CreateAccessList of the call against the pending state, with the gas it uses with it and the vm error it
+// would fail with (if any).
+func (c *RPCEthClient) CreateAccessList(ctx context.Context, msg ethereum.CallMsg) (*types.AccessList, uint64, string, error) {
+	var res accessListResult
+	if err := c.rpc.CallContext(ctx, &res, "eth_createAccessList", toCallArg(msg), "pending"); err != nil {
+		return nil, 0, "", fmt.Errorf("error creating access list: %s", err)
+	}
+	return res.AccessList, uint64(res.GasUsed), res.Error, nil
+}
+
+func toCallArg(msg ethereum.CallMsg) interface{} {
+	arg := map[string]interface{}{
+		"from": msg.From,
+		"to":   msg.To,
+	}
+	if len(msg.Data) > 0 {
+		arg["data"] = hexutil.Bytes(msg.Data)
+	}
+	if msg.Value != nil {
+		arg["value"] = (*hexutil.Big)(msg.Value)
+	}
+	if msg.Gas != 0 {
+		arg["gas"] = hexutil.Uint64(msg.Gas)
+	}
+	if msg.GasPrice != nil {
+		arg["gasPrice"] = (*hexutil.Big)(msg.GasPrice)
+	}
+	return arg
+}
@@ -24,9 +24,13 @@ type (
 		bind.ContractBackend
 
 		SendTransaction(context.Context, *types.Transaction) error
+		PendingCallContract(context.Context, ethereum.CallMsg) ([]byte, error)
+		CreateAccessList(context.Context, ethereum.CallMsg) (*types.AccessList, uint64, string, error)
 
 		TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, isPending bool, err error)
 		TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+		NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+		PendingBalanceAt(ctx context.Context, account common.Address) (*big.Int, error)
 
 		BlockByNumber(context.Context, *big.Int) (b *types.Block, err error)
 
@@ -88,6 +92,14 @@ func (e *EthClientCluster) PendingNonceAt(ctx context.Context, account common.Ad
 	return e.delegateAt(ctx).PendingNonceAt(ctx, account)
 }
 
+func (e *EthClientCluster) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	return e.delegateAt(ctx).NonceAt(ctx, account, blockNumber)
+}
+
+func (e *EthClientCluster) PendingBalanceAt(ctx context.Context, account common.Address) (*big.Int, error) {
+	return e.delegateAt(ctx).PendingBalanceAt(ctx, account)
+}
+
 func (e *EthClientCluster) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
 	return e.delegateAt(ctx).SuggestGasPrice(ctx)
 }
@@ -120,6 +132,14 @@ func (e *EthClientCluster) TransactionReceipt(ctx context.Context, txHash common
 	return e.delegateAt(ctx).TransactionReceipt(ctx, txHash)
 }
 
+func (e *EthClientCluster) PendingCallContract(ctx context.Context, call ethereum.CallMsg) ([]byte, error) {
+	return e.delegateAt(ctx).PendingCallContract(ctx, call)
+}
+
+func (e *EthClientCluster) CreateAccessList(ctx context.Context, call ethereum.CallMsg) (*types.AccessList, uint64, string, error) {
+	return e.delegateAt(ctx).CreateAccessList(ctx, call)
+}
+
 func (e *EthClientCluster) NetworkID(ctx context.Context) (*big.Int, error) {
 	return e.delegateAt(ctx).NetworkID(ctx)
 }
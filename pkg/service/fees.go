@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+type (
+	// txFees of a tx. Chains without a fee market (eg. BSC) only have a gas price, which is both its Tip and its Cap.
+	txFees struct {
+		Tip *big.Int
+		Cap *big.Int
+	}
+
+	feeMarketClient interface {
+		HeaderByNumber(context.Context, *big.Int) (*types.Header, error)
+	}
+)
+
+// DetectDynamicFees of the chain, which has them if its latest block has a base fee (EIP-1559).
+func DetectDynamicFees(ctx context.Context, c feeMarketClient) (bool, error) {
+	h, err := c.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("error getting latest header to detect the fee market: %s", err)
+	}
+	return h.BaseFee != nil, nil
+}
+
+// feesOf the tx. A legacy tx pays its gas price no matter the base fee, so copying it as both the tip and the cap gets
+// us the very same priority in a chain with dynamic fees.
+func feesOf(tx *types.Transaction) txFees {
+	return txFees{Tip: tx.GasTipCap(), Cap: tx.GasFeeCap()}
+}
+
+// effectiveGasPrice the tx paid, mined in a block with the given base fee (nil in chains without a fee market)
+func effectiveGasPrice(tx *types.Transaction, baseFee *big.Int) *big.Int {
+	if baseFee == nil {
+		return tx.GasPrice()
+	}
+	p := new(big.Int).Add(baseFee, tx.GasTipCap())
+	if p.Cmp(tx.GasFeeCap()) > 0 {
+		return new(big.Int).Set(tx.GasFeeCap())
+	}
+	return p
+}
+
+// bump the tip and the cap by the given %. Both have to be bumped for a replacement to be accepted.
+func (f txFees) bump(pct int64) txFees {
+	return txFees{
+		Tip: new(big.Int).Div(new(big.Int).Mul(f.Tip, big.NewInt(100+pct)), big.NewInt(100)),
+		Cap: new(big.Int).Div(new(big.Int).Mul(f.Cap, big.NewInt(100+pct)), big.NewInt(100)),
+	}
+}
+
+func (f txFees) equal(tx *types.Transaction) bool {
+	return tx.GasTipCap().Cmp(f.Tip) == 0 && tx.GasFeeCap().Cmp(f.Cap) == 0
+}
+
+func (f txFees) String() string {
+	if f.Tip.Cmp(f.Cap) == 0 {
+		return f.Cap.String()
+	}
+	return fmt.Sprintf("%s (tip %s)", f.Cap.String(), f.Tip.String())
+}
+
+// newTxData with the fees, as a dynamic fee tx if the chain has them or else a legacy one (with an access list, if any).
+func newTxData(
+	dynamic bool,
+	chainID *big.Int,
+	nonce uint64,
+	fees txFees,
+	gasLimit uint64,
+	to *common.Address,
+	value *big.Int,
+	data []byte,
+	al types.AccessList,
+) types.TxData {
+
+	switch {
+	case dynamic:
+		return &types.DynamicFeeTx{
+			ChainID:    chainID,
+			Nonce:      nonce,
+			GasTipCap:  fees.Tip,
+			GasFeeCap:  fees.Cap,
+			Gas:        gasLimit,
+			To:         to,
+			Value:      value,
+			Data:       data,
+			AccessList: al,
+		}
+	case len(al) > 0:
+		return &types.AccessListTx{
+			ChainID:    chainID,
+			Nonce:      nonce,
+			GasPrice:   fees.Cap,
+			Gas:        gasLimit,
+			To:         to,
+			Value:      value,
+			Data:       data,
+			AccessList: al,
+		}
+	default:
+		return &types.LegacyTx{
+			Nonce:    nonce,
+			GasPrice: fees.Cap,
+			Gas:      gasLimit,
+			To:       to,
+			Value:    value,
+			Data:     data,
+		}
+	}
+}
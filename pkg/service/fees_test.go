@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+type feesTestClient struct {
+	header *types.Header
+	err    error
+}
+
+func (c feesTestClient) HeaderByNumber(context.Context, *big.Int) (*types.Header, error) {
+	return c.header, c.err
+}
+
+func TestDetectDynamicFees(t *testing.T) {
+	tests := []struct {
+		name      string
+		client    feesTestClient
+		expect    bool
+		expectErr bool
+	}{
+		{"legacy", feesTestClient{header: &types.Header{}}, false, false},
+		{"dynamic", feesTestClient{header: &types.Header{BaseFee: big.NewInt(7)}}, true, false},
+		{"error", feesTestClient{err: errors.New("boom")}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectDynamicFees(context.Background(), tt.client)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if got != tt.expect {
+				t.Fatalf("expected %v, got %v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestFeesOf(t *testing.T) {
+	to := common.Address{}
+	tests := []struct {
+		name      string
+		tx        *types.Transaction
+		expectTip int64
+		expectCap int64
+	}{
+		{"legacy", types.NewTx(&types.LegacyTx{GasPrice: big.NewInt(5), To: &to}), 5, 5},
+		{"access list", types.NewTx(&types.AccessListTx{GasPrice: big.NewInt(6), To: &to}), 6, 6},
+		{"dynamic", types.NewTx(&types.DynamicFeeTx{GasTipCap: big.NewInt(2), GasFeeCap: big.NewInt(30), To: &to}), 2, 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := feesOf(tt.tx)
+			if f.Tip.Int64() != tt.expectTip || f.Cap.Int64() != tt.expectCap {
+				t.Fatalf("expected tip %d cap %d, got %s", tt.expectTip, tt.expectCap, f)
+			}
+			if !f.equal(tt.tx) {
+				t.Fatalf("expected %s to equal the fees of the tx", f)
+			}
+		})
+	}
+}
+
+func TestTxFees_Bump(t *testing.T) {
+	f := txFees{Tip: big.NewInt(100), Cap: big.NewInt(1000)}
+	b := f.bump(12)
+	if b.Tip.Int64() != 112 || b.Cap.Int64() != 1120 {
+		t.Fatalf("expected tip 112 cap 1120, got %s", b)
+	}
+	if f.Tip.Int64() != 100 || f.Cap.Int64() != 1000 {
+		t.Fatalf("bump changed the original fees %s", f)
+	}
+}
+
+func TestNewTxData(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainID := big.NewInt(1)
+	to := common.HexToAddress("0x10ED43C718714eb63d5aA57B78B54704E256024E")
+	fees := txFees{Tip: big.NewInt(2), Cap: big.NewInt(30)}
+	al := types.AccessList{{Address: to}}
+
+	tests := []struct {
+		name       string
+		dynamic    bool
+		al         types.AccessList
+		expectType uint8
+		expectTip  int64
+	}{
+		{"legacy", false, nil, types.LegacyTxType, 30},
+		{"legacy with access list", false, al, types.AccessListTxType, 30},
+		{"dynamic", true, nil, types.DynamicFeeTxType, 2},
+		{"dynamic with access list", true, al, types.DynamicFeeTxType, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx, err := types.SignNewTx(
+				pk,
+				types.LatestSignerForChainID(chainID),
+				newTxData(tt.dynamic, chainID, 3, fees, 21000, &to, big.NewInt(0), nil, tt.al),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tx.Type() != tt.expectType {
+				t.Fatalf("expected type %d, got %d", tt.expectType, tx.Type())
+			}
+			if tx.GasTipCap().Int64() != tt.expectTip || tx.GasFeeCap().Int64() != 30 {
+				t.Fatalf("unexpected fees %s / %s", tx.GasTipCap(), tx.GasFeeCap())
+			}
+			if len(tx.AccessList()) != len(tt.al) {
+				t.Fatalf("expected access list %v, got %v", tt.al, tx.AccessList())
+			}
+			sender, err := types.Sender(types.LatestSignerForChainID(chainID), tx)
+			if err != nil || sender != crypto.PubkeyToAddress(pk.PublicKey) {
+				t.Fatalf("unexpected sender %s: %v", sender.Hex(), err)
+			}
+		})
+	}
+}
+
+func TestEffectiveGasPrice(t *testing.T) {
+	legacy := types.NewTx(&types.LegacyTx{GasPrice: big.NewInt(5)})
+	dynamic := types.NewTx(&types.DynamicFeeTx{GasTipCap: big.NewInt(2), GasFeeCap: big.NewInt(10)})
+	tests := []struct {
+		name    string
+		tx      *types.Transaction
+		baseFee *big.Int
+		expect  int64
+	}{
+		{"legacy", legacy, nil, 5},
+		{"tip over the base fee", dynamic, big.NewInt(7), 9},
+		{"capped", dynamic, big.NewInt(9), 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := effectiveGasPrice(tt.tx, tt.baseFee); got.Int64() != tt.expect {
+				t.Fatalf("expected %d, got %s", tt.expect, got)
+			}
+		})
+	}
+}
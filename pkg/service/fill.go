@@ -0,0 +1,180 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+var (
+	erc20TransferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	uniswapSwapTopic   = crypto.Keccak256Hash([]byte("Swap(address,uint256,uint256,uint256,uint256,address)"))
+	uniswapV3SwapTopic = crypto.Keccak256Hash([]byte("Swap(address,address,int256,int256,uint160,uint128,int24)"))
+)
+
+type (
+	// snipeFill is what a mined snipe actually bought, read from its receipt
+	snipeFill struct {
+		// Expected tokens are the ones the pair sent, as priced by the AMM
+		Expected *big.Int
+		// Received tokens by the recipients of the swaps, after any transfer tax
+		Received *big.Int
+		// Recipients of the swaps. More than one if the order was split across wallets because of a max wallet.
+		Recipients []common.Address
+	}
+)
+
+// newSnipeFill from the logs of the snipe, given the pair we bought from and the token we bought. A split order swaps
+// once per recipient, so everything the pair sent to any of them is accounted.
+func newSnipeFill(logs []*types.Log, pair, token, paired common.Address) snipeFill {
+	f := snipeFill{
+		Expected: new(big.Int),
+		Received: new(big.Int),
+	}
+
+	// the pair sorts its tokens by address
+	outWord := 2 // amount0Out
+	if bytes.Compare(token.Bytes(), paired.Bytes()) > 0 {
+		outWord = 3 // amount1Out
+	}
+	for _, l := range logs {
+		if l.Address != pair || len(l.Topics) != 3 || l.Topics[0] != uniswapSwapTopic || len(l.Data) != 4*common.HashLength {
+			continue
+		}
+		f.Expected.Add(f.Expected, new(big.Int).SetBytes(l.Data[outWord*common.HashLength:(outWord+1)*common.HashLength]))
+		f.Recipients = append(f.Recipients, common.BytesToAddress(l.Topics[2].Bytes()))
+	}
+
+	f.receive(logs, token)
+	return f
+}
+
+// newSnipeFillV3 from the logs of a snipe on a v3 pool, given the token we bought. We don't know the address of the
+// pool, but it's the one sending us the token, so its swaps are the ones of the pools the token was transferred from.
+// When the paired token isn't wbnb the snipe swaps twice, and only the last hop sends the token.
+func newSnipeFillV3(logs []*types.Log, token, paired common.Address) snipeFill {
+	f := snipeFill{
+		Expected: new(big.Int),
+		Received: new(big.Int),
+	}
+
+	// the pool sorts its tokens by address, and what it sends is negative
+	outWord := 0 // amount0
+	if bytes.Compare(token.Bytes(), paired.Bytes()) > 0 {
+		outWord = 1 // amount1
+	}
+	for _, l := range logs {
+		if len(l.Topics) != 3 || l.Topics[0] != uniswapV3SwapTopic || len(l.Data) != 5*common.HashLength {
+			continue
+		}
+		if !sentToken(logs, l.Address, token) {
+			continue
+		}
+		amount := math.S256(new(big.Int).SetBytes(l.Data[outWord*common.HashLength : (outWord+1)*common.HashLength]))
+		if amount.Sign() >= 0 {
+			continue // it bought the token instead
+		}
+		f.Expected.Sub(f.Expected, amount)
+		f.Recipients = append(f.Recipients, common.BytesToAddress(l.Topics[2].Bytes()))
+	}
+
+	f.receive(logs, token)
+	return f
+}
+
+// receive everything transferred of the token to the recipients of the swaps
+func (f snipeFill) receive(logs []*types.Log, token common.Address) {
+	for _, l := range logs {
+		if l.Address != token || len(l.Topics) != 3 || l.Topics[0] != erc20TransferTopic {
+			continue
+		}
+		if f.isRecipient(common.BytesToAddress(l.Topics[2].Bytes())) {
+			f.Received.Add(f.Received, new(big.Int).SetBytes(l.Data))
+		}
+	}
+}
+
+// sentToken if any log is a transfer of the token from the given address
+func sentToken(logs []*types.Log, from, token common.Address) bool {
+	for _, l := range logs {
+		if l.Address == token && len(l.Topics) == 3 && l.Topics[0] == erc20TransferTopic &&
+			common.BytesToAddress(l.Topics[1].Bytes()) == from {
+			return true
+		}
+	}
+	return false
+}
+
+func (f snipeFill) isRecipient(addr common.Address) bool {
+	for _, r := range f.Recipients {
+		if r == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyFill of a mined snipe, alerting if it bought nothing or received way less than the pair sent (beyond the
+// tolerated % of loss). It returns the outcome of the fill of the trade, to journal it.
+func (c *Sniper) verifyFill(t domain.Trade, f snipeFill) domain.TradeOutcome {
+	if f.Received.Sign() == 0 {
+		msg := fmt.Sprintf("snipe mined but no %s was received (pair sent %s)", c.sniperTTBAddr.Hex(), f.Expected.String())
+		if spent := formatSpent(t); len(spent) > 0 {
+			msg = fmt.Sprintf("%s, spent %s", msg, spent)
+		}
+		c.alerts.Alert(domain.Alert{
+			Kind:    domain.AlertZeroFill,
+			Target:  c.sniperName,
+			Tx:      t.Hash,
+			Message: msg,
+		})
+		return domain.TradeZeroFill
+	}
+	if f.Expected.Sign() == 0 {
+		return domain.TradeFilled // no swap of our pair, nothing to compare with
+	}
+
+	ratio, _ := new(big.Float).Quo(new(big.Float).SetInt(f.Received), new(big.Float).SetInt(f.Expected)).Float64()
+	if lost := 100 * (1 - ratio); lost > c.fillTolerance {
+		c.alerts.Alert(domain.Alert{
+			Kind:   domain.AlertShortFill,
+			Target: c.sniperName,
+			Tx:     t.Hash,
+			Message: fmt.Sprintf(
+				"received %s of %s the pair sent (%.2f%% lost), more than the %.2f%% tolerated",
+				f.Received.String(),
+				f.Expected.String(),
+				lost,
+				c.fillTolerance,
+			),
+		})
+		return domain.TradeShortFill
+	}
+	return domain.TradeFilled
+}
+
+// formatSpent by the trade, in the native coin and in USD if it's known. Empty if nothing is known of it.
+func formatSpent(t domain.Trade) string {
+	usd := func(v float64) string {
+		if t.NativeUSD == 0 {
+			return ""
+		}
+		return fmt.Sprintf(" ($%.2f)", v)
+	}
+	parts := make([]string, 0, 2)
+	if t.AmountIn != nil {
+		parts = append(parts, fmt.Sprintf("%.4f%s", formatETHWeiToEther(t.AmountIn), usd(t.AmountInUSD())))
+	}
+	if t.GasCost != nil {
+		parts = append(parts, fmt.Sprintf("%.6f%s of gas", formatETHWeiToEther(t.GasCost), usd(t.GasCostUSD())))
+	}
+	return strings.Join(parts, " and ")
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var (
+	fillPair   = common.HexToAddress("0x58F876857a02D6762E0101bb5C46A8c1ED44Dc16")
+	fillToken  = common.HexToAddress("0x8f5d3374373aDA8b2c201C5cAc4c384FD42d2390")
+	fillPaired = common.HexToAddress("0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c")
+	fillRouter = common.HexToAddress("0x10ED43C718714eb63d5aA57B78B54704E256024E")
+	fillBeeA   = common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	fillBeeB   = common.HexToAddress("0x00000000000000000000000000000000000000b2")
+	fillOther  = common.HexToAddress("0x00000000000000000000000000000000000000c3")
+)
+
+// newFillSwap log of the pair sending out tokens to the recipient. Our token sorts before the paired one, so it's
+// amount0Out.
+func newFillSwap(to common.Address, out int64) *types.Log {
+	data := make([]byte, 0, 4*common.HashLength)
+	for _, w := range []int64{0, 1, out, 0} {
+		data = append(data, common.LeftPadBytes(big.NewInt(w).Bytes(), common.HashLength)...)
+	}
+	return &types.Log{
+		Address: fillPair,
+		Topics:  []common.Hash{uniswapSwapTopic, common.BytesToHash(fillRouter.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:    data,
+	}
+}
+
+func newFillTransfer(from, to common.Address, amount int64) *types.Log {
+	return &types.Log{
+		Address: fillToken,
+		Topics:  []common.Hash{erc20TransferTopic, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:    common.LeftPadBytes(big.NewInt(amount).Bytes(), common.HashLength),
+	}
+}
+
+func TestNewSnipeFill(t *testing.T) {
+	tests := []struct {
+		name             string
+		logs             []*types.Log
+		expectExpected   int64
+		expectReceived   int64
+		expectRecipients []common.Address
+	}{
+		{
+			name:           "nothing",
+			expectExpected: 0,
+			expectReceived: 0,
+		},
+		{
+			name: "single",
+			logs: []*types.Log{
+				newFillTransfer(fillPair, fillBeeA, 1000),
+				newFillSwap(fillBeeA, 1000),
+			},
+			expectExpected:   1000,
+			expectReceived:   1000,
+			expectRecipients: []common.Address{fillBeeA},
+		},
+		{
+			name: "taxed",
+			logs: []*types.Log{
+				newFillTransfer(fillPair, fillBeeA, 900),
+				newFillTransfer(fillPair, fillOther, 100), // the tax wallet
+				newFillSwap(fillBeeA, 1000),
+			},
+			expectExpected:   1000,
+			expectReceived:   900,
+			expectRecipients: []common.Address{fillBeeA},
+		},
+		{
+			name: "split",
+			logs: []*types.Log{
+				newFillTransfer(fillPair, fillBeeA, 1000),
+				newFillSwap(fillBeeA, 1000),
+				newFillTransfer(fillPair, fillBeeB, 800),
+				newFillSwap(fillBeeB, 800),
+			},
+			expectExpected:   1800,
+			expectReceived:   1800,
+			expectRecipients: []common.Address{fillBeeA, fillBeeB},
+		},
+		{
+			name: "split taxed",
+			logs: []*types.Log{
+				newFillTransfer(fillPair, fillBeeA, 950),
+				newFillTransfer(fillPair, fillOther, 50),
+				newFillSwap(fillBeeA, 1000),
+				newFillTransfer(fillPair, fillBeeB, 760),
+				newFillTransfer(fillPair, fillOther, 40),
+				newFillSwap(fillBeeB, 800),
+			},
+			expectExpected:   1800,
+			expectReceived:   1710,
+			expectRecipients: []common.Address{fillBeeA, fillBeeB},
+		},
+		{
+			name: "other pair",
+			logs: []*types.Log{
+				func() *types.Log { l := newFillSwap(fillBeeA, 1000); l.Address = fillOther; return l }(),
+				newFillTransfer(fillPair, fillBeeA, 1000),
+			},
+			expectExpected: 0,
+			expectReceived: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newSnipeFill(tt.logs, fillPair, fillToken, fillPaired)
+			if f.Expected.Cmp(big.NewInt(tt.expectExpected)) != 0 {
+				t.Fatalf("expected %d expected, got %s", tt.expectExpected, f.Expected)
+			}
+			if f.Received.Cmp(big.NewInt(tt.expectReceived)) != 0 {
+				t.Fatalf("expected %d received, got %s", tt.expectReceived, f.Received)
+			}
+			if len(f.Recipients) != len(tt.expectRecipients) {
+				t.Fatalf("expected recipients %v, got %v", tt.expectRecipients, f.Recipients)
+			}
+			for i, r := range tt.expectRecipients {
+				if f.Recipients[i] != r {
+					t.Fatalf("expected recipients %v, got %v", tt.expectRecipients, f.Recipients)
+				}
+			}
+		})
+	}
+}
+
+// newFillV3Swap log of the v3 pool sending out tokens to the recipient. Our token sorts before the paired one, so it's
+// amount0, negative as the pool sends it.
+func newFillV3Swap(pool, to common.Address, out int64) *types.Log {
+	data := make([]byte, 0, 5*common.HashLength)
+	for _, w := range []*big.Int{big.NewInt(-out), big.NewInt(1), big.NewInt(1), big.NewInt(1), big.NewInt(0)} {
+		data = append(data, math.U256Bytes(new(big.Int).Set(w))...)
+	}
+	return &types.Log{
+		Address: pool,
+		Topics:  []common.Hash{uniswapV3SwapTopic, common.BytesToHash(fillRouter.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:    data,
+	}
+}
+
+func TestNewSnipeFillV3(t *testing.T) {
+	tests := []struct {
+		name             string
+		logs             []*types.Log
+		expectExpected   int64
+		expectReceived   int64
+		expectRecipients []common.Address
+	}{
+		{
+			name:           "nothing",
+			expectExpected: 0,
+			expectReceived: 0,
+		},
+		{
+			name: "single",
+			logs: []*types.Log{
+				newFillTransfer(fillPair, fillBeeA, 1000),
+				newFillV3Swap(fillPair, fillBeeA, 1000),
+			},
+			expectExpected:   1000,
+			expectReceived:   1000,
+			expectRecipients: []common.Address{fillBeeA},
+		},
+		{
+			name: "taxed",
+			logs: []*types.Log{
+				newFillTransfer(fillPair, fillBeeA, 900),
+				newFillTransfer(fillPair, fillOther, 100),
+				newFillV3Swap(fillPair, fillBeeA, 1000),
+			},
+			expectExpected:   1000,
+			expectReceived:   900,
+			expectRecipients: []common.Address{fillBeeA},
+		},
+		{
+			name: "split",
+			logs: []*types.Log{
+				newFillTransfer(fillPair, fillBeeA, 1000),
+				newFillV3Swap(fillPair, fillBeeA, 1000),
+				newFillTransfer(fillPair, fillBeeB, 800),
+				newFillV3Swap(fillPair, fillBeeB, 800),
+			},
+			expectExpected:   1800,
+			expectReceived:   1800,
+			expectRecipients: []common.Address{fillBeeA, fillBeeB},
+		},
+		{
+			name: "routed through the paired token",
+			logs: []*types.Log{
+				newFillV3Swap(fillOther, fillPair, 5000), // wbnb to paired, its pool sends no token of ours
+				newFillTransfer(fillPair, fillBeeA, 1000),
+				newFillV3Swap(fillPair, fillBeeA, 1000),
+			},
+			expectExpected:   1000,
+			expectReceived:   1000,
+			expectRecipients: []common.Address{fillBeeA},
+		},
+		{
+			name: "v2 swap",
+			logs: []*types.Log{
+				newFillTransfer(fillPair, fillBeeA, 1000),
+				newFillSwap(fillBeeA, 1000),
+			},
+			expectExpected: 0,
+			expectReceived: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newSnipeFillV3(tt.logs, fillToken, fillPaired)
+			if f.Expected.Cmp(big.NewInt(tt.expectExpected)) != 0 {
+				t.Fatalf("expected %d expected, got %s", tt.expectExpected, f.Expected)
+			}
+			if f.Received.Cmp(big.NewInt(tt.expectReceived)) != 0 {
+				t.Fatalf("expected %d received, got %s", tt.expectReceived, f.Received)
+			}
+			if len(f.Recipients) != len(tt.expectRecipients) {
+				t.Fatalf("expected recipients %v, got %v", tt.expectRecipients, f.Recipients)
+			}
+			for i, r := range tt.expectRecipients {
+				if f.Recipients[i] != r {
+					t.Fatalf("expected recipients %v, got %v", tt.expectRecipients, f.Recipients)
+				}
+			}
+		})
+	}
+}
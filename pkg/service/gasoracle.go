@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// gasOracleSamples is the number of most recent gas prices we keep to compute the network median
+	gasOracleSamples = 2048
+)
+
+type (
+	// GasOracle keeps track of the gas prices being used in the network, so we can reason about
+	// how sane (or not) a given gas price is at any moment.
+	GasOracle struct {
+		ethClient gasOracleETHClient
+
+		samples []*big.Int
+		next    int
+		mut     *sync.RWMutex
+	}
+
+	gasOracleETHClient interface {
+		SuggestGasPrice(context.Context) (*big.Int, error)
+	}
+)
+
+func NewGasOracle(e gasOracleETHClient) *GasOracle {
+	return &GasOracle{
+		ethClient: e,
+		samples:   make([]*big.Int, 0, gasOracleSamples),
+		mut:       new(sync.RWMutex),
+	}
+}
+
+// Observe samples the gas price of a tx seen in the network. It can be used as a Monitor.
+func (o *GasOracle) Observe(ctx context.Context, tx *types.Transaction) {
+	gp := tx.GasPrice()
+	if gp == nil || gp.Sign() == 0 {
+		return // nothing to learn from it
+	}
+
+	o.mut.Lock()
+	defer o.mut.Unlock()
+	if len(o.samples) < gasOracleSamples {
+		o.samples = append(o.samples, gp)
+		return
+	}
+	o.samples[o.next] = gp
+	o.next = (o.next + 1) % gasOracleSamples
+}
+
+// Median gas price of the network. If we haven't observed anything yet we fallback to the gas price
+// suggested by the node.
+func (o *GasOracle) Median(ctx context.Context) (*big.Int, error) {
+	o.mut.RLock()
+	s := make([]*big.Int, len(o.samples))
+	copy(s, o.samples)
+	o.mut.RUnlock()
+
+	if len(s) == 0 {
+		return o.ethClient.SuggestGasPrice(ctx)
+	}
+
+	sort.Slice(s, func(i, j int) bool {
+		return s[i].Cmp(s[j]) == -1
+	})
+	m := new(big.Int).Set(s[len(s)/2])
+	log.Debug(fmt.Sprintf("gas median %s of %d samples (%s - %s)", m.String(), len(s), s[0].String(), s[len(s)-1].String()))
+	return m, nil
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	healthCheckTimeout = 5 * time.Second
+)
+
+type (
+	// HealthCheck pings an external dead man's snitch (eg. healthchecks.io), which alerts through its own
+	// integrations when the pings stop. It's the only way to hear of a crash, as a crashed bot can't alert of it.
+	HealthCheck struct {
+		url    string // optional
+		client *http.Client
+	}
+)
+
+func NewHealthCheck(url string) *HealthCheck {
+	return &HealthCheck{
+		url: strings.TrimSuffix(url, "/"),
+		client: &http.Client{
+			Timeout: healthCheckTimeout,
+		},
+	}
+}
+
+// Enabled if there's a check to ping
+func (h *HealthCheck) Enabled() bool {
+	return len(h.url) > 0
+}
+
+// Ping the check, telling we are alive
+func (h *HealthCheck) Ping(ctx context.Context, msg string) error {
+	return h.post(ctx, h.url, msg)
+}
+
+// Fail the check right away, instead of waiting for it to miss our pings (eg. when exiting)
+func (h *HealthCheck) Fail(ctx context.Context, msg string) error {
+	return h.post(ctx, fmt.Sprintf("%s/fail", h.url), msg)
+}
+
+func (h *HealthCheck) post(ctx context.Context, url, msg string) error {
+	if !h.Enabled() {
+		return nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(msg))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+const (
+	// inclusionWatcherMaxBlocks we wait for a block, a safety net in case the chain halts or our node stops syncing
+	inclusionWatcherMaxBlocks = 10
+)
+
+type (
+	// InclusionWatcher tells whether a tx we submitted landed in the block we were targeting.
+	InclusionWatcher struct {
+		ethClient inclusionWatcherETHClient
+		timing    domain.Timing
+	}
+
+	inclusionWatcherETHClient interface {
+		HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+		TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	}
+)
+
+func NewInclusionWatcher(e inclusionWatcherETHClient, t domain.Timing) *InclusionWatcher {
+	return &InclusionWatcher{
+		ethClient: e,
+		timing:    t,
+	}
+}
+
+// Head block number of the chain
+func (w *InclusionWatcher) Head(ctx context.Context) (uint64, error) {
+	h, err := w.ethClient.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	return h.Number.Uint64(), nil
+}
+
+// WaitForBlock blocks until the given block is mined. Heads are polled twice as often as our txs, as re-entries race
+// for the start of the block.
+func (w *InclusionWatcher) WaitForBlock(ctx context.Context, block uint64) error {
+	ctx, canc := context.WithTimeout(ctx, w.timing.Blocks(inclusionWatcherMaxBlocks))
+	defer canc()
+
+	t := time.NewTicker(w.timing.Poll() / 2)
+	defer t.Stop()
+
+	for {
+		head, err := w.Head(ctx)
+		if err == nil && head >= block {
+			return nil
+		}
+
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return fmt.Errorf("timeout waiting for block %d: %s", block, ctx.Err())
+		}
+	}
+}
+
+// WaitForInclusion blocks until the target block is mined and reports if the tx was included up to it.
+func (w *InclusionWatcher) WaitForInclusion(ctx context.Context, h common.Hash, block uint64) (bool, error) {
+	if err := w.WaitForBlock(ctx, block); err != nil {
+		return false, err
+	}
+
+	r, err := w.ethClient.TransactionReceipt(ctx, h)
+	if err == ethereum.NotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return r.BlockNumber.Uint64() <= block, nil
+}
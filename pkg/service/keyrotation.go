@@ -0,0 +1,159 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var (
+	// keyQuotaCodes of the json-rpc errors providers answer once the key is rate limited or its quota is spent
+	keyQuotaCodes = map[int]bool{
+		-32005: true, // limit exceeded (eg. daily request count exceeded)
+		429:    true,
+	}
+	// keyQuotaMessages of the json-rpc errors of providers that don't answer one of the codes
+	keyQuotaMessages = []string{"request count exceeded", "rate limit", "quota"}
+)
+
+type (
+	// KeyRotator is an http transport for rpc providers that take the api key in the url (eg. https://host/{key}/bsc).
+	// Each time the provider rate limits the key in use (429, or a json-rpc error once its daily quota is spent) we
+	// move to the next key and retry the request with it, so a limited key during a launch doesn't blind us.
+	// Requests go through the key in use, we don't spread them across keys.
+	KeyRotator struct {
+		provider string
+		urls     []*url.URL // one per key
+		current  *uint32
+
+		transport http.RoundTripper
+	}
+
+	keyRotatorResponse struct {
+		Error *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+)
+
+// NewKeyRotator for the given url, with the placeholder of the key of the provider (eg. {key}) and the keys of it.
+func NewKeyRotator(rawURL, placeholder string, keys []string, t http.RoundTripper) (*KeyRotator, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no keys for %s", placeholder)
+	}
+	urls := make([]*url.URL, len(keys))
+	for i, k := range keys {
+		u, err := url.Parse(strings.ReplaceAll(rawURL, placeholder, k))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing url with key %d of %s: %s", i, placeholder, err)
+		}
+		urls[i] = u
+	}
+	return &KeyRotator{
+		provider:  placeholder,
+		urls:      urls,
+		current:   new(uint32),
+		transport: t,
+	}, nil
+}
+
+// RoundTrip the request through the key in use, rotating keys while they are rate limited. If all of them are, the
+// last limited response is returned.
+func (r *KeyRotator) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		b, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = b
+	}
+
+	for i := 0; ; i++ {
+		current := atomic.LoadUint32(r.current)
+		try := req.Clone(req.Context())
+		try.URL = r.urls[current]
+		try.Host = ""
+		try.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		res, err := r.transport.RoundTrip(try)
+		if err != nil {
+			return res, err
+		}
+		limited, err := limitedKey(res)
+		if err != nil || !limited {
+			return res, err
+		}
+		r.rotate(current)
+		if i == len(r.urls)-1 {
+			return res, nil
+		}
+		res.Body.Close()
+	}
+}
+
+// rotate from the given key to the next one, unless another request already did
+func (r *KeyRotator) rotate(from uint32) {
+	next := (from + 1) % uint32(len(r.urls))
+	if atomic.CompareAndSwapUint32(r.current, from, next) {
+		log.Warn(fmt.Sprintf("key %d of %s is rate limited, rotating to key %d", from, r.provider, next))
+	}
+}
+
+// limitedKey if the response rate limits the key, either by its status or by a quota error in its body. The body is
+// peeked, so it's left as it was to be read.
+func limitedKey(res *http.Response) (bool, error) {
+	if res.StatusCode == http.StatusTooManyRequests {
+		return true, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		return false, nil
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return false, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if !bytes.Contains(body, []byte(`"error"`)) {
+		return false, nil // most responses, don't bother decoding them
+	}
+
+	var batch []keyRotatorResponse
+	if err := json.Unmarshal(body, &batch); err != nil {
+		var single keyRotatorResponse
+		if err := json.Unmarshal(body, &single); err != nil {
+			return false, nil // not ours to judge, the rpc client reports it
+		}
+		batch = []keyRotatorResponse{single}
+	}
+	for _, r := range batch {
+		if r.Error != nil && isQuotaError(r.Error.Code, r.Error.Message) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func isQuotaError(code int, msg string) bool {
+	if keyQuotaCodes[code] {
+		return true
+	}
+	msg = strings.ToLower(msg)
+	for _, m := range keyQuotaMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
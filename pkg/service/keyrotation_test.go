@@ -0,0 +1,116 @@
+package service
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestKeyRotator_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name         string
+		limited      map[string]bool
+		expectStatus int
+		expectKeys   []string
+		expectNext   string
+	}{
+		{"key in use", nil, http.StatusOK, []string{"a"}, "a"},
+		{"rotates", map[string]bool{"a": true}, http.StatusOK, []string{"a", "b"}, "b"},
+		{"rotates twice", map[string]bool{"a": true, "b": true}, http.StatusOK, []string{"a", "b", "c"}, "c"},
+		{"all limited", map[string]bool{"a": true, "b": true, "c": true}, http.StatusTooManyRequests, []string{"a", "b", "c"}, "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mut := new(sync.Mutex)
+			var used []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				key := strings.Trim(r.URL.Path, "/")
+				body, _ := ioutil.ReadAll(r.Body)
+				if string(body) != "req" {
+					t.Errorf("expected the body on every try, got %q", body)
+				}
+				mut.Lock()
+				used = append(used, key)
+				mut.Unlock()
+				if tt.limited[key] {
+					w.WriteHeader(http.StatusTooManyRequests)
+				}
+			}))
+			defer srv.Close()
+
+			r, err := NewKeyRotator(srv.URL+"/{key}", "{key}", []string{"a", "b", "c"}, http.DefaultTransport)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := &http.Client{Transport: r}
+			res, err := c.Post(srv.URL+"/{key}", "application/json", strings.NewReader("req"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+			if res.StatusCode != tt.expectStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectStatus, res.StatusCode)
+			}
+			if strings.Join(used, ",") != strings.Join(tt.expectKeys, ",") {
+				t.Fatalf("expected keys %v, got %v", tt.expectKeys, used)
+			}
+			if next := strings.Trim(r.urls[*r.current].Path, "/"); next != tt.expectNext {
+				t.Fatalf("expected key %s in use, got %s", tt.expectNext, next)
+			}
+		})
+	}
+}
+
+func TestKeyRotator_RoundTrip_QuotaErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		expectRotate bool
+	}{
+		{"result", `{"jsonrpc":"2.0","id":1,"result":"0x1"}`, false},
+		{"other error", `{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"nonce too low"}}`, false},
+		{"quota code", `{"jsonrpc":"2.0","id":1,"error":{"code":-32005,"message":"daily request count exceeded, request rate limited"}}`, true},
+		{"quota message", `{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"Your monthly quota is spent"}}`, true},
+		{"batch", `[{"jsonrpc":"2.0","id":1,"result":"0x1"},{"jsonrpc":"2.0","id":2,"error":{"code":429,"message":"too many requests"}}]`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.Trim(r.URL.Path, "/") == "a" {
+					_, _ = w.Write([]byte(tt.body))
+					return
+				}
+				_, _ = w.Write([]byte("ok"))
+			}))
+			defer srv.Close()
+
+			r, err := NewKeyRotator(srv.URL+"/{key}", "{key}", []string{"a", "b"}, http.DefaultTransport)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := (&http.Client{Transport: r}).Post(srv.URL+"/{key}", "application/json", strings.NewReader("req"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := ioutil.ReadAll(res.Body)
+			res.Body.Close()
+
+			expect := tt.body
+			if tt.expectRotate {
+				expect = "ok"
+			}
+			if string(body) != expect {
+				t.Fatalf("expected body %s, got %s", expect, body)
+			}
+		})
+	}
+}
+
+func TestNewKeyRotator_NoKeys(t *testing.T) {
+	if _, err := NewKeyRotator("https://host/{key}", "{key}", nil, http.DefaultTransport); err == nil {
+		t.Fatal("expected an error without keys")
+	}
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// logModules are the source files of each module whose logs can be made more verbose than the rest
+var logModules = map[string][]string{
+	"decoder":   {"controller/*", "usecase/txclassifier.go", "usecase/txlanes.go", "service/uniswap.go", "service/sendercache.go"},
+	"gas":       {"service/gasoracle.go", "service/presign.go"},
+	"execution": {"service/sniper.go", "service/relay.go", "service/inclusion.go", "service/fill.go", "service/revert.go"},
+}
+
+type (
+	// LogLevels of the bot, changeable while it runs. Modules can log more verbosely than the rest (eg. debugging the
+	// execution during a launch without flooding the logs with the decoding of every tx), never less.
+	LogLevels struct {
+		glog    *log.GlogHandler
+		level   log.Lvl
+		modules map[string]log.Lvl
+
+		mut *sync.Mutex
+	}
+)
+
+func NewLogLevels(h *log.GlogHandler, l log.Lvl) *LogLevels {
+	h.Verbosity(l)
+	return &LogLevels{
+		glog:    h,
+		level:   l,
+		modules: make(map[string]log.Lvl),
+		mut:     new(sync.Mutex),
+	}
+}
+
+// LogModules that can be configured
+func LogModules() []string {
+	res := make([]string, 0, len(logModules))
+	for m := range logModules {
+		res = append(res, m)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Level of the logs, besides the modules
+func (l *LogLevels) Level() log.Lvl {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+	return l.level
+}
+
+// Modules with their own level
+func (l *LogLevels) Modules() map[string]log.Lvl {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+	res := make(map[string]log.Lvl, len(l.modules))
+	for m, lvl := range l.modules {
+		res[m] = lvl
+	}
+	return res
+}
+
+func (l *LogLevels) SetLevel(lvl log.Lvl) {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+	l.level = lvl
+	l.glog.Verbosity(lvl)
+	log.Info(fmt.Sprintf("log level set to %s", lvl.AlignedString()))
+}
+
+// SetModule level. Modules at or below the level of the rest are reset to it.
+func (l *LogLevels) SetModule(module string, lvl log.Lvl) error {
+	if _, ok := logModules[module]; !ok {
+		return fmt.Errorf("unknown log module %s, expected one of %s", module, strings.Join(LogModules(), ", "))
+	}
+
+	l.mut.Lock()
+	defer l.mut.Unlock()
+	if lvl > l.level {
+		l.modules[module] = lvl
+	} else {
+		delete(l.modules, module)
+	}
+	if err := l.glog.Vmodule(l.vmodule()); err != nil {
+		return err
+	}
+	if lvl > l.level {
+		log.Info(fmt.Sprintf("log level of %s set to %s", module, lvl.AlignedString()))
+	} else {
+		log.Info(fmt.Sprintf("log level of %s reset", module))
+	}
+	return nil
+}
+
+// ResetModule to the level of the rest
+func (l *LogLevels) ResetModule(module string) error {
+	return l.SetModule(module, log.LvlCrit)
+}
+
+// vmodule rules of the glog handler for the modules
+func (l *LogLevels) vmodule() string {
+	rules := make([]string, 0)
+	for m, lvl := range l.modules {
+		for _, p := range logModules[m] {
+			rules = append(rules, fmt.Sprintf("%s=%d", p, lvl))
+		}
+	}
+	return strings.Join(rules, ",")
+}
@@ -0,0 +1,23 @@
+package service
+
+import (
+	"expvar"
+	"sync"
+)
+
+// childMap of the parent at the given key, created if it's the first time we see it. Reads don't lock, mut only
+// serializes the creations so concurrent callers never replace each other's counters.
+func childMap(mut *sync.Mutex, parent *expvar.Map, key string) *expvar.Map {
+	if v, ok := parent.Get(key).(*expvar.Map); ok {
+		return v
+	}
+
+	mut.Lock()
+	defer mut.Unlock()
+	if v, ok := parent.Get(key).(*expvar.Map); ok {
+		return v
+	}
+	v := new(expvar.Map).Init()
+	parent.Set(key, v)
+	return v
+}
@@ -0,0 +1,328 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+const (
+	// uniswapRemoveLiquidityDataLen is the call data length of removeLiquidity: selector + 7 words
+	uniswapRemoveLiquidityDataLen = 4 + 7*32
+	// uniswapRemoveLiquidityETHDataLen is the call data length of removeLiquidityETH: selector + 6 words
+	uniswapRemoveLiquidityETHDataLen = 4 + 6*32
+	// positionManagerMintDataLen is the call data length of mint of the v3 position manager: selector + 11 words
+	positionManagerMintDataLen = 4 + 11*32
+	// positionManagerDecreaseDataLen is the call data length of decreaseLiquidity of the v3 position manager:
+	// selector + 5 words
+	positionManagerDecreaseDataLen = 4 + 5*32
+	// positionManagerPositionLen is the length of the output of positions of the v3 position manager: 12 words
+	positionManagerPositionLen = 12 * 32
+)
+
+var (
+	positionManagerMint      = [4]byte{0x88, 0x31, 0x64, 0x56} // function 'mint' of the v3 position manager
+	positionManagerDecrease  = [4]byte{0x0c, 0x49, 0xcc, 0xbe} // function 'decreaseLiquidity' of the v3 position manager
+	positionManagerMulticall = [4]byte{0xac, 0x96, 0x50, 0xd8} // function 'multicall' of the v3 position manager
+	positionManagerPositions = []byte{0x99, 0xfb, 0xab, 0x88}  // function 'positions' of the v3 position manager
+
+	multicallArgs = func() abi.Arguments {
+		t, err := abi.NewType("bytes[]", "", nil)
+		if err != nil {
+			panic(err)
+		}
+		return abi.Arguments{{Type: t}}
+	}()
+)
+
+type (
+	// LiquidityMigration follows the liquidity of the target when the project migrates it between pool versions: it
+	// removes its v2 liquidity and adds it again on a v3 pool (or the other way around). The v2 watcher only sees the
+	// liquidity additions to the router, so it can't follow it.
+	// A v2 removal followed by a v3 mint within the window is a migration, and we may enter on the new pool at it. A
+	// position of a v3 pool removed as a whole moves the trigger back to the v2 pair, where the re-addition is sniped
+	// as any launch.
+	LiquidityMigration struct {
+		ethClient    liquidityMigrationETHClient
+		sniperClient liquidityMigrationSniperClient
+		alerts       liquidityMigrationAlerter
+		decisions    liquidityMigrationDecisionRecorder
+
+		positionManager common.Address
+		// enter the new pool at the migration, else we only alert about it
+		enter bool
+		// window after a v2 removal in which a v3 mint is a migration
+		window time.Duration
+		// pairedFee is the fee tier of the v3 pool between wbnb and the paired token, if it isn't wbnb
+		pairedFee uint32
+
+		target *atomic.Value // *liquidityMigrationTarget
+		// removed is when the v2 liquidity of the target was last removed (unix nanos), zero if it wasn't
+		removed *int64
+	}
+
+	liquidityMigrationTarget struct {
+		name   string
+		token  common.Address
+		paired common.Address
+		armed  bool
+	}
+
+	liquidityMigrationETHClient interface {
+		CallContract(context.Context, ethereum.CallMsg, *big.Int) ([]byte, error)
+	}
+
+	liquidityMigrationSniperClient interface {
+		SetVenue(fee, pairedFee uint32) error
+		Snipe(context.Context, *types.Transaction) error
+	}
+
+	liquidityMigrationAlerter interface {
+		Alert(domain.Alert)
+	}
+
+	liquidityMigrationDecisionRecorder interface {
+		Record(domain.Decision)
+	}
+)
+
+func NewLiquidityMigration(
+	e liquidityMigrationETHClient,
+	s liquidityMigrationSniperClient,
+	a liquidityMigrationAlerter,
+	d liquidityMigrationDecisionRecorder,
+	sn domain.Sniper,
+	pm common.Address,
+	en bool,
+	w time.Duration,
+	pf uint32,
+) *LiquidityMigration {
+
+	m := &LiquidityMigration{
+		ethClient:       e,
+		sniperClient:    s,
+		alerts:          a,
+		decisions:       d,
+		positionManager: pm,
+		enter:           en,
+		window:          w,
+		pairedFee:       pf,
+		target:          new(atomic.Value),
+		removed:         new(int64),
+	}
+	_ = m.SetTarget(sn)
+	return m
+}
+
+// SetTarget whose liquidity we follow. A removal of the previous one is forgotten.
+func (m *LiquidityMigration) SetTarget(sn domain.Sniper) error {
+	m.target.Store(&liquidityMigrationTarget{
+		name:   sn.Name,
+		token:  common.HexToAddress(sn.AddressTargetToken),
+		paired: common.HexToAddress(sn.AddressTargetPaired),
+		armed:  sn.Armed,
+	})
+	atomic.StoreInt64(m.removed, 0)
+	return nil
+}
+
+// Remove of the v2 liquidity of two tokens through the router (removeLiquidity and its permit variant)
+func (m *LiquidityMigration) Remove(ctx context.Context, tx *types.Transaction) error {
+	data := tx.Data()
+	if len(data) < uniswapRemoveLiquidityDataLen {
+		return fmt.Errorf("malformed removeLiquidity tx %s: %d bytes of data", tx.Hash().String(), len(data))
+	}
+	t := m.target.Load().(*liquidityMigrationTarget)
+	if !t.armed {
+		return nil
+	}
+	a, b := common.BytesToAddress(data[16:36]), common.BytesToAddress(data[48:68])
+	if a != t.token && b != t.token {
+		return nil
+	}
+	m.removedV2(t, tx)
+	return nil
+}
+
+// RemoveETH of the v2 liquidity of a token and eth through the router (removeLiquidityETH and its variants)
+func (m *LiquidityMigration) RemoveETH(ctx context.Context, tx *types.Transaction) error {
+	data := tx.Data()
+	if len(data) < uniswapRemoveLiquidityETHDataLen {
+		return fmt.Errorf("malformed removeLiquidityETH tx %s: %d bytes of data", tx.Hash().String(), len(data))
+	}
+	t := m.target.Load().(*liquidityMigrationTarget)
+	if !t.armed {
+		return nil
+	}
+	if common.BytesToAddress(data[16:36]) != t.token {
+		return nil
+	}
+	m.removedV2(t, tx)
+	return nil
+}
+
+// Manage of the positions of the v3 position manager: mints, decreases and multicalls of them
+func (m *LiquidityMigration) Manage(ctx context.Context, tx *types.Transaction) error {
+	t := m.target.Load().(*liquidityMigrationTarget)
+	if !t.armed {
+		return nil
+	}
+	return m.manage(ctx, t, tx, tx.Data())
+}
+
+// manage the call to the position manager, unwrapping it if it's a multicall
+func (m *LiquidityMigration) manage(ctx context.Context, t *liquidityMigrationTarget, tx *types.Transaction, data []byte) error {
+	if len(data) < 4 {
+		return nil
+	}
+	var selector [4]byte
+	copy(selector[:], data[:4])
+
+	switch selector {
+	case positionManagerMint:
+		return m.mint(ctx, t, tx, data)
+	case positionManagerDecrease:
+		return m.decrease(ctx, t, tx, data)
+	case positionManagerMulticall:
+		calls, err := multicallArgs.Unpack(data[4:])
+		if err != nil {
+			return fmt.Errorf("malformed multicall tx %s: %s", tx.Hash().String(), err)
+		}
+		for _, c := range calls[0].([][]byte) {
+			if err := m.manage(ctx, t, tx, c); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// mint of a v3 position. If it's on the pool of the target after its v2 liquidity was removed, the liquidity migrated.
+func (m *LiquidityMigration) mint(ctx context.Context, t *liquidityMigrationTarget, tx *types.Transaction, data []byte) error {
+	if len(data) < positionManagerMintDataLen {
+		return fmt.Errorf("malformed mint tx %s: %d bytes of data", tx.Hash().String(), len(data))
+	}
+	data = data[4:]
+	token0, token1 := common.BytesToAddress(data[12:32]), common.BytesToAddress(data[44:64])
+	fee := uint32(new(big.Int).SetBytes(data[64:96]).Uint64())
+	if token0 != t.token && token1 != t.token {
+		return nil
+	}
+
+	removed := atomic.LoadInt64(m.removed)
+	if removed == 0 || time.Since(time.Unix(0, removed)) > m.window {
+		log.Debug(fmt.Sprintf("v3 mint %s of %s, but its v2 liquidity wasn't removed lately", tx.Hash().Hex(), t.token.Hex()))
+		return nil
+	}
+	if !atomic.CompareAndSwapInt64(m.removed, removed, 0) {
+		return nil // another mint of the migration got it first
+	}
+
+	pooled := token0 == t.paired || token1 == t.paired
+	m.alert(t, tx, fmt.Sprintf(
+		"liquidity of %s migrated from v2 to the v3 pool %s / %s with fee tier %d (entering: %t)",
+		t.token.Hex(), token0.Hex(), token1.Hex(), fee, m.enter && pooled,
+	))
+	if !m.enter {
+		return nil
+	}
+	if !pooled {
+		log.Warn(fmt.Sprintf("liquidity of %s migrated to a pool without %s, we can't enter it", t.token.Hex(), t.paired.Hex()))
+		return nil
+	}
+
+	m.decide(t, tx, domain.DecisionCandidateSeen, "", fmt.Sprintf("v3 mint, fee tier %d", fee))
+	if err := m.sniperClient.SetVenue(fee, m.pairedFee); err != nil {
+		m.decide(t, tx, domain.DecisionSnipeFailed, "", err.Error())
+		return fmt.Errorf("error moving %s to the v3 pool: %s", t.name, err)
+	}
+	log.Info(fmt.Sprintf("snipe executed for migration tx: %s", tx.Hash().String()))
+	if err := m.sniperClient.Snipe(ctx, tx); err != nil {
+		var skip *domain.SkipError
+		if errors.As(err, &skip) {
+			m.decide(t, tx, domain.DecisionRejected, skip.Reason, skip.Detail)
+			return nil
+		}
+		m.decide(t, tx, domain.DecisionSnipeFailed, "", err.Error())
+		return err
+	}
+	m.decide(t, tx, domain.DecisionSniped, "", "migration")
+	return nil
+}
+
+// decrease of a v3 position. If it removes a whole position of the pool of the target, the liquidity may be migrating
+// back to v2: the trigger moves back to the v2 pair, so its re-addition is sniped there.
+func (m *LiquidityMigration) decrease(ctx context.Context, t *liquidityMigrationTarget, tx *types.Transaction, data []byte) error {
+	if len(data) < positionManagerDecreaseDataLen {
+		return fmt.Errorf("malformed decreaseLiquidity tx %s: %d bytes of data", tx.Hash().String(), len(data))
+	}
+	data = data[4:]
+	tokenID := data[0:32]
+	liquidity := new(big.Int).SetBytes(data[32:64])
+
+	call := append(append([]byte{}, positionManagerPositions...), tokenID...)
+	pos, err := m.ethClient.CallContract(ctx, ethereum.CallMsg{To: &m.positionManager, Data: call}, nil)
+	if err != nil {
+		return fmt.Errorf("error getting v3 position %s: %s", new(big.Int).SetBytes(tokenID).String(), err)
+	}
+	if len(pos) < positionManagerPositionLen {
+		return fmt.Errorf("malformed v3 position %s: %d bytes", new(big.Int).SetBytes(tokenID).String(), len(pos))
+	}
+	token0, token1 := common.BytesToAddress(pos[76:96]), common.BytesToAddress(pos[108:128])
+	if token0 != t.token && token1 != t.token {
+		return nil
+	}
+	if liquidity.Cmp(new(big.Int).SetBytes(pos[224:256])) < 0 {
+		return nil // a partial decrease (eg. an lp taking profits), the pool stays
+	}
+
+	m.alert(t, tx, fmt.Sprintf(
+		"a v3 position of %s was removed, watching for its liquidity on v2 (entering: %t)", t.token.Hex(), m.enter,
+	))
+	if !m.enter {
+		return nil
+	}
+	if err := m.sniperClient.SetVenue(0, 0); err != nil {
+		return fmt.Errorf("error moving %s back to the v2 pair: %s", t.name, err)
+	}
+	return nil
+}
+
+// removedV2 liquidity of the target, a v3 mint within the window is a migration
+func (m *LiquidityMigration) removedV2(t *liquidityMigrationTarget, tx *types.Transaction) {
+	atomic.StoreInt64(m.removed, time.Now().UnixNano())
+	m.alert(t, tx, fmt.Sprintf(
+		"v2 liquidity of %s is being removed, watching for it on v3 for %s", t.token.Hex(), m.window,
+	))
+}
+
+func (m *LiquidityMigration) alert(t *liquidityMigrationTarget, tx *types.Transaction, msg string) {
+	log.Info(msg)
+	m.alerts.Alert(domain.Alert{
+		Kind:    domain.AlertMigration,
+		Target:  t.name,
+		Tx:      tx.Hash().Hex(),
+		Message: msg,
+	})
+}
+
+func (m *LiquidityMigration) decide(t *liquidityMigrationTarget, tx *types.Transaction, k domain.DecisionKind, r domain.SkipReason, detail string) {
+	m.decisions.Record(domain.Decision{
+		Kind:   k,
+		Target: t.name,
+		Tx:     tx.Hash().Hex(),
+		Reason: r,
+		Detail: detail,
+	})
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type (
+	fakeMigrationSniper struct {
+		venues []triggerVenue
+		sniped []common.Hash
+	}
+
+	fakeMigrationETHClient struct {
+		position []byte
+	}
+
+	fakeMigrationRecorder struct {
+		alerts    []domain.Alert
+		decisions []domain.Decision
+	}
+)
+
+func (f *fakeMigrationSniper) SetVenue(fee, pairedFee uint32) error {
+	f.venues = append(f.venues, triggerVenue{fee: fee, pairedFee: pairedFee})
+	return nil
+}
+
+func (f *fakeMigrationSniper) Snipe(_ context.Context, tx *types.Transaction) error {
+	f.sniped = append(f.sniped, tx.Hash())
+	return nil
+}
+
+func (f *fakeMigrationETHClient) CallContract(context.Context, ethereum.CallMsg, *big.Int) ([]byte, error) {
+	return f.position, nil
+}
+
+func (f *fakeMigrationRecorder) Alert(a domain.Alert) {
+	f.alerts = append(f.alerts, a)
+}
+
+func (f *fakeMigrationRecorder) Record(d domain.Decision) {
+	f.decisions = append(f.decisions, d)
+}
+
+func newMigrationTestTxs() (removeETH, mint *types.Transaction) {
+	one := big.NewInt(1)
+	removeETH = newBenchTx(
+		[]byte{0x02, 0x75, 0x1c, 0xec},
+		benchTokenA, one, one, one, benchTo, big.NewInt(1700000000),
+	)
+	mint = newBenchTx(
+		positionManagerMint[:],
+		benchTokenB, benchTokenA, big.NewInt(2500), one, one, one, one, one, one, benchTo, big.NewInt(1700000000),
+	)
+	return removeETH, mint
+}
+
+func newMigrationTest(enter bool, e liquidityMigrationETHClient) (*LiquidityMigration, *fakeMigrationSniper, *fakeMigrationRecorder) {
+	s := new(fakeMigrationSniper)
+	r := new(fakeMigrationRecorder)
+	m := NewLiquidityMigration(e, s, r, r, domain.Sniper{
+		Name:                "test",
+		AddressTargetToken:  benchTokenA.Hex(),
+		AddressTargetPaired: benchTokenB.Hex(),
+		Armed:               true,
+	}, benchTo, enter, time.Hour, 500)
+	return m, s, r
+}
+
+func TestLiquidityMigration_Mint(t *testing.T) {
+	removeETH, mint := newMigrationTestTxs()
+	multicall, err := multicallArgs.Pack([][]byte{mint.Data()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapped := types.NewTransaction(1, benchTo, big.NewInt(0), 500000, big.NewInt(5000000000), append(positionManagerMulticall[:], multicall...))
+
+	tests := []struct {
+		name         string
+		enter        bool
+		removed      bool
+		tx           *types.Transaction
+		expectAlerts int
+		expectSniped bool
+	}{
+		{"not migrating", true, false, mint, 0, false},
+		{"migrating", true, true, mint, 2, true},
+		{"migrating in a multicall", true, true, wrapped, 2, true},
+		{"migrating without entering", false, true, mint, 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, s, r := newMigrationTest(tt.enter, nil)
+			if tt.removed {
+				if err := m.RemoveETH(context.Background(), removeETH); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := m.Manage(context.Background(), tt.tx); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(r.alerts) != tt.expectAlerts {
+				t.Fatalf("expected %d alerts, got %d", tt.expectAlerts, len(r.alerts))
+			}
+			if sniped := len(s.sniped) > 0; sniped != tt.expectSniped {
+				t.Fatalf("expected sniped %t, got %t", tt.expectSniped, sniped)
+			}
+			if tt.expectSniped && s.venues[0] != (triggerVenue{fee: 2500, pairedFee: 500}) {
+				t.Fatalf("expected the v3 venue, got %+v", s.venues[0])
+			}
+		})
+	}
+}
+
+func TestLiquidityMigration_MintOnce(t *testing.T) {
+	removeETH, mint := newMigrationTestTxs()
+	m, s, _ := newMigrationTest(true, nil)
+	if err := m.RemoveETH(context.Background(), removeETH); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := m.Manage(context.Background(), mint); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(s.sniped) != 1 {
+		t.Fatalf("expected a single snipe of the migration, got %d", len(s.sniped))
+	}
+}
+
+func TestLiquidityMigration_Decrease(t *testing.T) {
+	position := make([]byte, positionManagerPositionLen)
+	copy(position[64:96], newBenchWord(benchTokenB))
+	copy(position[96:128], newBenchWord(benchTokenA))
+	copy(position[224:256], newBenchWord(big.NewInt(100)))
+
+	tests := []struct {
+		name        string
+		liquidity   int64
+		expectVenue bool
+	}{
+		{"whole position", 100, true},
+		{"partial", 40, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, s, _ := newMigrationTest(true, &fakeMigrationETHClient{position: position})
+			one := big.NewInt(1)
+			tx := newBenchTx(positionManagerDecrease[:], big.NewInt(7), big.NewInt(tt.liquidity), one, one, big.NewInt(1700000000))
+			if err := m.Manage(context.Background(), tx); err != nil {
+				t.Fatal(err)
+			}
+			if moved := len(s.venues) > 0 && s.venues[0] == (triggerVenue{}); moved != tt.expectVenue {
+				t.Fatalf("expected moving back to v2 %t, got %t", tt.expectVenue, moved)
+			}
+		})
+	}
+}
+
+func TestNewTriggerCalldata(t *testing.T) {
+	sn := domain.Sniper{AddressTargetPaired: benchTokenB.Hex(), AddressTargetToken: benchTokenA.Hex()}
+	revealed := sn
+	revealed.Reveal = &domain.SniperReveal{AmountIn: big.NewInt(1), AmountOutMin: big.NewInt(2)}
+
+	tests := []struct {
+		name     string
+		sn       domain.Sniper
+		venue    triggerVenue
+		selector []byte
+		words    int
+	}{
+		{"v2", sn, triggerVenue{}, triggerSmartContract, 0},
+		{"v2 revealed", revealed, triggerVenue{}, triggerRevealSmartContract, 5},
+		{"v3", sn, triggerVenue{fee: 2500, pairedFee: 500}, triggerV3SmartContract, 2},
+		{"v3 revealed", revealed, triggerVenue{fee: 2500, pairedFee: 500}, triggerRevealV3SmartContract, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := newTriggerCalldata(tt.sn, tt.venue)
+			if string(data[:4]) != string(tt.selector) || len(data) != 4+tt.words*common.HashLength {
+				t.Fatalf("expected %x with %d words, got %x", tt.selector, tt.words, data)
+			}
+			if tt.venue.fee > 0 && new(big.Int).SetBytes(data[len(data)-64:len(data)-32]).Uint64() != uint64(tt.venue.fee) {
+				t.Fatalf("expected fee tier %d in %x", tt.venue.fee, data)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// Reconcile the nonces of the swarm against the chain. Our local nonces drift when a reorg drops txs we already
+// counted, a sent tx never makes it out of the mempool or a bee is used from somewhere else, and from then on every
+// snipe fails with nonce too low/high. If any nonce moved, the presigned txs are signed again and the last round is
+// forgotten, as its nonces may be taken or free by now.
+//
+// Reconcile is concurrently safe
+func (c *Sniper) Reconcile(ctx context.Context) error {
+	c.sniping.Lock()
+	defer c.sniping.Unlock()
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.reconcile(ctx)
+}
+
+// RunReconcile of the swarm every given interval until the context is done
+func (c *Sniper) RunReconcile(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+		if err := c.Reconcile(ctx); err != nil {
+			log.Error(fmt.Sprintf("error reconciling nonces of %s: %s", c.sniperName, err))
+		}
+	}
+}
+
+// reconcile the nonces of the swarm. Must be called holding the lock.
+func (c *Sniper) reconcile(ctx context.Context) error {
+	changed := false
+	for _, b := range c.swarm {
+		addr := crypto.PubkeyToAddress(b.RawPK.PublicKey)
+		pending, err := c.ethClient.PendingNonceAt(ctx, addr)
+		if err != nil {
+			return fmt.Errorf("error getting pending nonce of bee %s: %s", addr.Hex(), err)
+		}
+		confirmed, err := c.ethClient.NonceAt(ctx, addr, nil)
+		if err != nil {
+			return fmt.Errorf("error getting confirmed nonce of bee %s: %s", addr.Hex(), err)
+		}
+
+		nonce := pending
+		if confirmed > nonce { // the node may not have caught up its pool with the last block yet
+			nonce = confirmed
+		}
+		if nonce == b.PendingNonce {
+			continue
+		}
+		log.Warn(fmt.Sprintf("bee %s nonce out of sync, moving it from %d to %d", addr.Hex(), b.PendingNonce, nonce))
+		b.PendingNonce = nonce
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+	c.last = nil
+	return c.presignSwarm()
+}
+
+// flagNonceError of a sent tx, if it's one, so the swarm is reconciled after the round
+func (c *Sniper) flagNonceError(err error) {
+	if isNonceError(err) {
+		atomic.StoreInt32(c.staleNonces, 1)
+	}
+}
+
+// reconcileStale nonces of the swarm, if a bee hit a nonce error. Must be called holding the lock.
+func (c *Sniper) reconcileStale(ctx context.Context) {
+	if atomic.LoadInt32(c.staleNonces) == 0 {
+		return
+	}
+	if err := c.reconcile(ctx); err != nil {
+		log.Error(fmt.Sprintf("error reconciling nonces after a nonce error: %s", err))
+		return // we try again after the next round
+	}
+	atomic.StoreInt32(c.staleNonces, 0)
+}
+
+// isNonceError if the node rejected the tx for its nonce
+func isNonceError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "nonce too low") || strings.Contains(msg, "nonce too high")
+}
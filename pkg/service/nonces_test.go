@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+type noncesTestClient struct {
+	sniperETHClient
+
+	pending, confirmed uint64
+	err                error
+}
+
+func (c noncesTestClient) PendingNonceAt(context.Context, common.Address) (uint64, error) {
+	return c.pending, c.err
+}
+
+func (c noncesTestClient) NonceAt(context.Context, common.Address, *big.Int) (uint64, error) {
+	return c.confirmed, c.err
+}
+
+func TestSniper_Reconcile(t *testing.T) {
+	tests := []struct {
+		name        string
+		local       uint64
+		client      noncesTestClient
+		expect      uint64
+		expectReset bool
+		expectErr   bool
+	}{
+		{"in sync", 5, noncesTestClient{pending: 5, confirmed: 4}, 5, false, false},
+		{"dropped txs", 9, noncesTestClient{pending: 5, confirmed: 5}, 5, true, false},
+		{"used elsewhere", 5, noncesTestClient{pending: 7, confirmed: 6}, 7, true, false},
+		{"lagging pool", 5, noncesTestClient{pending: 4, confirmed: 6}, 6, true, false},
+		{"node error", 5, noncesTestClient{err: errors.New("boom")}, 5, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pk, err := crypto.GenerateKey()
+			if err != nil {
+				t.Fatal(err)
+			}
+			bee := NewBee(pk, tt.local)
+			last := &snipeRound{nonces: []uint64{tt.local}}
+			s := &Sniper{
+				mut:         new(sync.Mutex),
+				sniping:     new(sync.Mutex),
+				ethClient:   tt.client,
+				swarm:       []*Bee{bee},
+				presigned:   newPresignedTxs(),
+				last:        last,
+				staleNonces: new(int32),
+			}
+
+			err = s.Reconcile(context.Background())
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if bee.PendingNonce != tt.expect {
+				t.Fatalf("expected nonce %d, got %d", tt.expect, bee.PendingNonce)
+			}
+			if (s.last == nil) != tt.expectReset {
+				t.Fatalf("expected last round reset %t, got %v", tt.expectReset, s.last)
+			}
+		})
+	}
+}
+
+func TestSniper_ReconcileStale(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bee := NewBee(pk, 9)
+	s := &Sniper{
+		mut:         new(sync.Mutex),
+		sniping:     new(sync.Mutex),
+		ethClient:   noncesTestClient{pending: 5, confirmed: 5},
+		swarm:       []*Bee{bee},
+		presigned:   newPresignedTxs(),
+		staleNonces: new(int32),
+	}
+
+	s.flagNonceError(errors.New("insufficient funds for gas * price + value"))
+	s.reconcileStale(context.Background())
+	if bee.PendingNonce != 9 {
+		t.Fatalf("expected no reconcile without a nonce error, got nonce %d", bee.PendingNonce)
+	}
+
+	s.flagNonceError(errors.New("nonce too high"))
+	s.reconcileStale(context.Background())
+	if bee.PendingNonce != 5 {
+		t.Fatalf("expected nonce 5 after a nonce error, got %d", bee.PendingNonce)
+	}
+	if *s.staleNonces != 0 {
+		t.Fatal("expected the nonce error to be cleared")
+	}
+}
+
+func TestIsNonceError(t *testing.T) {
+	tests := []struct {
+		err    error
+		expect bool
+	}{
+		{errors.New("nonce too low"), true},
+		{errors.New("Nonce too high"), true},
+		{errors.New("replacement transaction underpriced"), false},
+		{errors.New("already known"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			if got := isNonceError(tt.err); got != tt.expect {
+				t.Fatalf("expected %t, got %t", tt.expect, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,434 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+	"github.com/saantiaguilera/liquidity-sniper/third_party/uniswap"
+)
+
+const (
+	// panicApproveGasLimit and panicSellGasLimit of the txs of a panic sell. Both are sent at once, so the sell can't
+	// be estimated until its approval is mined.
+	panicApproveGasLimit = uint64(100000)
+	panicSellGasLimit    = uint64(600000)
+	// panicSellDeadlineBlocks of the sells, past them they revert instead of selling at whatever price is left by then
+	panicSellDeadlineBlocks = 100
+)
+
+var (
+	erc20ABI  = mustParseABI(uniswap.IERC20ABI)
+	routerABI = mustParseABI(uniswap.IUniswapV2Router02ABI)
+)
+
+type (
+	// PanicSeller market sells the tokens held by our wallets, for "everything is rugging" moments. Sells don't expect
+	// any min amount out and pay a multiple of the network median gas, so they land before anything else we could do.
+	// Only the wallets we hold the keys of are sold: the bees of the snipers and the panic wallets.
+	PanicSeller struct {
+		gasOracle panicSellerGasOracle
+		receipts  panicSellerReceipts
+		senders   []walletSender
+
+		router        common.Address
+		wbnb          common.Address
+		gasMultiplier float64
+		timing        domain.Timing
+	}
+
+	panicSellerGasOracle interface {
+		Median(context.Context) (*big.Int, error)
+	}
+
+	panicSellerReceipts interface {
+		TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	}
+
+	// walletSender sends calls as one of the wallets it holds the keys of, reporting false if it doesn't hold the
+	// ones of the owner. Calls are sent one after the other with the next nonces of the wallet, stopping at the first
+	// failure, and the hashes of the sent ones are returned. signAs signs them the same way without sending them, so
+	// they are only valid until the wallet sends anything else.
+	walletSender interface {
+		sendAs(ctx context.Context, owner common.Address, calls []walletCall) ([]common.Hash, bool, error)
+		signAs(ctx context.Context, owner common.Address, calls []walletCall) ([]*types.Transaction, bool, error)
+	}
+
+	// walletCall of a contract made by one of our wallets
+	walletCall struct {
+		to       common.Address
+		data     []byte
+		gasLimit uint64
+		fees     txFees
+	}
+
+	// PanicWallets are wallets only used to panic sell (eg. the admin, which gets the sniped tokens). Nothing else in
+	// the bot sends from them, so their nonces are the pending ones of the chain.
+	PanicWallets struct {
+		ethClient   panicWalletsETHClient
+		keys        []*ecdsa.PrivateKey
+		chainID     *big.Int
+		dynamicFees bool
+		mut         *sync.Mutex
+	}
+
+	panicWalletsETHClient interface {
+		PendingNonceAt(context.Context, common.Address) (uint64, error)
+		SendTransaction(context.Context, *types.Transaction) error
+	}
+)
+
+func NewPanicSeller(
+	o panicSellerGasOracle,
+	r panicSellerReceipts,
+	w *PanicWallets,
+	s []*Sniper,
+	router, wbnb common.Address,
+	gm float64,
+	tm domain.Timing,
+) *PanicSeller {
+
+	senders := make([]walletSender, 0, len(s)+1)
+	for _, sn := range s {
+		senders = append(senders, sn)
+	}
+	if w != nil {
+		senders = append(senders, w)
+	}
+	return &PanicSeller{
+		gasOracle:     o,
+		receipts:      r,
+		senders:       senders,
+		router:        router,
+		wbnb:          wbnb,
+		gasMultiplier: gm,
+		timing:        tm,
+	}
+}
+
+func NewPanicWallets(e panicWalletsETHClient, chainID *big.Int, dynamicFees bool, keys ...*ecdsa.PrivateKey) *PanicWallets {
+	return &PanicWallets{
+		ethClient:   e,
+		keys:        keys,
+		chainID:     chainID,
+		dynamicFees: dynamicFees,
+		mut:         new(sync.Mutex),
+	}
+}
+
+// Sell the balances of the token held by each wallet (by address) into BNB, through the paired token if it isn't
+// WBNB. A zero paired token sells straight into WBNB. Each wallet approves the router and sells, getting the BNB.
+func (s *PanicSeller) Sell(ctx context.Context, token, paired common.Address, balances map[string]*big.Int) []domain.Sale {
+	wallets := make([]string, 0, len(balances))
+	for w, b := range balances {
+		if b != nil && b.Sign() > 0 {
+			wallets = append(wallets, w)
+		}
+	}
+	sort.Strings(wallets)
+
+	res := make([]domain.Sale, 0, len(wallets))
+	fees, feesErr := s.fees(ctx)
+	for _, w := range wallets {
+		sale := domain.Sale{Token: token.Hex(), Wallet: w, Amount: balances[w]}
+		if feesErr != nil {
+			sale.Error = feesErr.Error()
+			res = append(res, sale)
+			continue
+		}
+
+		owner := common.HexToAddress(w)
+		calls, err := s.calls(token, paired, owner, balances[w], fees, time.Now().Add(s.timing.Blocks(panicSellDeadlineBlocks)))
+		if err != nil {
+			sale.Error = err.Error()
+			res = append(res, sale)
+			continue
+		}
+		hashes, err := s.sendAs(ctx, owner, calls)
+		if err != nil {
+			sale.Error = err.Error()
+		}
+		if len(hashes) == len(calls) {
+			sale.Tx = hashes[len(hashes)-1].Hex()
+		}
+		res = append(res, sale)
+	}
+	return res
+}
+
+// Proceeds of a sent sale once it's mined, in the paired token the token was sold for (before it's swapped into BNB).
+// A zero paired token is WBNB. We wait for it until the deadline of the sell.
+func (s *PanicSeller) Proceeds(ctx context.Context, sale domain.Sale, paired common.Address) (*big.Int, error) {
+	if len(sale.Tx) == 0 {
+		return nil, fmt.Errorf("sale of %s by %s wasn't sent", sale.Token, sale.Wallet)
+	}
+	if paired == (common.Address{}) {
+		paired = s.wbnb
+	}
+	ctx, canc := context.WithTimeout(ctx, s.timing.Blocks(panicSellDeadlineBlocks))
+	defer canc()
+
+	tk := time.NewTicker(s.timing.Poll())
+	defer tk.Stop()
+
+	hash := common.HexToHash(sale.Tx)
+	for {
+		r, err := s.receipts.TransactionReceipt(ctx, hash)
+		switch {
+		case err == nil && r.Status == types.ReceiptStatusFailed:
+			return nil, fmt.Errorf("sale %s reverted", sale.Tx)
+		case err == nil:
+			return saleProceeds(r.Logs, common.HexToAddress(sale.Token), paired), nil
+		case !errors.Is(err, ethereum.NotFound):
+			log.Warn(fmt.Sprintf("error getting the receipt of sale %s: %s", sale.Tx, err))
+		}
+
+		select {
+		case <-tk.C:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("sale %s not mined: %s", sale.Tx, ctx.Err())
+		}
+	}
+}
+
+// saleProceeds of the paired token in the logs of a sell: what the pair of the token sent of it. Taxed tokens may swap
+// their fees back in the same tx through the same pair, but they do it while we transfer them the token, so our swap
+// is the last one.
+func saleProceeds(logs []*types.Log, token, paired common.Address) *big.Int {
+	received := make(map[common.Address]bool)
+	for _, l := range logs {
+		if l.Address == token && len(l.Topics) == 3 && l.Topics[0] == erc20TransferTopic {
+			received[common.BytesToAddress(l.Topics[2].Bytes())] = true
+		}
+	}
+	for i := len(logs) - 1; i >= 0; i-- {
+		l := logs[i]
+		if l.Address != paired || len(l.Topics) != 3 || l.Topics[0] != erc20TransferTopic {
+			continue
+		}
+		if received[common.BytesToAddress(l.Topics[1].Bytes())] {
+			return new(big.Int).SetBytes(l.Data)
+		}
+	}
+	return new(big.Int)
+}
+
+// Presign the sells of the balances of the token the same way Sell sends them, without sending them, and valid until
+// the deadline. Each of them is the approval and the sell of a wallet, in order. Wallets we have no keys of are skipped.
+func (s *PanicSeller) Presign(
+	ctx context.Context,
+	token, paired common.Address,
+	balances map[string]*big.Int,
+	deadline time.Time,
+) ([][]*types.Transaction, error) {
+
+	fees, err := s.fees(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([][]*types.Transaction, 0, len(balances))
+	for w, b := range balances {
+		if b == nil || b.Sign() <= 0 {
+			continue
+		}
+		owner := common.HexToAddress(w)
+		calls, err := s.calls(token, paired, owner, b, fees, deadline)
+		if err != nil {
+			return nil, err
+		}
+		for _, sender := range s.senders {
+			txs, ok, err := sender.signAs(ctx, owner, calls)
+			if !ok {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, txs)
+			break
+		}
+	}
+	return res, nil
+}
+
+// sendAs the owner through whichever sender holds its keys
+func (s *PanicSeller) sendAs(ctx context.Context, owner common.Address, calls []walletCall) ([]common.Hash, error) {
+	for _, sender := range s.senders {
+		hashes, ok, err := sender.sendAs(ctx, owner, calls)
+		if !ok {
+			continue
+		}
+		return hashes, err
+	}
+	return nil, fmt.Errorf("no keys of wallet %s, it has to be sold by hand", owner.Hex())
+}
+
+// calls of the owner to sell the amount of the token: approving the router and selling through it until the deadline
+func (s *PanicSeller) calls(
+	token, paired, owner common.Address,
+	amount *big.Int,
+	fees txFees,
+	deadline time.Time,
+) ([]walletCall, error) {
+
+	path := []common.Address{token, s.wbnb}
+	if paired != (common.Address{}) && paired != s.wbnb {
+		path = []common.Address{token, paired, s.wbnb}
+	}
+
+	approve, err := erc20ABI.Pack("approve", s.router, amount)
+	if err != nil {
+		return nil, fmt.Errorf("error packing approval: %s", err)
+	}
+	sell, err := routerABI.Pack(
+		"swapExactTokensForETHSupportingFeeOnTransferTokens",
+		amount,
+		new(big.Int), // whatever we get
+		path,
+		owner,
+		big.NewInt(deadline.Unix()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error packing sell: %s", err)
+	}
+	return []walletCall{
+		{to: token, data: approve, gasLimit: panicApproveGasLimit, fees: fees},
+		{to: s.router, data: sell, gasLimit: panicSellGasLimit, fees: fees},
+	}, nil
+}
+
+// fees of the sells, the multiplier of the network median gas price
+func (s *PanicSeller) fees(ctx context.Context) (txFees, error) {
+	median, err := s.gasOracle.Median(ctx)
+	if err != nil {
+		return txFees{}, fmt.Errorf("error getting network gas price median: %s", err)
+	}
+	price, _ := new(big.Float).Mul(new(big.Float).SetInt(median), big.NewFloat(s.gasMultiplier)).Int(nil)
+	if price.Cmp(median) < 0 {
+		price = median
+	}
+	return txFees{Tip: price, Cap: price}, nil
+}
+
+func (w *PanicWallets) sendAs(ctx context.Context, owner common.Address, calls []walletCall) ([]common.Hash, bool, error) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	txs, ok, err := w.signAs(ctx, owner, calls)
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+	hashes := make([]common.Hash, 0, len(txs))
+	for _, tx := range txs {
+		if err := w.ethClient.SendTransaction(ctx, tx); err != nil {
+			return hashes, true, fmt.Errorf("error sending tx of %s: %s", owner.Hex(), err)
+		}
+		hashes = append(hashes, tx.Hash())
+	}
+	return hashes, true, nil
+}
+
+func (w *PanicWallets) signAs(ctx context.Context, owner common.Address, calls []walletCall) ([]*types.Transaction, bool, error) {
+	for _, k := range w.keys {
+		if crypto.PubkeyToAddress(k.PublicKey) != owner {
+			continue
+		}
+		nonce, err := w.ethClient.PendingNonceAt(ctx, owner)
+		if err != nil {
+			return nil, true, fmt.Errorf("error getting nonce of %s: %s", owner.Hex(), err)
+		}
+		txs, err := signCalls(k, w.chainID, w.dynamicFees, nonce, calls)
+		return txs, true, err
+	}
+	return nil, false, nil
+}
+
+// sendAs the bee of the swarm, holding the lock so the calls never collide with the nonces of a snipe. The presigned
+// snipes are signed again for the nonces left.
+func (c *Sniper) sendAs(ctx context.Context, owner common.Address, calls []walletCall) ([]common.Hash, bool, error) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	b := c.beeOf(owner)
+	if b == nil {
+		return nil, false, nil
+	}
+	txs, err := signCalls(b.RawPK, c.sniperChainID, c.dynamicFees, b.PendingNonce, calls)
+	if err != nil {
+		return nil, true, err
+	}
+	hashes := make([]common.Hash, 0, len(txs))
+	for _, tx := range txs {
+		if err := c.ethClient.SendTransaction(ctx, tx); err != nil {
+			c.flagNonceError(err)
+			return hashes, true, fmt.Errorf("error sending tx of bee %s: %s", owner.Hex(), err)
+		}
+		c.advanceNonce(b, tx.Nonce())
+		hashes = append(hashes, tx.Hash())
+	}
+	return hashes, true, nil
+}
+
+// signAs the bee of the swarm with its pending nonces, holding the lock. A snipe of the bee invalidates them.
+func (c *Sniper) signAs(_ context.Context, owner common.Address, calls []walletCall) ([]*types.Transaction, bool, error) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	b := c.beeOf(owner)
+	if b == nil {
+		return nil, false, nil
+	}
+	txs, err := signCalls(b.RawPK, c.sniperChainID, c.dynamicFees, b.PendingNonce, calls)
+	return txs, true, err
+}
+
+// beeOf the swarm with the address, nil if there's none. Must be called holding the lock.
+func (c *Sniper) beeOf(addr common.Address) *Bee {
+	for _, b := range c.swarm {
+		if crypto.PubkeyToAddress(b.RawPK.PublicKey) == addr {
+			return b
+		}
+	}
+	return nil
+}
+
+// signCalls of the key one after the other, from the given nonce
+func signCalls(k *ecdsa.PrivateKey, chainID *big.Int, dynamicFees bool, nonce uint64, calls []walletCall) ([]*types.Transaction, error) {
+	txs := make([]*types.Transaction, len(calls))
+	for i, call := range calls {
+		to := call.to
+		tx, err := types.SignNewTx(
+			k,
+			types.LatestSignerForChainID(chainID),
+			newTxData(dynamicFees, chainID, nonce+uint64(i), call.fees, call.gasLimit, &to, txValue, call.data, nil),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error signing tx of %s: %s", crypto.PubkeyToAddress(k.PublicKey).Hex(), err)
+		}
+		txs[i] = tx
+	}
+	return txs, nil
+}
+
+func mustParseABI(s string) abi.ABI {
+	a, err := abi.JSON(strings.NewReader(s))
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type (
+	fakePanicOracle struct {
+		median *big.Int
+	}
+
+	fakeWalletSender struct {
+		owner common.Address
+		err   error
+		calls []walletCall
+	}
+)
+
+func (f fakePanicOracle) Median(context.Context) (*big.Int, error) {
+	return f.median, nil
+}
+
+func (f *fakeWalletSender) sendAs(_ context.Context, owner common.Address, calls []walletCall) ([]common.Hash, bool, error) {
+	if owner != f.owner {
+		return nil, false, nil
+	}
+	f.calls = append(f.calls, calls...)
+	if f.err != nil {
+		return []common.Hash{{0x1}}, true, f.err
+	}
+	return []common.Hash{{0x1}, {0x2}}, true, nil
+}
+
+func (f *fakeWalletSender) signAs(_ context.Context, owner common.Address, calls []walletCall) ([]*types.Transaction, bool, error) {
+	if owner != f.owner {
+		return nil, false, nil
+	}
+	txs := make([]*types.Transaction, len(calls))
+	for i, c := range calls {
+		txs[i] = types.NewTransaction(uint64(i), c.to, new(big.Int), c.gasLimit, c.fees.Cap, c.data)
+	}
+	return txs, true, nil
+}
+
+func TestPanicSeller_Sell(t *testing.T) {
+	wbnb := common.HexToAddress("0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c")
+	router := common.HexToAddress("0x10ED43C718714eb63d5aA57B78B54704E256024E")
+	sell := routerABI.Methods["swapExactTokensForETHSupportingFeeOnTransferTokens"]
+
+	tests := []struct {
+		name        string
+		paired      common.Address
+		err         error
+		wallet      common.Address
+		expectTx    bool
+		expectError bool
+		expectPath  int
+	}{
+		{"through wbnb", wbnb, nil, benchTo, true, false, 2},
+		{"without paired", common.Address{}, nil, benchTo, true, false, 2},
+		{"through the paired", fillPair, nil, benchTo, true, false, 3},
+		{"failing", wbnb, errors.New("nope"), benchTo, false, true, 2},
+		{"without keys", wbnb, nil, fillOther, false, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &fakeWalletSender{owner: benchTo, err: tt.err}
+			s := NewPanicSeller(fakePanicOracle{median: big.NewInt(5)}, nil, nil, nil, router, wbnb, 3, domain.NewTiming(big.NewInt(56), 0))
+			s.senders = []walletSender{sender}
+
+			sales := s.Sell(context.Background(), benchTokenA, tt.paired, map[string]*big.Int{
+				tt.wallet.Hex(): big.NewInt(100),
+				fillBeeA.Hex():  big.NewInt(0), // nothing to sell
+			})
+			if len(sales) != 1 {
+				t.Fatalf("expected a single sale, got %+v", sales)
+			}
+			if (len(sales[0].Tx) > 0) != tt.expectTx || (len(sales[0].Error) > 0) != tt.expectError {
+				t.Fatalf("expected tx %t and error %t, got %+v", tt.expectTx, tt.expectError, sales[0])
+			}
+			if tt.expectPath == 0 {
+				return
+			}
+
+			if len(sender.calls) < 2 || sender.calls[1].to != router || sender.calls[0].to != benchTokenA {
+				t.Fatalf("expected approving and selling through the router, got %+v", sender.calls)
+			}
+			if sender.calls[1].fees.Cap.Int64() != 15 {
+				t.Fatalf("expected 3x the median gas, got %s", sender.calls[1].fees)
+			}
+			args, err := sell.Inputs.Unpack(sender.calls[1].data[4:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if path := args[2].([]common.Address); len(path) != tt.expectPath || path[len(path)-1] != wbnb {
+				t.Fatalf("expected a path of %d into wbnb, got %v", tt.expectPath, path)
+			}
+			if args[1].(*big.Int).Sign() != 0 {
+				t.Fatalf("expected selling at any price, got a min out of %s", args[1])
+			}
+		})
+	}
+}
+
+func TestSaleProceeds(t *testing.T) {
+	wbnb := common.HexToAddress("0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c")
+	transfer := func(token, from, to common.Address, amount int64) *types.Log {
+		l := newFillTransfer(from, to, amount)
+		l.Address = token
+		return l
+	}
+	tests := []struct {
+		name   string
+		logs   []*types.Log
+		expect int64
+	}{
+		{"nothing", nil, 0},
+		{
+			"sold",
+			[]*types.Log{
+				transfer(fillToken, fillBeeA, fillPair, 1000),
+				transfer(wbnb, fillPair, fillRouter, 50),
+			},
+			50,
+		},
+		{
+			"swapping its fees back",
+			[]*types.Log{
+				transfer(fillToken, fillBeeA, fillOther, 100), // the fees
+				transfer(fillToken, fillOther, fillPair, 100),
+				transfer(wbnb, fillPair, fillRouter, 5),
+				transfer(fillToken, fillBeeA, fillPair, 900),
+				transfer(wbnb, fillPair, fillRouter, 40),
+			},
+			40,
+		},
+		{
+			"other pair",
+			[]*types.Log{
+				transfer(fillToken, fillBeeA, fillPair, 1000),
+				transfer(wbnb, fillOther, fillRouter, 50),
+			},
+			0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := saleProceeds(tt.logs, fillToken, wbnb); got.Int64() != tt.expect {
+				t.Fatalf("expected %d, got %s", tt.expect, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+type (
+	// presignedTxs holds snipe txs already signed for each bee at several gas levels, so once the liquidity is added
+	// we only need to broadcast them. Txs are only valid for the nonce they were signed with.
+	presignedTxs struct {
+		levels []*big.Int
+		// gen is bumped every time the txs are cleared, so a refresh that started before it doesn't store stale txs
+		gen uint64
+
+		txs map[*Bee][]*types.Transaction // indexed as levels
+		mut *sync.RWMutex
+	}
+)
+
+func newPresignedTxs() *presignedTxs {
+	return &presignedTxs{
+		txs: make(map[*Bee][]*types.Transaction),
+		mut: new(sync.RWMutex),
+	}
+}
+
+func (p *presignedTxs) setLevels(levels []*big.Int) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	p.levels = levels
+}
+
+// clear all the presigned txs, eg. because they were signed for another target
+func (p *presignedTxs) clear() {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	p.gen++
+	p.txs = make(map[*Bee][]*types.Transaction)
+}
+
+// refresh signs again the txs of the bee for its given nonce at all the gas levels. With dynamic fees a level is both
+// the tip and the cap, same as a legacy tx at that gas price.
+func (p *presignedTxs) refresh(bee *Bee, nonce uint64, sign func(*Bee, uint64, txFees) (*types.Transaction, error)) error {
+	p.mut.RLock()
+	levels, gen := p.levels, p.gen
+	p.mut.RUnlock()
+
+	txs := make([]*types.Transaction, len(levels))
+	for i, gp := range levels {
+		tx, err := sign(bee, nonce, txFees{Tip: gp, Cap: gp})
+		if err != nil {
+			return err
+		}
+		txs[i] = tx
+	}
+
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	if p.gen == gen {
+		p.txs[bee] = txs
+	}
+	return nil
+}
+
+// get a presigned tx of the bee for the given nonce and exact fees, if any.
+// We don't want close-enough fees: higher ones may frontrun the liquidity addition itself.
+func (p *presignedTxs) get(bee *Bee, nonce uint64, fees txFees) (*types.Transaction, bool) {
+	p.mut.RLock()
+	defer p.mut.RUnlock()
+	for _, tx := range p.txs[bee] {
+		if tx.Nonce() == nonce && fees.equal(tx) {
+			return tx, true
+		}
+	}
+	return nil, false
+}
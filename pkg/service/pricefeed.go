@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// priceFeedTTL of a price read from the feed, it's only used for reporting so it can be a bit old
+	priceFeedTTL = time.Minute
+	// priceFeedMaxAge of the last answer of the feed before we take it as stale (eg. a deprecated aggregator)
+	priceFeedMaxAge = 24 * time.Hour
+)
+
+var (
+	priceFeedLatestRoundData = []byte{0xfe, 0xaf, 0x96, 0x8c} // function 'latestRoundData' of chainlink aggregators
+	priceFeedDecimals        = []byte{0x31, 0x3c, 0xe5, 0x67} // function 'decimals' of chainlink aggregators
+)
+
+type (
+	// PriceFeed of the native coin in USD, read from a chainlink aggregator (eg. BNB / USD), so gas costs and trade
+	// values are reported on equal footing across chains.
+	PriceFeed struct {
+		aggregator common.Address
+		client     bind.ContractCaller
+
+		decimals uint8
+		price    float64
+		readAt   time.Time
+
+		mut *sync.Mutex
+	}
+)
+
+func NewPriceFeed(aggregator common.Address, c bind.ContractCaller) *PriceFeed {
+	return &PriceFeed{
+		aggregator: aggregator,
+		client:     c,
+		mut:        new(sync.Mutex),
+	}
+}
+
+// USD price of the native coin, read at most once a minute
+func (f *PriceFeed) USD(ctx context.Context) (float64, error) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	if time.Since(f.readAt) < priceFeedTTL {
+		return f.price, nil
+	}
+
+	if f.decimals == 0 {
+		res, err := f.call(ctx, priceFeedDecimals)
+		if err != nil {
+			return 0, err
+		}
+		f.decimals = uint8(new(big.Int).SetBytes(res).Uint64())
+	}
+	res, err := f.call(ctx, priceFeedLatestRoundData)
+	if err != nil {
+		return 0, err
+	}
+	if len(res) < 5*common.HashLength {
+		return 0, fmt.Errorf("unexpected round data of price feed %s: %x", f.aggregator.Hex(), res)
+	}
+	answer := new(big.Int).SetBytes(res[common.HashLength : 2*common.HashLength])
+	updatedAt := time.Unix(new(big.Int).SetBytes(res[3*common.HashLength:4*common.HashLength]).Int64(), 0)
+	if answer.Sign() <= 0 || answer.BitLen() >= 255 { // negative int256
+		return 0, fmt.Errorf("price feed %s answered a non positive price", f.aggregator.Hex())
+	}
+	if time.Since(updatedAt) > priceFeedMaxAge {
+		return 0, fmt.Errorf("price feed %s is stale, last updated at %s", f.aggregator.Hex(), updatedAt)
+	}
+
+	f.price, _ = new(big.Float).Quo(
+		new(big.Float).SetInt(answer),
+		new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(f.decimals)), nil)),
+	).Float64()
+	f.readAt = time.Now()
+	return f.price, nil
+}
+
+func (f *PriceFeed) call(ctx context.Context, data []byte) ([]byte, error) {
+	res, err := f.client.CallContract(ctx, ethereum.CallMsg{To: &f.aggregator, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error calling price feed %s: %s", f.aggregator.Hex(), err)
+	}
+	return res, nil
+}
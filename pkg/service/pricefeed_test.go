@@ -0,0 +1,74 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type fakePriceFeedCaller struct {
+	answer    *big.Int
+	updatedAt time.Time
+	calls     int
+}
+
+func (f *fakePriceFeedCaller) CodeAt(context.Context, common.Address, *big.Int) ([]byte, error) {
+	return []byte{0x1}, nil
+}
+
+func (f *fakePriceFeedCaller) CallContract(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	f.calls++
+	if bytes.Equal(msg.Data, priceFeedDecimals) {
+		return common.LeftPadBytes([]byte{8}, common.HashLength), nil
+	}
+	res := make([]byte, 0, 5*common.HashLength)
+	for _, v := range []*big.Int{big.NewInt(1), f.answer, big.NewInt(f.updatedAt.Unix()), big.NewInt(f.updatedAt.Unix()), big.NewInt(1)} {
+		res = append(res, common.LeftPadBytes(v.Bytes(), common.HashLength)...)
+	}
+	return res, nil
+}
+
+func TestPriceFeed_USD(t *testing.T) {
+	tests := []struct {
+		name      string
+		answer    *big.Int
+		updatedAt time.Time
+		expect    float64
+		expectErr bool
+	}{
+		{"fresh", big.NewInt(31234000000), time.Now(), 312.34, false},
+		{"stale", big.NewInt(31234000000), time.Now().Add(-48 * time.Hour), 0, true},
+		{"non positive", big.NewInt(0), time.Now(), 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &fakePriceFeedCaller{answer: tt.answer, updatedAt: tt.updatedAt}
+			f := NewPriceFeed(common.HexToAddress("0x0567F2323251f0Aab15c8dFb1967E4e8A7D42aeE"), c)
+			got, err := f.USD(context.Background())
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if got != tt.expect {
+				t.Fatalf("expected %g, got %g", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestPriceFeed_USD_Cached(t *testing.T) {
+	c := &fakePriceFeedCaller{answer: big.NewInt(31234000000), updatedAt: time.Now()}
+	f := NewPriceFeed(common.HexToAddress("0x0567F2323251f0Aab15c8dFb1967E4e8A7D42aeE"), c)
+	for i := 0; i < 3; i++ {
+		if _, err := f.USD(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if c.calls != 2 {
+		t.Fatalf("expected the decimals and a single round to be read, got %d calls", c.calls)
+	}
+}
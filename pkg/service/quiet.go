@@ -0,0 +1,123 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+const minutesPerDay = 24 * 60
+
+type (
+	// QuietHours are windows of the day in which we keep observing and recording candidates but never trade
+	// (eg. while the operator sleeps). Trading is re-armed by itself once a window ends.
+	QuietHours struct {
+		windows []QuietWindow
+		loc     *time.Location
+
+		mut   *sync.Mutex
+		quiet bool
+	}
+
+	// QuietWindow from Start to End, in minutes of the day. It spans midnight if End is before Start.
+	QuietWindow struct {
+		Start, End int
+	}
+)
+
+func NewQuietHours(w []QuietWindow, loc *time.Location) *QuietHours {
+	if loc == nil {
+		loc = time.Local
+	}
+	return &QuietHours{
+		windows: w,
+		loc:     loc,
+		mut:     new(sync.Mutex),
+	}
+}
+
+// ParseQuietWindow in the form "HH:MM-HH:MM", eg. "23:30-07:00"
+func ParseQuietWindow(s string) (QuietWindow, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return QuietWindow{}, fmt.Errorf("invalid quiet window %s, expected HH:MM-HH:MM", s)
+	}
+	start, err := parseMinuteOfDay(parts[0])
+	if err != nil {
+		return QuietWindow{}, fmt.Errorf("invalid quiet window %s: %s", s, err)
+	}
+	end, err := parseMinuteOfDay(parts[1])
+	if err != nil {
+		return QuietWindow{}, fmt.Errorf("invalid quiet window %s: %s", s, err)
+	}
+	if start == end {
+		return QuietWindow{}, fmt.Errorf("invalid quiet window %s, it's empty", s)
+	}
+	return QuietWindow{Start: start, End: end}, nil
+}
+
+func parseMinuteOfDay(s string) (int, error) {
+	hm := strings.Split(strings.TrimSpace(s), ":")
+	if len(hm) != 2 {
+		return 0, fmt.Errorf("%s isn't HH:MM", s)
+	}
+	h, err := strconv.Atoi(hm[0])
+	if err != nil || h < 0 || h > 23 {
+		return 0, fmt.Errorf("%s has an invalid hour", s)
+	}
+	m, err := strconv.Atoi(hm[1])
+	if err != nil || m < 0 || m > 59 {
+		return 0, fmt.Errorf("%s has invalid minutes", s)
+	}
+	return h*60 + m, nil
+}
+
+func (w QuietWindow) contains(m int) bool {
+	if w.Start < w.End {
+		return m >= w.Start && m < w.End
+	}
+	return m >= w.Start || m < w.End
+}
+
+func (w QuietWindow) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", w.Start/60, w.Start%60, w.End/60, w.End%60)
+}
+
+// window we are in at the given time, if any
+func (q *QuietHours) window(now time.Time) (QuietWindow, bool) {
+	t := now.In(q.loc)
+	m := (t.Hour()*60 + t.Minute()) % minutesPerDay
+	for _, w := range q.windows {
+		if w.contains(m) {
+			return w, true
+		}
+	}
+	return QuietWindow{}, false
+}
+
+// Check if we may trade at the given time. Entering and leaving a window is logged once.
+func (q *QuietHours) Check(now time.Time) error {
+	w, quiet := q.window(now)
+
+	q.mut.Lock()
+	if quiet != q.quiet {
+		q.quiet = quiet
+		if quiet {
+			log.Info(fmt.Sprintf("quiet hours %s started, observing without trading", w))
+		} else {
+			log.Info("quiet hours ended, trading re-armed")
+		}
+	}
+	q.mut.Unlock()
+
+	if quiet {
+		return domain.NewSkipError(domain.SkipReasonQuietHours, fmt.Sprintf("within quiet hours %s", w))
+	}
+	return nil
+}
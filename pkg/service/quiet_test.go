@@ -0,0 +1,94 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+func TestParseQuietWindow(t *testing.T) {
+	tests := []struct {
+		in        string
+		expect    QuietWindow
+		expectErr bool
+	}{
+		{in: "23:30-07:00", expect: QuietWindow{Start: 23*60 + 30, End: 7 * 60}},
+		{in: "01:00-05:15", expect: QuietWindow{Start: 60, End: 5*60 + 15}},
+		{in: " 00:00 - 23:59 ", expect: QuietWindow{Start: 0, End: 23*60 + 59}},
+		{in: "00:00-00:00", expectErr: true},
+		{in: "24:00-07:00", expectErr: true},
+		{in: "23:60-07:00", expectErr: true},
+		{in: "23:30", expectErr: true},
+		{in: "23-07", expectErr: true},
+		{in: "aa:bb-07:00", expectErr: true},
+		{in: "23:30-07:00-08:00", expectErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			w, err := ParseQuietWindow(tt.in)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %s", w)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if w != tt.expect {
+				t.Fatalf("expected %s, got %s", tt.expect, w)
+			}
+		})
+	}
+}
+
+func TestQuietHours_Check(t *testing.T) {
+	q := NewQuietHours([]QuietWindow{
+		{Start: 23*60 + 30, End: 7 * 60}, // spans midnight
+		{Start: 12 * 60, End: 13 * 60},
+	}, time.UTC)
+
+	tests := []struct {
+		at     string
+		expect bool
+	}{
+		{"23:29", false},
+		{"23:30", true},
+		{"00:00", true},
+		{"06:59", true},
+		{"07:00", false},
+		{"11:59", false},
+		{"12:00", true},
+		{"12:59", true},
+		{"13:00", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.at, func(t *testing.T) {
+			now, err := time.Parse("15:04", tt.at)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = q.Check(now)
+			var skip *domain.SkipError
+			if quiet := errors.As(err, &skip) && skip.Reason == domain.SkipReasonQuietHours; quiet != tt.expect {
+				t.Fatalf("expected quiet %v at %s, got %v", tt.expect, tt.at, err)
+			}
+		})
+	}
+}
+
+func TestQuietHours_Check_Timezone(t *testing.T) {
+	loc := time.FixedZone("UTC-3", -3*60*60)
+	q := NewQuietHours([]QuietWindow{{Start: 23*60 + 30, End: 7 * 60}}, loc)
+
+	// 02:00 UTC is 23:00 in UTC-3, still before the window
+	if err := q.Check(time.Date(2021, 1, 1, 2, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("expected no quiet hours, got %s", err)
+	}
+	// 03:00 UTC is 00:00 in UTC-3
+	if err := q.Check(time.Date(2021, 1, 1, 3, 0, 0, 0, time.UTC)); err == nil {
+		t.Fatal("expected quiet hours")
+	}
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type (
+	// RouterQuoter quotes amounts through the router of the AMM, hence with the current reserves of the pair and its fees.
+	RouterQuoter struct {
+		router routerQuoterRouter
+	}
+
+	routerQuoterRouter interface {
+		GetAmountsOut(opts *bind.CallOpts, amountIn *big.Int, path []common.Address) ([]*big.Int, error)
+	}
+)
+
+func NewRouterQuoter(r routerQuoterRouter) *RouterQuoter {
+	return &RouterQuoter{
+		router: r,
+	}
+}
+
+// Quote how much of the out token we would get selling the amount of the in token right now
+func (q *RouterQuoter) Quote(ctx context.Context, amount *big.Int, in, out common.Address) (*big.Int, error) {
+	amounts, err := q.router.GetAmountsOut(&bind.CallOpts{Context: ctx}, amount, []common.Address{in, out})
+	if err != nil {
+		return nil, fmt.Errorf("error quoting %s of %s: %s", amount.String(), in.Hex(), err)
+	}
+	if len(amounts) != 2 {
+		return nil, fmt.Errorf("unexpected quote of %s: %v", in.Hex(), amounts)
+	}
+	return amounts[1], nil
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"expvar"
+	"sync"
+	"time"
+)
+
+type (
+	// RateLimits of the external APIs we call (eg. bundle relays, Etherscan / GoPlus), shared by all their callers.
+	// Callers never wait for a slot: if the rate or the daily quota of a provider is exhausted the call is skipped, so
+	// a safety check degrades instead of blocking a snipe. Usage is exported through expvar as:
+	//   rate_limits: { provider: { allowed: n, limited: n, quota_left: n } }
+	// where quota_left is only present for providers with a quota.
+	RateLimits struct {
+		providers map[string]*rateLimit
+	}
+
+	// RateLimit of a provider
+	RateLimit struct {
+		// Rate of calls per second, and Burst of calls allowed at once. Zero rate means no rate limit.
+		Rate  float64
+		Burst int
+		// Quota of calls per day (UTC). Zero means no quota.
+		Quota uint
+	}
+
+	rateLimit struct {
+		RateLimit
+
+		tokens float64
+		last   time.Time
+		used   uint
+		day    time.Time
+		mut    *sync.Mutex
+
+		allowed   *expvar.Int
+		limited   *expvar.Int
+		quotaLeft *expvar.Int
+	}
+)
+
+// NewRateLimits publishes the usage of the providers, hence it can be created only once.
+func NewRateLimits(limits map[string]RateLimit) *RateLimits {
+	metrics := expvar.NewMap("rate_limits")
+	providers := make(map[string]*rateLimit, len(limits))
+	for name, l := range limits {
+		rl := newRateLimit(l)
+
+		m := new(expvar.Map).Init()
+		m.Set("allowed", rl.allowed)
+		m.Set("limited", rl.limited)
+		if l.Quota > 0 {
+			m.Set("quota_left", rl.quotaLeft)
+		}
+		metrics.Set(name, m)
+		providers[name] = rl
+	}
+	return &RateLimits{
+		providers: providers,
+	}
+}
+
+func newRateLimit(l RateLimit) *rateLimit {
+	if l.Burst < 1 {
+		l.Burst = 1
+	}
+	rl := &rateLimit{
+		RateLimit: l,
+		tokens:    float64(l.Burst),
+		mut:       new(sync.Mutex),
+		allowed:   new(expvar.Int),
+		limited:   new(expvar.Int),
+		quotaLeft: new(expvar.Int),
+	}
+	rl.quotaLeft.Set(int64(l.Quota))
+	return rl
+}
+
+// Allow a call to the provider right now, consuming it. Providers without limits are always allowed.
+func (r *RateLimits) Allow(provider string) bool {
+	rl, ok := r.providers[provider]
+	if !ok {
+		return true
+	}
+	return rl.allow(time.Now())
+}
+
+// Exhausted quota of the provider for today, eg. because it answered with a quota error we didn't see coming.
+func (r *RateLimits) Exhausted(provider string) {
+	rl, ok := r.providers[provider]
+	if !ok || rl.Quota == 0 {
+		return
+	}
+	rl.mut.Lock()
+	defer rl.mut.Unlock()
+	rl.resetDay(time.Now())
+	rl.used = rl.Quota
+	rl.quotaLeft.Set(0)
+}
+
+func (rl *rateLimit) allow(now time.Time) bool {
+	rl.mut.Lock()
+	defer rl.mut.Unlock()
+
+	if rl.Quota > 0 {
+		rl.resetDay(now)
+		if rl.used >= rl.Quota {
+			rl.limited.Add(1)
+			return false
+		}
+	}
+
+	if rl.Rate > 0 {
+		if !rl.last.IsZero() {
+			rl.tokens += now.Sub(rl.last).Seconds() * rl.Rate
+			if max := float64(rl.Burst); rl.tokens > max {
+				rl.tokens = max
+			}
+		}
+		rl.last = now
+		if rl.tokens < 1 {
+			rl.limited.Add(1)
+			return false
+		}
+		rl.tokens--
+	}
+
+	if rl.Quota > 0 {
+		rl.used++
+		rl.quotaLeft.Set(int64(rl.Quota - rl.used))
+	}
+	rl.allowed.Add(1)
+	return true
+}
+
+// resetDay of the quota if a new one started. Must be called holding the lock.
+func (rl *rateLimit) resetDay(now time.Time) {
+	day := now.UTC().Truncate(24 * time.Hour)
+	if day.After(rl.day) {
+		rl.day = day
+		rl.used = 0
+		rl.quotaLeft.Set(int64(rl.Quota))
+	}
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimit_Allow(t *testing.T) {
+	start := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		limit  RateLimit
+		calls  []time.Duration // since start
+		expect []bool
+	}{
+		{
+			name:   "no limits",
+			limit:  RateLimit{},
+			calls:  []time.Duration{0, 0, 0},
+			expect: []bool{true, true, true},
+		},
+		{
+			name:   "burst",
+			limit:  RateLimit{Rate: 1, Burst: 2},
+			calls:  []time.Duration{0, 0, 0},
+			expect: []bool{true, true, false},
+		},
+		{
+			name:   "no burst is one",
+			limit:  RateLimit{Rate: 1},
+			calls:  []time.Duration{0, 0},
+			expect: []bool{true, false},
+		},
+		{
+			name:   "refills with time",
+			limit:  RateLimit{Rate: 2, Burst: 1},
+			calls:  []time.Duration{0, 0, 250 * time.Millisecond, 500 * time.Millisecond},
+			expect: []bool{true, false, false, true},
+		},
+		{
+			name:   "refills up to the burst",
+			limit:  RateLimit{Rate: 10, Burst: 2},
+			calls:  []time.Duration{0, 0, time.Hour, time.Hour, time.Hour},
+			expect: []bool{true, true, true, true, false},
+		},
+		{
+			name:   "quota",
+			limit:  RateLimit{Quota: 2},
+			calls:  []time.Duration{0, time.Minute, time.Hour},
+			expect: []bool{true, true, false},
+		},
+		{
+			name:   "quota resets the next day",
+			limit:  RateLimit{Quota: 1},
+			calls:  []time.Duration{0, time.Hour, 12 * time.Hour},
+			expect: []bool{true, false, true},
+		},
+		{
+			name:   "rate limited calls don't use the quota",
+			limit:  RateLimit{Rate: 1, Quota: 2},
+			calls:  []time.Duration{0, 0, time.Second, time.Second},
+			expect: []bool{true, false, true, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl := newRateLimit(tt.limit)
+			for i, c := range tt.calls {
+				if got := rl.allow(start.Add(c)); got != tt.expect[i] {
+					t.Fatalf("call %d at +%s: expected %v, got %v", i, c, tt.expect[i], got)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimit_QuotaLeft(t *testing.T) {
+	rl := newRateLimit(RateLimit{Quota: 3})
+	now := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+	rl.allow(now)
+	if left := rl.quotaLeft.Value(); left != 2 {
+		t.Fatalf("expected 2 calls left, got %d", left)
+	}
+	if a, l := rl.allowed.Value(), rl.limited.Value(); a != 1 || l != 0 {
+		t.Fatalf("expected 1 allowed and 0 limited, got %d / %d", a, l)
+	}
+}
+
+func TestRateLimits_Exhausted(t *testing.T) {
+	r := &RateLimits{providers: map[string]*rateLimit{
+		"quota":    newRateLimit(RateLimit{Quota: 10}),
+		"no quota": newRateLimit(RateLimit{}),
+	}}
+
+	r.Exhausted("quota")
+	r.Exhausted("no quota")
+	r.Exhausted("unknown")
+
+	if r.Allow("quota") {
+		t.Fatal("expected an exhausted quota to be limited")
+	}
+	if !r.Allow("no quota") {
+		t.Fatal("expected a provider without quota to be allowed")
+	}
+	if !r.Allow("unknown") {
+		t.Fatal("expected an unknown provider to be allowed")
+	}
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+type (
+	// Rebroadcaster sends our own txs again through alternate nodes, for when they vanish from the mempool of our
+	// node without being mined (flaky public rpcs drop them often).
+	Rebroadcaster struct {
+		nodes []rebroadcasterNode
+	}
+
+	rebroadcasterNode interface {
+		SendTransaction(context.Context, *types.Transaction) error
+	}
+)
+
+func NewRebroadcaster(n ...*RPCEthClient) *Rebroadcaster {
+	nodes := make([]rebroadcasterNode, len(n))
+	for i, c := range n {
+		nodes[i] = c
+	}
+	return &Rebroadcaster{
+		nodes: nodes,
+	}
+}
+
+// Enabled reports whether there's at least one alternate node to rebroadcast through.
+func (r *Rebroadcaster) Enabled() bool {
+	return len(r.nodes) > 0
+}
+
+// Rebroadcast the tx through all the nodes concurrently. It only fails if no node accepted it. A node that already
+// knows the tx counts as accepted.
+func (r *Rebroadcaster) Rebroadcast(ctx context.Context, tx *types.Transaction) error {
+	errs := make(chan error, len(r.nodes))
+	for i, n := range r.nodes {
+		go func(i int, n rebroadcasterNode) {
+			err := fmt.Errorf("node %d: panicked rebroadcasting tx", i)
+			defer func() { errs <- err }()
+			defer recovery()
+			if err = n.SendTransaction(ctx, tx); err != nil && !isAlreadyKnown(err) {
+				err = fmt.Errorf("node %d: %s", i, err)
+			} else {
+				err = nil
+			}
+		}(i, n)
+	}
+
+	var err error
+	accepted := false
+	for range r.nodes {
+		if e := <-errs; e != nil {
+			log.Warn(e.Error())
+			err = e
+			continue
+		}
+		accepted = true
+	}
+	if !accepted {
+		return fmt.Errorf("no node accepted tx %s: %s", tx.Hash().Hex(), err)
+	}
+	return nil
+}
+
+// isAlreadyKnown if the node rejected the tx because it already has it
+func isAlreadyKnown(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), "already known")
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type rebroadcastTestNode struct {
+	err error
+}
+
+func (n rebroadcastTestNode) SendTransaction(context.Context, *types.Transaction) error {
+	return n.err
+}
+
+func TestRebroadcaster_Rebroadcast(t *testing.T) {
+	down := rebroadcastTestNode{err: errors.New("connection refused")}
+	tests := []struct {
+		name      string
+		nodes     []rebroadcasterNode
+		expectErr bool
+	}{
+		{"accepted", []rebroadcasterNode{rebroadcastTestNode{}}, false},
+		{"accepted by one", []rebroadcasterNode{down, rebroadcastTestNode{}, down}, false},
+		{"already known", []rebroadcasterNode{rebroadcastTestNode{err: errors.New("already known")}}, false},
+		{"rejected by all", []rebroadcasterNode{down, down}, true},
+	}
+	to := common.Address{}
+	tx := types.NewTx(&types.LegacyTx{GasPrice: big.NewInt(1), To: &to})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Rebroadcaster{nodes: tt.nodes}
+			if !r.Enabled() {
+				t.Fatal("expected the rebroadcaster to be enabled")
+			}
+			if err := r.Rebroadcast(context.Background(), tx); (err != nil) != tt.expectErr {
+				t.Fatalf("unexpected error %v", err)
+			}
+		})
+	}
+}
+
+func TestRebroadcaster_Disabled(t *testing.T) {
+	if NewRebroadcaster().Enabled() {
+		t.Fatal("expected a rebroadcaster without nodes to be disabled")
+	}
+}
+
+type (
+	// rebroadcastTestClient of a node whose mempool lost everything, the victim and our txs
+	rebroadcastTestClient struct {
+		sniperETHClient
+
+		sent []*types.Transaction
+	}
+
+	rebroadcastTestRebroadcaster struct {
+		rebroadcast []*types.Transaction
+	}
+)
+
+func (c *rebroadcastTestClient) TransactionByHash(context.Context, common.Hash) (*types.Transaction, bool, error) {
+	return nil, false, ethereum.NotFound
+}
+
+func (c *rebroadcastTestClient) TransactionReceipt(context.Context, common.Hash) (*types.Receipt, error) {
+	return nil, ethereum.NotFound
+}
+
+func (c *rebroadcastTestClient) SendTransaction(_ context.Context, tx *types.Transaction) error {
+	c.sent = append(c.sent, tx)
+	return nil
+}
+
+func (r *rebroadcastTestRebroadcaster) Enabled() bool {
+	return true
+}
+
+func (r *rebroadcastTestRebroadcaster) Rebroadcast(_ context.Context, tx *types.Transaction) error {
+	r.rebroadcast = append(r.rebroadcast, tx)
+	return nil
+}
+
+func TestSniper_CheckTxStatus_Cancelled(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainID := big.NewInt(56)
+	to := common.Address{0x1}
+	sign := func(nonce uint64) *types.Transaction {
+		tx, err := types.SignNewTx(pk, types.LatestSignerForChainID(chainID), &types.LegacyTx{
+			Nonce: nonce, GasPrice: big.NewInt(5), Gas: 21000, To: &to,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+
+	client := &rebroadcastTestClient{}
+	rebroadcaster := &rebroadcastTestRebroadcaster{}
+	c := &Sniper{
+		ethClient:     client,
+		rebroadcaster: rebroadcaster,
+		replacing:     new(sync.Map),
+		timing:        domain.NewTiming(chainID, 60*time.Millisecond),
+		sniperChainID: chainID,
+	}
+	tx := sign(3)
+	sent := []sentTx{{Hash: tx.Hash(), Bee: NewBee(pk, 4), Tx: tx, cancelled: new(int32)}}
+
+	// the victim vanishes, so the snipe is cancelled
+	if !c.watchVictim(context.Background(), sign(0), sent, make(chan struct{})) {
+		t.Fatal("expected the snipe to be cancelled")
+	}
+	if len(client.sent) != 1 || client.sent[0].Nonce() != tx.Nonce() {
+		t.Fatalf("expected a cancel with the nonce of the snipe, got %v", client.sent)
+	}
+
+	res := c.checkTxStatus(context.Background(), sent[0])
+	if !res.Cancelled || res.Success {
+		t.Fatalf("expected the snipe reported as cancelled, got %+v", res)
+	}
+	if len(rebroadcaster.rebroadcast) > 0 {
+		t.Fatalf("expected the cancelled snipe not to be rebroadcast, got %v", rebroadcaster.rebroadcast)
+	}
+}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	relayTimeout = 2 * time.Second
+
+	// relaySignatureHeader is the header used by flashbots-like relays to authenticate (and reputate) searchers
+	relaySignatureHeader = "X-Flashbots-Signature"
+)
+
+type (
+	// Relay is a private bundle relay (eg. Flashbots / 48Club) where we can submit bundles of txs that
+	// will be included atomically (or not at all) in a given block, without ever touching the public mempool.
+	Relay struct {
+		name    string
+		url     string
+		authKey *ecdsa.PrivateKey // optional
+		limits  relayRateLimits
+
+		client *http.Client
+		id     uint64
+	}
+
+	relayRateLimits interface {
+		Allow(string) bool
+		Exhausted(string)
+	}
+
+	// RelayCluster fans out bundles to all the relays we know of, so we maximize our inclusion odds.
+	RelayCluster struct {
+		relays []*Relay
+	}
+
+	relayRequest struct {
+		JSONRPC string        `json:"jsonrpc"`
+		ID      uint64        `json:"id"`
+		Method  string        `json:"method"`
+		Params  []interface{} `json:"params"`
+	}
+
+	relayResponse struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+
+	relaySendBundleArgs struct {
+		Txs         []hexutil.Bytes `json:"txs"`
+		BlockNumber hexutil.Uint64  `json:"blockNumber"`
+	}
+
+	relayCallBundleArgs struct {
+		Txs              []hexutil.Bytes `json:"txs"`
+		BlockNumber      hexutil.Uint64  `json:"blockNumber"`
+		StateBlockNumber string          `json:"stateBlockNumber"`
+	}
+
+	// BundleSimulation is the outcome of simulating a bundle on top of the current chain state
+	BundleSimulation struct {
+		Results      []BundleSimulationResult `json:"results"`
+		CoinbaseDiff *hexutil.Big             `json:"coinbaseDiff"`
+		TotalGasUsed uint64                   `json:"totalGasUsed"`
+	}
+
+	BundleSimulationResult struct {
+		TxHash  common.Hash `json:"txHash"`
+		GasUsed uint64      `json:"gasUsed"`
+		Error   string      `json:"error"`
+		Revert  string      `json:"revert"`
+	}
+)
+
+func NewRelay(name, url string, authKey *ecdsa.PrivateKey, l relayRateLimits) *Relay {
+	return &Relay{
+		name:    name,
+		url:     url,
+		authKey: authKey,
+		limits:  l,
+		client:  &http.Client{Timeout: relayTimeout},
+	}
+}
+
+func (r *Relay) Name() string {
+	return r.name
+}
+
+// SendBundle submits the txs (in order) as a bundle targeting the given block number.
+func (r *Relay) SendBundle(ctx context.Context, txs []*types.Transaction, block uint64) error {
+	raw, err := encodeBundleTxs(txs)
+	if err != nil {
+		return err
+	}
+	return r.call(ctx, "eth_sendBundle", nil, relaySendBundleArgs{
+		Txs:         raw,
+		BlockNumber: hexutil.Uint64(block),
+	})
+}
+
+// CallBundle simulates the txs (in order) as a bundle in the given block, on top of the latest state.
+func (r *Relay) CallBundle(ctx context.Context, txs []*types.Transaction, block uint64) (*BundleSimulation, error) {
+	raw, err := encodeBundleTxs(txs)
+	if err != nil {
+		return nil, err
+	}
+	var sim BundleSimulation
+	err = r.call(ctx, "eth_callBundle", &sim, relayCallBundleArgs{
+		Txs:              raw,
+		BlockNumber:      hexutil.Uint64(block),
+		StateBlockNumber: "latest",
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &sim, nil
+}
+
+// call the relay, unless it's over its rate limit (then it's skipped, as if it failed).
+func (r *Relay) call(ctx context.Context, method string, res interface{}, params ...interface{}) error {
+	if !r.limits.Allow(r.name) {
+		return fmt.Errorf("relay %s: rate limited, skipping %s", r.name, method)
+	}
+	body, err := json.Marshal(relayRequest{
+		JSONRPC: "2.0",
+		ID:      atomic.AddUint64(&r.id, 1),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.authKey != nil {
+		sig, err := crypto.Sign(accounts.TextHash([]byte(hexutil.Encode(crypto.Keccak256(body)))), r.authKey)
+		if err != nil {
+			return err
+		}
+		req.Header.Set(relaySignatureHeader, fmt.Sprintf("%s:%s", crypto.PubkeyToAddress(r.authKey.PublicKey).Hex(), hexutil.Encode(sig)))
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("relay %s: %s", r.name, err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("relay %s: error reading response: %s", r.name, err)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		r.limits.Exhausted(r.name)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("relay %s: unexpected status %d: %s", r.name, resp.StatusCode, string(b))
+	}
+
+	var rr relayResponse
+	if err := json.Unmarshal(b, &rr); err != nil {
+		return fmt.Errorf("relay %s: error decoding response: %s", r.name, err)
+	}
+	if rr.Error != nil {
+		return fmt.Errorf("relay %s: %s (code %d)", r.name, rr.Error.Message, rr.Error.Code)
+	}
+	if res != nil {
+		return json.Unmarshal(rr.Result, res)
+	}
+	return nil
+}
+
+func encodeBundleTxs(txs []*types.Transaction) ([]hexutil.Bytes, error) {
+	raw := make([]hexutil.Bytes, len(txs))
+	for i, tx := range txs {
+		b, err := tx.MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("error encoding tx %s: %s", tx.Hash().String(), err)
+		}
+		raw[i] = b
+	}
+	return raw, nil
+}
+
+func NewRelayCluster(r ...*Relay) *RelayCluster {
+	return &RelayCluster{
+		relays: r,
+	}
+}
+
+// Enabled reports whether there's at least one relay we can submit bundles to.
+func (c *RelayCluster) Enabled() bool {
+	return len(c.relays) > 0
+}
+
+// CallBundle simulates the bundle in the first relay that is able to.
+func (c *RelayCluster) CallBundle(ctx context.Context, txs []*types.Transaction, block uint64) (*BundleSimulation, error) {
+	err := errors.New("no relays")
+	for _, r := range c.relays {
+		var sim *BundleSimulation
+		if sim, err = r.CallBundle(ctx, txs, block); err == nil {
+			return sim, nil
+		}
+		log.Debug(err.Error())
+	}
+	return nil, fmt.Errorf("couldn't simulate bundle for block %d: %s", block, err)
+}
+
+// SendBundle submits the bundle to all the relays concurrently. It only fails if no relay accepted it.
+func (c *RelayCluster) SendBundle(ctx context.Context, txs []*types.Transaction, block uint64) error {
+	errs := make(chan error, len(c.relays))
+	for _, r := range c.relays {
+		go func(r *Relay) {
+			err := fmt.Errorf("relay %s: panicked sending bundle", r.Name())
+			defer func() { errs <- err }()
+			defer recovery()
+			err = r.SendBundle(ctx, txs, block)
+		}(r)
+	}
+
+	var err error
+	accepted := false
+	for range c.relays {
+		if e := <-errs; e != nil {
+			log.Warn(e.Error())
+			err = e
+			continue
+		}
+		accepted = true
+	}
+	if !accepted {
+		return fmt.Errorf("no relay accepted the bundle for block %d: %s", block, err)
+	}
+	return nil
+}
+
+// Reverted returns the first tx of the bundle that failed in the simulation, if any.
+func (s *BundleSimulation) Reverted() (BundleSimulationResult, bool) {
+	for _, r := range s.Results {
+		if len(r.Error) > 0 || len(r.Revert) > 0 {
+			return r, true
+		}
+	}
+	return BundleSimulationResult{}, false
+}
+
+// ResultOf the given tx in the simulation, if present.
+func (s *BundleSimulation) ResultOf(h common.Hash) (BundleSimulationResult, bool) {
+	for _, r := range s.Results {
+		if r.TxHash == h {
+			return r, true
+		}
+	}
+	return BundleSimulationResult{}, false
+}
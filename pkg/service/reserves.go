@@ -0,0 +1,88 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+	"github.com/saantiaguilera/liquidity-sniper/third_party/uniswap"
+)
+
+type (
+	// PairReserves samples the reserves of the v2 pairs of the factory. Pairs are looked up once, as they never move.
+	PairReserves struct {
+		factory pairReservesFactory
+		client  bind.ContractCaller
+		pairs   map[[2]common.Address]common.Address
+
+		mut *sync.RWMutex
+	}
+
+	pairReservesFactory interface {
+		GetPair(opts *bind.CallOpts, tokenA, tokenB common.Address) (common.Address, error)
+	}
+)
+
+func NewPairReserves(f pairReservesFactory, c bind.ContractCaller) *PairReserves {
+	return &PairReserves{
+		factory: f,
+		client:  c,
+		pairs:   make(map[[2]common.Address]common.Address),
+		mut:     new(sync.RWMutex),
+	}
+}
+
+// Sample the reserves of the pair of the token and the paired one right now
+func (r *PairReserves) Sample(ctx context.Context, token, paired common.Address) (domain.ReserveSample, error) {
+	s := domain.ReserveSample{Token: token.Hex(), Paired: paired.Hex(), Time: time.Now()}
+	opts := &bind.CallOpts{Context: ctx}
+	pair, err := r.pair(opts, token, paired)
+	if err != nil {
+		return s, err
+	}
+	s.Pair = pair.Hex()
+
+	caller, err := uniswap.NewIUniswapV2PairCaller(pair, r.client)
+	if err != nil {
+		return s, fmt.Errorf("error binding pair %s: %s", pair.Hex(), err)
+	}
+	reserves, err := caller.GetReserves(opts)
+	if err != nil {
+		return s, fmt.Errorf("error getting reserves of pair %s: %s", pair.Hex(), err)
+	}
+
+	// the pair sorts its tokens by address
+	s.ReserveToken, s.ReservePaired = reserves.Reserve0, reserves.Reserve1
+	if bytes.Compare(token.Bytes(), paired.Bytes()) > 0 {
+		s.ReserveToken, s.ReservePaired = reserves.Reserve1, reserves.Reserve0
+	}
+	return s, nil
+}
+
+func (r *PairReserves) pair(opts *bind.CallOpts, token, paired common.Address) (common.Address, error) {
+	k := [2]common.Address{token, paired}
+	r.mut.RLock()
+	pair, ok := r.pairs[k]
+	r.mut.RUnlock()
+	if ok {
+		return pair, nil
+	}
+
+	pair, err := r.factory.GetPair(opts, token, paired)
+	if err != nil {
+		return pair, fmt.Errorf("error getting pair of %s: %s", token.Hex(), err)
+	}
+	if pair == (common.Address{}) {
+		return pair, fmt.Errorf("there's no pair of %s and %s", token.Hex(), paired.Hex())
+	}
+	r.mut.Lock()
+	r.pairs[k] = pair
+	r.mut.Unlock()
+	return pair, nil
+}
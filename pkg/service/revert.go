@@ -0,0 +1,130 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+// rpcRevertCode is the json-rpc error code of a call that reverted with data
+const rpcRevertCode = 3
+
+var (
+	revertPanicSelector = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+
+	// revertCustomErrors are custom errors commonly used by tokens, by selector
+	revertCustomErrors = newRevertCustomErrors(map[string]domain.RevertCategory{
+		"TransferFromFailed()":       domain.RevertTransferFromFailed,
+		"TradingNotOpen()":           domain.RevertTradingNotOpen,
+		"TradingNotEnabled()":        domain.RevertTradingNotOpen,
+		"TradingNotActive()":         domain.RevertTradingNotOpen,
+		"TradingClosed()":            domain.RevertTradingNotOpen,
+		"MaxTxAmountExceeded()":      domain.RevertMaxTx,
+		"MaxTransactionExceeded()":   domain.RevertMaxTx,
+		"MaxWalletExceeded()":        domain.RevertMaxTx,
+		"InsufficientOutputAmount()": domain.RevertSlippage,
+	})
+
+	// revertReasons are the (lowercase) fragments of revert messages of each category
+	revertReasons = []struct {
+		fragment string
+		category domain.RevertCategory
+	}{
+		{"transfer_from_failed", domain.RevertTransferFromFailed},
+		{"transferfrom failed", domain.RevertTransferFromFailed},
+		{"transfer amount exceeds allowance", domain.RevertTransferFromFailed},
+		{"trading not", domain.RevertTradingNotOpen},
+		{"trading is not", domain.RevertTradingNotOpen},
+		{"trading has not", domain.RevertTradingNotOpen},
+		{"not enabled", domain.RevertTradingNotOpen},
+		{"max tx", domain.RevertMaxTx},
+		{"maxtx", domain.RevertMaxTx},
+		{"max transaction", domain.RevertMaxTx},
+		{"max wallet", domain.RevertMaxTx},
+		{"exceeds the max", domain.RevertMaxTx},
+		{"insufficient_output_amount", domain.RevertSlippage},
+		{"excessive_input_amount", domain.RevertSlippage}, // exact out buys
+		{"too much requested", domain.RevertSlippage},     // exact out buys on v3
+	}
+)
+
+type (
+	revertCustomError struct {
+		name     string
+		category domain.RevertCategory
+	}
+)
+
+func newRevertCustomErrors(errs map[string]domain.RevertCategory) map[[4]byte]revertCustomError {
+	m := make(map[[4]byte]revertCustomError, len(errs))
+	for sig, c := range errs {
+		var sel [4]byte
+		copy(sel[:], crypto.Keccak256([]byte(sig))[:4])
+		m[sel] = revertCustomError{name: sig, category: c}
+	}
+	return m
+}
+
+// isRevert if the error of a call is the call reverting (rather than the node failing to run it, eg. being rate
+// limited or not supporting the method).
+func isRevert(err error) bool {
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		return true
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == rpcRevertCode {
+		return true
+	}
+	return strings.Contains(strings.ToLower(err.Error()), "execution reverted")
+}
+
+// newRevertError from the error of a call that reverted, decoding its revert data if the node returned it.
+func newRevertError(err error) *domain.RevertError {
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return domain.NewRevertError(categorizeRevert(err.Error()), err.Error())
+	}
+	hexData, ok := dataErr.ErrorData().(string)
+	if !ok {
+		return domain.NewRevertError(categorizeRevert(err.Error()), err.Error())
+	}
+	data, derr := hexutil.Decode(hexData)
+	if derr != nil || len(data) < 4 {
+		return domain.NewRevertError(categorizeRevert(err.Error()), err.Error())
+	}
+
+	if reason, uerr := abi.UnpackRevert(data); uerr == nil {
+		return domain.NewRevertError(categorizeRevert(reason), reason)
+	}
+	if bytes.Equal(data[:4], revertPanicSelector) {
+		return domain.NewRevertError(domain.RevertUnknown, fmt.Sprintf("panic 0x%x", new(big.Int).SetBytes(data[4:])))
+	}
+
+	var sel [4]byte
+	copy(sel[:], data[:4])
+	if ce, ok := revertCustomErrors[sel]; ok {
+		return domain.NewRevertError(ce.category, ce.name)
+	}
+	return domain.NewRevertError(domain.RevertUnknown, fmt.Sprintf("custom error %s", hexutil.Encode(data[:4])))
+}
+
+// categorizeRevert by its reason
+func categorizeRevert(reason string) domain.RevertCategory {
+	r := strings.ToLower(reason)
+	for _, rr := range revertReasons {
+		if strings.Contains(r, rr.fragment) {
+			return rr.category
+		}
+	}
+	return domain.RevertUnknown
+}
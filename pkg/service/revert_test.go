@@ -0,0 +1,150 @@
+package service
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type (
+	// revertTestError is an error as returned by the rpc client, with its code and data
+	revertTestError struct {
+		msg  string
+		code int
+		data interface{}
+	}
+
+	// revertTestCodeError has a code but no data, eg. a node rate limiting us
+	revertTestCodeError struct {
+		msg  string
+		code int
+	}
+)
+
+func (e revertTestError) Error() string          { return e.msg }
+func (e revertTestError) ErrorCode() int         { return e.code }
+func (e revertTestError) ErrorData() interface{} { return e.data }
+
+func (e revertTestCodeError) Error() string  { return e.msg }
+func (e revertTestCodeError) ErrorCode() int { return e.code }
+
+func newRevertTestReason(t *testing.T, reason string) string {
+	typ, err := abi.NewType("string", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packed, err := abi.Arguments{{Type: typ}}.Pack(reason)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hexutil.Encode(append(crypto.Keccak256([]byte("Error(string)"))[:4], packed...))
+}
+
+func newRevertTestSelector(sig string, words ...*big.Int) string {
+	data := crypto.Keccak256([]byte(sig))[:4]
+	for _, w := range words {
+		data = append(data, common.LeftPadBytes(w.Bytes(), common.HashLength)...)
+	}
+	return hexutil.Encode(data)
+}
+
+func TestNewRevertError(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectCategory domain.RevertCategory
+		expectReason   string
+	}{
+		{
+			name:           "reason",
+			err:            revertTestError{"execution reverted: x", 3, newRevertTestReason(t, "Pancake: TRANSFER_FROM_FAILED")},
+			expectCategory: domain.RevertTransferFromFailed,
+			expectReason:   "Pancake: TRANSFER_FROM_FAILED",
+		},
+		{
+			name:           "exact out above the max in",
+			err:            revertTestError{"execution reverted: x", 3, newRevertTestReason(t, "Router: EXCESSIVE_INPUT_AMOUNT")},
+			expectCategory: domain.RevertSlippage,
+			expectReason:   "Router: EXCESSIVE_INPUT_AMOUNT",
+		},
+		{
+			name:           "unknown reason",
+			err:            revertTestError{"execution reverted: x", 3, newRevertTestReason(t, "nope")},
+			expectCategory: domain.RevertUnknown,
+			expectReason:   "nope",
+		},
+		{
+			name:           "custom error",
+			err:            revertTestError{"execution reverted", 3, newRevertTestSelector("TradingNotOpen()")},
+			expectCategory: domain.RevertTradingNotOpen,
+			expectReason:   "TradingNotOpen()",
+		},
+		{
+			name:           "unknown custom error",
+			err:            revertTestError{"execution reverted", 3, "0xdeadbeef"},
+			expectCategory: domain.RevertUnknown,
+			expectReason:   "custom error 0xdeadbeef",
+		},
+		{
+			name:           "panic",
+			err:            revertTestError{"execution reverted", 3, newRevertTestSelector("Panic(uint256)", big.NewInt(0x11))},
+			expectCategory: domain.RevertUnknown,
+			expectReason:   "panic 0x11",
+		},
+		{
+			name:           "no data",
+			err:            errors.New("execution reverted: Trading not open yet"),
+			expectCategory: domain.RevertTradingNotOpen,
+			expectReason:   "execution reverted: Trading not open yet",
+		},
+		{
+			name:           "malformed data",
+			err:            revertTestError{"execution reverted: max wallet", 3, "0x12"},
+			expectCategory: domain.RevertMaxTx,
+			expectReason:   "execution reverted: max wallet",
+		},
+		{
+			name:           "data isn't hex",
+			err:            revertTestError{"execution reverted", 3, 42},
+			expectCategory: domain.RevertUnknown,
+			expectReason:   "execution reverted",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRevertError(tt.err)
+			if r.Category != tt.expectCategory || r.Reason != tt.expectReason {
+				t.Fatalf("expected %s (%s), got %s (%s)", tt.expectCategory, tt.expectReason, r.Category, r.Reason)
+			}
+		})
+	}
+}
+
+func TestIsRevert(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		expect bool
+	}{
+		{"data", revertTestError{"whatever", 3, "0x"}, true},
+		{"revert code", revertTestCodeError{"whatever", rpcRevertCode}, true},
+		{"message", errors.New("Execution Reverted: nope"), true},
+		{"rate limited", revertTestCodeError{"too many requests", -32005}, false},
+		{"unsupported", revertTestCodeError{"the method eth_call does not exist", -32601}, false},
+		{"network", errors.New("connection refused"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRevert(tt.err); got != tt.expect {
+				t.Fatalf("expected %v, got %v", tt.expect, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	routerABIsProvider = "explorer"
+	routerABIsTimeout  = 10 * time.Second
+)
+
+var (
+	// UniswapRouterMethods adding liquidity, the ones of the routers forked from uniswap v2 as is
+	UniswapRouterMethods = RouterMethods{
+		AddLiquidity:    [][4]byte{{0xe8, 0xe3, 0x37, 0x00}},
+		AddLiquidityETH: [][4]byte{{0xf3, 0x05, 0xd7, 0x19}},
+	}
+
+	// routerAddLiquidityInputs of addLiquidity(tokenA, tokenB, amountADesired, amountBDesired, amountAMin, amountBMin, to, deadline)
+	routerAddLiquidityInputs = []string{"address", "address", "uint256", "uint256", "uint256", "uint256", "address", "uint256"}
+	// routerAddLiquidityETHInputs of addLiquidityETH(token, amountTokenDesired, amountTokenMin, amountETHMin, to, deadline)
+	routerAddLiquidityETHInputs = []string{"address", "uint256", "uint256", "uint256", "address", "uint256"}
+)
+
+type (
+	// RouterABIs of the routers, fetched from an etherscan compatible explorer api (eg. https://api.bscscan.com/api)
+	// once and cached in a directory, so routers we don't ship the abi of are decoded anyway. Calls are rate limited as
+	// the 'explorer' provider.
+	RouterABIs struct {
+		url    string
+		key    string
+		dir    string
+		limits routerABIsLimits
+		client *http.Client
+	}
+
+	// RouterMethods adding liquidity to a router, by their selectors. Forks rename them (eg. addLiquidityAVAX) but keep
+	// their arguments, so they are decoded as the uniswap ones.
+	RouterMethods struct {
+		AddLiquidity    [][4]byte
+		AddLiquidityETH [][4]byte
+	}
+
+	routerABIsLimits interface {
+		Allow(provider string) bool
+		Exhausted(provider string)
+	}
+
+	routerABIsResponse struct {
+		Status string `json:"status"`
+		Result string `json:"result"`
+	}
+)
+
+func NewRouterABIs(explorer, key, dir string, l routerABIsLimits) *RouterABIs {
+	return &RouterABIs{
+		url:    explorer,
+		key:    key,
+		dir:    dir,
+		limits: l,
+		client: &http.Client{
+			Timeout: routerABIsTimeout,
+		},
+	}
+}
+
+// ABI of the router, from the cache if we fetched it before. It must be verified in the explorer.
+func (r *RouterABIs) ABI(ctx context.Context, router common.Address) (abi.ABI, error) {
+	path := filepath.Join(r.dir, strings.ToLower(router.Hex())+".json")
+	if raw, err := os.ReadFile(path); err == nil {
+		if a, err := abi.JSON(strings.NewReader(string(raw))); err == nil {
+			return a, nil
+		}
+	}
+
+	raw, err := r.fetch(ctx, router)
+	if err != nil {
+		return abi.ABI{}, err
+	}
+	a, err := abi.JSON(strings.NewReader(raw))
+	if err != nil {
+		return abi.ABI{}, fmt.Errorf("malformed abi of router %s: %s", router.Hex(), err)
+	}
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return a, fmt.Errorf("error caching the abi of router %s: %s", router.Hex(), err)
+	}
+	if err := os.WriteFile(path, []byte(raw), 0o644); err != nil {
+		return a, fmt.Errorf("error caching the abi of router %s: %s", router.Hex(), err)
+	}
+	return a, nil
+}
+
+func (r *RouterABIs) fetch(ctx context.Context, router common.Address) (string, error) {
+	if !r.limits.Allow(routerABIsProvider) {
+		return "", fmt.Errorf("rate limited fetching the abi of router %s", router.Hex())
+	}
+	q := url.Values{}
+	q.Set("module", "contract")
+	q.Set("action", "getabi")
+	q.Set("address", router.Hex())
+	if len(r.key) > 0 {
+		q.Set("apikey", r.key)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url+"?"+q.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusTooManyRequests {
+		r.limits.Exhausted(routerABIsProvider)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var res routerABIsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return "", fmt.Errorf("malformed explorer response: %s", err)
+	}
+	if res.Status != "1" {
+		// the result is the reason, eg. Contract source code not verified
+		return "", fmt.Errorf("explorer has no abi of router %s: %s", router.Hex(), res.Result)
+	}
+	return res.Result, nil
+}
+
+// NewRouterMethods adding liquidity in the abi of a router, the ones named addLiquidity* with the arguments of the
+// uniswap ones
+func NewRouterMethods(a abi.ABI) RouterMethods {
+	var m RouterMethods
+	for _, method := range a.Methods {
+		if !strings.HasPrefix(method.RawName, "addLiquidity") {
+			continue
+		}
+		var sel [4]byte
+		copy(sel[:], method.ID)
+		switch {
+		case routerMethodTakes(method, routerAddLiquidityInputs):
+			m.AddLiquidity = append(m.AddLiquidity, sel)
+		case method.IsPayable() && routerMethodTakes(method, routerAddLiquidityETHInputs):
+			m.AddLiquidityETH = append(m.AddLiquidityETH, sel)
+		}
+	}
+	return m
+}
+
+// Empty if the router has no method to add liquidity we can decode
+func (m RouterMethods) Empty() bool {
+	return len(m.AddLiquidity) == 0 && len(m.AddLiquidityETH) == 0
+}
+
+func routerMethodTakes(m abi.Method, inputs []string) bool {
+	if len(m.Inputs) != len(inputs) {
+		return false
+	}
+	for i, in := range m.Inputs {
+		if in.Type.String() != inputs[i] {
+			return false
+		}
+	}
+	return true
+}
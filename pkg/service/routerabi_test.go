@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// routerABITest of a fork renaming the native coin methods, eg. trader joe on avalanche
+const routerABITest = `[
+	{"type": "function", "name": "addLiquidity", "stateMutability": "nonpayable", "inputs": [
+		{"name": "tokenA", "type": "address"}, {"name": "tokenB", "type": "address"},
+		{"name": "amountADesired", "type": "uint256"}, {"name": "amountBDesired", "type": "uint256"},
+		{"name": "amountAMin", "type": "uint256"}, {"name": "amountBMin", "type": "uint256"},
+		{"name": "to", "type": "address"}, {"name": "deadline", "type": "uint256"}], "outputs": []},
+	{"type": "function", "name": "addLiquidityAVAX", "stateMutability": "payable", "inputs": [
+		{"name": "token", "type": "address"}, {"name": "amountTokenDesired", "type": "uint256"},
+		{"name": "amountTokenMin", "type": "uint256"}, {"name": "amountAVAXMin", "type": "uint256"},
+		{"name": "to", "type": "address"}, {"name": "deadline", "type": "uint256"}], "outputs": []},
+	{"type": "function", "name": "removeLiquidityAVAX", "stateMutability": "nonpayable", "inputs": [
+		{"name": "token", "type": "address"}, {"name": "liquidity", "type": "uint256"},
+		{"name": "amountTokenMin", "type": "uint256"}, {"name": "amountAVAXMin", "type": "uint256"},
+		{"name": "to", "type": "address"}, {"name": "deadline", "type": "uint256"}], "outputs": []}
+]`
+
+func TestRouterABIs_ABI(t *testing.T) {
+	router := common.HexToAddress("0x60aE616a2155Ee3d9A68541Ba4544862310933d4")
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		q := r.URL.Query()
+		if q.Get("action") != "getabi" || q.Get("apikey") != "key" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if q.Get("address") != router.Hex() {
+			fmt.Fprint(w, `{"status": "0", "message": "NOTOK", "result": "Contract source code not verified"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"status": "1", "message": "OK", "result": %s}`, strconv.Quote(routerABITest))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	r := NewRouterABIs(srv.URL, "key", dir, &fakeDexscreenerLimits{allow: true})
+	for i := 0; i < 2; i++ {
+		a, err := r.ABI(context.Background(), router)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := NewRouterMethods(a)
+		if len(m.AddLiquidity) != 1 || m.AddLiquidity[0] != UniswapRouterMethods.AddLiquidity[0] {
+			t.Fatalf("expected addLiquidity to be the uniswap one, got %x", m.AddLiquidity)
+		}
+		if len(m.AddLiquidityETH) != 1 || m.AddLiquidityETH[0] != [4]byte{0xf9, 0x1b, 0x3f, 0x72} {
+			t.Fatalf("expected addLiquidityAVAX as addLiquidityETH, got %x", m.AddLiquidityETH)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected the abi to be fetched once and then read from the cache, got %d calls", calls)
+	}
+	if _, err := os.Stat(filepath.Join(dir, strings.ToLower(router.Hex())+".json")); err != nil {
+		t.Fatalf("expected the abi to be cached, got %s", err)
+	}
+
+	if _, err := r.ABI(context.Background(), common.HexToAddress("0x01")); err == nil ||
+		!strings.Contains(err.Error(), "not verified") {
+		t.Fatalf("expected an unverified router to fail, got %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+var (
+	triggerReducedSmartContract = []byte{0x1f, 0x27, 0x5c, 0xa0} // function 'snipeListingReduced' in our trigger smart contract.
+)
+
+type (
+	// SandwichBots likely targeting the pool of the target, seen pending in the mempool. Bots don't go through the
+	// router but through their own contracts, so any pending call to a contract other than the router, the token and
+	// our trigger carrying the target token is taken as one of them. Calls are forgotten after the ttl, mined or not.
+	SandwichBots struct {
+		ttl    time.Duration
+		router common.Address
+		target *atomic.Value // sandwichBotsTarget
+
+		seen []sandwichBot
+		mut  *sync.Mutex
+	}
+
+	sandwichBotsTarget struct {
+		token, trigger common.Address
+	}
+
+	sandwichBot struct {
+		hash common.Hash
+		to   common.Address
+		seen time.Time
+	}
+
+	sniperSandwichBots interface {
+		Pending() []common.Address
+	}
+)
+
+func NewSandwichBots(ttl time.Duration, router common.Address, sn domain.Sniper) *SandwichBots {
+	b := &SandwichBots{
+		ttl:    ttl,
+		router: router,
+		target: new(atomic.Value),
+		mut:    new(sync.Mutex),
+	}
+	_ = b.SetTarget(sn)
+	return b
+}
+
+// SetTarget whose bots we watch, forgetting the ones of the previous target
+func (b *SandwichBots) SetTarget(sn domain.Sniper) error {
+	b.target.Store(sandwichBotsTarget{
+		token:   common.HexToAddress(sn.AddressTargetToken),
+		trigger: common.HexToAddress(sn.AddressTrigger),
+	})
+	b.mut.Lock()
+	defer b.mut.Unlock()
+	b.seen = nil
+	return nil
+}
+
+// Observe a pending tx, remembering it if it's a likely bot of our target. It's a monitor of every tx.
+func (b *SandwichBots) Observe(_ context.Context, tx *types.Transaction) {
+	to := tx.To()
+	if to == nil || *to == b.router {
+		return
+	}
+	t := b.target.Load().(sandwichBotsTarget)
+	if *to == t.token || *to == t.trigger || !bytes.Contains(tx.Data(), t.token.Bytes()) {
+		return
+	}
+	b.add(sandwichBot{hash: tx.Hash(), to: *to, seen: time.Now()})
+}
+
+// Pending bots seen within the ttl, by the contracts they call
+func (b *SandwichBots) Pending() []common.Address {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	b.expire(time.Now())
+	res := make([]common.Address, 0, len(b.seen))
+	known := make(map[common.Address]bool)
+	for _, s := range b.seen {
+		if !known[s.to] {
+			known[s.to] = true
+			res = append(res, s.to)
+		}
+	}
+	return res
+}
+
+func (b *SandwichBots) add(s sandwichBot) {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	b.expire(s.seen)
+	for _, prev := range b.seen {
+		if prev.hash == s.hash {
+			return // the node delivered it twice
+		}
+	}
+	b.seen = append(b.seen, s)
+}
+
+// expire the calls older than the ttl. They are kept in the order we saw them. Must be called holding the lock.
+func (b *SandwichBots) expire(now time.Time) {
+	i := 0
+	for i < len(b.seen) && now.Sub(b.seen[i].seen) > b.ttl {
+		i++
+	}
+	b.seen = b.seen[i:]
+}
+
+// GuardSandwiches of the snipes: while bots are pending around the target, each snipe is estimated how much of the
+// paired token a sandwich may take from it in the worst case (buying before us until we get our min amount out).
+// Above the max of the guard, it's sent only through the relays or reduced until it's within it. It needs the adaptive
+// slippage, which simulates the snipes: the rest are sent as usual.
+func (c *Sniper) GuardSandwiches(g domain.SandwichGuard, b sniperSandwichBots) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.sandwich = &g
+	c.sandwichBots = b
+}
+
+// guardSandwich of the order, if sandwich bots are pending around the target. It returns the min amount out of the
+// order, which changes if it's reduced.
+// Must be called holding the lock.
+func (c *Sniper) guardSandwich(o *snipeOrder, reserves launchReserves, competing, minOut *big.Int) (*big.Int, error) {
+	bots := c.sandwichBots.Pending()
+	if len(bots) == 0 {
+		return minOut, nil
+	}
+	e, err := domain.EstimateSandwich(c.slippage.Fee, o.size, reserves.paired, reserves.token, minOut)
+	if err != nil {
+		return nil, err
+	}
+	log.Warn(fmt.Sprintf(
+		"%d sandwich bots pending around %s, in the worst case the snipe gets %s of the %s tokens expected (exposure %.6f)",
+		len(bots), c.sniperTTBAddr.Hex(), e.Worst, e.Expected, formatETHWeiToEther(e.Exposure),
+	))
+	if !c.sandwich.Exceeds(e) {
+		return minOut, nil
+	}
+
+	switch c.sandwich.Action {
+	case domain.SandwichActionPrivate:
+		if !c.relays.Enabled() {
+			log.Warn("no relays to keep the snipe out of the public mempool, sending it as usual")
+			return minOut, nil
+		}
+		log.Info("exposure above the max, sending the snipe only through the relays")
+		o.private = true
+	case domain.SandwichActionReduce:
+		if c.sniperReveal != nil {
+			log.Warn("a committed order can't be reduced, sending it as usual")
+			return minOut, nil
+		}
+		o.size = c.sandwich.Reduced(e)
+		if o.size.Sign() == 0 {
+			return nil, domain.NewSkipError(domain.SkipReasonSandwichExposure, fmt.Sprintf(
+				"%d sandwich bots around, any snipe is exposed above the max", len(bots),
+			))
+		}
+		log.Info(fmt.Sprintf("exposure above the max, reducing the snipe to %s", o.size))
+		return c.slippage.MinOut(o.size, reserves.paired, reserves.token, competing)
+	}
+	return minOut, nil
+}
+
+// newTriggerReducedCalldata for the trigger on the v2 pair, lowering the amount in and raising the min amount out to
+// the given ones
+func newTriggerReducedCalldata(amountIn, minOut *big.Int) []byte {
+	data := make([]byte, 0, 4+2*common.HashLength)
+	data = append(data, triggerReducedSmartContract...)
+	data = append(data, common.LeftPadBytes(amountIn.Bytes(), common.HashLength)...)
+	return append(data, common.LeftPadBytes(minOut.Bytes(), common.HashLength)...)
+}
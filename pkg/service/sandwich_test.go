@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+func TestSandwichBots_Pending(t *testing.T) {
+	router := common.HexToAddress("0x10ED43C718714eb63d5aA57B78B54704E256024E")
+	trigger := common.HexToAddress("0x00000000000000000000000000000000000000e1")
+	bot, other := common.HexToAddress("0x00000000000000000000000000000000000000b1"), common.HexToAddress("0x00000000000000000000000000000000000000b2")
+	b := NewSandwichBots(time.Minute, router, domain.Sniper{AddressTargetToken: benchTokenA.Hex(), AddressTrigger: trigger.Hex()})
+	call := func(nonce uint64, to common.Address, data []byte) *types.Transaction {
+		return types.NewTransaction(nonce, to, new(big.Int), 300000, big.NewInt(5), data)
+	}
+	withToken := append([]byte{0x01, 0x02, 0x03, 0x04}, common.LeftPadBytes(benchTokenA.Bytes(), common.HashLength)...)
+
+	txs := []*types.Transaction{
+		call(0, bot, withToken),                  // a bot
+		call(1, bot, withToken),                  // the same bot again
+		call(2, router, withToken),               // a router swap, competing buys
+		call(3, trigger, withToken),              // our own trigger
+		call(4, benchTokenA, withToken),          // the token itself (eg. enabling trading)
+		call(5, other, []byte{0x01, 0x02, 0x03}), // not carrying the token
+	}
+	for _, tx := range append(txs, txs[0]) { // delivered twice
+		b.Observe(context.Background(), tx)
+	}
+
+	if p := b.Pending(); len(p) != 1 || p[0] != bot {
+		t.Fatalf("expected bot %s pending, got %v", bot.Hex(), p)
+	}
+	if len(b.seen) != 2 {
+		t.Fatalf("expected 2 calls seen, got %d", len(b.seen))
+	}
+
+	b.seen[0].seen = time.Now().Add(-2 * time.Minute)
+	b.seen[1].seen = time.Now().Add(-2 * time.Minute)
+	if p := b.Pending(); len(p) != 0 {
+		t.Fatalf("expected the expired calls forgotten, got %v", p)
+	}
+
+	b.Observe(context.Background(), txs[0])
+	if err := b.SetTarget(domain.Sniper{AddressTargetToken: benchTokenB.Hex(), AddressTrigger: trigger.Hex()}); err != nil {
+		t.Fatal(err)
+	}
+	if p := b.Pending(); len(p) != 0 {
+		t.Fatalf("expected the bots of the previous target forgotten, got %v", p)
+	}
+}
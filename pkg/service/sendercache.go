@@ -0,0 +1,92 @@
+package service
+
+import (
+	"container/list"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+type (
+	// SenderCache is a LRU cache of the senders recovered from txs, keyed by tx hash.
+	// Recovering a sender means an ECDSA recovery, which is by far the most expensive thing we do per tx. Since the same
+	// pending tx may be delivered more than once (retries, duplicate gossip, several monitors) we only pay it once.
+	SenderCache struct {
+		size int
+
+		entries map[common.Hash]*list.Element
+		order   *list.List
+		mut     *sync.Mutex
+
+		signers *sync.Map // chain id -> types.Signer
+	}
+
+	senderCacheEntry struct {
+		hash   common.Hash
+		sender common.Address
+	}
+)
+
+func NewSenderCache(size int) *SenderCache {
+	if size <= 0 {
+		panic("sender cache size > 0")
+	}
+	return &SenderCache{
+		size:    size,
+		entries: make(map[common.Hash]*list.Element, size),
+		order:   list.New(),
+		mut:     new(sync.Mutex),
+		signers: new(sync.Map),
+	}
+}
+
+// Sender of the tx in the given chain
+func (c *SenderCache) Sender(chainID *big.Int, tx *types.Transaction) (common.Address, error) {
+	h := tx.Hash()
+	if addr, ok := c.get(h); ok {
+		return addr, nil
+	}
+
+	addr, err := types.Sender(c.signer(chainID), tx)
+	if err != nil {
+		return common.Address{}, err
+	}
+	c.put(h, addr)
+	return addr, nil
+}
+
+func (c *SenderCache) signer(chainID *big.Int) types.Signer {
+	k := chainID.String()
+	if s, ok := c.signers.Load(k); ok {
+		return s.(types.Signer)
+	}
+	s, _ := c.signers.LoadOrStore(k, types.LatestSignerForChainID(chainID))
+	return s.(types.Signer)
+}
+
+func (c *SenderCache) get(h common.Hash) (common.Address, bool) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	if e, ok := c.entries[h]; ok {
+		c.order.MoveToFront(e)
+		return e.Value.(senderCacheEntry).sender, true
+	}
+	return common.Address{}, false
+}
+
+func (c *SenderCache) put(h common.Hash, addr common.Address) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	if e, ok := c.entries[h]; ok {
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[h] = c.order.PushFront(senderCacheEntry{hash: h, sender: addr})
+	if c.order.Len() > c.size {
+		last := c.order.Back()
+		c.order.Remove(last)
+		delete(c.entries, last.Value.(senderCacheEntry).hash)
+	}
+}
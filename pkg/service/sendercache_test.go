@@ -0,0 +1,95 @@
+package service
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func newSenderCacheTx(t *testing.T, nonce uint64) (*types.Transaction, common.Address) {
+	pk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := types.SignTx(
+		types.NewTransaction(nonce, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil),
+		types.NewEIP155Signer(big.NewInt(56)),
+		pk,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tx, crypto.PubkeyToAddress(pk.PublicKey)
+}
+
+func TestSenderCache_Sender(t *testing.T) {
+	c := NewSenderCache(2)
+	tx, sender := newSenderCacheTx(t, 0)
+
+	for i := 0; i < 2; i++ { // recovered, then cached
+		addr, err := c.Sender(big.NewInt(56), tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if addr != sender {
+			t.Fatalf("expected sender %s, got %s", sender.Hex(), addr.Hex())
+		}
+	}
+	if len(c.entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(c.entries))
+	}
+}
+
+func TestSenderCache_Sender_WrongChain(t *testing.T) {
+	c := NewSenderCache(2)
+	tx, _ := newSenderCacheTx(t, 0)
+
+	if _, err := c.Sender(big.NewInt(1), tx); err == nil {
+		t.Fatal("expected an error recovering the sender of another chain")
+	}
+	if len(c.entries) != 0 {
+		t.Fatalf("expected no entries, got %d", len(c.entries))
+	}
+}
+
+func TestSenderCache_Evicts(t *testing.T) {
+	c := NewSenderCache(2)
+	txA, _ := newSenderCacheTx(t, 0)
+	txB, _ := newSenderCacheTx(t, 1)
+	txC, _ := newSenderCacheTx(t, 2)
+
+	for _, tx := range []*types.Transaction{txA, txB, txA, txC} { // B is the least recently used when C comes
+		if _, err := c.Sender(big.NewInt(56), tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		tx     *types.Transaction
+		cached bool
+	}{
+		{"recently used", txA, true},
+		{"least recently used", txB, false},
+		{"latest", txC, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := c.entries[tt.tx.Hash()]; ok != tt.cached {
+				t.Fatalf("expected cached %v, got %v", tt.cached, ok)
+			}
+		})
+	}
+}
+
+func TestNewSenderCache_InvalidSize(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic")
+		}
+	}()
+	NewSenderCache(0)
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+	"github.com/saantiaguilera/liquidity-sniper/third_party/uniswap"
+)
+
+var (
+	triggerMinSmartContract       = []byte{0x40, 0x36, 0x93, 0x54} // function 'snipeListingMin' in our trigger smart contract.
+	triggerRevealMinSmartContract = []byte{0x71, 0x6c, 0xc6, 0xef} // function 'revealAndSnipeMin' in our trigger smart contract.
+)
+
+type (
+	sniperCompetingBuys interface {
+		Competing(gasPrice *big.Int) *big.Int
+	}
+
+	// launchReservesKey of the context carrying the reserves a pending liquidity addition will leave in the pair
+	launchReservesKey struct{}
+
+	launchReserves struct {
+		paired, token *big.Int
+	}
+)
+
+// withLaunchReserves the pending liquidity addition being sniped leaves in the pair, so the snipe can be simulated
+// before the pair holds them. They are copied, as the decoded additions are pooled.
+func withLaunchReserves(ctx context.Context, paired, token *big.Int) context.Context {
+	return context.WithValue(ctx, launchReservesKey{}, launchReserves{
+		paired: new(big.Int).Set(paired),
+		token:  new(big.Int).Set(token),
+	})
+}
+
+// AdaptSlippage of the snipes: instead of the static min amount out of the trigger, each of them asks for the amount
+// simulated against the reserves after the competing buys (minus the slippage buffer). The trigger min is still the
+// floor. It's only done buying exact in on the v2 pair paired with wbnb, the rest keep the static min.
+// Txs are signed on each snipe with their own min, so the presigned ones are never used.
+func (c *Sniper) AdaptSlippage(wbnb common.Address, s domain.Slippage, b sniperCompetingBuys) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.wbnb = wbnb
+	c.slippage = &s
+	c.competing = b
+}
+
+// order of a snipe behind the victim. Unless we use the static min amount out, the trigger is asked for the simulated
+// one: when racing the pending victim the buy is simulated against the reserves it adds, after the competing buys
+// paying at least its gas. Once it's mined the pair reserves already reflect them. If sandwich bots are around, the
+// order is guarded from them too (see GuardSandwiches).
+// It only fails if the snipe must be skipped, when it can't be simulated we fall back to the static min.
+// Must be called holding the lock.
+func (c *Sniper) order(ctx context.Context, gasPrice *big.Int, mined bool) (snipeOrder, error) {
+	o := snipeOrder{size: c.sniperOrderSize}
+	if c.slippage == nil || c.venue.fee > 0 || c.exactOut || c.sniperOrderSize == nil || c.sniperTokenPaired != c.wbnb {
+		return o, nil
+	}
+
+	var reserves launchReserves
+	competing := new(big.Int)
+	if mined {
+		_, paired, token, err := c.reserves(ctx)
+		if err != nil {
+			log.Warn(fmt.Sprintf("couldn't simulate the snipe, using the static min amount out: %s", err))
+			return o, nil
+		}
+		reserves = launchReserves{paired: paired, token: token}
+	} else {
+		r, ok := ctx.Value(launchReservesKey{}).(launchReserves)
+		if !ok {
+			return o, nil
+		}
+		reserves = r
+		competing = c.competing.Competing(gasPrice)
+	}
+
+	minOut, err := c.slippage.MinOut(o.size, reserves.paired, reserves.token, competing)
+	if err == nil && c.sandwich != nil {
+		minOut, err = c.guardSandwich(&o, reserves, competing, minOut)
+	}
+	if err != nil {
+		if _, skip := err.(*domain.SkipError); skip {
+			return o, err
+		}
+		log.Warn(fmt.Sprintf("couldn't simulate the snipe, using the static min amount out: %s", err))
+		return snipeOrder{size: c.sniperOrderSize}, nil
+	}
+	log.Debug(fmt.Sprintf("snipe of %s min amount out %s, behind competing buys of %s", o.size, minOut, competing))
+	if o.size.Cmp(c.sniperOrderSize) < 0 {
+		o.data = newTriggerReducedCalldata(o.size, minOut)
+	} else {
+		o.data = newTriggerMinOutCalldata(c.sniperReveal, c.sniperTokenPaired, c.sniperTTBAddr, minOut)
+	}
+	return o, nil
+}
+
+// newTriggerMinOutCalldata for the trigger on the v2 pair, raising the min amount out to the given one
+func newTriggerMinOutCalldata(reveal *domain.SniperReveal, paired, token common.Address, minOut *big.Int) []byte {
+	data := make([]byte, 0, 4+6*common.HashLength)
+	if reveal == nil {
+		data = append(data, triggerMinSmartContract...)
+	} else {
+		data = append(data, triggerRevealMinSmartContract...)
+		data = append(data, common.LeftPadBytes(paired.Bytes(), common.HashLength)...)
+		data = append(data, common.LeftPadBytes(reveal.AmountIn.Bytes(), common.HashLength)...)
+		data = append(data, common.LeftPadBytes(token.Bytes(), common.HashLength)...)
+		data = append(data, common.LeftPadBytes(reveal.AmountOutMin.Bytes(), common.HashLength)...)
+		data = append(data, reveal.Salt[:]...)
+	}
+	return append(data, common.LeftPadBytes(minOut.Bytes(), common.HashLength)...)
+}
+
+// reserves of the paired token and the target token in their v2 pair, which is returned too.
+// Must be called holding the lock.
+func (c *Sniper) reserves(ctx context.Context) (common.Address, *big.Int, *big.Int, error) {
+	opts := &bind.CallOpts{Context: ctx}
+	pair, err := c.factoryClient.GetPair(opts, c.sniperTTBAddr, c.sniperTokenPaired)
+	if err != nil {
+		return pair, nil, nil, fmt.Errorf("error getting pair: %s", err)
+	}
+	if pair == (common.Address{}) {
+		return pair, nil, nil, domain.NewSkipError(domain.SkipReasonPoolUnfunded, "pair doesn't exist")
+	}
+
+	caller, err := uniswap.NewIUniswapV2PairCaller(pair, c.ethClient)
+	if err != nil {
+		return pair, nil, nil, fmt.Errorf("error binding pair %s: %s", pair.Hex(), err)
+	}
+	reserves, err := caller.GetReserves(opts)
+	if err != nil {
+		return pair, nil, nil, fmt.Errorf("error getting reserves of pair %s: %s", pair.Hex(), err)
+	}
+
+	// the pair sorts its tokens by address
+	if bytes.Compare(c.sniperTokenPaired.Bytes(), c.sniperTTBAddr.Bytes()) > 0 {
+		return pair, reserves.Reserve1, reserves.Reserve0, nil
+	}
+	return pair, reserves.Reserve0, reserves.Reserve1, nil
+}
@@ -3,21 +3,24 @@ package service
 import (
 	"context"
 	"crypto/ecdsa"
-	"encoding/hex"
+	"errors"
 	"fmt"
 	"math/big"
+	"math/rand"
 	"runtime/debug"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
 
 	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
-	"github.com/saantiaguilera/liquidity-sniper/third_party/erc20"
 )
 
 const (
@@ -25,23 +28,146 @@ const (
 )
 
 var (
-	triggerSmartContract = []byte{0x4e, 0xfa, 0xc3, 0x29} // function 'snipeListing' in our trigger smart contract.
-	txValue              = big.NewInt(0)
-	txGasLimit           = uint64(500000)
+	triggerSmartContract         = []byte{0x4e, 0xfa, 0xc3, 0x29} // function 'snipeListing' in our trigger smart contract.
+	triggerRevealSmartContract   = []byte{0x01, 0x23, 0x53, 0x11} // function 'revealAndSnipe' in our trigger smart contract.
+	triggerV3SmartContract       = []byte{0xf8, 0x94, 0xa9, 0xcc} // function 'snipeListingV3' in our trigger smart contract.
+	triggerRevealV3SmartContract = []byte{0x55, 0x1d, 0x0d, 0xe1} // function 'revealAndSnipeV3' in our trigger smart contract.
+	txValue                      = big.NewInt(0)
+	// defaultTxGasLimit of snipe txs, when the target has none and it can't be estimated
+	defaultTxGasLimit = uint64(500000)
+	cancelGasLimit    = uint64(21000)
+	// cancelGasBump is the percentage of gas added to a tx to cancel it, a bit above the usual min bump for replacements.
+	cancelGasBump = int64(12)
+	// maxRebroadcasts of a snipe tx that vanishes from the mempool without being mined.
+	maxRebroadcasts = 3
+	// sniperTxMaxBlocks a snipe tx may stay pending before we give up on it
+	sniperTxMaxBlocks = uint64(2)
 )
 
 type (
 	Sniper struct {
 		mut *sync.Mutex
+		// sniping serializes the rounds with what changes them (targets, venues, nonce reconciles). It's taken before
+		// mut, which a round releases while it waits for its bundle, so eg. a panic sell isn't held behind the relays.
+		sniping *sync.Mutex
 
 		factoryClient sniperFactoryClient // eg. PCS
 		ethClient     sniperETHClient
+		gasOracle     sniperGasOracle
+		relays        sniperRelays
+		rebroadcaster sniperRebroadcaster
+		watcher       sniperInclusionWatcher
+		trades        sniperTradeRepository
+		alerts        sniperAlerter
+		quietHours    sniperQuietHours
 		swarm         []*Bee
 
+		// gasMaxMultiplier of the network median we are willing to pay. Zero means no cap.
+		gasMaxMultiplier float64
+		// gasMinMultiplier of the network median a victim must pay so we snipe it. Zero means no minimum.
+		gasMinMultiplier float64
+		// fillTolerance is the % of the tokens sent by the pair we may not receive before alerting (eg. transfer taxes).
+		fillTolerance float64
+		// fallbackGasBump is the percentage of gas added when a bundle misses its block and we go public after the victim.
+		fallbackGasBump uint
+		// concurrent submits through the relays and the public mempool at once, instead of falling back to the latter.
+		concurrent bool
+		// accessList of the snipe txs is created on each snipe, and used if it makes them cheaper.
+		accessList bool
+		// dynamicFees if the chain has a fee market (EIP-1559), in which case our txs are dynamic fee ones
+		dynamicFees bool
+		// reentryBlocks after a reverted snipe in which we try again. Zero means no re-entry.
+		reentryBlocks uint
+		// gasLimitBuffer is the percentage of gas added to the estimated gas limit of snipe txs.
+		gasLimitBuffer uint
+		// delayed is true if we only see victims once mined (eg. block mode), so the pool should already be funded.
+		delayed bool
+		// stealthDelay is the most each bee randomly waits before sending when we aren't racing the victim (eg. delayed
+		// or re-entering), so the swarm doesn't fire in lockstep. Zero means no delay.
+		stealthDelay time.Duration
+		// timing of the chain our txs and victims are polled with
+		timing domain.Timing
+
+		sniperName        string
 		sniperTTBAddr     common.Address
 		sniperTriggerAddr common.Address
 		sniperTokenPaired common.Address
+		sniperMinLiq      *big.Int
 		sniperChainID     *big.Int
+		sniperGasLimit    uint64
+		sniperReveal      *domain.SniperReveal
+		triggerCalldata   []byte
+		// venue the trigger snipes on, which changes when the liquidity of the target migrates between pool versions
+		venue triggerVenue
+		// exactOut if the target buys exact out, in which case the min amount out of the trigger is what we buy
+		exactOut bool
+		// slippage of each snipe, simulated from the reserves and the competing buys of the target. Nil keeps the
+		// static min amount out of the trigger. See AdaptSlippage.
+		slippage  *domain.Slippage
+		competing sniperCompetingBuys
+		wbnb      common.Address
+		// sandwich guard of the snipes while sandwich bots are around, nil if they aren't guarded. See GuardSandwiches.
+		sandwich     *domain.SandwichGuard
+		sandwichBots sniperSandwichBots
+		// priceFeed of the native coin in USD the trades are reported with, nil if they aren't. See ReportUSD.
+		priceFeed sniperPriceFeed
+
+		// sniperOrderSize is what the trigger spends on each snipe, sniperBudget the most we may spend overall (nil for no budget).
+		// Buying exact out the order size is the most a snipe spends, and it's what we count against the budget.
+		sniperOrderSize *big.Int
+		sniperBudget    *big.Int
+		spent           *big.Int
+
+		presigned *presignedTxs
+		decimals  *TokenDecimals
+		// last snipe round, in case its victim gets replaced
+		last *snipeRound
+		// replacing are the victims being replaced right now, so we don't take them for dropped
+		replacing *sync.Map
+		// inflight are the cancel funcs of the rounds being sniped by victim, so a replacement of the victim cuts its
+		// stale round short instead of waiting behind it for the lock
+		inflight *sync.Map
+		// staleNonces is set when a bee hits a nonce error, so the swarm is reconciled once the round is over
+		staleNonces *int32
+	}
+
+	// triggerVenue is the pool the trigger swaps on: the v2 pair if the fee tier is zero, else the v3 pool of the fee
+	// tier. pairedFee is the fee tier of the v3 pool between wbnb and the paired token, if it isn't wbnb.
+	triggerVenue struct {
+		fee       uint32
+		pairedFee uint32
+	}
+
+	// snipeTx parameters shared by the txs of the swarm in a round, besides their gas price
+	snipeTx struct {
+		gasLimit   uint64
+		accessList types.AccessList
+		// data of the trigger call, nil for the trigger calldata of the target (eg. asking for our own min amount out)
+		data []byte
+		// private txs are only sent through the relays, never to the public mempool
+		private bool
+	}
+
+	// snipeOrder of a round: the trigger call, what it spends and if it must stay out of the public mempool
+	snipeOrder struct {
+		// data of the trigger call, nil for the trigger calldata of the target
+		data    []byte
+		size    *big.Int
+		private bool
+	}
+
+	// snipeRound of the swarm behind a victim. nonces are the ones each bee used (aligned with the swarm).
+	snipeRound struct {
+		victim common.Hash
+		nonces []uint64
+		filled bool
+	}
+
+	// beeTx signed by a bee of the swarm for a round, with the nonce it uses
+	beeTx struct {
+		bee   *Bee
+		nonce uint64
+		tx    *types.Transaction
 	}
 
 	sniperFactoryClient interface {
@@ -55,6 +181,45 @@ type (
 
 		TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, isPending bool, err error)
 		TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+		NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+		CreateAccessList(context.Context, ethereum.CallMsg) (*types.AccessList, uint64, string, error)
+	}
+
+	sniperGasOracle interface {
+		Median(context.Context) (*big.Int, error)
+	}
+
+	sniperRelays interface {
+		Enabled() bool
+		CallBundle(context.Context, []*types.Transaction, uint64) (*BundleSimulation, error)
+		SendBundle(context.Context, []*types.Transaction, uint64) error
+	}
+
+	sniperRebroadcaster interface {
+		Enabled() bool
+		Rebroadcast(context.Context, *types.Transaction) error
+	}
+
+	sniperTradeRepository interface {
+		Save(context.Context, domain.Trade) error
+	}
+
+	sniperAlerter interface {
+		Alert(domain.Alert)
+	}
+
+	sniperPriceFeed interface {
+		USD(context.Context) (float64, error)
+	}
+
+	sniperQuietHours interface {
+		Check(time.Time) error
+	}
+
+	sniperInclusionWatcher interface {
+		Head(context.Context) (uint64, error)
+		WaitForBlock(context.Context, uint64) error
+		WaitForInclusion(context.Context, common.Hash, uint64) (bool, error)
 	}
 
 	Bee struct {
@@ -62,32 +227,121 @@ type (
 		PendingNonce uint64
 	}
 
+	sentTx struct {
+		Hash       common.Hash
+		Simulation *BundleSimulation
+		Bee        *Bee
+		Tx         *types.Transaction
+		// cancelled is set once the tx was replaced by a cancel, so it's never rebroadcast. Shared by the copies of
+		// the sent tx, nil if it can't be cancelled.
+		cancelled *int32
+	}
+
 	txRes struct {
-		Hash    common.Hash
-		Receipt *types.Receipt
-		Success bool
+		Hash       common.Hash
+		Tx         *types.Transaction
+		Receipt    *types.Receipt
+		Success    bool
+		Simulation *BundleSimulation
+		// Cancelled if the tx was replaced by a cancel and it isn't around anymore
+		Cancelled bool
 	}
 )
 
 func NewSniper(
 	e sniperETHClient,
 	f sniperFactoryClient,
+	g sniperGasOracle,
+	r sniperRelays,
+	b sniperRebroadcaster,
+	w sniperInclusionWatcher,
+	t sniperTradeRepository,
+	a sniperAlerter,
+	q sniperQuietHours,
 	s []*Bee,
 	sn domain.Sniper,
+	gmax, gmin, ft float64,
+	gb, rb, lb uint,
+	sd time.Duration,
+	tm domain.Timing,
+	d, cs, al, df bool,
 ) *Sniper {
 
 	return &Sniper{
 		mut:               new(sync.Mutex),
+		sniping:           new(sync.Mutex),
 		ethClient:         e,
 		factoryClient:     f,
+		gasOracle:         g,
+		relays:            r,
+		rebroadcaster:     b,
+		watcher:           w,
+		trades:            t,
+		alerts:            a,
+		quietHours:        q,
 		swarm:             s,
+		gasMaxMultiplier:  gmax,
+		gasMinMultiplier:  gmin,
+		fillTolerance:     ft,
+		fallbackGasBump:   gb,
+		reentryBlocks:     rb,
+		gasLimitBuffer:    lb,
+		delayed:           d,
+		stealthDelay:      sd,
+		timing:            tm,
+		concurrent:        cs,
+		accessList:        al,
+		dynamicFees:       df,
+		sniperName:        sn.Name,
 		sniperTTBAddr:     common.HexToAddress(sn.AddressTargetToken),
 		sniperTriggerAddr: common.HexToAddress(sn.AddressTrigger),
 		sniperTokenPaired: common.HexToAddress(sn.AddressTargetPaired),
+		sniperMinLiq:      sn.MinimumLiquidity,
 		sniperChainID:     sn.ChainID,
+		sniperGasLimit:    sn.GasLimit,
+		sniperReveal:      sn.Reveal,
+		triggerCalldata:   newTriggerCalldata(sn, triggerVenue{}),
+		exactOut:          sn.BuyMode.ExactOut(),
+		sniperOrderSize:   sn.OrderSize,
+		sniperBudget:      sn.Budget,
+		spent:             new(big.Int),
+		presigned:         newPresignedTxs(),
+		decimals:          NewTokenDecimals(e),
+		replacing:         new(sync.Map),
+		inflight:          new(sync.Map),
+		staleNonces:       new(int32),
 	}
 }
 
+// newTriggerCalldata for the trigger contract on the given venue, revealing the committed order if needed.
+func newTriggerCalldata(sn domain.Sniper, v triggerVenue) []byte {
+	if sn.Reveal == nil && v.fee == 0 {
+		return triggerSmartContract
+	}
+
+	data := make([]byte, 0, 4+7*common.HashLength)
+	switch {
+	case sn.Reveal == nil:
+		data = append(data, triggerV3SmartContract...)
+	case v.fee == 0:
+		data = append(data, triggerRevealSmartContract...)
+	default:
+		data = append(data, triggerRevealV3SmartContract...)
+	}
+	if sn.Reveal != nil {
+		data = append(data, common.LeftPadBytes(common.HexToAddress(sn.AddressTargetPaired).Bytes(), common.HashLength)...)
+		data = append(data, common.LeftPadBytes(sn.Reveal.AmountIn.Bytes(), common.HashLength)...)
+		data = append(data, common.LeftPadBytes(common.HexToAddress(sn.AddressTargetToken).Bytes(), common.HashLength)...)
+		data = append(data, common.LeftPadBytes(sn.Reveal.AmountOutMin.Bytes(), common.HashLength)...)
+		data = append(data, sn.Reveal.Salt[:]...)
+	}
+	if v.fee > 0 {
+		data = append(data, common.LeftPadBytes(new(big.Int).SetUint64(uint64(v.fee)).Bytes(), common.HashLength)...)
+		data = append(data, common.LeftPadBytes(new(big.Int).SetUint64(uint64(v.pairedFee)).Bytes(), common.HashLength)...)
+	}
+	return data
+}
+
 func NewBee(
 	rawPK *ecdsa.PrivateKey,
 	pn uint64,
@@ -100,95 +354,697 @@ func NewBee(
 }
 
 // Snipe cloggs the mempool triggering our Trigger contract for performing the swap
-//   gas provided will be used on all txs. It's ideal to use the same gas as the addLiq tx so our txs gets the same priority as the addLiq one
+//   the victim gas will be used on all txs. It's ideal to use the same gas as the addLiq tx so our txs gets the same priority as the addLiq one
+//   if relays are configured, the swarm first tries to backrun the victim through a single bundle and falls back to the public
+//   mempool if the bundle misses its block.
 //
 // Snipe is concurrently safe
-func (c *Sniper) Snipe(ctx context.Context, gas *big.Int) error {
+func (c *Sniper) Snipe(ctx context.Context, victim *types.Transaction) error {
+	if err := c.quietHours.Check(time.Now()); err != nil {
+		return err
+	}
+
+	gas := victim.GasPrice()
+	if err := c.checkGasPrice(ctx, gas); err != nil {
+		return err
+	}
+
+	c.sniping.Lock()
+	defer c.sniping.Unlock()
 	c.mut.Lock()
 	defer c.mut.Unlock()
 
-	wg := new(sync.WaitGroup)
-	wg.Add(len(c.swarm))
+	if err := c.checkBudget(); err != nil {
+		return err
+	}
+	return c.snipe(ctx, victim, nil)
+}
 
-	pendingTxRes := make(chan common.Hash, len(c.swarm))
+// Replace the snipe of a victim that was sped up or re-submitted (same sender and nonce, new gas) by the given one.
+// The swarm re-sends its txs with the nonces it used for the replaced victim and the new gas price, so we replace our
+// own txs instead of racing behind a stale one. If we never sniped the replaced victim, this is a snipe as any other.
+//
+// Replace is concurrently safe
+func (c *Sniper) Replace(ctx context.Context, replaced, victim *types.Transaction) error {
+	c.replacing.Store(replaced.Hash(), struct{}{})
+	defer c.replacing.Delete(replaced.Hash())
 
-	for _, b := range c.swarm {
-		go func(ctx context.Context, b *Bee, wg *sync.WaitGroup, gas *big.Int, h chan<- common.Hash) {
-			defer recovery()
-			defer wg.Done()
-			h <- c.execute(ctx, b, gas)
-		}(ctx, b, wg, gas, pendingTxRes)
+	if err := c.quietHours.Check(time.Now()); err != nil {
+		return err
+	}
+	if err := c.checkGasPrice(ctx, victim.GasPrice()); err != nil {
+		return err
 	}
 
-	wg.Wait()
-	close(pendingTxRes)
+	// the round behind the replaced victim is stale, stop waiting for it so we can re-send with the new gas
+	if cancel, ok := c.inflight.Load(replaced.Hash()); ok {
+		log.Info(fmt.Sprintf("cutting short the snipe behind %s, it was replaced by %s", replaced.Hash().Hex(), victim.Hash().Hex()))
+		cancel.(context.CancelFunc)()
+	}
 
-	finishedTxRes := make(chan txRes, len(pendingTxRes))
-	wg.Add(len(pendingTxRes))
+	c.sniping.Lock()
+	defer c.sniping.Unlock()
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	r := c.last
+	if r == nil || r.victim != replaced.Hash() {
+		if err := c.checkBudget(); err != nil {
+			return err
+		}
+		return c.snipe(ctx, victim, nil)
+	}
+	if r.filled {
+		log.Info(fmt.Sprintf("snipe behind %s already filled, ignoring its replacement %s", replaced.Hash().Hex(), victim.Hash().Hex()))
+		return nil
+	}
+	return c.snipe(ctx, victim, r.nonces)
+}
+
+// snipe the victim with the whole swarm, using the given nonces of each bee (or their pending ones if nil).
+// Must be called holding the lock.
+func (c *Sniper) snipe(ctx context.Context, victim *types.Transaction, nonces []uint64) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	c.inflight.Store(victim.Hash(), cancel)
+	defer c.inflight.Delete(victim.Hash())
 
-	for txHash := range pendingTxRes {
-		go func(ctx context.Context, h common.Hash, wg *sync.WaitGroup, ch chan<- txRes) {
+	if c.delayed {
+		if err := c.checkReserves(ctx); err != nil {
+			return err
+		}
+	}
+
+	o, err := c.order(ctx, victim.GasPrice(), c.delayed)
+	if err != nil {
+		return err
+	}
+
+	if nonces == nil {
+		nonces = c.pendingNonces()
+	}
+
+	filled, reverted, cancelled := c.round(ctx, victim, nonces, c.delayed, o)
+	defer c.reconcileStale(ctx)
+	var revert *domain.RevertError
+	if !filled && reverted != nil {
+		revert = c.revertOf(ctx, *reverted)
+		c.alerts.Alert(domain.Alert{
+			Kind:    domain.AlertSnipeReverted,
+			Target:  c.sniperName,
+			Tx:      reverted.Hash.Hex(),
+			Message: fmt.Sprintf("snipe %s", revert.Error()),
+		})
+		if !cancelled && c.reentryBlocks > 0 {
+			if ro, ok := c.reenter(ctx, victim); ok {
+				filled, o = true, ro
+			}
+		}
+	}
+
+	if filled && o.size != nil {
+		c.spent.Add(c.spent, o.size)
+	}
+	c.last = &snipeRound{victim: victim.Hash(), nonces: nonces, filled: filled}
+	if cancelled && !filled {
+		return fmt.Errorf("victim %s dropped from the mempool, snipe cancelled", victim.Hash().Hex())
+	}
+	if revert != nil && !filled {
+		return revert
+	}
+	return nil // TODO Add formal error handling in case snipe doesn't succeeds
+}
+
+// round of the swarm sniping the victim with the given nonces, waiting for its txs. It reports if any of them filled,
+// one that was mined but reverted (if any) and if the round was cancelled because the victim was dropped.
+// In a round after the victim was mined (eg. block mode or re-entries) we aren't racing it: the liquidity is there so
+// the snipe can be simulated, and bees wait a random stealth delay before sending publicly. o is the order of the round.
+// Must be called holding the lock.
+func (c *Sniper) round(ctx context.Context, victim *types.Transaction, nonces []uint64, mined bool, o snipeOrder) (bool, *txRes, bool) {
+	// we can only backrun the victim in a bundle if it's still pending (eg. not in block mode)
+	backrun := false
+	if c.relays.Enabled() {
+		if _, pending, err := c.ethClient.TransactionByHash(ctx, victim.Hash()); err == nil && pending {
+			backrun = true
+		}
+	}
+
+	stx := snipeTx{gasLimit: c.presignGasLimit()}
+	if mined {
+		stx = c.snipeTx(ctx)
+	}
+	stx.data = o.data
+	stx.private = o.private
+
+	sent := c.execute(ctx, victim, nonces, mined, backrun, stx)
+	for i := range sent {
+		sent[i].cancelled = new(int32)
+	}
+
+	// while our txs are pending, the victim may vanish. If so we cancel them, so they don't fire into an empty pool.
+	stop := make(chan struct{})
+	cancelled := false
+	watchWg := new(sync.WaitGroup)
+	watchWg.Add(1)
+	go func() {
+		defer recovery()
+		defer watchWg.Done()
+		cancelled = c.watchVictim(ctx, victim, sent, stop)
+	}()
+
+	finishedTxRes := make(chan txRes, len(sent))
+	wg := new(sync.WaitGroup)
+	wg.Add(len(sent))
+
+	for _, s := range sent {
+		go func(ctx context.Context, s sentTx, wg *sync.WaitGroup, ch chan<- txRes) {
 			defer recovery()
 			defer wg.Done()
-			ch <- c.checkTxStatus(ctx, h)
-		}(ctx, txHash, wg, finishedTxRes)
+			res := c.checkTxStatus(ctx, s)
+			res.Tx = s.Tx
+			res.Simulation = s.Simulation
+			ch <- res
+		}(ctx, s, wg, finishedTxRes)
 	}
 
 	wg.Wait()
+	close(stop)
+	watchWg.Wait()
 	close(finishedTxRes)
 
+	filled := false
+	var reverted *txRes
 	for res := range finishedTxRes {
 		if res.Success {
-			// proudly displaying the tx receipt
-			for _, l := range res.Receipt.Logs {
-				if l.Address == c.sniperTTBAddr {
-					hexAmount := hex.EncodeToString(l.Data)
-					var value = new(big.Int)
-					value.SetString(hexAmount, 16)
-					var buf strings.Builder
-					_, _ = buf.WriteString("Sniping succeeded!\n")
-					_, _ = buf.WriteString(fmt.Sprintf("    Hash: %s\n", res.Hash.String()))
-					_, _ = buf.WriteString(fmt.Sprintf("    Token: %s\n", c.sniperTTBAddr.String()))
-
-					if amountBought, err := c.formatERC20Decimals(value, c.sniperTTBAddr); err == nil {
-						_, _ = buf.WriteString(fmt.Sprintf("    Amount Bought: %.4f\n", amountBought))
-					}
-
-					if pairAddress, err := c.factoryClient.GetPair(&bind.CallOpts{}, c.sniperTTBAddr, c.sniperTokenPaired); err == nil {
-						_, _ = buf.WriteString(fmt.Sprintf("    Pair Address: %s", pairAddress.String()))
-					}
-
-					log.Info(buf.String())
-				}
+			filled = true
+			c.reportFill(ctx, res, o.size)
+		} else if res.Receipt != nil && reverted == nil {
+			res := res
+			reverted = &res
+		}
+	}
+	return filled, reverted, cancelled
+}
+
+// reenter after a snipe reverted (eg. trading isn't enabled yet), trying again on each of the following blocks up to
+// the configured ones. The snipe is simulated against each new block first, and only sent if it would succeed.
+// It returns the order that filled, if any.
+// Must be called holding the lock.
+func (c *Sniper) reenter(ctx context.Context, victim *types.Transaction) (snipeOrder, bool) {
+	head, err := c.watcher.Head(ctx)
+	if err != nil {
+		log.Error(fmt.Sprintf("error getting head block for re-entry: %s", err))
+		return snipeOrder{}, false
+	}
+
+	for i := uint(1); i <= c.reentryBlocks; i++ {
+		head++
+		if err := c.watcher.WaitForBlock(ctx, head); err != nil {
+			log.Error(fmt.Sprintf("aborting re-entry: %s", err))
+			return snipeOrder{}, false
+		}
+		if err := c.checkBudget(); err != nil {
+			log.Info(fmt.Sprintf("aborting re-entry: %s", err))
+			return snipeOrder{}, false
+		}
+		if err := c.quietHours.Check(time.Now()); err != nil {
+			log.Info(fmt.Sprintf("aborting re-entry: %s", err))
+			return snipeOrder{}, false
+		}
+		o, err := c.order(ctx, victim.GasPrice(), true)
+		if err != nil {
+			log.Info(fmt.Sprintf("aborting re-entry: %s", err))
+			return snipeOrder{}, false
+		}
+		if err := c.simulate(ctx, nil, o.data); err != nil {
+			log.Info(fmt.Sprintf("re-entry %d/%d at block %d would revert: %s", i, c.reentryBlocks, head, newRevertError(err)))
+			continue
+		}
+
+		log.Info(fmt.Sprintf("re-entry %d/%d at block %d", i, c.reentryBlocks, head))
+		filled, _, cancelled := c.round(ctx, victim, c.pendingNonces(), true, o)
+		if filled {
+			return o, true
+		}
+		if cancelled {
+			return snipeOrder{}, false
+		}
+	}
+	return snipeOrder{}, false
+}
+
+// stealthWait a random time up to the stealth delay, or until the context is done.
+func (c *Sniper) stealthWait(ctx context.Context) {
+	if c.stealthDelay <= 0 {
+		return
+	}
+	t := time.NewTimer(time.Duration(rand.Int63n(int64(c.stealthDelay))))
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
+
+// revertOf a mined snipe, replaying it on top of the block it was mined in. Our tx changed nothing since it reverted,
+// so that state is the closest to the one it saw (the parent one would miss eg. the liquidity addition).
+func (c *Sniper) revertOf(ctx context.Context, res txRes) *domain.RevertError {
+	var data []byte
+	if res.Tx != nil {
+		data = res.Tx.Data()
+	}
+	err := c.simulate(ctx, res.Receipt.BlockNumber, data)
+	if err == nil {
+		return domain.NewRevertError(domain.RevertUnknown, "doesn't revert when replayed")
+	}
+	return newRevertError(err)
+}
+
+// simulate a snipe of the first bee with the given data of the trigger call (nil for the trigger calldata of the
+// target) against the given block (nil for the latest one).
+func (c *Sniper) simulate(ctx context.Context, block *big.Int, data []byte) error {
+	if len(c.swarm) == 0 {
+		return errors.New("no bees")
+	}
+	call := c.snipeCall()
+	if data != nil {
+		call.Data = data
+	}
+	_, err := c.ethClient.CallContract(ctx, call, block)
+	return err
+}
+
+// gasLimit of the snipe txs. Unless the target has its own, it's estimated with a buffer on top, since token taxes make
+// the gas used by a launch buy wildly variable. It can only be estimated once the liquidity is there, so a round
+// racing a pending victim uses the presigned one instead. If it can't be estimated we fall back to the default one.
+// Must be called holding the lock.
+func (c *Sniper) gasLimit(ctx context.Context) uint64 {
+	if c.sniperGasLimit > 0 || len(c.swarm) == 0 {
+		return c.presignGasLimit()
+	}
+
+	est, err := c.ethClient.EstimateGas(ctx, c.snipeCall())
+	if err != nil {
+		log.Debug(fmt.Sprintf("couldn't estimate snipe gas, using %d: %s", defaultTxGasLimit, err))
+		return defaultTxGasLimit
+	}
+	return est + est*uint64(c.gasLimitBuffer)/100
+}
+
+// snipeTx of the round. If enabled, the node creates an access list for it (warming up the storage of the router,
+// pair and token the trigger touches) and we only keep it if it uses less gas than the tx without it.
+// Must be called holding the lock.
+func (c *Sniper) snipeTx(ctx context.Context) snipeTx {
+	stx := snipeTx{gasLimit: c.gasLimit(ctx)}
+	if !c.accessList || len(c.swarm) == 0 {
+		return stx
+	}
+
+	al, used, vmErr, err := c.ethClient.CreateAccessList(ctx, c.snipeCall())
+	if err != nil || len(vmErr) > 0 || al == nil || len(*al) == 0 {
+		log.Debug(fmt.Sprintf("no access list for the snipe (error: %v, vm error: %s)", err, vmErr))
+		return stx
+	}
+	est, err := c.ethClient.EstimateGas(ctx, c.snipeCall())
+	if err != nil || used >= est {
+		log.Debug(fmt.Sprintf("access list doesn't lower the snipe gas (%d with it, %d without it)", used, est))
+		return stx
+	}
+
+	log.Debug(fmt.Sprintf("using access list of %d addresses, snipe gas %d (%d without it)", len(*al), used, est))
+	stx.accessList = *al
+	if c.sniperGasLimit == 0 {
+		stx.gasLimit = used + used*uint64(c.gasLimitBuffer)/100
+	}
+	return stx
+}
+
+// snipeCall of the trigger by the first bee
+func (c *Sniper) snipeCall() ethereum.CallMsg {
+	return ethereum.CallMsg{
+		From: crypto.PubkeyToAddress(c.swarm[0].RawPK.PublicKey),
+		To:   &c.sniperTriggerAddr,
+		Data: c.triggerCalldata,
+	}
+}
+
+// presignGasLimit of the snipe txs signed beforehand, when there's nothing to estimate yet.
+// Must be called holding the lock.
+func (c *Sniper) presignGasLimit() uint64 {
+	if c.sniperGasLimit > 0 {
+		return c.sniperGasLimit
+	}
+	return defaultTxGasLimit
+}
+
+// pendingNonces of the swarm (aligned with it). Must be called holding the lock.
+func (c *Sniper) pendingNonces() []uint64 {
+	nonces := make([]uint64, len(c.swarm))
+	for i, b := range c.swarm {
+		nonces[i] = b.PendingNonce
+	}
+	return nonces
+}
+
+// watchVictim until stopped, cancelling the sent txs if the victim is dropped from the mempool without being mined.
+// A victim being replaced also leaves the mempool, so it has to be missing for two polls in a row (giving its
+// replacement time to reach us) before we take it for dropped. Returns if the txs were cancelled.
+func (c *Sniper) watchVictim(ctx context.Context, victim *types.Transaction, sent []sentTx, stop <-chan struct{}) bool {
+	t := time.NewTicker(c.timing.Poll())
+	defer t.Stop()
+
+	missing := 0
+	for {
+		select {
+		case <-stop:
+			return false
+		case <-ctx.Done():
+			return false
+		case <-t.C:
+		}
+
+		_, pending, err := c.ethClient.TransactionByHash(ctx, victim.Hash())
+		switch {
+		case err == nil && !pending:
+			return false // mined, nothing to cancel
+		case err == nil:
+			missing = 0
+		case errors.Is(err, ethereum.NotFound):
+			if _, ok := c.replacing.Load(victim.Hash()); ok {
+				return false
+			}
+			missing++
+			if missing < 2 {
+				continue
 			}
+			log.Warn(fmt.Sprintf("victim %s dropped from the mempool, cancelling snipe", victim.Hash().Hex()))
+			for _, s := range sent {
+				c.cancel(ctx, s)
+			}
+			return true
+		default:
+			log.Error(fmt.Sprintf("error getting victim %s: %s", victim.Hash().Hex(), err))
 		}
 	}
-	return nil // TODO Add formal error handling in case snipe doesn't succeeds
 }
 
-// Format # of tokens transferred into required float
-func (c *Sniper) formatERC20Decimals(tokensSent *big.Int, tokenAddress common.Address) (float64, error) {
-	// Create a ERC20 instance and connect to geth to get decimals
-	tokenInstance, _ := erc20.NewErc20(tokenAddress, c.ethClient)
-	decimals, err := tokenInstance.Decimals(nil)
+// cancel the sent tx replacing it with an empty transfer of the bee to itself, with the same nonce and more gas.
+func (c *Sniper) cancel(ctx context.Context, s sentTx) {
+	if s.Tx == nil {
+		return // never sent
+	}
+
+	fees := feesOf(s.Tx).bump(cancelGasBump)
+	self := crypto.PubkeyToAddress(s.Bee.RawPK.PublicKey)
+	tx, err := types.SignNewTx(
+		s.Bee.RawPK,
+		types.LatestSignerForChainID(c.sniperChainID),
+		newTxData(c.dynamicFees, c.sniperChainID, s.Tx.Nonce(), fees, cancelGasLimit, &self, txValue, nil, nil),
+	)
+	if err != nil {
+		log.Error(fmt.Sprintf("error signing cancel of tx %s: %s", s.Hash.Hex(), err))
+		return
+	}
+	if err := c.ethClient.SendTransaction(ctx, tx); err != nil {
+		log.Error(fmt.Sprintf("error cancelling tx %s: %s", s.Hash.Hex(), err))
+		return
+	}
+	if s.cancelled != nil {
+		atomic.StoreInt32(s.cancelled, 1)
+	}
+	log.Info(fmt.Sprintf("cancelled tx %s with %s", s.Hash.Hex(), tx.Hash().Hex()))
+}
+
+// isCancelled if the sent tx was replaced by a cancel
+func (s sentTx) isCancelled() bool {
+	return s.cancelled != nil && atomic.LoadInt32(s.cancelled) == 1
+}
+
+// reportFill of a mined snipe of the given size, verifying and saving what it actually bought.
+func (c *Sniper) reportFill(ctx context.Context, res txRes, size *big.Int) {
+	var (
+		pair common.Address
+		fill snipeFill
+		err  error
+	)
+	if c.venue.fee > 0 {
+		fill = newSnipeFillV3(res.Receipt.Logs, c.sniperTTBAddr, c.sniperTokenPaired)
+	} else {
+		pair, err = c.factoryClient.GetPair(&bind.CallOpts{Context: ctx}, c.sniperTTBAddr, c.sniperTokenPaired)
+		if err != nil {
+			log.Error(fmt.Sprintf("error getting pair of snipe %s: %s", res.Hash.Hex(), err))
+		}
+		fill = newSnipeFill(res.Receipt.Logs, pair, c.sniperTTBAddr, c.sniperTokenPaired)
+	}
+	t := c.newTrade(ctx, res, size, fill.Received)
+	t.Outcome = c.verifyFill(t, fill)
+	c.saveTrade(ctx, t)
+
+	// proudly displaying the tx receipt
+	var buf strings.Builder
+	_, _ = buf.WriteString("Sniping succeeded!\n")
+	if len(c.sniperName) > 0 {
+		_, _ = buf.WriteString(fmt.Sprintf("    Instance: %s\n", c.sniperName))
+	}
+	_, _ = buf.WriteString(fmt.Sprintf("    Hash: %s\n", res.Hash.String()))
+	_, _ = buf.WriteString(fmt.Sprintf("    Token: %s\n", c.sniperTTBAddr.String()))
+	if decimals, err := c.decimals.Of(ctx, c.sniperTTBAddr); err == nil {
+		_, _ = buf.WriteString(fmt.Sprintf("    Amount Bought: %.4f\n", formatUnits(fill.Received, decimals)))
+	}
+	if len(fill.Recipients) > 1 {
+		_, _ = buf.WriteString(fmt.Sprintf("    Split Across: %d wallets\n", len(fill.Recipients)))
+	}
+	if spent := formatSpent(t); len(spent) > 0 {
+		_, _ = buf.WriteString(fmt.Sprintf("    Spent: %s\n", spent))
+	}
+	if res.Simulation != nil {
+		if sr, ok := res.Simulation.ResultOf(res.Hash); ok {
+			_, _ = buf.WriteString(fmt.Sprintf("    Simulated Gas Used: %d\n", sr.GasUsed))
+		}
+		if res.Simulation.CoinbaseDiff != nil {
+			_, _ = buf.WriteString(fmt.Sprintf("    Simulated Coinbase Diff: %.6f\n", formatETHWeiToEther(res.Simulation.CoinbaseDiff.ToInt())))
+		}
+	}
+	if c.venue.fee > 0 {
+		_, _ = buf.WriteString(fmt.Sprintf("    Pool Fee Tier: %d", c.venue.fee))
+	} else {
+		_, _ = buf.WriteString(fmt.Sprintf("    Pair Address: %s", pair.String()))
+	}
+	log.Info(buf.String())
+}
+
+// newTrade of a filled snipe, with its gas cost and the price of the native coin if we report in USD
+func (c *Sniper) newTrade(ctx context.Context, res txRes, amountIn, amountOut *big.Int) domain.Trade {
+	t := domain.Trade{
+		Target:    c.sniperName,
+		Hash:      res.Hash.Hex(),
+		Token:     c.sniperTTBAddr.Hex(),
+		Paired:    c.sniperTokenPaired.Hex(),
+		AmountIn:  amountIn,
+		AmountOut: amountOut,
+		Block:     res.Receipt.BlockNumber.Uint64(),
+		Time:      time.Now(),
+		GasUsed:   res.Receipt.GasUsed,
+	}
+	if res.Simulation != nil {
+		if sr, ok := res.Simulation.ResultOf(res.Hash); ok {
+			t.SimulatedGasUsed = sr.GasUsed
+		}
+		if res.Simulation.CoinbaseDiff != nil {
+			t.SimulatedCoinbaseDiff = res.Simulation.CoinbaseDiff.ToInt()
+		}
+	}
+	if res.Tx != nil {
+		var baseFee *big.Int
+		if c.dynamicFees {
+			h, err := c.ethClient.HeaderByNumber(ctx, res.Receipt.BlockNumber)
+			if err != nil {
+				log.Warn(fmt.Sprintf("error getting base fee of snipe %s: %s", res.Hash.Hex(), err))
+			} else {
+				baseFee = h.BaseFee
+			}
+		}
+		if !c.dynamicFees || baseFee != nil {
+			t.GasCost = new(big.Int).Mul(effectiveGasPrice(res.Tx, baseFee), new(big.Int).SetUint64(t.GasUsed))
+		}
+	}
+	if c.priceFeed != nil {
+		p, err := c.priceFeed.USD(ctx)
+		if err != nil {
+			log.Warn(fmt.Sprintf("error getting the usd price of snipe %s: %s", res.Hash.Hex(), err))
+		}
+		t.NativeUSD = p
+	}
+	return t
+}
+
+// saveTrade of a filled snipe. Failing to save it doesn't undo the snipe, so we only log it.
+func (c *Sniper) saveTrade(ctx context.Context, t domain.Trade) {
+	if err := c.trades.Save(ctx, t); err != nil {
+		log.Error(fmt.Sprintf("error saving trade %s: %s", t.Hash, err))
+	}
+}
+
+// ReportUSD the trades with the price of the native coin in the feed: their value and gas cost are journaled,
+// notified and exported in USD too.
+func (c *Sniper) ReportUSD(f sniperPriceFeed) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.priceFeed = f
+}
+
+// checkBudget so we never spend more than what this sniper was given. Must be called holding the lock.
+func (c *Sniper) checkBudget() error {
+	if c.sniperBudget == nil || c.sniperOrderSize == nil {
+		return nil
+	}
+	if new(big.Int).Add(c.spent, c.sniperOrderSize).Cmp(c.sniperBudget) > 0 {
+		return domain.NewSkipError(domain.SkipReasonBudgetExceeded, fmt.Sprintf(
+			"sniper %s spent %.4f of %.4f",
+			c.sniperName,
+			formatETHWeiToEther(c.spent),
+			formatETHWeiToEther(c.sniperBudget),
+		))
+	}
+	return nil
+}
+
+// SetTarget of the sniper while it runs. It waits for any ongoing snipe to finish and signs again the presigned txs,
+// since they were made for the previous target. What was already spent still counts against the new budget.
+// A new target is sniped on its v2 pair until its liquidity migrates (see SetVenue).
+func (c *Sniper) SetTarget(sn domain.Sniper) error {
+	c.sniping.Lock()
+	defer c.sniping.Unlock()
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.sniperName = sn.Name
+	c.sniperTTBAddr = common.HexToAddress(sn.AddressTargetToken)
+	c.sniperTriggerAddr = common.HexToAddress(sn.AddressTrigger)
+	c.sniperTokenPaired = common.HexToAddress(sn.AddressTargetPaired)
+	c.sniperMinLiq = sn.MinimumLiquidity
+	c.sniperChainID = sn.ChainID
+	c.sniperGasLimit = sn.GasLimit
+	c.sniperReveal = sn.Reveal
+	c.venue = triggerVenue{}
+	c.triggerCalldata = newTriggerCalldata(sn, c.venue)
+	c.exactOut = sn.BuyMode.ExactOut()
+	c.sniperOrderSize = sn.OrderSize
+	c.sniperBudget = sn.Budget
+	c.last = nil
+	return c.presignSwarm()
+}
+
+// SetVenue the trigger snipes the target on: the v3 pool of the given fee tier, or its v2 pair if it's zero. It's
+// changed when the liquidity of the target migrates between pool versions. pairedFee is the fee tier of the v3 pool
+// between wbnb and the paired token, if the latter isn't wbnb.
+//
+// SetVenue is concurrently safe
+func (c *Sniper) SetVenue(fee, pairedFee uint32) error {
+	c.sniping.Lock()
+	defer c.sniping.Unlock()
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	v := triggerVenue{fee: fee, pairedFee: pairedFee}
+	if v == c.venue {
+		return nil
+	}
+	c.venue = v
+	c.triggerCalldata = newTriggerCalldata(domain.Sniper{
+		AddressTargetPaired: c.sniperTokenPaired.Hex(),
+		AddressTargetToken:  c.sniperTTBAddr.Hex(),
+		Reveal:              c.sniperReveal,
+	}, v)
+	c.last = nil
+	log.Info(fmt.Sprintf("%s snipes on the pool with fee tier %d (0 is the v2 pair)", c.sniperName, fee))
+	return c.presignSwarm()
+}
+
+// Presign the snipe txs of the whole swarm at the given gas levels, so when the liquidity is added with one of them
+// we only have to broadcast. Txs are signed again as soon as a bee uses its nonce.
+func (c *Sniper) Presign(levels ...*big.Int) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.presigned.setLevels(levels)
+	return c.presignSwarm()
+}
+
+// presignSwarm at the current gas levels. Must be called holding the lock.
+func (c *Sniper) presignSwarm() error {
+	c.presigned.clear()
+	for _, b := range c.swarm {
+		if err := c.presigned.refresh(b, b.PendingNonce, c.newSigner(c.presignGasLimit(), c.triggerCalldata)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkGasPrice rejects gas prices that are absurdly higher than what the network is currently paying.
+// Such gas prices are either a decoding bug on our side or a bait tx made to drain our swarm in fees.
+// It also rejects gas prices far below the network ones: those victims won't confirm any time soon (and are often
+// bait too), so we would be waiting behind them.
+func (c *Sniper) checkGasPrice(ctx context.Context, gas *big.Int) error {
+	if c.gasMaxMultiplier <= 0 && c.gasMinMultiplier <= 0 {
+		return nil // no bounds
+	}
+
+	median, err := c.gasOracle.Median(ctx)
 	if err != nil {
-		return 0, err
+		return fmt.Errorf("error getting network gas price median: %s", err)
 	}
-	// Construct a denominator based on the decimals
-	// 18 decimals would result in denominator = 10^18
-	var base, exponent = big.NewInt(10), big.NewInt(int64(decimals))
-	denominator := base.Exp(base, exponent, nil)
-	// Convert to float for precision
-	tokensSentFloat := new(big.Float).SetInt(tokensSent)
-	denominatorFloat := new(big.Float).SetInt(denominator)
-	// Divide and return the final result
-	final, _ := new(big.Float).Quo(tokensSentFloat, denominatorFloat).Float64()
-	// TODO Take big.Accuracy into account
-	return final, nil
+
+	if c.gasMaxMultiplier > 0 {
+		max, _ := new(big.Float).Mul(new(big.Float).SetInt(median), big.NewFloat(c.gasMaxMultiplier)).Int(nil)
+		if gas.Cmp(max) == 1 {
+			return domain.NewSkipError(domain.SkipReasonGasAboveCap, fmt.Sprintf(
+				"gas price %s exceeds %.2fx the network median (%s)",
+				gas.String(), c.gasMaxMultiplier, median.String(),
+			))
+		}
+	}
+	if c.gasMinMultiplier > 0 {
+		min, _ := new(big.Float).Mul(new(big.Float).SetInt(median), big.NewFloat(c.gasMinMultiplier)).Int(nil)
+		if gas.Cmp(min) == -1 {
+			return domain.NewSkipError(domain.SkipReasonGasBelowMin, fmt.Sprintf(
+				"gas price %s is below %.2fx the network median (%s)",
+				gas.String(), c.gasMinMultiplier, median.String(),
+			))
+		}
+	}
+	return nil
+}
+
+// checkReserves of the pair hold at least the min liquidity of the paired token, so the trigger never fires into an
+// unfunded pair. Only meaningful once the liquidity addition was mined. We only know v2 pairs, a v3 pool isn't checked.
+func (c *Sniper) checkReserves(ctx context.Context) error {
+	if c.sniperMinLiq == nil || c.venue.fee > 0 {
+		return nil
+	}
+
+	pair, reserve, _, err := c.reserves(ctx)
+	if err != nil {
+		return err
+	}
+	if reserve.Cmp(c.sniperMinLiq) == -1 {
+		return domain.NewSkipError(domain.SkipReasonPoolUnfunded, fmt.Sprintf(
+			"pair %s holds %.4f vs %.4f expected",
+			pair.Hex(),
+			c.decimals.Format(ctx, c.sniperTokenPaired, reserve),
+			c.decimals.Format(ctx, c.sniperTokenPaired, c.sniperMinLiq),
+		))
+	}
+	return nil
 }
 
 // once all tx has been sent, check for status and feed StatusResults and WatchPending chan that are listening
-func (c *Sniper) checkTxStatus(ctx context.Context, txHash common.Hash) txRes {
+// If the tx vanishes from the mempool without being mined, it's rebroadcast through the alternate nodes (if any),
+// unless we cancelled it ourselves.
+func (c *Sniper) checkTxStatus(ctx context.Context, s sentTx) txRes {
+	txHash := s.Hash
 	if txHash == common.HexToHash(nullHash) {
 		return txRes{
 			Hash:    txHash,
@@ -197,25 +1053,39 @@ func (c *Sniper) checkTxStatus(ctx context.Context, txHash common.Hash) txRes {
 		}
 	}
 
-	t := time.NewTicker(500 * time.Millisecond)
+	t := time.NewTicker(c.timing.Poll())
 	defer t.Stop()
 
-	s := time.Now()
+	start := time.Now()
+	rebroadcasts := 0
 
 	for range t.C {
 		_, pend, err := c.ethClient.TransactionByHash(ctx, txHash)
 
+		if errors.Is(err, ethereum.NotFound) && s.isCancelled() {
+			log.Info(fmt.Sprintf("tx %s was cancelled", txHash.Hex()))
+			return txRes{
+				Hash:      txHash,
+				Success:   false,
+				Cancelled: true,
+			}
+		}
+		if errors.Is(err, ethereum.NotFound) && rebroadcasts < maxRebroadcasts && c.rebroadcast(ctx, s) {
+			rebroadcasts++
+			pend = true // keep polling it until it shows up again
+		}
+
 		if !pend {
 			break // see Stop() internals.
 		}
 
-		if err != nil {
+		if err != nil && !errors.Is(err, ethereum.NotFound) {
 			log.Error(fmt.Sprintf("error getting tx by hash %s: %s", txHash.String(), err))
 		}
 
-		// fail fast after 5s
+		// fail fast after a couple of blocks
 		// TODO Use ctx?
-		if time.Now().Add(-5 * time.Second).After(s) {
+		if time.Since(start) > c.timing.Blocks(sniperTxMaxBlocks) {
 			return txRes{
 				Hash:    txHash,
 				Success: false,
@@ -242,28 +1112,310 @@ func (c *Sniper) checkTxStatus(ctx context.Context, txHash common.Hash) txRes {
 	}
 }
 
-func (c *Sniper) execute(ctx context.Context, bee *Bee, gasPrice *big.Int) common.Hash {
-	nonce := bee.PendingNonce
-	// create the tx
-	txBee := types.NewTransaction(nonce, c.sniperTriggerAddr, txValue, txGasLimit, gasPrice, triggerSmartContract)
-	// sign the tx
-	signedTxBee, err := types.SignTx(txBee, types.NewEIP155Signer(c.sniperChainID), bee.RawPK)
+// signSwarm txs of the round with the given nonces of each bee (aligned with the swarm). Bees whose tx can't be signed
+// sit the round out.
+func (c *Sniper) signSwarm(nonces []uint64, fees txFees, stx snipeTx) []beeTx {
+	signed := make([]beeTx, 0, len(c.swarm))
+	for i, b := range c.swarm {
+		tx, err := c.sign(b, nonces[i], fees, stx)
+		if err != nil {
+			log.Error(fmt.Sprintf("sendBee: problem with signedTxBee: %s", err))
+			continue
+		}
+		log.Debug(fmt.Sprintf(
+			"bee %s signed %s with nonce %d, gas price %s and gas limit %d",
+			crypto.PubkeyToAddress(b.RawPK.PublicKey).Hex(), tx.Hash().Hex(), nonces[i], fees, stx.gasLimit,
+		))
+		signed = append(signed, beeTx{bee: b, nonce: nonces[i], tx: tx})
+	}
+	return signed
+}
+
+// executePublic sends the txs of the swarm to the public mempool, each bee on its own. When we aren't racing the victim
+// (mined) they wait a random stealth delay first. It reports the error of each tx (aligned with the given ones).
+func (c *Sniper) executePublic(ctx context.Context, signed []beeTx, mined bool) []error {
+	errs := make([]error, len(signed))
+	wg := new(sync.WaitGroup)
+	wg.Add(len(signed))
+	for i, s := range signed {
+		go func(i int, s beeTx) {
+			defer wg.Done()
+			defer recovery()
+			if mined {
+				c.stealthWait(ctx)
+			}
+			// TODO Ctx timeout?
+			if errs[i] = c.ethClient.SendTransaction(ctx, s.tx); errs[i] != nil {
+				log.Error(fmt.Sprintf("error sending tx %s: %s", s.tx.Hash().Hex(), errs[i]))
+				c.flagNonceError(errs[i])
+				return
+			}
+			log.Info(fmt.Sprintf("sent tx: %s", s.tx.Hash().Hex()))
+		}(i, s)
+	}
+	wg.Wait()
+	return errs
+}
+
+// execute the round of the swarm. With relays, its txs are submitted as a single bundle (see executeBundle), else
+// they are sent to the public mempool. Bees whose tx wasn't sent are reported with a null hash.
+// Must be called holding the lock.
+func (c *Sniper) execute(ctx context.Context, victim *types.Transaction, nonces []uint64, mined, backrun bool, stx snipeTx) []sentTx {
+	signed := c.signSwarm(nonces, feesOf(victim), stx)
+	if c.relays.Enabled() {
+		return c.executeBundle(ctx, victim, signed, mined, backrun, stx)
+	}
+
+	sent := make([]sentTx, 0, len(signed))
+	for i, err := range c.executePublic(ctx, signed, mined) {
+		if err != nil {
+			sent = append(sent, sentTx{Hash: common.HexToHash(nullHash)})
+			continue
+		}
+		c.advanceNonce(signed[i].bee, signed[i].nonce)
+		sent = append(sent, signed[i].sent(nil))
+	}
+	return sent
+}
+
+// executeBundle submits the txs of the swarm to the relays as a single bundle for the next block (backrunning the
+// victim if possible), and waits until we know if it landed or not. If it missed, the bees fall back to the public
+// mempool. With concurrent (for must-win launches) they are sent to the public mempool at once instead: both carry
+// the very same txs, so once one of them lands the other is dropped for its nonce.
+// If the bundle simulation reverts, the public txs would too, so none is sent (and the concurrent ones are cancelled).
+// Must be called holding the lock.
+func (c *Sniper) executeBundle(ctx context.Context, victim *types.Transaction, signed []beeTx, mined, backrun bool, stx snipeTx) []sentTx {
+	if len(signed) == 0 {
+		return nil
+	}
+	concurrent := c.concurrent && !stx.private
+
+	public := make(chan []error, 1)
+	if concurrent {
+		go func() {
+			defer recovery()
+			public <- c.executePublic(ctx, signed, mined)
+		}()
+	}
+	sim, target, bundleErr := c.sendBundle(ctx, victim, signed, backrun)
+	var publicErrs []error
+	if concurrent {
+		publicErrs = <-public
+	}
+
+	if bundleErr != nil {
+		log.Error(fmt.Sprintf("aborting snipe of the swarm: %s", bundleErr))
+		for i, s := range signed {
+			if concurrent && publicErrs[i] == nil {
+				c.cancel(ctx, s.sent(sim))
+				c.advanceNonce(s.bee, s.nonce)
+			}
+		}
+		return unsent(len(signed), sim)
+	}
+
+	sent := unsent(len(signed), sim)
+	for i, s := range signed {
+		if concurrent && publicErrs[i] == nil {
+			sent[i] = s.sent(sim)
+		}
+	}
+
+	// the bundle takes the nonces of the bees, they are used before the lock is released while waiting for it
+	for i, s := range signed {
+		if target > 0 || (concurrent && publicErrs[i] == nil) {
+			c.advanceNonce(s.bee, s.nonce)
+		}
+	}
+	if target > 0 && c.waitForBundle(ctx, signed[0].tx.Hash(), target) {
+		log.Info(fmt.Sprintf("bundle of %d txs landed through the relays", len(signed)))
+		for i, s := range signed {
+			sent[i] = s.sent(sim)
+		}
+		return sent
+	}
+	if concurrent {
+		return sent
+	}
+	if stx.private {
+		log.Warn("bundle missed, not falling back to the public mempool as sandwich bots are around")
+		atomic.StoreInt32(c.staleNonces, 1) // the nonces the bundle took are free again
+		return sent
+	}
+	return c.fallback(ctx, victim, signed, sim, mined, stx)
+}
+
+// fallback to the public mempool after the bundle of the swarm missed its block.
+// The victim may have landed without us, in which case the pool must already be funded. Only then we bump the gas so
+// we have better chances of landing in the next block: while the victim is pending, a higher gas would land us before
+// it, buying from an empty pool. The nonces were taken by the bundle, so any tx that isn't sent frees its nonce.
+// Must be called holding the lock.
+func (c *Sniper) fallback(ctx context.Context, victim *types.Transaction, signed []beeTx, sim *BundleSimulation, mined bool, stx snipeTx) []sentTx {
+	fees := feesOf(victim)
+	if _, pending, err := c.ethClient.TransactionByHash(ctx, victim.Hash()); err == nil && !pending {
+		if err := c.checkReserves(ctx); err != nil {
+			log.Error(fmt.Sprintf("aborting fallback of the swarm: %s", err))
+			atomic.StoreInt32(c.staleNonces, 1)
+			return unsent(len(signed), sim)
+		}
+		fees = fees.bump(int64(c.fallbackGasBump))
+		bumped := make([]beeTx, 0, len(signed))
+		for _, s := range signed {
+			tx, err := c.sign(s.bee, s.nonce, fees, stx)
+			if err != nil {
+				log.Error(fmt.Sprintf("sendBee: problem with fallback signedTxBee: %s", err))
+				atomic.StoreInt32(c.staleNonces, 1)
+				continue
+			}
+			bumped = append(bumped, beeTx{bee: s.bee, nonce: s.nonce, tx: tx})
+		}
+		signed = bumped
+	}
+	log.Warn(fmt.Sprintf("bundle missed, falling back to public mempool with gas price %s", fees))
+
+	sent := unsent(len(signed), sim)
+	for i, err := range c.executePublic(ctx, signed, mined) {
+		if err != nil {
+			atomic.StoreInt32(c.staleNonces, 1)
+			continue
+		}
+		sent[i] = signed[i].sent(sim)
+	}
+	return sent
+}
+
+// sendBundle simulates and submits the txs of the swarm (behind the victim if we backrun it) to the relays for the next
+// block, which it returns. The target is zero if the bundle couldn't be submitted.
+// An error is only returned if the bundle shouldn't be executed at all (eg. it reverts), meaning a public fallback
+// will also be worthless.
+func (c *Sniper) sendBundle(ctx context.Context, victim *types.Transaction, signed []beeTx, backrun bool) (*BundleSimulation, uint64, error) {
+	head, err := c.watcher.Head(ctx)
+	if err != nil {
+		log.Error(fmt.Sprintf("error getting head block for bundle: %s", err))
+		return nil, 0, nil
+	}
+	target := head + 1
+
+	txs := make([]*types.Transaction, 0, len(signed)+1)
+	if backrun {
+		txs = append(txs, victim)
+	}
+	for _, s := range signed {
+		txs = append(txs, s.tx)
+	}
+
+	sim, err := c.relays.CallBundle(ctx, txs, target)
 	if err != nil {
-		log.Error(fmt.Sprintf("sendBee: problem with signedTxBee: %s", err))
-		return common.HexToHash(nullHash)
+		log.Warn(fmt.Sprintf("submitting bundle without simulation: %s", err))
+	} else if r, reverted := sim.Reverted(); reverted {
+		return sim, 0, fmt.Errorf("bundle simulation reverted at tx %s: %s%s", r.TxHash.Hex(), r.Error, r.Revert)
+	}
+
+	if err := c.relays.SendBundle(ctx, txs, target); err != nil {
+		log.Error(fmt.Sprintf("error sending bundle: %s", err))
+		return sim, 0, nil
 	}
+	log.Info(fmt.Sprintf("sent bundle of %d txs targeting block %d", len(signed), target))
+	return sim, target, nil
+}
 
-	// TODO Ctx timeout?
-	err = c.ethClient.SendTransaction(ctx, signedTxBee)
+// waitForBundle with the tx until the target block is mined, reporting if it landed. A bundle lands as a whole, so
+// any of its txs tells. The lock is released meanwhile, so eg. a panic sell isn't held behind the relays: the round
+// is still ours, as changing it also takes sniping.
+// Must be called holding the lock.
+func (c *Sniper) waitForBundle(ctx context.Context, h common.Hash, target uint64) bool {
+	c.mut.Unlock()
+	included, err := c.watcher.WaitForInclusion(ctx, h, target)
+	c.mut.Lock()
 
 	if err != nil {
-		log.Error(fmt.Sprintf("error sending tx: %s", err.Error()))
-		return common.HexToHash(nullHash)
+		log.Error(fmt.Sprintf("error watching bundle inclusion of tx %s: %s", h.Hex(), err))
+	}
+	if !included {
+		log.Warn(fmt.Sprintf("bundle with tx %s missed block %d", h.Hex(), target))
+	}
+	return included
+}
+
+// sent tx of the bee, with the simulation of its bundle if any
+func (s beeTx) sent(sim *BundleSimulation) sentTx {
+	return sentTx{Hash: s.tx.Hash(), Simulation: sim, Bee: s.bee, Tx: s.tx}
+}
+
+// unsent txs of n bees, with the simulation of their bundle if any
+func unsent(n int, sim *BundleSimulation) []sentTx {
+	sent := make([]sentTx, n)
+	for i := range sent {
+		sent[i] = sentTx{Hash: common.HexToHash(nullHash), Simulation: sim}
+	}
+	return sent
+}
+
+// rebroadcast the sent tx through the alternate nodes, unless it was mined meanwhile. Reports if it was rebroadcast.
+func (c *Sniper) rebroadcast(ctx context.Context, s sentTx) bool {
+	if s.Tx == nil || !c.rebroadcaster.Enabled() {
+		return false
+	}
+	if _, err := c.ethClient.TransactionReceipt(ctx, s.Hash); err == nil {
+		return false // mined, our node just doesn't index it yet
+	}
+
+	log.Warn(fmt.Sprintf("tx %s vanished from the mempool without being mined, rebroadcasting it", s.Hash.Hex()))
+	if err := c.rebroadcaster.Rebroadcast(ctx, s.Tx); err != nil {
+		log.Error(fmt.Sprintf("error rebroadcasting tx %s: %s", s.Hash.Hex(), err))
+		return false
+	}
+	return true
+}
+
+// advanceNonce of the bee past the used one, presigning its txs in the background.
+// A replacement reuses an older nonce, in which case there's nothing to advance.
+func (c *Sniper) advanceNonce(bee *Bee, used uint64) {
+	if used != bee.PendingNonce {
+		return
+	}
+	bee.PendingNonce++
+	sign := c.newSigner(c.presignGasLimit(), c.triggerCalldata)
+	go func(nonce uint64) {
+		defer recovery()
+		if err := c.presigned.refresh(bee, nonce, sign); err != nil {
+			log.Error(fmt.Sprintf("error presigning txs with nonce %d: %s", nonce, err))
+		}
+	}(bee.PendingNonce)
+}
+
+func (c *Sniper) sign(bee *Bee, nonce uint64, fees txFees, stx snipeTx) (*types.Transaction, error) {
+	data := c.triggerCalldata
+	if stx.data != nil {
+		data = stx.data
+	}
+	if len(stx.accessList) > 0 {
+		to := c.sniperTriggerAddr
+		return types.SignNewTx(
+			bee.RawPK,
+			types.LatestSignerForChainID(c.sniperChainID),
+			newTxData(c.dynamicFees, c.sniperChainID, nonce, fees, stx.gasLimit, &to, txValue, data, stx.accessList),
+		)
+	}
+	// presigned txs carry the trigger calldata of the target
+	if tx, ok := c.presigned.get(bee, nonce, fees); ok && tx.Gas() == stx.gasLimit && stx.data == nil {
+		return tx, nil
 	}
-	log.Info(fmt.Sprintf("sent tx: %s", signedTxBee.Hash().Hex()))
-	bee.PendingNonce++ // increment nonce for next one
+	return c.newSigner(stx.gasLimit, data)(bee, nonce, fees)
+}
 
-	return signedTxBee.Hash()
+// newSigner of snipe txs for the current target with the given gas limit and trigger call data, safe to use once the
+// lock is released.
+// Must be called holding the lock.
+func (c *Sniper) newSigner(gasLimit uint64, data []byte) func(*Bee, uint64, txFees) (*types.Transaction, error) {
+	to, chainID, dynamic := c.sniperTriggerAddr, c.sniperChainID, c.dynamicFees
+	return func(bee *Bee, nonce uint64, fees txFees) (*types.Transaction, error) {
+		return types.SignNewTx(
+			bee.RawPK,
+			types.LatestSignerForChainID(chainID),
+			newTxData(dynamic, chainID, nonce, fees, gasLimit, &to, txValue, data, nil),
+		)
+	}
 }
 
 func recovery() {
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type (
+	// sniperTestClient of a node where the victim is still pending
+	sniperTestClient struct {
+		sniperETHClient
+
+		mut  sync.Mutex
+		sent []*types.Transaction
+	}
+
+	sniperTestRelays struct {
+		bundles [][]*types.Transaction
+	}
+
+	// sniperTestWatcher landing the bundles (or not), checking the sniper lock is free while waiting for them
+	sniperTestWatcher struct {
+		sniper   *Sniper
+		included bool
+		locked   bool
+	}
+)
+
+func (c *sniperTestClient) TransactionByHash(context.Context, common.Hash) (*types.Transaction, bool, error) {
+	return nil, true, nil
+}
+
+func (c *sniperTestClient) SendTransaction(_ context.Context, tx *types.Transaction) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.sent = append(c.sent, tx)
+	return nil
+}
+
+func (r *sniperTestRelays) Enabled() bool {
+	return true
+}
+
+func (r *sniperTestRelays) CallBundle(context.Context, []*types.Transaction, uint64) (*BundleSimulation, error) {
+	return &BundleSimulation{}, nil
+}
+
+func (r *sniperTestRelays) SendBundle(_ context.Context, txs []*types.Transaction, _ uint64) error {
+	r.bundles = append(r.bundles, txs)
+	return nil
+}
+
+func (w *sniperTestWatcher) Head(context.Context) (uint64, error) {
+	return 10, nil
+}
+
+func (w *sniperTestWatcher) WaitForBlock(context.Context, uint64) error {
+	return nil
+}
+
+func (w *sniperTestWatcher) WaitForInclusion(context.Context, common.Hash, uint64) (bool, error) {
+	free := make(chan struct{})
+	go func() {
+		w.sniper.mut.Lock()
+		defer w.sniper.mut.Unlock()
+		close(free)
+	}()
+	select {
+	case <-free:
+	case <-time.After(time.Second):
+		w.locked = true
+	}
+	return w.included, nil
+}
+
+func TestSniper_Execute_Bundle(t *testing.T) {
+	chainID := big.NewInt(56)
+	victim := types.NewTx(&types.LegacyTx{GasPrice: big.NewInt(5), To: &common.Address{0x1}})
+
+	tests := []struct {
+		name         string
+		included     bool
+		expectPublic int
+	}{
+		{"landed", true, 0},
+		{"missed", false, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var swarm []*Bee
+			for i := 0; i < 2; i++ {
+				pk, err := crypto.GenerateKey()
+				if err != nil {
+					t.Fatal(err)
+				}
+				swarm = append(swarm, NewBee(pk, uint64(i+3)))
+			}
+			client, relays := &sniperTestClient{}, &sniperTestRelays{}
+			c := &Sniper{
+				mut:             new(sync.Mutex),
+				sniping:         new(sync.Mutex),
+				ethClient:       client,
+				relays:          relays,
+				swarm:           swarm,
+				sniperChainID:   chainID,
+				triggerCalldata: triggerSmartContract,
+				presigned:       newPresignedTxs(),
+				timing:          domain.NewTiming(chainID, 60*time.Millisecond),
+				staleNonces:     new(int32),
+			}
+			watcher := &sniperTestWatcher{sniper: c, included: tt.included}
+			c.watcher = watcher
+
+			c.mut.Lock()
+			sent := c.execute(context.Background(), victim, c.pendingNonces(), false, true, snipeTx{gasLimit: defaultTxGasLimit})
+			c.mut.Unlock()
+
+			if len(relays.bundles) != 1 {
+				t.Fatalf("expected a single bundle, got %d", len(relays.bundles))
+			}
+			if b := relays.bundles[0]; len(b) != 3 || b[0] != victim {
+				t.Fatalf("expected a bundle of the victim and the txs of the swarm, got %v", b)
+			}
+			if watcher.locked {
+				t.Fatal("expected the lock released while waiting for the bundle")
+			}
+			if len(client.sent) != tt.expectPublic {
+				t.Fatalf("expected %d public txs, got %d", tt.expectPublic, len(client.sent))
+			}
+			if len(sent) != 2 {
+				t.Fatalf("expected the 2 txs of the swarm sent, got %d", len(sent))
+			}
+			for i, s := range sent {
+				if s.Tx == nil || s.Tx.Nonce() != uint64(i+3) {
+					t.Fatalf("expected tx %d sent with nonce %d, got %+v", i, i+3, s)
+				}
+				if swarm[i].PendingNonce != uint64(i+4) {
+					t.Fatalf("expected bee %d nonce advanced to %d, got %d", i, i+4, swarm[i].PendingNonce)
+				}
+			}
+		})
+	}
+}
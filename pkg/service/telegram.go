@@ -0,0 +1,125 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+const (
+	telegramURL = "https://api.telegram.org/bot%s/%s"
+	// telegramPollTimeout is how long an update poll is held open waiting for messages
+	telegramPollTimeout = 30 * time.Second
+	telegramTimeout     = telegramPollTimeout + 10*time.Second
+)
+
+type (
+	// TelegramBot reads the messages sent to a bot through the Telegram bot api, and answers them
+	TelegramBot struct {
+		token  string
+		client *http.Client
+	}
+
+	telegramResponse struct {
+		OK          bool            `json:"ok"`
+		Description string          `json:"description"`
+		Result      json.RawMessage `json:"result"`
+	}
+
+	telegramUpdate struct {
+		UpdateID int64 `json:"update_id"`
+		Message  *struct {
+			Chat struct {
+				ID int64 `json:"id"`
+			} `json:"chat"`
+			Text string `json:"text"`
+			Date int64  `json:"date"`
+		} `json:"message"`
+	}
+
+	telegramMessage struct {
+		ChatID int64  `json:"chat_id"`
+		Text   string `json:"text"`
+	}
+)
+
+// NewTelegramBot with the token BotFather gave it
+func NewTelegramBot(token string) *TelegramBot {
+	return &TelegramBot{
+		token: token,
+		client: &http.Client{
+			Timeout: telegramTimeout,
+		},
+	}
+}
+
+// Messages sent to the bot after the given update, waiting a while for them if there are none yet
+func (t *TelegramBot) Messages(ctx context.Context, after int64) ([]domain.ChatMessage, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"offset":          after + 1,
+		"timeout":         int(telegramPollTimeout.Seconds()),
+		"allowed_updates": []string{"message"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var updates []telegramUpdate
+	if err := t.call(ctx, "getUpdates", body, &updates); err != nil {
+		return nil, err
+	}
+
+	res := make([]domain.ChatMessage, 0, len(updates))
+	for _, u := range updates {
+		m := domain.ChatMessage{ID: u.UpdateID}
+		if u.Message != nil { // updates without a message (eg. edits) are returned empty, to move past them
+			m.Chat, m.Text, m.Time = u.Message.Chat.ID, u.Message.Text, time.Unix(u.Message.Date, 0)
+		}
+		res = append(res, m)
+	}
+	return res, nil
+}
+
+// Send a message to the chat
+func (t *TelegramBot) Send(ctx context.Context, chat int64, text string) error {
+	body, err := json.Marshal(telegramMessage{ChatID: chat, Text: text})
+	if err != nil {
+		return err
+	}
+	return t.call(ctx, "sendMessage", body, nil)
+}
+
+func (t *TelegramBot) call(ctx context.Context, method string, body []byte, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf(telegramURL, t.token, method), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating telegram %s request", method) // its error holds the url, with the token
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		var uerr *url.Error
+		if errors.As(err, &uerr) { // without the url, it holds the token
+			err = uerr.Err
+		}
+		return fmt.Errorf("error calling telegram %s: %s", method, err)
+	}
+	defer resp.Body.Close()
+
+	var res telegramResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return fmt.Errorf("malformed telegram response (status %d): %s", resp.StatusCode, err)
+	}
+	if !res.OK {
+		return fmt.Errorf("telegram %s failed (status %d): %s", method, resp.StatusCode, res.Description)
+	}
+	if v == nil {
+		return nil
+	}
+	return json.Unmarshal(res.Result, v)
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"expvar"
+	"sync"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type (
+	// TradeMetrics counts the trades saved per target along with what they cost in USD, so spend can be followed
+	// across chains. It decorates the repository the trades are saved to, and they are exported through expvar as:
+	//   trades: { target: { count: n, spent_usd: x, gas_usd: x } }
+	// Trades whose price in USD isn't known are counted but don't add to the costs.
+	TradeMetrics struct {
+		repo   tradeMetricsRepository
+		trades *expvar.Map
+
+		mut *sync.Mutex
+	}
+
+	tradeMetricsRepository interface {
+		Save(context.Context, domain.Trade) error
+	}
+)
+
+// NewTradeMetrics publishes the counters, hence it can be created only once.
+func NewTradeMetrics(r tradeMetricsRepository) *TradeMetrics {
+	return &TradeMetrics{
+		repo:   r,
+		trades: expvar.NewMap("trades"),
+		mut:    new(sync.Mutex),
+	}
+}
+
+// Save the trade in the repository, counting it if it was saved
+func (m *TradeMetrics) Save(ctx context.Context, t domain.Trade) error {
+	if err := m.repo.Save(ctx, t); err != nil {
+		return err
+	}
+	v := childMap(m.mut, m.trades, t.Target)
+	v.Add("count", 1)
+	v.AddFloat("spent_usd", t.AmountInUSD())
+	v.AddFloat("gas_usd", t.GasCostUSD())
+	return nil
+}
@@ -2,9 +2,14 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -14,26 +19,100 @@ import (
 	"github.com/saantiaguilera/liquidity-sniper/third_party/erc20"
 )
 
+const (
+	// uniswapAddLiquidityDataLen is the call data length of addLiquidity: selector + 8 words
+	uniswapAddLiquidityDataLen = 4 + 8*32
+	// uniswapAddLiquidityETHDataLen is the call data length of addLiquidityETH: selector + 6 words
+	uniswapAddLiquidityETHDataLen = 4 + 6*32
+	// victimTrackerSize is how many sniped victims we remember to detect their replacements
+	victimTrackerSize = 64
+)
+
+var (
+	// burnAddresses whose balances are out of circulation, for the market cap of a launch
+	burnAddresses = []common.Address{
+		common.HexToAddress("0x0000000000000000000000000000000000000000"),
+		common.HexToAddress("0x000000000000000000000000000000000000dEaD"),
+	}
+
+	uniswapAddLiquidityInputPool = sync.Pool{
+		New: func() interface{} {
+			return &uniswapAddLiquidityInput{
+				AmountTokenADesired: new(big.Int),
+				AmountTokenBDesired: new(big.Int),
+				AmountTokenAMin:     new(big.Int),
+				AmountTokenBMin:     new(big.Int),
+				Deadline:            new(big.Int),
+			}
+		},
+	}
+	uniswapAddLiquidityETHInputPool = sync.Pool{
+		New: func() interface{} {
+			return &uniswapAddLiquidityETHInput{
+				AmountTokenDesired: new(big.Int),
+				AmountTokenMin:     new(big.Int),
+				AmountETHMin:       new(big.Int),
+				Deadline:           new(big.Int),
+			}
+		},
+	}
+)
+
 type (
 	UniswapLiquidity struct {
 		ethClient    uniswapLiquidityETHClient
 		sniperClient uniswapLiquiditySniperClient
+		senders      uniswapLiquiditySenderResolver
+		decisions    uniswapLiquidityDecisionRecorder
+
+		// pending is true if the victims we get aren't mined yet (as opposed to reading them from blocks)
+		pending bool
+		// validateVictim simulating it before sniping, only useful while it's pending
+		validateVictim bool
+		// deadlineMaxAhead of now a victim deadline can be. Zero means no max.
+		deadlineMaxAhead time.Duration
+		// valuation bands of the launches we snipe
+		valuation domain.ValuationBands
+		// constructors adding liquidity, nil doesn't look for them
+		constructors *uniswapConstructors
 
+		victims  *victimTracker
+		decimals *TokenDecimals
+		target   *atomic.Value // *uniswapLiquidityTarget
+	}
+
+	// uniswapLiquidityTarget we are looking liquidity additions for. It's replaced as a whole when the target changes,
+	// so a tx is always evaluated against a single target.
+	uniswapLiquidityTarget struct {
+		name              string
 		sniperTTBAddr     common.Address
 		sniperTTBTkn      *erc20.Erc20
 		sniperTokenPaired common.Address
+		sniperPairedTkn   *erc20.Erc20
 		sniperMinLiq      *big.Int
 		sniperChainID     *big.Int
+		armed             bool
 	}
 
 	uniswapLiquidityETHClient interface {
 		bind.ContractBackend
 
+		PendingCallContract(context.Context, ethereum.CallMsg) ([]byte, error)
+		PendingBalanceAt(context.Context, common.Address) (*big.Int, error)
 		NetworkID(context.Context) (*big.Int, error)
 	}
 
+	uniswapLiquiditySenderResolver interface {
+		Sender(*big.Int, *types.Transaction) (common.Address, error)
+	}
+
+	uniswapLiquidityDecisionRecorder interface {
+		Record(domain.Decision)
+	}
+
 	uniswapLiquiditySniperClient interface {
-		Snipe(context.Context, *big.Int) error
+		Snipe(context.Context, *types.Transaction) error
+		Replace(ctx context.Context, replaced, victim *types.Transaction) error
 	}
 
 	uniswapAddLiquidityInput struct {
@@ -60,85 +139,99 @@ type (
 func NewUniswapLiquidity(
 	e uniswapLiquidityETHClient,
 	s uniswapLiquiditySniperClient,
+	r uniswapLiquiditySenderResolver,
+	d uniswapLiquidityDecisionRecorder,
 	sn domain.Sniper,
+	p, v bool,
+	dl time.Duration,
+	vb domain.ValuationBands,
 ) (*UniswapLiquidity, error) {
 
+	u := &UniswapLiquidity{
+		ethClient:        e,
+		sniperClient:     s,
+		senders:          r,
+		decisions:        d,
+		pending:          p,
+		validateVictim:   v,
+		deadlineMaxAhead: dl,
+		valuation:        vb,
+		victims:          newVictimTracker(victimTrackerSize),
+		decimals:         NewTokenDecimals(e),
+		target:           new(atomic.Value),
+	}
+	if err := u.SetTarget(sn); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// SetTarget we look liquidity additions for. Txs being evaluated keep using the previous one.
+func (u *UniswapLiquidity) SetTarget(sn domain.Sniper) error {
 	ttb := common.HexToAddress(sn.AddressTargetToken)
-	ttbTkn, err := erc20.NewErc20(ttb, e)
+	ttbTkn, err := erc20.NewErc20(ttb, u.ethClient)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	tp := common.HexToAddress(sn.AddressTargetPaired)
+	tpTkn, err := erc20.NewErc20(tp, u.ethClient)
+	if err != nil {
+		return err
+	}
 
-	return &UniswapLiquidity{
-		ethClient:         e,
-		sniperClient:      s,
+	u.target.Store(&uniswapLiquidityTarget{
+		name:              sn.Name,
 		sniperTTBAddr:     ttb,
 		sniperTTBTkn:      ttbTkn,
 		sniperTokenPaired: tp,
+		sniperPairedTkn:   tpTkn,
 		sniperMinLiq:      sn.MinimumLiquidity,
 		sniperChainID:     sn.ChainID,
-	}, nil
-}
-
-func (u *UniswapLiquidity) newInputFromTx(tx *types.Transaction) uniswapAddLiquidityInput {
-	data := tx.Data()[4:]
-	tokenA := common.BytesToAddress(data[12:32])
-	tokenB := common.BytesToAddress(data[44:64])
-	var amountTokenADesired = new(big.Int)
-	amountTokenADesired.SetString(common.Bytes2Hex(data[64:96]), 16)
-	var amountTokenBDesired = new(big.Int)
-	amountTokenBDesired.SetString(common.Bytes2Hex(data[96:128]), 16)
-	var amountTokenAMin = new(big.Int)
-	amountTokenAMin.SetString(common.Bytes2Hex(data[128:160]), 16)
-	var amountTokenBMin = new(big.Int)
-	amountTokenBMin.SetString(common.Bytes2Hex(data[160:192]), 16)
-	to := common.BytesToAddress(data[204:224])
-	var deadline = new(big.Int)
-	deadline.SetString(common.Bytes2Hex(data[224:256]), 16)
-
-	return uniswapAddLiquidityInput{
-		TokenAddressA:       tokenA,
-		TokenAddressB:       tokenB,
-		AmountTokenADesired: amountTokenADesired,
-		AmountTokenBDesired: amountTokenBDesired,
-		AmountTokenAMin:     amountTokenAMin,
-		AmountTokenBMin:     amountTokenBMin,
-		Deadline:            deadline,
-		To:                  to,
-	}
-}
-
-func (u *UniswapLiquidity) newETHInputFromTx(tx *types.Transaction) uniswapAddLiquidityETHInput {
-	data := tx.Data()[4:]
-	token := common.BytesToAddress(data[12:32])
-	var amountTokenDesired = new(big.Int)
-	amountTokenDesired.SetString(common.Bytes2Hex(data[32:64]), 16)
-	var amountTokenMin = new(big.Int)
-	amountTokenMin.SetString(common.Bytes2Hex(data[64:96]), 16)
-	var amountETHMin = new(big.Int)
-	amountETHMin.SetString(common.Bytes2Hex(data[96:128]), 16)
-
-	to := common.BytesToAddress(data[140:160])
-	var deadline = new(big.Int)
-	deadline.SetString(common.Bytes2Hex(data[160:192]), 16)
-
-	return uniswapAddLiquidityETHInput{
-		TokenAddress:       token,
-		AmountTokenDesired: amountTokenDesired,
-		AmountETHMin:       amountETHMin,
-		AmountTokenMin:     amountTokenMin,
-		Deadline:           deadline,
-		To:                 to,
-	}
-}
-
-func (u *UniswapLiquidity) getTxSenderAddressQuick(tx *types.Transaction) (common.Address, error) {
-	msg, err := tx.AsMessage(types.NewEIP155Signer(u.sniperChainID), nil)
-	if err != nil {
-		return common.Address{}, err
-	}
-	return msg.From(), nil
+		armed:             sn.Armed,
+	})
+	return nil
+}
+
+// newInputFromTx decodes the addLiquidity call data straight from its bytes.
+// This runs on every tx to the router, so the input is pooled: release it once you are done with it.
+func (u *UniswapLiquidity) newInputFromTx(data []byte) *uniswapAddLiquidityInput {
+	in := uniswapAddLiquidityInputPool.Get().(*uniswapAddLiquidityInput)
+	data = data[4:]
+	in.TokenAddressA = common.BytesToAddress(data[12:32])
+	in.TokenAddressB = common.BytesToAddress(data[44:64])
+	in.AmountTokenADesired.SetBytes(data[64:96])
+	in.AmountTokenBDesired.SetBytes(data[96:128])
+	in.AmountTokenAMin.SetBytes(data[128:160])
+	in.AmountTokenBMin.SetBytes(data[160:192])
+	in.To = common.BytesToAddress(data[204:224])
+	in.Deadline.SetBytes(data[224:256])
+	return in
+}
+
+// newETHInputFromTx decodes the addLiquidityETH call data straight from its bytes.
+// This runs on every tx to the router, so the input is pooled: release it once you are done with it.
+func (u *UniswapLiquidity) newETHInputFromTx(data []byte) *uniswapAddLiquidityETHInput {
+	in := uniswapAddLiquidityETHInputPool.Get().(*uniswapAddLiquidityETHInput)
+	data = data[4:]
+	in.TokenAddress = common.BytesToAddress(data[12:32])
+	in.AmountTokenDesired.SetBytes(data[32:64])
+	in.AmountTokenMin.SetBytes(data[64:96])
+	in.AmountETHMin.SetBytes(data[96:128])
+	in.To = common.BytesToAddress(data[140:160])
+	in.Deadline.SetBytes(data[160:192])
+	return in
+}
+
+func (in *uniswapAddLiquidityInput) release() {
+	uniswapAddLiquidityInputPool.Put(in)
+}
+
+func (in *uniswapAddLiquidityETHInput) release() {
+	uniswapAddLiquidityETHInputPool.Put(in)
+}
+
+func (u *UniswapLiquidity) getTxSenderAddressQuick(t *uniswapLiquidityTarget, tx *types.Transaction) (common.Address, error) {
+	return u.senders.Sender(t.sniperChainID, tx)
 }
 
 func (u *UniswapLiquidity) getTokenSymbol(tokenAddress common.Address) string {
@@ -151,95 +244,459 @@ func (u *UniswapLiquidity) getTokenSymbol(tokenAddress common.Address) string {
 }
 
 func (u *UniswapLiquidity) Add(ctx context.Context, tx *types.Transaction) error {
-	sender, err := u.getTxSenderAddressQuick(tx)
-	if err != nil {
-		return fmt.Errorf("error getting sender address: %s", err)
+	t := u.target.Load().(*uniswapLiquidityTarget)
+	if !t.armed {
+		return nil
+	}
+
+	data := tx.Data()
+	if len(data) < uniswapAddLiquidityDataLen {
+		return fmt.Errorf("malformed addLiquidity tx %s: %d bytes of data", tx.Hash().String(), len(data))
 	}
 
 	// parse the info of the swap so that we can access it easily
-	var addLiquidity = u.newInputFromTx(tx)
+	addLiquidity := u.newInputFromTx(data)
+	defer addLiquidity.release()
 
-	// security checks
+	// security checks, cheapest first
 	// does the liquidity addition deals with the token i'm targetting?
-	if addLiquidity.TokenAddressA == u.sniperTTBAddr || addLiquidity.TokenAddressB == u.sniperTTBAddr {
-		// does the liquidity is added on the right pair?
-		if addLiquidity.TokenAddressA == u.sniperTokenPaired || addLiquidity.TokenAddressB == u.sniperTokenPaired {
-			tknBalanceSender, err := u.sniperTTBTkn.BalanceOf(nil, sender)
-			if err != nil {
-				return fmt.Errorf("error getting balance of token to buy: %s", err)
-			}
+	if addLiquidity.TokenAddressA != t.sniperTTBAddr && addLiquidity.TokenAddressB != t.sniperTTBAddr {
+		return nil
+	}
+	// the input is pooled, so the candidate copies its amounts
+	candidate := &domain.Candidate{
+		Method:       "addLiquidity",
+		Token:        t.sniperTTBAddr.Hex(),
+		Paired:       addLiquidity.TokenAddressA.Hex(),
+		AmountToken:  new(big.Int).Set(addLiquidity.AmountTokenBDesired),
+		AmountPaired: new(big.Int).Set(addLiquidity.AmountTokenADesired),
+		GasPrice:     tx.GasPrice(),
+		Deadline:     new(big.Int).Set(addLiquidity.Deadline),
+	}
+	if addLiquidity.TokenAddressA == t.sniperTTBAddr {
+		candidate.Paired = addLiquidity.TokenAddressB.Hex()
+		candidate.AmountToken, candidate.AmountPaired = candidate.AmountPaired, candidate.AmountToken
+	}
+	u.see(t, tx, candidate)
+	log.Debug(fmt.Sprintf(
+		"decoded addLiquidity %s: %s of %s / %s of %s, deadline %s",
+		tx.Hash().Hex(),
+		addLiquidity.AmountTokenADesired.String(), addLiquidity.TokenAddressA.Hex(),
+		addLiquidity.AmountTokenBDesired.String(), addLiquidity.TokenAddressB.Hex(),
+		addLiquidity.Deadline.String(),
+	))
+	// does the liquidity is added on the right pair?
+	if addLiquidity.TokenAddressA != t.sniperTokenPaired && addLiquidity.TokenAddressB != t.sniperTokenPaired {
+		u.reject(t, tx, domain.SkipReasonWrongPair, fmt.Sprintf(
+			"pair %s / %s", addLiquidity.TokenAddressA.Hex(), addLiquidity.TokenAddressB.Hex(),
+		))
+		return nil
+	}
+	if !u.checkDeadline(t, tx, addLiquidity.Deadline) {
+		return nil
+	}
 
-			var amountTknMin *big.Int
-			var amountPairedMin *big.Int
-			if addLiquidity.TokenAddressA == u.sniperTTBAddr {
-				amountTknMin = addLiquidity.AmountTokenAMin
-				amountPairedMin = addLiquidity.AmountTokenBMin
-			} else {
-				amountTknMin = addLiquidity.AmountTokenBMin
-				amountPairedMin = addLiquidity.AmountTokenAMin
-			}
-			// we check if the liquidity provider really possess the liquidity he wants to add, because it is possible to be lured by other bots that fake liquidity addition.
-			checkBalanceTknLP := amountTknMin.Cmp(tknBalanceSender)
-			if checkBalanceTknLP == 0 || checkBalanceTknLP == -1 {
-				// we check if the liquidity provider add enough collateral (WBNB or BUSD) as expected by our configuration. Bc sometimes the dev fuck the pleb and add way less liquidity that was advertised on telegram.
-				if amountPairedMin.Cmp(u.sniperMinLiq) == 1 {
-					log.Info(fmt.Sprintf("snipe executed for tx: %s", tx.Hash().String()))
-					return u.sniperClient.Snipe(ctx, tx.GasPrice())
-				} else {
-					log.Info(fmt.Sprintf(
-						"liquidity added but lower than expected: %.4f %s vs %.4f expected",
-						formatETHWeiToEther(amountPairedMin),
-						u.getTokenSymbol(u.sniperTokenPaired),
-						formatETHWeiToEther(u.sniperMinLiq),
-					))
-				}
-			}
-		}
+	var amountTkn *big.Int
+	var amountPairedMin, amountPaired *big.Int
+	if addLiquidity.TokenAddressA == t.sniperTTBAddr {
+		amountTkn = addLiquidity.AmountTokenADesired
+		amountPairedMin, amountPaired = addLiquidity.AmountTokenBMin, addLiquidity.AmountTokenBDesired
+	} else {
+		amountTkn = addLiquidity.AmountTokenBDesired
+		amountPairedMin, amountPaired = addLiquidity.AmountTokenAMin, addLiquidity.AmountTokenADesired
 	}
-	return nil
+
+	// we check if the liquidity provider add enough collateral (WBNB or BUSD) as expected by our configuration. Bc sometimes the dev fuck the pleb and add way less liquidity that was advertised on telegram.
+	if amountPairedMin.Cmp(t.sniperMinLiq) != 1 {
+		u.reject(t, tx, domain.SkipReasonLiqBelowMin, fmt.Sprintf(
+			"%.4f %s vs %.4f expected",
+			u.decimals.Format(ctx, t.sniperTokenPaired, amountPairedMin),
+			u.getTokenSymbol(t.sniperTokenPaired),
+			u.decimals.Format(ctx, t.sniperTokenPaired, t.sniperMinLiq),
+		))
+		return nil
+	}
+
+	sender, ok, err := u.checkSender(t, tx)
+	if !ok {
+		return err
+	}
+	// both tokens of the pair are moved from the sender, else it could spoof the paired side.
+	// The router pulls the desired amounts into a fresh pair, the mins only matter once it has reserves
+	if ok, err := u.checkSenderBalance(t, tx, t.sniperTTBTkn, t.sniperTTBAddr, sender, amountTkn); !ok {
+		return err
+	}
+	if ok, err := u.checkSenderBalance(t, tx, t.sniperPairedTkn, t.sniperTokenPaired, sender, amountPaired); !ok {
+		return err
+	}
+	if ok, err := u.checkSenderAllowance(t, tx, t.sniperTTBTkn, sender, amountTkn); !ok {
+		return err
+	}
+	if ok, err := u.checkSenderAllowance(t, tx, t.sniperPairedTkn, sender, amountPaired); !ok {
+		return err
+	}
+	if ok, err := u.checkValuation(ctx, t, tx, amountTkn, amountPaired); !ok {
+		return err
+	}
+	if !u.checkVictim(ctx, t, tx, sender) {
+		return nil
+	}
+	return u.snipe(withLaunchReserves(ctx, amountPaired, amountTkn), t, tx, sender)
 }
 
 // interest Sniping and filter addliquidity tx
 // TODO Super similars, refactor?
 func (u *UniswapLiquidity) AddETH(ctx context.Context, tx *types.Transaction) error {
+	t := u.target.Load().(*uniswapLiquidityTarget)
+	if !t.armed {
+		return nil
+	}
+
+	data := tx.Data()
+	if len(data) < uniswapAddLiquidityETHDataLen {
+		return fmt.Errorf("malformed addLiquidityETH tx %s: %d bytes of data", tx.Hash().String(), len(data))
+	}
+
 	// parse the info of the swap so that we can access it easily
-	sender, err := u.getTxSenderAddressQuick(tx)
+	addLiquidity := u.newETHInputFromTx(data)
+	defer addLiquidity.release()
+
+	// security checks, cheapest first
+	// does the liquidity addition deals with the token i'm targetting?
+	if addLiquidity.TokenAddress != t.sniperTTBAddr {
+		return nil
+	}
+	// the input is pooled, so the candidate copies its amounts
+	u.see(t, tx, &domain.Candidate{
+		Method:       "addLiquidityETH",
+		Token:        t.sniperTTBAddr.Hex(),
+		AmountToken:  new(big.Int).Set(addLiquidity.AmountTokenDesired),
+		AmountPaired: tx.Value(),
+		GasPrice:     tx.GasPrice(),
+		Deadline:     new(big.Int).Set(addLiquidity.Deadline),
+	})
+	log.Debug(fmt.Sprintf(
+		"decoded addLiquidityETH %s: %s of %s / %s wei, deadline %s",
+		tx.Hash().Hex(),
+		addLiquidity.AmountTokenDesired.String(), addLiquidity.TokenAddress.Hex(),
+		tx.Value().String(),
+		addLiquidity.Deadline.String(),
+	))
+	if !u.checkDeadline(t, tx, addLiquidity.Deadline) {
+		return nil
+	}
+
+	// we check if the liquidity provider add enough collateral (WBNB or BUSD) as expected by our configuration. Bc sometimes the dev fuck the pleb and add way less liquidity that was advertised on telegram.
+	if tx.Value().Cmp(t.sniperMinLiq) != 1 {
+		u.reject(t, tx, domain.SkipReasonLiqBelowMin, fmt.Sprintf(
+			"%.4f vs %.4f expected",
+			formatETHWeiToEther(tx.Value()),
+			formatETHWeiToEther(t.sniperMinLiq),
+		))
+		return nil
+	}
+	if addLiquidity.AmountETHMin.Cmp(t.sniperMinLiq) != 1 {
+		u.reject(t, tx, domain.SkipReasonLiqBelowMin, fmt.Sprintf(
+			"min ETH %.4f vs %.4f expected",
+			formatETHWeiToEther(addLiquidity.AmountETHMin),
+			formatETHWeiToEther(t.sniperMinLiq),
+		))
+		return nil
+	}
+
+	sender, ok, err := u.checkSender(t, tx)
+	if !ok {
+		return err
+	}
+	if ok, err := u.checkSenderBalance(t, tx, t.sniperTTBTkn, t.sniperTTBAddr, sender, addLiquidity.AmountTokenDesired); !ok {
+		return err
+	}
+	if ok, err := u.checkSenderNativeBalance(ctx, t, tx, sender); !ok {
+		return err
+	}
+	if ok, err := u.checkSenderAllowance(t, tx, t.sniperTTBTkn, sender, addLiquidity.AmountTokenDesired); !ok {
+		return err
+	}
+	if ok, err := u.checkValuation(ctx, t, tx, addLiquidity.AmountTokenDesired, tx.Value()); !ok {
+		return err
+	}
+	if !u.checkVictim(ctx, t, tx, sender) {
+		return nil
+	}
+	return u.snipe(withLaunchReserves(ctx, tx.Value(), addLiquidity.AmountTokenDesired), t, tx, sender)
+}
+
+// checkDeadline of the victim, we should only snipe if it's ok.
+// An expired deadline means the tx will revert, and one too far ahead is something no frontend does: both are usually
+// bait or junk txs.
+func (u *UniswapLiquidity) checkDeadline(t *uniswapLiquidityTarget, tx *types.Transaction, deadline *big.Int) bool {
+	now := time.Now()
+	if u.pending && deadline.Cmp(big.NewInt(now.Unix())) == -1 {
+		u.reject(t, tx, domain.SkipReasonDeadlineExpired, fmt.Sprintf("deadline %s is before %d", deadline.String(), now.Unix()))
+		return false
+	}
+	if u.deadlineMaxAhead > 0 && deadline.Cmp(big.NewInt(now.Add(u.deadlineMaxAhead).Unix())) == 1 {
+		u.reject(t, tx, domain.SkipReasonDeadlineTooFar, fmt.Sprintf(
+			"deadline %s is more than %s ahead of %d", deadline.String(), u.deadlineMaxAhead, now.Unix(),
+		))
+		return false
+	}
+	return true
+}
+
+// checkSender of the tx can be recovered, we should only snipe if it's ok.
+func (u *UniswapLiquidity) checkSender(t *uniswapLiquidityTarget, tx *types.Transaction) (common.Address, bool, error) {
+	sender, err := u.getTxSenderAddressQuick(t, tx)
 	if err != nil {
-		return fmt.Errorf("error getting sender address: %s", err)
+		u.reject(t, tx, domain.SkipReasonSenderUnknown, err.Error())
+		return sender, false, fmt.Errorf("error getting sender address: %s", err)
 	}
+	return sender, true, nil
+}
 
-	addLiquidity := u.newETHInputFromTx(tx)
+// checkSenderBalance of a token being added, we should only snipe if it's ok.
+// we check if the liquidity provider really possess the liquidity he wants to add, because it is possible to be lured by other bots that fake liquidity addition.
+// A mined victim already moved its tokens into the pair, so there's nothing to check then.
+func (u *UniswapLiquidity) checkSenderBalance(
+	t *uniswapLiquidityTarget,
+	tx *types.Transaction,
+	tkn *erc20.Erc20,
+	tknAddr common.Address,
+	sender common.Address,
+	amount *big.Int,
+) (bool, error) {
+
+	if !u.pending {
+		return true, nil
+	}
 
-	tknBalanceSender, err := u.sniperTTBTkn.BalanceOf(nil, sender)
+	balance, err := tkn.BalanceOf(nil, sender)
 	if err != nil {
-		return fmt.Errorf("error getting balance of token to buy: %s", err)
+		u.reject(t, tx, domain.SkipReasonSenderBalanceUnknown, err.Error())
+		return false, fmt.Errorf("error getting balance of sender %s: %s", sender.Hex(), err)
 	}
+	if amount.Cmp(balance) == 1 {
+		u.reject(t, tx, domain.SkipReasonSenderBalanceInsufficient, fmt.Sprintf(
+			"sender %s holds %s of the %s %s tokens added", sender.Hex(), balance.String(), amount.String(), tknAddr.Hex(),
+		))
+		return false, nil
+	}
+	return true, nil
+}
 
-	checkBalanceLP := addLiquidity.AmountTokenMin.Cmp(tknBalanceSender)
+// checkSenderNativeBalance of a liquidity addition paired with the native coin, we should only snipe if it's ok.
+// The sender pays the value added on top of the gas, a tx it can't afford is never mined.
+func (u *UniswapLiquidity) checkSenderNativeBalance(
+	ctx context.Context,
+	t *uniswapLiquidityTarget,
+	tx *types.Transaction,
+	sender common.Address,
+) (bool, error) {
 
-	// security checks:
-	// does the liquidity addition deals with the token i'm targetting?
-	if addLiquidity.TokenAddress == u.sniperTTBAddr {
-		// we check if the liquidity provider really possess the liquidity he wants to add, because it is possible to be lured by other bots that fake liquidity addition.
-		if checkBalanceLP == 0 || checkBalanceLP == -1 {
-			// we check if the liquidity provider add enough collateral (WBNB or BUSD) as expected by our configuration. Bc sometimes the dev fuck the pleb and add way less liquidity that was advertised on telegram.
-			if tx.Value().Cmp(u.sniperMinLiq) == 1 {
-				if addLiquidity.AmountETHMin.Cmp(u.sniperMinLiq) == 1 {
-					log.Info(fmt.Sprintf("snipe executed for tx: %s", tx.Hash().String()))
-					return u.sniperClient.Snipe(ctx, tx.GasPrice())
-				}
-			} else {
-				log.Info(fmt.Sprintf(
-					"liquidity added but lower than expected: %.4f vs %.4f expected",
-					formatETHWeiToEther(tx.Value()),
-					formatETHWeiToEther(u.sniperMinLiq),
-				))
-			}
+	if !u.pending {
+		return true, nil
+	}
+
+	balance, err := u.ethClient.PendingBalanceAt(ctx, sender)
+	if err != nil {
+		u.reject(t, tx, domain.SkipReasonSenderBalanceUnknown, err.Error())
+		return false, fmt.Errorf("error getting native balance of sender %s: %s", sender.Hex(), err)
+	}
+	if tx.Cost().Cmp(balance) == 1 {
+		u.reject(t, tx, domain.SkipReasonSenderBalanceInsufficient, fmt.Sprintf(
+			"sender %s holds %.4f of the %.4f ETH added (gas included)",
+			sender.Hex(), formatETHWeiToEther(balance), formatETHWeiToEther(tx.Cost()),
+		))
+		return false, nil
+	}
+	return true, nil
+}
+
+// checkSenderAllowance of the token being added to the router (the victim tx recipient), we should only snipe if it's ok.
+// Holding the tokens isn't enough: without allowing the router to move them the liquidity addition can't happen, and
+// bots luring others rely on that. A mined victim already spent its allowance, so there's nothing to check then.
+func (u *UniswapLiquidity) checkSenderAllowance(
+	t *uniswapLiquidityTarget,
+	tx *types.Transaction,
+	tkn *erc20.Erc20,
+	sender common.Address,
+	amount *big.Int,
+) (bool, error) {
+
+	if !u.pending {
+		return true, nil
+	}
+
+	allowance, err := tkn.Allowance(nil, sender, *tx.To())
+	if err != nil {
+		u.reject(t, tx, domain.SkipReasonSenderAllowanceUnknown, err.Error())
+		return false, fmt.Errorf("error getting allowance of sender %s: %s", sender.Hex(), err)
+	}
+	if amount.Cmp(allowance) == 1 {
+		u.reject(t, tx, domain.SkipReasonSenderAllowanceInsufficient, fmt.Sprintf(
+			"sender %s allows %s of the %s tokens added", sender.Hex(), allowance.String(), amount.String(),
+		))
+		return false, nil
+	}
+	return true, nil
+}
+
+// checkValuation of the launch, we should only snipe if it's within the bands of the target.
+// The price is the one the liquidity addition sets (paired / token amounts added), so the market cap and FDV are the
+// ones the token launches with. We can't exit a launch too tiny, and an absurdly pre-valued one has nowhere to go.
+func (u *UniswapLiquidity) checkValuation(
+	ctx context.Context,
+	t *uniswapLiquidityTarget,
+	tx *types.Transaction,
+	amountTkn, amountPaired *big.Int,
+) (bool, error) {
+
+	if !u.valuation.Enabled() {
+		return true, nil
+	}
+
+	opts := &bind.CallOpts{Context: ctx}
+	supply, err := t.sniperTTBTkn.TotalSupply(opts)
+	if err != nil {
+		u.reject(t, tx, domain.SkipReasonValuationUnknown, err.Error())
+		return false, fmt.Errorf("error getting total supply of %s: %s", t.sniperTTBAddr.Hex(), err)
+	}
+	burned := new(big.Int)
+	for _, a := range burnAddresses {
+		b, err := t.sniperTTBTkn.BalanceOf(opts, a)
+		if err != nil {
+			u.reject(t, tx, domain.SkipReasonValuationUnknown, err.Error())
+			return false, fmt.Errorf("error getting burned balance of %s: %s", t.sniperTTBAddr.Hex(), err)
+		}
+		burned.Add(burned, b)
+	}
+
+	v := domain.NewValuation(supply, burned, amountTkn, amountPaired)
+	if r, detail, ok := u.withinBands(ctx, t, v); !ok {
+		u.reject(t, tx, r, detail)
+		return false, nil
+	}
+	return true, nil
+}
+
+// withinBands the valuation is, else why it isn't
+func (u *UniswapLiquidity) withinBands(ctx context.Context, t *uniswapLiquidityTarget, v domain.Valuation) (domain.SkipReason, string, bool) {
+	bands := []struct {
+		name     string
+		value    *big.Int
+		min, max *big.Int
+	}{
+		{"market cap", v.MarketCap, u.valuation.MinMarketCap, u.valuation.MaxMarketCap},
+		{"fdv", v.FDV, u.valuation.MinFDV, u.valuation.MaxFDV},
+	}
+	for _, b := range bands {
+		switch {
+		case b.min != nil && b.value.Cmp(b.min) < 0:
+			return domain.SkipReasonValuationBelowMin, fmt.Sprintf(
+				"%s %.4f vs %.4f min",
+				b.name, u.decimals.Format(ctx, t.sniperTokenPaired, b.value), u.decimals.Format(ctx, t.sniperTokenPaired, b.min),
+			), false
+		case b.max != nil && b.value.Cmp(b.max) > 0:
+			return domain.SkipReasonValuationAboveMax, fmt.Sprintf(
+				"%s %.4f vs %.4f max",
+				b.name, u.decimals.Format(ctx, t.sniperTokenPaired, b.value), u.decimals.Format(ctx, t.sniperTokenPaired, b.max),
+			), false
 		}
 	}
+	if r := v.LiquidityRatio(); r < u.valuation.MinLiquidityRatio {
+		return domain.SkipReasonLiqRatioBelowMin, fmt.Sprintf(
+			"liquidity is %.2f%% of the fdv vs %.2f%% min", float64(r)/100, float64(u.valuation.MinLiquidityRatio)/100,
+		), false
+	}
+	return "", "", true
+}
+
+// checkVictim simulating it against the pending state, we should only snipe if it's ok.
+// A doomed liquidity addition (eg. without allowance or past its deadline) adds nothing, and we would be burning
+// aggressive gas behind it. If the simulation itself fails (eg. the node is down) we don't block the snipe.
+func (u *UniswapLiquidity) checkVictim(ctx context.Context, t *uniswapLiquidityTarget, tx *types.Transaction, sender common.Address) bool {
+	if !u.pending || !u.validateVictim {
+		return true
+	}
+
+	_, err := u.ethClient.PendingCallContract(ctx, ethereum.CallMsg{
+		From:     sender,
+		To:       tx.To(),
+		Gas:      tx.Gas(),
+		GasPrice: tx.GasPrice(),
+		Value:    tx.Value(),
+		Data:     tx.Data(),
+	})
+	if err == nil {
+		return true
+	}
+
+	if !isRevert(err) {
+		log.Warn(fmt.Sprintf("couldn't simulate tx %s, sniping without it: %s", tx.Hash().Hex(), err))
+		return true
+	}
+	u.reject(t, tx, domain.SkipReasonVictimReverts, err.Error())
+	return false
+}
+
+// snipe the tx. If it replaces a victim we already sniped (the provider sped it up or re-submitted it), the snipe is
+// replaced too so it follows the new gas.
+func (u *UniswapLiquidity) snipe(ctx context.Context, t *uniswapLiquidityTarget, tx *types.Transaction, sender common.Address) error {
+	var err error
+	var detail string
+	replaced, seen := u.victims.track(sender, tx)
+	if seen {
+		log.Debug(fmt.Sprintf("tx %s was already sniped, ignoring it", tx.Hash().Hex()))
+		return nil
+	}
+	if replaced != nil {
+		detail = fmt.Sprintf("replaces %s", replaced.Hash().Hex())
+		log.Info(fmt.Sprintf("snipe executed for tx: %s, replacing %s", tx.Hash().String(), replaced.Hash().String()))
+		err = u.sniperClient.Replace(ctx, replaced, tx)
+	} else {
+		log.Info(fmt.Sprintf("snipe executed for tx: %s", tx.Hash().String()))
+		err = u.sniperClient.Snipe(ctx, tx)
+	}
+	if err != nil {
+		var skip *domain.SkipError
+		if errors.As(err, &skip) {
+			u.reject(t, tx, skip.Reason, skip.Detail)
+			return nil
+		}
+		u.decide(t, tx, domain.DecisionSnipeFailed, "", err.Error())
+		return err
+	}
+	u.decide(t, tx, domain.DecisionSniped, "", detail)
 	return nil
 }
 
+// reject the candidate for the given reason
+func (u *UniswapLiquidity) reject(t *uniswapLiquidityTarget, tx *types.Transaction, r domain.SkipReason, detail string) {
+	log.Info("candidate rejected", "target", t.name, "tx", tx.Hash().Hex(), "reason", r, "detail", detail)
+	u.decide(t, tx, domain.DecisionRejected, r, detail)
+}
+
+// see the candidate, which enters the filter chain
+func (u *UniswapLiquidity) see(t *uniswapLiquidityTarget, tx *types.Transaction, c *domain.Candidate) {
+	u.decisions.Record(domain.Decision{
+		Kind:      domain.DecisionCandidateSeen,
+		Target:    t.name,
+		Tx:        tx.Hash().Hex(),
+		Detail:    c.Method,
+		Candidate: c,
+	})
+}
+
+func (u *UniswapLiquidity) decide(t *uniswapLiquidityTarget, tx *types.Transaction, k domain.DecisionKind, r domain.SkipReason, detail string) {
+	u.decisions.Record(domain.Decision{
+		Kind:   k,
+		Target: t.name,
+		Tx:     tx.Hash().Hex(),
+		Reason: r,
+		Detail: detail,
+	})
+}
+
 func formatETHWeiToEther(etherAmount *big.Int) float64 {
 	var base, exponent = big.NewInt(10), big.NewInt(18)
 	denominator := base.Exp(base, exponent, nil)
@@ -0,0 +1,338 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+var (
+	benchTokenA = common.HexToAddress("0x0e09fabb73bd3ade0a17ecc321fd13a19e81ce82")
+	benchTokenB = common.HexToAddress("0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c")
+	benchTo     = common.HexToAddress("0x13DEaEe548d2De1400a4B95737874623d9CF0e13")
+)
+
+func newBenchWord(v interface{}) []byte {
+	switch t := v.(type) {
+	case common.Address:
+		return common.LeftPadBytes(t.Bytes(), common.HashLength)
+	case *big.Int:
+		return common.LeftPadBytes(t.Bytes(), common.HashLength)
+	}
+	panic("unsupported word")
+}
+
+func newBenchTx(selector []byte, words ...interface{}) *types.Transaction {
+	data := append([]byte{}, selector...)
+	for _, w := range words {
+		data = append(data, newBenchWord(w)...)
+	}
+	return types.NewTransaction(0, benchTo, big.NewInt(0), 500000, big.NewInt(5000000000), data)
+}
+
+func BenchmarkUniswapLiquidity_NewInputFromTx(b *testing.B) {
+	u := &UniswapLiquidity{}
+	e18, _ := new(big.Int).SetString("1000000000000000000", 10)
+	tx := newBenchTx(
+		[]byte{0xe8, 0xe3, 0x37, 0x00},
+		benchTokenA, benchTokenB, e18, e18, e18, e18, benchTo, big.NewInt(1700000000),
+	)
+	data := tx.Data()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		u.newInputFromTx(data).release()
+	}
+}
+
+func BenchmarkUniswapLiquidity_NewETHInputFromTx(b *testing.B) {
+	u := &UniswapLiquidity{}
+	e18, _ := new(big.Int).SetString("1000000000000000000", 10)
+	tx := newBenchTx(
+		[]byte{0xf3, 0x05, 0xd7, 0x19},
+		benchTokenA, e18, e18, e18, benchTo, big.NewInt(1700000000),
+	)
+	data := tx.Data()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		u.newETHInputFromTx(data).release()
+	}
+}
+
+func BenchmarkUniswapLiquidity_AddNotTargeted(b *testing.B) {
+	u := &UniswapLiquidity{target: new(atomic.Value)}
+	u.target.Store(&uniswapLiquidityTarget{
+		sniperTTBAddr:     common.HexToAddress("0x8f5d3374373aDA8b2c201C5cAc4c384FD42d2390"),
+		sniperTokenPaired: benchTokenB,
+		armed:             true,
+	})
+	e18, _ := new(big.Int).SetString("1000000000000000000", 10)
+	tx := newBenchTx(
+		[]byte{0xe8, 0xe3, 0x37, 0x00},
+		benchTokenA, benchTokenB, e18, e18, e18, e18, benchTo, big.NewInt(1700000000),
+	)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := u.Add(context.Background(), tx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestUniswapLiquidity_WithinBands(t *testing.T) {
+	bands := domain.ValuationBands{
+		MinMarketCap:      big.NewInt(50),
+		MaxFDV:            big.NewInt(1000),
+		MinLiquidityRatio: 2000,
+	}
+	tests := []struct {
+		name      string
+		valuation domain.Valuation
+		expect    domain.SkipReason
+	}{
+		{"within", domain.Valuation{MarketCap: big.NewInt(100), FDV: big.NewInt(500), Liquidity: big.NewInt(250)}, ""},
+		{"at the bounds", domain.Valuation{MarketCap: big.NewInt(50), FDV: big.NewInt(1000), Liquidity: big.NewInt(200)}, ""},
+		{"market cap too tiny", domain.Valuation{MarketCap: big.NewInt(49), FDV: big.NewInt(500), Liquidity: big.NewInt(250)}, domain.SkipReasonValuationBelowMin},
+		{"fdv pre-valued", domain.Valuation{MarketCap: big.NewInt(100), FDV: big.NewInt(1001), Liquidity: big.NewInt(250)}, domain.SkipReasonValuationAboveMax},
+		{"dump setup", domain.Valuation{MarketCap: big.NewInt(100), FDV: big.NewInt(1000), Liquidity: big.NewInt(199)}, domain.SkipReasonLiqRatioBelowMin},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &UniswapLiquidity{valuation: bands, decimals: NewTokenDecimals(nil)}
+			u.decimals.cache.Store(benchTokenB, uint8(18))
+
+			r, _, ok := u.withinBands(context.Background(), &uniswapLiquidityTarget{sniperTokenPaired: benchTokenB}, tt.valuation)
+			if ok != (tt.expect == "") || r != tt.expect {
+				t.Fatalf("expected %q, got %q (ok: %t)", tt.expect, r, ok)
+			}
+		})
+	}
+}
+
+type (
+	// uniswapTestClient answering the balances and allowances of the tokens of the launch, by token
+	uniswapTestClient struct {
+		uniswapLiquidityETHClient
+
+		native     *big.Int
+		balances   map[common.Address]*big.Int
+		allowances map[common.Address]*big.Int
+	}
+
+	uniswapTestSenders struct {
+		sender common.Address
+	}
+)
+
+func (c uniswapTestClient) CallContract(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	var v *big.Int
+	switch {
+	case bytes.HasPrefix(msg.Data, []byte{0x70, 0xa0, 0x82, 0x31}): // balanceOf
+		v = c.balances[*msg.To]
+	case bytes.HasPrefix(msg.Data, []byte{0xdd, 0x62, 0xed, 0x3e}): // allowance
+		v = c.allowances[*msg.To]
+	}
+	if v == nil {
+		return nil, errors.New("execution reverted")
+	}
+	return common.LeftPadBytes(v.Bytes(), common.HashLength), nil
+}
+
+func (c uniswapTestClient) PendingBalanceAt(context.Context, common.Address) (*big.Int, error) {
+	if c.native == nil {
+		return nil, errors.New("unknown account")
+	}
+	return c.native, nil
+}
+
+func (s uniswapTestSenders) Sender(*big.Int, *types.Transaction) (common.Address, error) {
+	return s.sender, nil
+}
+
+// newUniswapTestLiquidity of a pending launch of benchTokenA paired with benchTokenB, whose sender holds and allows
+// the given amounts of both
+func newUniswapTestLiquidity(t *testing.T, c uniswapTestClient) (*UniswapLiquidity, *fakeConstructorSniper, *fakeConstructorDecisions) {
+	sniper := &fakeConstructorSniper{}
+	decisions := &fakeConstructorDecisions{}
+	u, err := NewUniswapLiquidity(c, sniper, uniswapTestSenders{sender: fillBeeA}, decisions, domain.Sniper{
+		AddressTargetToken:  benchTokenA.Hex(),
+		AddressTargetPaired: benchTokenB.Hex(),
+		MinimumLiquidity:    big.NewInt(1),
+		ChainID:             big.NewInt(56),
+		Armed:               true,
+	}, true, false, 0, domain.ValuationBands{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u, sniper, decisions
+}
+
+func rejectionOf(d *fakeConstructorDecisions) domain.SkipReason {
+	for _, dc := range d.decisions {
+		if dc.Kind == domain.DecisionRejected {
+			return dc.Reason
+		}
+	}
+	return ""
+}
+
+func TestUniswapLiquidity_Add_SenderAllowance(t *testing.T) {
+	deadline := big.NewInt(time.Now().Add(time.Minute).Unix())
+	// 100 tokens desired (50 min) and 10 paired desired (5 min)
+	tx := newBenchTx(
+		[]byte{0xe8, 0xe3, 0x37, 0x00},
+		benchTokenA, benchTokenB, big.NewInt(100), big.NewInt(10), big.NewInt(50), big.NewInt(5), benchTo, deadline,
+	)
+
+	tests := []struct {
+		name      string
+		allowance map[common.Address]*big.Int
+		reason    domain.SkipReason
+	}{
+		{"allows the desired", map[common.Address]*big.Int{benchTokenA: big.NewInt(100), benchTokenB: big.NewInt(10)}, ""},
+		{"token between min and desired", map[common.Address]*big.Int{benchTokenA: big.NewInt(60), benchTokenB: big.NewInt(10)}, domain.SkipReasonSenderAllowanceInsufficient},
+		{"paired between min and desired", map[common.Address]*big.Int{benchTokenA: big.NewInt(100), benchTokenB: big.NewInt(7)}, domain.SkipReasonSenderAllowanceInsufficient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, sniper, decisions := newUniswapTestLiquidity(t, uniswapTestClient{
+				balances:   map[common.Address]*big.Int{benchTokenA: big.NewInt(1000), benchTokenB: big.NewInt(1000)},
+				allowances: tt.allowance,
+			})
+			if err := u.Add(context.Background(), tx); err != nil {
+				t.Fatal(err)
+			}
+			if r := rejectionOf(decisions); r != tt.reason {
+				t.Fatalf("expected rejection %q, got %q", tt.reason, r)
+			}
+			if sniped := len(sniper.sniped) > 0; sniped != (tt.reason == "") {
+				t.Fatalf("expected sniped %t, got %t", tt.reason == "", sniped)
+			}
+		})
+	}
+}
+
+func TestUniswapLiquidity_AddETH_SenderAllowance(t *testing.T) {
+	deadline := big.NewInt(time.Now().Add(time.Minute).Unix())
+	// 100 tokens desired (50 min) for 10 wei (5 min)
+	tx := types.NewTransaction(0, benchTo, big.NewInt(10), 500000, big.NewInt(5), newBenchTx(
+		[]byte{0xf3, 0x05, 0xd7, 0x19},
+		benchTokenA, big.NewInt(100), big.NewInt(50), big.NewInt(5), benchTo, deadline,
+	).Data())
+
+	tests := []struct {
+		name      string
+		allowance *big.Int
+		reason    domain.SkipReason
+	}{
+		{"allows the desired", big.NewInt(100), ""},
+		{"between min and desired", big.NewInt(60), domain.SkipReasonSenderAllowanceInsufficient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, sniper, decisions := newUniswapTestLiquidity(t, uniswapTestClient{
+				native:     tx.Cost(),
+				balances:   map[common.Address]*big.Int{benchTokenA: big.NewInt(1000)},
+				allowances: map[common.Address]*big.Int{benchTokenA: tt.allowance},
+			})
+			if err := u.AddETH(context.Background(), tx); err != nil {
+				t.Fatal(err)
+			}
+			if r := rejectionOf(decisions); r != tt.reason {
+				t.Fatalf("expected rejection %q, got %q", tt.reason, r)
+			}
+			if sniped := len(sniper.sniped) > 0; sniped != (tt.reason == "") {
+				t.Fatalf("expected sniped %t, got %t", tt.reason == "", sniped)
+			}
+		})
+	}
+}
+
+func TestUniswapLiquidity_Add_SenderBalance(t *testing.T) {
+	deadline := big.NewInt(time.Now().Add(time.Minute).Unix())
+	// 100 tokens desired (50 min) and 10 paired desired (5 min)
+	tx := newBenchTx(
+		[]byte{0xe8, 0xe3, 0x37, 0x00},
+		benchTokenA, benchTokenB, big.NewInt(100), big.NewInt(10), big.NewInt(50), big.NewInt(5), benchTo, deadline,
+	)
+
+	tests := []struct {
+		name    string
+		balance map[common.Address]*big.Int
+		reason  domain.SkipReason
+	}{
+		{"holds the desired", map[common.Address]*big.Int{benchTokenA: big.NewInt(100), benchTokenB: big.NewInt(10)}, ""},
+		{"token between min and desired", map[common.Address]*big.Int{benchTokenA: big.NewInt(60), benchTokenB: big.NewInt(10)}, domain.SkipReasonSenderBalanceInsufficient},
+		{"paired between min and desired", map[common.Address]*big.Int{benchTokenA: big.NewInt(100), benchTokenB: big.NewInt(7)}, domain.SkipReasonSenderBalanceInsufficient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, sniper, decisions := newUniswapTestLiquidity(t, uniswapTestClient{
+				balances:   tt.balance,
+				allowances: map[common.Address]*big.Int{benchTokenA: big.NewInt(1000), benchTokenB: big.NewInt(1000)},
+			})
+			if err := u.Add(context.Background(), tx); err != nil {
+				t.Fatal(err)
+			}
+			if r := rejectionOf(decisions); r != tt.reason {
+				t.Fatalf("expected rejection %q, got %q", tt.reason, r)
+			}
+			if sniped := len(sniper.sniped) > 0; sniped != (tt.reason == "") {
+				t.Fatalf("expected sniped %t, got %t", tt.reason == "", sniped)
+			}
+		})
+	}
+}
+
+func TestUniswapLiquidity_AddETH_SenderBalance(t *testing.T) {
+	deadline := big.NewInt(time.Now().Add(time.Minute).Unix())
+	// 100 tokens desired (50 min) for 10 wei (5 min)
+	tx := types.NewTransaction(0, benchTo, big.NewInt(10), 500000, big.NewInt(5), newBenchTx(
+		[]byte{0xf3, 0x05, 0xd7, 0x19},
+		benchTokenA, big.NewInt(100), big.NewInt(50), big.NewInt(5), benchTo, deadline,
+	).Data())
+	cost := tx.Cost().Int64()
+
+	tests := []struct {
+		name    string
+		balance *big.Int
+		native  *big.Int
+		reason  domain.SkipReason
+	}{
+		{"holds the desired", big.NewInt(100), big.NewInt(cost), ""},
+		{"token between min and desired", big.NewInt(60), big.NewInt(cost), domain.SkipReasonSenderBalanceInsufficient},
+		{"can't afford the value", big.NewInt(100), big.NewInt(cost - 1), domain.SkipReasonSenderBalanceInsufficient},
+		{"unknown native balance", big.NewInt(100), nil, domain.SkipReasonSenderBalanceUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, sniper, decisions := newUniswapTestLiquidity(t, uniswapTestClient{
+				native:     tt.native,
+				balances:   map[common.Address]*big.Int{benchTokenA: tt.balance},
+				allowances: map[common.Address]*big.Int{benchTokenA: big.NewInt(1000)},
+			})
+			_ = u.AddETH(context.Background(), tx)
+			if r := rejectionOf(decisions); r != tt.reason {
+				t.Fatalf("expected rejection %q, got %q", tt.reason, r)
+			}
+			if sniped := len(sniper.sniped) > 0; sniped != (tt.reason == "") {
+				t.Fatalf("expected sniped %t, got %t", tt.reason == "", sniped)
+			}
+		})
+	}
+}
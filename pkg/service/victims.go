@@ -0,0 +1,68 @@
+package service
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+type (
+	// victimTracker is a LRU of the victims we sniped, keyed by sender and nonce.
+	// A liquidity provider speeding up or re-submitting its tx keeps both, so this is how we tell a replacement apart
+	// from a new liquidity addition.
+	victimTracker struct {
+		size int
+
+		entries map[victimKey]*list.Element
+		order   *list.List
+		mut     *sync.Mutex
+	}
+
+	victimKey struct {
+		sender common.Address
+		nonce  uint64
+	}
+
+	victimEntry struct {
+		key victimKey
+		tx  *types.Transaction
+	}
+)
+
+func newVictimTracker(size int) *victimTracker {
+	return &victimTracker{
+		size:    size,
+		entries: make(map[victimKey]*list.Element, size),
+		order:   list.New(),
+		mut:     new(sync.Mutex),
+	}
+}
+
+// track the tx of the sender as its latest victim, returning the one it replaces (if any) and if we already tracked
+// this very tx (eg. the node delivered it twice).
+func (v *victimTracker) track(sender common.Address, tx *types.Transaction) (*types.Transaction, bool) {
+	v.mut.Lock()
+	defer v.mut.Unlock()
+
+	k := victimKey{sender: sender, nonce: tx.Nonce()}
+	if e, ok := v.entries[k]; ok {
+		v.order.MoveToFront(e)
+		entry := e.Value.(*victimEntry)
+		prev := entry.tx
+		entry.tx = tx
+		if prev.Hash() == tx.Hash() {
+			return nil, true
+		}
+		return prev, false
+	}
+
+	v.entries[k] = v.order.PushFront(&victimEntry{key: k, tx: tx})
+	if v.order.Len() > v.size {
+		last := v.order.Back()
+		v.order.Remove(last)
+		delete(v.entries, last.Value.(*victimEntry).key)
+	}
+	return nil, false
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func newVictimTrackerTx(nonce uint64, gasPrice int64) *types.Transaction {
+	return types.NewTransaction(nonce, common.Address{}, big.NewInt(0), 21000, big.NewInt(gasPrice), nil)
+}
+
+func TestVictimTracker_Track(t *testing.T) {
+	senderA := common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	senderB := common.HexToAddress("0x00000000000000000000000000000000000000b2")
+	tx := newVictimTrackerTx(1, 5)
+	speedUp := newVictimTrackerTx(1, 6)
+
+	tests := []struct {
+		name       string
+		tracked    []*types.Transaction // by sender A, in order
+		sender     common.Address
+		tx         *types.Transaction
+		expectPrev *types.Transaction
+		expectSeen bool
+	}{
+		{name: "new", sender: senderA, tx: tx},
+		{name: "duplicate", tracked: []*types.Transaction{tx}, sender: senderA, tx: tx, expectSeen: true},
+		{name: "replacement", tracked: []*types.Transaction{tx}, sender: senderA, tx: speedUp, expectPrev: tx},
+		{name: "replaced back", tracked: []*types.Transaction{tx, speedUp}, sender: senderA, tx: tx, expectPrev: speedUp},
+		{name: "another nonce", tracked: []*types.Transaction{tx}, sender: senderA, tx: newVictimTrackerTx(2, 5)},
+		{name: "another sender", tracked: []*types.Transaction{tx}, sender: senderB, tx: speedUp},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newVictimTracker(victimTrackerSize)
+			for _, tx := range tt.tracked {
+				v.track(senderA, tx)
+			}
+
+			prev, seen := v.track(tt.sender, tt.tx)
+			if seen != tt.expectSeen {
+				t.Fatalf("expected seen %v, got %v", tt.expectSeen, seen)
+			}
+			if (prev == nil) != (tt.expectPrev == nil) || (prev != nil && prev.Hash() != tt.expectPrev.Hash()) {
+				t.Fatalf("expected replaced %v, got %v", tt.expectPrev, prev)
+			}
+		})
+	}
+}
+
+func TestVictimTracker_Track_Evicts(t *testing.T) {
+	sender := common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	v := newVictimTracker(2)
+	first := newVictimTrackerTx(1, 5)
+	v.track(sender, first)
+	v.track(sender, newVictimTrackerTx(2, 5))
+	v.track(sender, newVictimTrackerTx(3, 5))
+
+	// the first one was forgotten, so its replacement looks like a new victim
+	if prev, seen := v.track(sender, newVictimTrackerTx(1, 6)); prev != nil || seen {
+		t.Fatalf("expected the first victim to be evicted, got replaced %v seen %v", prev, seen)
+	}
+	if len(v.entries) != 2 || v.order.Len() != 2 {
+		t.Fatalf("expected 2 victims tracked, got %d / %d", len(v.entries), v.order.Len())
+	}
+}
@@ -0,0 +1,209 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+const (
+	// deadManPresignInterval is how often the emergency sells are signed again while our nodes answer, as the
+	// holdings and the nonces of the wallets change
+	deadManPresignInterval = time.Minute
+	// deadManSellDeadline is how long past the threshold the emergency sells are valid
+	deadManSellDeadline = time.Hour
+)
+
+type (
+	// DeadManSwitch watches our nodes. If none of them answers for longer than the threshold it alerts and, if it
+	// fires, sends the emergency sells of everything we hold (signed while they answered) through the backup routes.
+	DeadManSwitch struct {
+		nodes     deadManNodes
+		portfolio deadManPortfolio
+		seller    deadManSeller
+		backup    deadManBackup
+		alerts    deadManAlerter
+
+		threshold time.Duration
+		interval  time.Duration
+		blockTime time.Duration
+		fire      bool
+
+		presigned   [][]*types.Transaction
+		presignedAt time.Time
+		head        uint64
+		lastSeen    time.Time
+		tripped     bool
+	}
+
+	deadManNodes interface {
+		HeaderByNumber(context.Context, *big.Int) (*types.Header, error)
+	}
+
+	deadManPortfolio interface {
+		Holdings(context.Context) ([]domain.Holding, error)
+	}
+
+	deadManSeller interface {
+		Presign(ctx context.Context, token, paired common.Address, balances map[string]*big.Int, deadline time.Time) ([][]*types.Transaction, error)
+	}
+
+	deadManBackup interface {
+		Enabled() bool
+		Send(ctx context.Context, txs []*types.Transaction, head uint64) error
+	}
+
+	deadManAlerter interface {
+		Alert(domain.Alert)
+	}
+)
+
+func NewDeadManSwitch(
+	n deadManNodes,
+	p deadManPortfolio,
+	s deadManSeller,
+	b deadManBackup,
+	a deadManAlerter,
+	threshold, interval, blockTime time.Duration,
+	fire bool,
+) *DeadManSwitch {
+
+	return &DeadManSwitch{
+		nodes:     n,
+		portfolio: p,
+		seller:    s,
+		backup:    b,
+		alerts:    a,
+		threshold: threshold,
+		interval:  interval,
+		blockTime: blockTime,
+		fire:      fire,
+	}
+}
+
+// Run the switch until the context is done, probing our nodes every interval
+func (d *DeadManSwitch) Run(ctx context.Context) {
+	d.lastSeen = time.Now()
+	t := time.NewTicker(d.interval)
+	defer t.Stop()
+	for {
+		d.check(ctx, time.Now())
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (d *DeadManSwitch) check(ctx context.Context, now time.Time) {
+	pctx, cancel := context.WithTimeout(ctx, d.interval)
+	h, err := d.nodes.HeaderByNumber(pctx, nil)
+	cancel()
+	if err == nil {
+		d.head, d.lastSeen = h.Number.Uint64(), now
+		if d.tripped {
+			d.tripped = false
+			d.alerts.Alert(domain.Alert{
+				Kind:    domain.AlertConnectivityRestored,
+				Message: fmt.Sprintf("our nodes answer again at block %d", d.head),
+			})
+		}
+		if d.fire && now.Sub(d.presignedAt) >= deadManPresignInterval {
+			d.presign(ctx, now)
+		}
+		return
+	}
+
+	log.Warn(fmt.Sprintf("our nodes don't answer since %s: %s", d.lastSeen.Format(time.RFC3339), err))
+	if d.tripped || now.Sub(d.lastSeen) < d.threshold {
+		return
+	}
+	d.trip(ctx, now)
+}
+
+// presign the emergency sells of everything we hold right now. If it fails we keep the previous ones, they are
+// better than nothing.
+func (d *DeadManSwitch) presign(ctx context.Context, now time.Time) {
+	holdings, err := d.portfolio.Holdings(ctx)
+	if err != nil {
+		log.Error(fmt.Sprintf("error getting holdings to presign emergency sells: %s", err))
+		return
+	}
+
+	res := make([][]*types.Transaction, 0)
+	for _, h := range holdings {
+		if h.Balance == nil || h.Balance.Sign() == 0 {
+			continue
+		}
+		var paired common.Address
+		if len(h.Paired) > 0 {
+			paired = common.HexToAddress(h.Paired)
+		}
+		txs, err := d.seller.Presign(ctx, common.HexToAddress(h.Token), paired, h.Balances, now.Add(d.threshold+deadManSellDeadline))
+		if err != nil {
+			log.Error(fmt.Sprintf("error presigning emergency sells of %s: %s", h.Token, err))
+			return
+		}
+		res = append(res, txs...)
+	}
+	d.presigned, d.presignedAt = res, now
+	log.Debug(fmt.Sprintf("presigned %d emergency sells", len(res)))
+}
+
+// trip the switch, alerting and firing the emergency sells (if it fires) through the backup routes. They are fired
+// once, as they are spent even if the nodes come back.
+func (d *DeadManSwitch) trip(ctx context.Context, now time.Time) {
+	d.tripped = true
+	down := now.Sub(d.lastSeen).Truncate(time.Second)
+
+	switch {
+	case !d.fire:
+		d.alerts.Alert(domain.Alert{
+			Kind:    domain.AlertConnectivityLost,
+			Message: fmt.Sprintf("our nodes don't answer for %s", down),
+		})
+		return
+	case len(d.presigned) == 0:
+		d.alerts.Alert(domain.Alert{
+			Kind:    domain.AlertConnectivityLost,
+			Message: fmt.Sprintf("our nodes don't answer for %s, there's nothing held to sell", down),
+		})
+		return
+	case !d.backup.Enabled():
+		d.alerts.Alert(domain.Alert{
+			Kind: domain.AlertConnectivityLost,
+			Message: fmt.Sprintf(
+				"our nodes don't answer for %s, there's no backup route to fire %d emergency sells through",
+				down, len(d.presigned),
+			),
+		})
+		return
+	}
+
+	// the head keeps moving without us
+	head := d.head + uint64(down/d.blockTime)
+	fired := 0
+	for _, txs := range d.presigned {
+		if err := d.backup.Send(ctx, txs, head); err != nil {
+			log.Error(fmt.Sprintf("error firing emergency sell: %s", err))
+			continue
+		}
+		fired++
+	}
+	d.alerts.Alert(domain.Alert{
+		Kind: domain.AlertConnectivityLost,
+		Message: fmt.Sprintf(
+			"our nodes don't answer for %s, %d of %d emergency sells fired through the backup routes",
+			down, fired, len(d.presigned),
+		),
+	})
+	d.presigned = nil
+}
@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type (
+	fakeDeadManNodes struct {
+		down bool
+	}
+
+	fakeDeadManPortfolio struct{}
+
+	fakeDeadManSeller struct{}
+
+	fakeDeadManBackup struct {
+		heads []uint64
+	}
+
+	fakeDeadManAlerter struct {
+		alerts []domain.Alert
+	}
+)
+
+func (f *fakeDeadManNodes) HeaderByNumber(context.Context, *big.Int) (*types.Header, error) {
+	if f.down {
+		return nil, errors.New("connection refused")
+	}
+	return &types.Header{Number: big.NewInt(100)}, nil
+}
+
+func (fakeDeadManPortfolio) Holdings(context.Context) ([]domain.Holding, error) {
+	return []domain.Holding{
+		{Token: "0x01", Balance: big.NewInt(10), Balances: map[string]*big.Int{"0x02": big.NewInt(10)}},
+		{Token: "0x03", Balance: new(big.Int)},
+	}, nil
+}
+
+func (fakeDeadManSeller) Presign(context.Context, common.Address, common.Address, map[string]*big.Int, time.Time) ([][]*types.Transaction, error) {
+	return [][]*types.Transaction{{types.NewTransaction(1, common.Address{}, nil, 0, nil, nil)}}, nil
+}
+
+func (f *fakeDeadManBackup) Enabled() bool {
+	return true
+}
+
+func (f *fakeDeadManBackup) Send(_ context.Context, _ []*types.Transaction, head uint64) error {
+	f.heads = append(f.heads, head)
+	return nil
+}
+
+func (f *fakeDeadManAlerter) Alert(a domain.Alert) {
+	f.alerts = append(f.alerts, a)
+}
+
+func TestDeadManSwitch_Check(t *testing.T) {
+	tests := []struct {
+		name         string
+		fire         bool
+		down         time.Duration
+		expectKinds  []domain.AlertKind
+		expectFired  int
+		expectHeadAt uint64
+	}{
+		{"nodes answer", true, 0, nil, 0, 0},
+		{"below the threshold", true, 30 * time.Second, nil, 0, 0},
+		{"tripped", true, 90 * time.Second, []domain.AlertKind{domain.AlertConnectivityLost}, 1, 130},
+		{"tripped without firing", false, 90 * time.Second, []domain.AlertKind{domain.AlertConnectivityLost}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes, backup, alerts := new(fakeDeadManNodes), new(fakeDeadManBackup), new(fakeDeadManAlerter)
+			d := NewDeadManSwitch(nodes, fakeDeadManPortfolio{}, fakeDeadManSeller{}, backup, alerts, time.Minute, time.Second, 3*time.Second, tt.fire)
+
+			now := time.Now()
+			d.check(context.Background(), now)
+			if tt.down > 0 {
+				nodes.down = true
+				d.check(context.Background(), now.Add(tt.down))
+			}
+			if tt.down > time.Minute {
+				d.check(context.Background(), now.Add(tt.down+time.Minute)) // it only trips once
+			}
+
+			if len(alerts.alerts) != len(tt.expectKinds) {
+				t.Fatalf("expected alerts %v, got %+v", tt.expectKinds, alerts.alerts)
+			}
+			for i, k := range tt.expectKinds {
+				if alerts.alerts[i].Kind != k {
+					t.Fatalf("expected alerts %v, got %+v", tt.expectKinds, alerts.alerts)
+				}
+			}
+			if len(backup.heads) != tt.expectFired {
+				t.Fatalf("expected %d emergency sells fired, got %d", tt.expectFired, len(backup.heads))
+			}
+			if tt.expectFired > 0 && backup.heads[0] != tt.expectHeadAt {
+				t.Fatalf("expected guessing the head at %d, got %d", tt.expectHeadAt, backup.heads[0])
+			}
+		})
+	}
+}
+
+func TestDeadManSwitch_Restored(t *testing.T) {
+	nodes, alerts := new(fakeDeadManNodes), new(fakeDeadManAlerter)
+	d := NewDeadManSwitch(nodes, fakeDeadManPortfolio{}, fakeDeadManSeller{}, new(fakeDeadManBackup), alerts, time.Minute, time.Second, 3*time.Second, false)
+
+	now := time.Now()
+	d.check(context.Background(), now)
+	nodes.down = true
+	d.check(context.Background(), now.Add(2*time.Minute))
+	nodes.down = false
+	d.check(context.Background(), now.Add(3*time.Minute))
+
+	if len(alerts.alerts) != 2 || alerts.alerts[1].Kind != domain.AlertConnectivityRestored {
+		t.Fatalf("expected the connectivity to be restored, got %+v", alerts.alerts)
+	}
+}
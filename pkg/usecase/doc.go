@@ -0,0 +1,5 @@
+// Package usecase orchestrates the services: it routes the txs we see to their strategies (TransactionLanes,
+// TransactionClassifier), manages the targets while the bot runs (TargetManager), values what we hold (Portfolio),
+// follows the markets (Markets) and reserves (ReserveSeries) of what we sniped and runs the watchdogs (PriceAlerts,
+// DeadManSwitch, Heartbeat). It only knows the services through interfaces.
+package usecase
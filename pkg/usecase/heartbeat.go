@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+const (
+	// heartbeatStopTimeout is how long we wait to tell we stopped, as we are exiting
+	heartbeatStopTimeout = 5 * time.Second
+)
+
+type (
+	// Heartbeat tells every interval that we are alive and what we watch, through the alerts and an external check.
+	// When we exit it tells the heartbeats stopped. A crash can't tell anything, the external check alerts of it when
+	// it misses our pings.
+	Heartbeat struct {
+		targets  heartbeatTargets
+		alerts   heartbeatNotifier
+		check    heartbeatCheck
+		interval time.Duration
+	}
+
+	heartbeatTargets interface {
+		List(context.Context) ([]domain.Sniper, error)
+	}
+
+	heartbeatNotifier interface {
+		Alert(domain.Alert)
+		Notify(domain.Alert)
+	}
+
+	heartbeatCheck interface {
+		Ping(ctx context.Context, msg string) error
+		Fail(ctx context.Context, msg string) error
+	}
+)
+
+func NewHeartbeat(t heartbeatTargets, a heartbeatNotifier, c heartbeatCheck, i time.Duration) *Heartbeat {
+	return &Heartbeat{
+		targets:  t,
+		alerts:   a,
+		check:    c,
+		interval: i,
+	}
+}
+
+// Run the heartbeats until the context is done
+func (h *Heartbeat) Run(ctx context.Context) {
+	t := time.NewTicker(h.interval)
+	defer t.Stop()
+	for {
+		h.beat(ctx)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Stop tells the heartbeats stopped and why. It blocks for a while, as it's called on the way out.
+func (h *Heartbeat) Stop(reason string) {
+	msg := fmt.Sprintf("stopped, nothing is watched anymore: %s", reason)
+	h.alerts.Alert(domain.Alert{
+		Kind:    domain.AlertHeartbeatStopped,
+		Message: msg,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), heartbeatStopTimeout)
+	defer cancel()
+	if err := h.check.Fail(ctx, msg); err != nil {
+		log.Error(fmt.Sprintf("error failing the health check: %s", err))
+	}
+}
+
+func (h *Heartbeat) beat(ctx context.Context) {
+	var msg string
+	if targets, err := h.targets.List(ctx); err != nil {
+		msg = fmt.Sprintf("alive, but we can't list the targets: %s", err)
+	} else {
+		armed := 0
+		for _, t := range targets {
+			if t.Armed {
+				armed++
+			}
+		}
+		msg = fmt.Sprintf("alive and watching %d targets (%d armed)", len(targets), armed)
+	}
+
+	h.alerts.Notify(domain.Alert{
+		Kind:    domain.AlertHeartbeat,
+		Message: msg,
+	})
+	if err := h.check.Ping(ctx, msg); err != nil {
+		log.Warn(fmt.Sprintf("error pinging the health check: %s", err))
+	}
+}
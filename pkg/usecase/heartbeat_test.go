@@ -0,0 +1,95 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type (
+	fakeHeartbeatTargets struct {
+		err error
+	}
+
+	fakeHeartbeatNotifier struct {
+		alerts, notifications []domain.Alert
+	}
+
+	fakeHeartbeatCheck struct {
+		pings, fails []string
+	}
+)
+
+func (f fakeHeartbeatTargets) List(context.Context) ([]domain.Sniper, error) {
+	return []domain.Sniper{{Name: "a", Armed: true}, {Name: "b"}}, f.err
+}
+
+func (f *fakeHeartbeatNotifier) Alert(a domain.Alert) {
+	f.alerts = append(f.alerts, a)
+}
+
+func (f *fakeHeartbeatNotifier) Notify(a domain.Alert) {
+	f.notifications = append(f.notifications, a)
+}
+
+func (f *fakeHeartbeatCheck) Ping(_ context.Context, msg string) error {
+	f.pings = append(f.pings, msg)
+	return nil
+}
+
+func (f *fakeHeartbeatCheck) Fail(_ context.Context, msg string) error {
+	f.fails = append(f.fails, msg)
+	return nil
+}
+
+func TestHeartbeat_Beat(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		msg  string
+	}{
+		{
+			name: "counts the targets",
+			msg:  "alive and watching 2 targets (1 armed)",
+		},
+		{
+			name: "still beats if the targets can't be listed",
+			err:  errors.New("db down"),
+			msg:  "alive, but we can't list the targets: db down",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, c := new(fakeHeartbeatNotifier), new(fakeHeartbeatCheck)
+			h := NewHeartbeat(fakeHeartbeatTargets{err: tt.err}, n, c, 0)
+
+			h.beat(context.Background())
+
+			if len(n.notifications) != 1 || n.notifications[0].Kind != domain.AlertHeartbeat || n.notifications[0].Message != tt.msg {
+				t.Fatalf("notifications %+v, want one heartbeat %q", n.notifications, tt.msg)
+			}
+			if len(n.alerts) != 0 {
+				t.Fatalf("a heartbeat alerted: %+v", n.alerts)
+			}
+			if len(c.pings) != 1 || c.pings[0] != tt.msg {
+				t.Fatalf("pings %v, want %q", c.pings, tt.msg)
+			}
+		})
+	}
+}
+
+func TestHeartbeat_Stop(t *testing.T) {
+	n, c := new(fakeHeartbeatNotifier), new(fakeHeartbeatCheck)
+	h := NewHeartbeat(fakeHeartbeatTargets{}, n, c, 0)
+
+	h.Stop("the mempool stream closed")
+
+	if len(n.alerts) != 1 || n.alerts[0].Kind != domain.AlertHeartbeatStopped {
+		t.Fatalf("alerts %+v, want one stopped heartbeat", n.alerts)
+	}
+	if len(c.fails) != 1 {
+		t.Fatalf("fails %v, want the check failed once", c.fails)
+	}
+}
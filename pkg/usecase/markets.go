@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type (
+	// Markets keeps the market of the sniped tokens as reported by the pair trackers (chart links, volume, txns), and
+	// notifies it once a snipe fills so someone can assess the position at a glance. Trackers take a while to index
+	// a launch, so the notification waits for it.
+	Markets struct {
+		portfolio marketsPortfolio
+		source    marketsSource
+		alerts    marketsNotifier
+		interval  time.Duration
+
+		markets map[string]domain.Market
+		// trades of each position last time we checked, a position with more trades sniped again. Nil until the
+		// first check, so the positions we already had aren't notified.
+		trades map[marketsKey]uint
+		// pending notifications of tokens whose market isn't known yet, with the target that sniped them
+		pending map[string]string
+
+		mut *sync.RWMutex
+	}
+
+	marketsKey struct {
+		target, token string
+	}
+
+	marketsPortfolio interface {
+		Positions(context.Context) ([]domain.PositionValue, error)
+	}
+
+	marketsSource interface {
+		Market(ctx context.Context, token, paired common.Address) (domain.Market, bool, error)
+	}
+
+	marketsNotifier interface {
+		Notify(domain.Alert)
+	}
+)
+
+func NewMarkets(p marketsPortfolio, s marketsSource, a marketsNotifier, interval time.Duration) *Markets {
+	return &Markets{
+		portfolio: p,
+		source:    s,
+		alerts:    a,
+		interval:  interval,
+		markets:   make(map[string]domain.Market),
+		pending:   make(map[string]string),
+		mut:       new(sync.RWMutex),
+	}
+}
+
+// Run the refreshes of the markets until the context is done
+func (m *Markets) Run(ctx context.Context) {
+	t := time.NewTicker(m.interval)
+	defer t.Stop()
+	for {
+		m.refresh(ctx)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Market of the token, if it's known
+func (m *Markets) Market(token string) (domain.Market, bool) {
+	m.mut.RLock()
+	defer m.mut.RUnlock()
+	mk, ok := m.markets[strings.ToLower(token)]
+	return mk, ok
+}
+
+func (m *Markets) refresh(ctx context.Context) {
+	positions, err := m.portfolio.Positions(ctx)
+	if err != nil {
+		log.Error(fmt.Sprintf("error refreshing markets: %s", err))
+		return
+	}
+
+	first := m.trades == nil
+	if first {
+		m.trades = make(map[marketsKey]uint)
+	}
+	paired := make(map[string]string)
+	for _, p := range positions {
+		if len(p.Paired) == 0 {
+			continue
+		}
+		token := strings.ToLower(p.Token)
+		paired[token] = p.Paired
+
+		k := marketsKey{target: p.Target, token: token}
+		if p.Trades > m.trades[k] && !first {
+			m.pending[token] = p.Target
+		}
+		m.trades[k] = p.Trades
+	}
+
+	for token, pd := range paired {
+		mk, ok, err := m.source.Market(ctx, common.HexToAddress(token), common.HexToAddress(pd))
+		if err != nil {
+			log.Warn(fmt.Sprintf("error getting market of %s: %s", token, err))
+			continue
+		}
+		if !ok {
+			continue
+		}
+		m.mut.Lock()
+		m.markets[token] = mk
+		m.mut.Unlock()
+
+		if target, ok := m.pending[token]; ok {
+			delete(m.pending, token)
+			m.notify(target, mk)
+		}
+	}
+}
+
+func (m *Markets) notify(target string, mk domain.Market) {
+	var buf strings.Builder
+	_, _ = buf.WriteString(fmt.Sprintf(
+		"sniped %s at $%g (liquidity $%.0f, fdv $%.0f), 24h volume $%.0f in %d buys / %d sells",
+		mk.Token, mk.PriceUSD, mk.LiquidityUSD, mk.FDV, mk.VolumeH24, mk.BuysH24, mk.SellsH24,
+	))
+	for _, url := range []string{mk.DexscreenerURL, mk.DextoolsURL} {
+		if len(url) > 0 {
+			_, _ = buf.WriteString(" " + url)
+		}
+	}
+	m.alerts.Notify(domain.Alert{
+		Kind:    domain.AlertSnipeMarket,
+		Target:  target,
+		Message: buf.String(),
+	})
+}
@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type (
+	fakeMarketsPortfolio struct {
+		positions []domain.PositionValue
+	}
+
+	fakeMarketsSource struct {
+		tracked bool
+	}
+
+	fakeMarketsNotifier struct {
+		notifications []domain.Alert
+	}
+)
+
+func (f *fakeMarketsPortfolio) Positions(context.Context) ([]domain.PositionValue, error) {
+	return f.positions, nil
+}
+
+func (f *fakeMarketsSource) Market(_ context.Context, token, _ common.Address) (domain.Market, bool, error) {
+	if !f.tracked {
+		return domain.Market{}, false, nil
+	}
+	return domain.Market{Token: token.Hex(), PriceUSD: 0.5, DexscreenerURL: "https://dexscreener.com/bsc/0x01"}, true, nil
+}
+
+func (f *fakeMarketsNotifier) Notify(a domain.Alert) {
+	f.notifications = append(f.notifications, a)
+}
+
+func TestMarkets_Refresh(t *testing.T) {
+	const (
+		held   = "0x8f5d3374373aDA8b2c201C5cAc4c384FD42d2390"
+		sniped = "0x00000000000000000000000000000000000000c3"
+		paired = "0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c"
+	)
+	position := func(target, token string, trades uint) domain.PositionValue {
+		return domain.PositionValue{Position: domain.Position{Target: target, Token: token, Trades: trades}, Paired: paired}
+	}
+	portfolio := &fakeMarketsPortfolio{positions: []domain.PositionValue{position("a", held, 1)}}
+	source := &fakeMarketsSource{tracked: true}
+	notifier := &fakeMarketsNotifier{}
+	m := NewMarkets(portfolio, source, notifier, 0)
+
+	m.refresh(context.Background())
+	if len(notifier.notifications) > 0 {
+		t.Fatalf("expected the positions we already had not to be notified, got %v", notifier.notifications)
+	}
+	if _, ok := m.Market(sniped); ok {
+		t.Fatal("expected a token we don't hold not to have a market")
+	}
+	if mk, ok := m.Market(held); !ok || mk.PriceUSD != 0.5 {
+		t.Fatalf("expected the market of the held token, got %+v", mk)
+	}
+
+	// a snipe fills before the trackers index the pair
+	source.tracked = false
+	portfolio.positions = []domain.PositionValue{position("a", held, 1), position("b", sniped, 1)}
+	m.refresh(context.Background())
+	if len(notifier.notifications) > 0 {
+		t.Fatalf("expected to wait for the market to notify, got %v", notifier.notifications)
+	}
+
+	source.tracked = true
+	m.refresh(context.Background())
+	if len(notifier.notifications) != 1 {
+		t.Fatalf("expected a notification of the snipe, got %v", notifier.notifications)
+	}
+	if n := notifier.notifications[0]; n.Kind != domain.AlertSnipeMarket || n.Target != "b" ||
+		!strings.Contains(n.Message, "https://dexscreener.com/bsc/0x01") {
+		t.Fatalf("unexpected notification %+v", n)
+	}
+
+	m.refresh(context.Background())
+	if len(notifier.notifications) != 1 {
+		t.Fatalf("expected a single notification of the snipe, got %v", notifier.notifications)
+	}
+}
@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type (
+	// PanicSell market sells every token held across our wallets at once, bypassing any exit strategy
+	PanicSell struct {
+		portfolio panicSellPortfolio
+		seller    panicSellSeller
+		alerts    panicSellAlerter
+		// profits of the targets the realized sells are reinvested through, nil if they aren't. See ReinvestProfits.
+		profits panicSellProfits
+	}
+
+	panicSellPortfolio interface {
+		Holdings(context.Context) ([]domain.Holding, error)
+		Positions(context.Context) ([]domain.PositionValue, error)
+	}
+
+	panicSellSeller interface {
+		Sell(ctx context.Context, token, paired common.Address, balances map[string]*big.Int) []domain.Sale
+		Proceeds(ctx context.Context, sale domain.Sale, paired common.Address) (*big.Int, error)
+	}
+
+	panicSellProfits interface {
+		RealizeProfit(ctx context.Context, name string, profit *big.Int) (*big.Int, error)
+	}
+
+	panicSellAlerter interface {
+		Alert(domain.Alert)
+	}
+)
+
+func NewPanicSell(p panicSellPortfolio, s panicSellSeller, a panicSellAlerter) *PanicSell {
+	return &PanicSell{
+		portfolio: p,
+		seller:    s,
+		alerts:    a,
+	}
+}
+
+// ReinvestProfits of the targets once their sells are mined, so the reinvest policy compounds them. It must be done
+// before running any panic sell.
+func (p *PanicSell) ReinvestProfits(pr panicSellProfits) {
+	p.profits = pr
+}
+
+// Run the panic sell of everything held right now. Sales that couldn't be sent are reported with their error, so
+// they can be sold by hand.
+func (p *PanicSell) Run(ctx context.Context) ([]domain.Sale, error) {
+	holdings, err := p.portfolio.Holdings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting holdings: %s", err)
+	}
+	var positions []domain.PositionValue
+	if p.profits != nil {
+		if positions, err = p.portfolio.Positions(ctx); err != nil {
+			log.Warn(fmt.Sprintf("error getting positions, profits of the panic sell won't be reinvested: %s", err))
+		}
+	}
+
+	res := make([]domain.Sale, 0)
+	for _, h := range holdings {
+		if h.Balance == nil || h.Balance.Sign() == 0 {
+			continue
+		}
+		var paired common.Address
+		if len(h.Paired) > 0 {
+			paired = common.HexToAddress(h.Paired)
+		}
+		sales := p.seller.Sell(ctx, common.HexToAddress(h.Token), paired, h.Balances)
+		if len(positions) > 0 {
+			// the sells are mined way after the caller is answered
+			go p.realize(context.Background(), h, paired, sales, positions)
+		}
+		res = append(res, sales...)
+	}
+
+	failed := 0
+	for _, s := range res {
+		if len(s.Error) > 0 {
+			failed++
+		}
+	}
+	p.alerts.Alert(domain.Alert{
+		Kind:    domain.AlertPanicSell,
+		Message: fmt.Sprintf("panic selling everything: %d sells sent, %d failed", len(res)-failed, failed),
+	})
+	return res, nil
+}
+
+// realize the profits of the targets holding the token once its sales are mined. The proceeds are split across the
+// targets by the cost of their positions, as is the cost of what was sold.
+func (p *PanicSell) realize(ctx context.Context, h domain.Holding, paired common.Address, sales []domain.Sale, positions []domain.PositionValue) {
+	proceeds, sold := new(big.Int), new(big.Int)
+	for _, s := range sales {
+		if len(s.Tx) == 0 {
+			continue
+		}
+		v, err := p.seller.Proceeds(ctx, s, paired)
+		if err != nil {
+			log.Warn(fmt.Sprintf("error getting the proceeds of the sale of %s, its profit isn't reinvested: %s", s.Token, err))
+			continue
+		}
+		proceeds.Add(proceeds, v)
+		sold.Add(sold, s.Amount)
+	}
+	if sold.Sign() == 0 {
+		return
+	}
+
+	cost := new(big.Int)
+	held := make([]domain.PositionValue, 0, len(positions))
+	for _, pos := range positions {
+		if pos.Cost == nil || pos.Cost.Sign() == 0 || common.HexToAddress(pos.Token) != common.HexToAddress(h.Token) {
+			continue
+		}
+		cost.Add(cost, pos.Cost)
+		held = append(held, pos)
+	}
+	for _, pos := range held {
+		share := new(big.Int).Div(new(big.Int).Mul(proceeds, pos.Cost), cost)
+		spent := new(big.Int).Div(new(big.Int).Mul(pos.Cost, sold), h.Balance)
+		if spent.Cmp(pos.Cost) > 0 {
+			spent = pos.Cost
+		}
+		profit := share.Sub(share, spent)
+		if profit.Sign() <= 0 {
+			continue
+		}
+		if _, err := p.profits.RealizeProfit(ctx, pos.Target, profit); err != nil {
+			log.Error(fmt.Sprintf("error reinvesting the profit of %s: %s", pos.Target, err))
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type (
+	fakePanicSellSeller struct {
+		proceeds map[string]*big.Int
+	}
+
+	fakePanicSellProfits struct {
+		profits map[string]*big.Int
+	}
+)
+
+func (fakePanicSellSeller) Sell(context.Context, common.Address, common.Address, map[string]*big.Int) []domain.Sale {
+	return nil
+}
+
+func (f fakePanicSellSeller) Proceeds(_ context.Context, s domain.Sale, _ common.Address) (*big.Int, error) {
+	if v, ok := f.proceeds[s.Tx]; ok {
+		return v, nil
+	}
+	return nil, errors.New("reverted")
+}
+
+func (f *fakePanicSellProfits) RealizeProfit(_ context.Context, name string, profit *big.Int) (*big.Int, error) {
+	f.profits[name] = profit
+	return profit, nil
+}
+
+func TestPanicSell_Realize(t *testing.T) {
+	token := "0x8f5d3374373aDA8b2c201C5cAc4c384FD42d2390"
+	positions := []domain.PositionValue{
+		{Position: domain.Position{Target: "alice", Token: token, Cost: big.NewInt(300)}},
+		{Position: domain.Position{Target: "bob", Token: token, Cost: big.NewInt(100)}},
+		{Position: domain.Position{Target: "carol", Token: "0x01", Cost: big.NewInt(100)}},
+	}
+	holding := domain.Holding{Token: token, Balance: big.NewInt(1000)}
+
+	tests := []struct {
+		name          string
+		sales         []domain.Sale
+		expectProfits map[string]int64
+	}{
+		{
+			name: "all sold",
+			sales: []domain.Sale{
+				{Token: token, Amount: big.NewInt(600), Tx: "0xa"},
+				{Token: token, Amount: big.NewInt(400), Tx: "0xb"},
+			},
+			expectProfits: map[string]int64{"alice": 300, "bob": 100},
+		},
+		{
+			name: "half sold",
+			sales: []domain.Sale{
+				{Token: token, Amount: big.NewInt(600), Tx: "0xa"},
+				{Token: token, Amount: big.NewInt(400), Error: "no keys"},
+			},
+			expectProfits: map[string]int64{"alice": 180, "bob": 60},
+		},
+		{
+			name: "sold at a loss",
+			sales: []domain.Sale{
+				{Token: token, Amount: big.NewInt(1000), Tx: "0xb"},
+			},
+			expectProfits: map[string]int64{},
+		},
+		{
+			name: "reverted",
+			sales: []domain.Sale{
+				{Token: token, Amount: big.NewInt(1000), Tx: "0xc"},
+			},
+			expectProfits: map[string]int64{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profits := &fakePanicSellProfits{profits: make(map[string]*big.Int)}
+			p := NewPanicSell(nil, fakePanicSellSeller{proceeds: map[string]*big.Int{
+				"0xa": big.NewInt(480),
+				"0xb": big.NewInt(320),
+			}}, nil)
+			p.ReinvestProfits(profits)
+
+			p.realize(context.Background(), holding, common.Address{}, tt.sales, positions)
+			if len(profits.profits) != len(tt.expectProfits) {
+				t.Fatalf("expected profits %v, got %v", tt.expectProfits, profits.profits)
+			}
+			for name, v := range tt.expectProfits {
+				if profits.profits[name] == nil || profits.profits[name].Int64() != v {
+					t.Fatalf("expected profits %v, got %v", tt.expectProfits, profits.profits)
+				}
+			}
+		})
+	}
+}
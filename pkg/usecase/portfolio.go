@@ -0,0 +1,151 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type (
+	// Portfolio values the positions of the targets, and what our wallets hold, in the paired token they were bought with.
+	Portfolio struct {
+		trades   portfolioTradeRepository
+		targets  portfolioTargets
+		quoter   portfolioQuoter
+		balances portfolioBalances
+		wallets  []common.Address
+	}
+
+	portfolioTradeRepository interface {
+		Positions(context.Context) ([]domain.Position, error)
+	}
+
+	portfolioTargets interface {
+		List(context.Context) ([]domain.Sniper, error)
+	}
+
+	portfolioQuoter interface {
+		Quote(ctx context.Context, amount *big.Int, in, out common.Address) (*big.Int, error)
+	}
+
+	portfolioBalances interface {
+		BalanceOf(ctx context.Context, token, owner common.Address) (*big.Int, error)
+	}
+)
+
+func NewPortfolio(
+	t portfolioTradeRepository,
+	tg portfolioTargets,
+	q portfolioQuoter,
+	b portfolioBalances,
+	w []common.Address,
+) *Portfolio {
+
+	return &Portfolio{
+		trades:   t,
+		targets:  tg,
+		quoter:   q,
+		balances: b,
+		wallets:  w,
+	}
+}
+
+// Positions of all the targets, valued at the current reserves. Positions that can't be quoted are kept without value.
+func (p *Portfolio) Positions(ctx context.Context) ([]domain.PositionValue, error) {
+	positions, err := p.trades.Positions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting positions: %s", err)
+	}
+	targets, err := p.targets.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting targets: %s", err)
+	}
+	paired := make(map[string]string, len(targets))
+	for _, t := range targets {
+		paired[t.Name] = t.AddressTargetPaired
+	}
+
+	res := make([]domain.PositionValue, len(positions))
+	for i, pos := range positions {
+		res[i] = domain.PositionValue{Position: pos, Paired: paired[pos.Target]}
+		if len(res[i].Paired) == 0 || pos.Amount == nil || pos.Amount.Sign() == 0 {
+			continue
+		}
+		v, err := p.quoter.Quote(ctx, pos.Amount, common.HexToAddress(pos.Token), common.HexToAddress(res[i].Paired))
+		if err != nil {
+			log.Warn(fmt.Sprintf("error valuing position of %s in %s: %s", pos.Target, pos.Token, err))
+			continue
+		}
+		res[i].Value = v
+	}
+	return res, nil
+}
+
+// Holdings of the tokens of the targets (current or past) across all our wallets, valued at the current reserves.
+// Their cost is the one of the positions of all the targets in them, so the PnL is unrealized.
+func (p *Portfolio) Holdings(ctx context.Context) ([]domain.Holding, error) {
+	positions, err := p.trades.Positions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting positions: %s", err)
+	}
+	targets, err := p.targets.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting targets: %s", err)
+	}
+
+	holdings := make(map[common.Address]*domain.Holding)
+	tokens := make([]common.Address, 0)
+	holding := func(token string) *domain.Holding {
+		addr := common.HexToAddress(token)
+		if h, ok := holdings[addr]; ok {
+			return h
+		}
+		h := &domain.Holding{Token: addr.Hex(), Balances: make(map[string]*big.Int), Balance: new(big.Int)}
+		holdings[addr] = h
+		tokens = append(tokens, addr)
+		return h
+	}
+	for _, t := range targets {
+		holding(t.AddressTargetToken).Paired = t.AddressTargetPaired
+	}
+	for _, pos := range positions {
+		h := holding(pos.Token)
+		if pos.Cost != nil {
+			if h.Cost == nil {
+				h.Cost = new(big.Int)
+			}
+			h.Cost.Add(h.Cost, pos.Cost)
+		}
+	}
+
+	res := make([]domain.Holding, 0, len(tokens))
+	for _, token := range tokens {
+		h := holdings[token]
+		for _, w := range p.wallets {
+			b, err := p.balances.BalanceOf(ctx, token, w)
+			if err != nil {
+				return nil, err
+			}
+			if b.Sign() > 0 {
+				h.Balances[w.Hex()] = b
+				h.Balance.Add(h.Balance, b)
+			}
+		}
+
+		if h.Balance.Sign() > 0 && len(h.Paired) > 0 {
+			v, err := p.quoter.Quote(ctx, h.Balance, token, common.HexToAddress(h.Paired))
+			if err != nil {
+				log.Warn(fmt.Sprintf("error valuing holding of %s: %s", h.Token, err))
+			} else {
+				h.Value = v
+			}
+		}
+		res = append(res, *h)
+	}
+	return res, nil
+}
@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type (
+	// PriceAlerts watches the value of the held positions and alerts once each time one crosses a multiple of its cost,
+	// eg. 3 (it tripled) or 0.5 (it lost half). They only notify, they never trade.
+	PriceAlerts struct {
+		portfolio priceAlertsPortfolio
+		alerts    priceAlertsAlerter
+		multiples []float64
+		interval  time.Duration
+
+		// above tells, per position and multiple, if the position was at or above it last time we checked
+		above map[priceAlertKey]bool
+	}
+
+	priceAlertKey struct {
+		target, token string
+		multiple      float64
+	}
+
+	priceAlertsPortfolio interface {
+		Positions(context.Context) ([]domain.PositionValue, error)
+	}
+
+	priceAlertsAlerter interface {
+		Alert(domain.Alert)
+	}
+)
+
+func NewPriceAlerts(p priceAlertsPortfolio, a priceAlertsAlerter, m []float64, i time.Duration) *PriceAlerts {
+	return &PriceAlerts{
+		portfolio: p,
+		alerts:    a,
+		multiples: m,
+		interval:  i,
+		above:     make(map[priceAlertKey]bool),
+	}
+}
+
+// Run the alerts until the context is done
+func (a *PriceAlerts) Run(ctx context.Context) {
+	if len(a.multiples) == 0 {
+		return
+	}
+
+	t := time.NewTicker(a.interval)
+	defer t.Stop()
+	for {
+		a.check(ctx)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (a *PriceAlerts) check(ctx context.Context) {
+	positions, err := a.portfolio.Positions(ctx)
+	if err != nil {
+		log.Error(fmt.Sprintf("error checking price alerts: %s", err))
+		return
+	}
+
+	for _, p := range positions {
+		if p.Value == nil || p.Cost == nil || p.Cost.Sign() <= 0 {
+			continue
+		}
+		multiple, _ := new(big.Float).Quo(new(big.Float).SetInt(p.Value), new(big.Float).SetInt(p.Cost)).Float64()
+
+		for _, m := range a.multiples {
+			k := priceAlertKey{target: p.Target, token: p.Token, multiple: m}
+			above := multiple >= m
+			was, seen := a.above[k]
+			a.above[k] = above
+			// gains alert going above their multiple and losses going below it. A position we value for the first
+			// time (eg. after a restart) alerts if it's already beyond.
+			if (!seen || was != above) && above == (m >= 1) {
+				a.alert(p, m, multiple)
+			}
+		}
+	}
+}
+
+func (a *PriceAlerts) alert(p domain.PositionValue, m, multiple float64) {
+	a.alerts.Alert(domain.Alert{
+		Kind:   domain.AlertPrice,
+		Target: p.Target,
+		Message: fmt.Sprintf(
+			"position in %s crossed %gx of its cost, it's at %.2fx (PnL %.4f)",
+			p.Token, m, multiple, formatETHWeiToEther(p.PnL()),
+		),
+	})
+}
+
+func formatETHWeiToEther(v *big.Int) float64 {
+	f, _ := new(big.Float).Quo(new(big.Float).SetInt(v), big.NewFloat(1e18)).Float64()
+	return f
+}
@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type (
+	// ReserveSeries records the reserves of the pair of every sniped token at regular intervals, from the first snipe
+	// until some time after we exit (nothing is held anymore), so exit strategies can be evaluated against what the
+	// price actually did. A token bought again after exiting is recorded again. Exits are only known while running, so
+	// after a restart the tokens we already exited are recorded for that time again.
+	ReserveSeries struct {
+		holdings reserveSeriesHoldings
+		reserves reserveSeriesReserves
+		series   reserveSeriesRepository
+		interval time.Duration
+		after    time.Duration
+
+		// exits of the tokens nothing is held of anymore, when we first saw them empty
+		exits map[string]time.Time
+	}
+
+	reserveSeriesHoldings interface {
+		Holdings(context.Context) ([]domain.Holding, error)
+	}
+
+	reserveSeriesReserves interface {
+		Sample(ctx context.Context, token, paired common.Address) (domain.ReserveSample, error)
+	}
+
+	reserveSeriesRepository interface {
+		Save(context.Context, domain.ReserveSample) error
+	}
+)
+
+func NewReserveSeries(
+	h reserveSeriesHoldings,
+	r reserveSeriesReserves,
+	s reserveSeriesRepository,
+	interval, after time.Duration,
+) *ReserveSeries {
+
+	return &ReserveSeries{
+		holdings: h,
+		reserves: r,
+		series:   s,
+		interval: interval,
+		after:    after,
+		exits:    make(map[string]time.Time),
+	}
+}
+
+// Run the recording until the context is done
+func (s *ReserveSeries) Run(ctx context.Context) {
+	t := time.NewTicker(s.interval)
+	defer t.Stop()
+	for {
+		s.record(ctx, time.Now())
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *ReserveSeries) record(ctx context.Context, now time.Time) {
+	holdings, err := s.holdings.Holdings(ctx)
+	if err != nil {
+		log.Error(fmt.Sprintf("error recording reserves: %s", err))
+		return
+	}
+
+	for _, h := range holdings {
+		if h.Cost == nil || len(h.Paired) == 0 {
+			continue // never sniped
+		}
+		if !s.recording(h, now) {
+			continue
+		}
+
+		sample, err := s.reserves.Sample(ctx, common.HexToAddress(h.Token), common.HexToAddress(h.Paired))
+		if err != nil {
+			log.Warn(fmt.Sprintf("error sampling reserves of %s: %s", h.Token, err))
+			continue
+		}
+		sample.Held = h.Balance
+		if err := s.series.Save(ctx, sample); err != nil {
+			log.Error(fmt.Sprintf("error saving reserves of %s: %s", h.Token, err))
+		}
+	}
+}
+
+// recording tells if the token held is still recorded: while held, and until after the time we first saw it empty
+func (s *ReserveSeries) recording(h domain.Holding, now time.Time) bool {
+	if h.Balance != nil && h.Balance.Sign() > 0 {
+		delete(s.exits, h.Token)
+		return true
+	}
+	exit, ok := s.exits[h.Token]
+	if !ok {
+		exit = now
+		s.exits[h.Token] = exit
+		log.Info(fmt.Sprintf("exited %s, recording its reserves for %s more", h.Token, s.after))
+	}
+	return now.Sub(exit) <= s.after
+}
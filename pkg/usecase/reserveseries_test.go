@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+	"github.com/saantiaguilera/liquidity-sniper/pkg/repository"
+)
+
+const (
+	reserveSeriesToken  = "0x8f5d3374373aDA8b2c201C5cAc4c384FD42d2390"
+	reserveSeriesPaired = "0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c"
+	reserveSeriesOther  = "0x00000000000000000000000000000000000000c3"
+)
+
+type (
+	fakeReserveSeriesHoldings struct {
+		holdings []domain.Holding
+	}
+
+	fakeReserveSeriesReserves struct{}
+)
+
+func (f *fakeReserveSeriesHoldings) Holdings(context.Context) ([]domain.Holding, error) {
+	return f.holdings, nil
+}
+
+func (fakeReserveSeriesReserves) Sample(_ context.Context, token, paired common.Address) (domain.ReserveSample, error) {
+	return domain.ReserveSample{
+		Token:         token.Hex(),
+		Paired:        paired.Hex(),
+		ReserveToken:  big.NewInt(1000),
+		ReservePaired: big.NewInt(10),
+	}, nil
+}
+
+func TestReserveSeries_Record(t *testing.T) {
+	holdings := &fakeReserveSeriesHoldings{}
+	series := repository.NewMemoryReserves()
+	s := NewReserveSeries(holdings, fakeReserveSeriesReserves{}, series, time.Minute, time.Hour)
+	held := func(balance int64) {
+		holdings.holdings = []domain.Holding{
+			{Token: reserveSeriesToken, Paired: reserveSeriesPaired, Balance: big.NewInt(balance), Cost: big.NewInt(1)},
+			{Token: reserveSeriesOther, Paired: reserveSeriesPaired, Balance: new(big.Int)}, // a target never sniped
+		}
+	}
+	now := time.Now()
+
+	steps := []struct {
+		name    string
+		balance int64
+		at      time.Duration
+		expect  int
+	}{
+		{name: "held", balance: 100, at: 0, expect: 1},
+		{name: "still held", balance: 100, at: time.Minute, expect: 2},
+		{name: "exited", balance: 0, at: 2 * time.Minute, expect: 3},
+		{name: "within the hour after exiting", balance: 0, at: 62 * time.Minute, expect: 4},
+		{name: "over the hour after exiting", balance: 0, at: 63 * time.Minute, expect: 4},
+		{name: "bought again", balance: 50, at: 5 * time.Hour, expect: 5},
+		{name: "exited again", balance: 0, at: 6 * time.Hour, expect: 6},
+	}
+	for _, st := range steps {
+		held(st.balance)
+		s.record(context.Background(), now.Add(st.at))
+		samples, err := series.Series(context.Background(), reserveSeriesToken, time.Time{}, time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(samples) != st.expect {
+			t.Fatalf("%s: expected %d samples, got %d", st.name, st.expect, len(samples))
+		}
+		if last := samples[len(samples)-1]; last.Held.Cmp(big.NewInt(st.balance)) != 0 {
+			t.Fatalf("%s: expected the last sample to hold %d, got %s", st.name, st.balance, last.Held)
+		}
+	}
+	if other, _ := series.Series(context.Background(), reserveSeriesOther, time.Time{}, time.Time{}); len(other) > 0 {
+		t.Fatalf("expected tokens never sniped not to be recorded, got %v", other)
+	}
+}
@@ -0,0 +1,18 @@
+package usecase
+
+import "context"
+
+type sheddingCtxKey struct{}
+
+// Shedding marks the context of a tx taken while we are flooded (eg. the backlog of pending txs is above its
+// threshold), so only the critical path handles it: txs to the router still go through the filters, everything else
+// is dropped until the backlog drains.
+func Shedding(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sheddingCtxKey{}, true)
+}
+
+// IsShedding reports if the tx of the context was taken while flooded.
+func IsShedding(ctx context.Context) bool {
+	v, _ := ctx.Value(sheddingCtxKey{}).(bool)
+	return v
+}
@@ -0,0 +1,265 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type (
+	// TargetManager allows us to change what we snipe while the bot runs.
+	// Targets are kept in a repository, and each of them is run by the sniper instance registered with its name.
+	TargetManager struct {
+		repository targetManagerRepository
+		chainID    *big.Int
+		reinvest   domain.ReinvestPolicy
+		runners    map[string][]targetManagerRunner
+
+		// mut serializes changes, so the repository and the runners never diverge
+		mut *sync.Mutex
+	}
+
+	targetManagerRepository interface {
+		Get(context.Context, string) (domain.Sniper, error)
+		List(context.Context) ([]domain.Sniper, error)
+		Save(context.Context, domain.Sniper) error
+		Delete(context.Context, string) error
+	}
+
+	targetManagerRunner interface {
+		SetTarget(domain.Sniper) error
+	}
+)
+
+func NewTargetManager(r targetManagerRepository, chainID *big.Int, rp domain.ReinvestPolicy) *TargetManager {
+	return &TargetManager{
+		repository: r,
+		chainID:    chainID,
+		reinvest:   rp,
+		runners:    make(map[string][]targetManagerRunner),
+		mut:        new(sync.Mutex),
+	}
+}
+
+// Register the runners of the sniper instance with the given name. They get every change of its target.
+func (m *TargetManager) Register(name string, r ...targetManagerRunner) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	m.runners[name] = append(m.runners[name], r...)
+}
+
+// Restore the targets of the given instances from the repository. Instances without a stored target use (and store)
+// the given one.
+func (m *TargetManager) Restore(ctx context.Context, defaults ...domain.Sniper) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	stored, err := m.repository.List(ctx)
+	if err != nil {
+		return fmt.Errorf("error listing targets: %s", err)
+	}
+	byName := make(map[string]domain.Sniper, len(stored))
+	for _, sn := range stored {
+		byName[sn.Name] = sn
+	}
+
+	for _, sn := range defaults {
+		if s, ok := byName[sn.Name]; ok {
+			log.Info(fmt.Sprintf("restoring stored target of %s: %s", sn.Name, s.AddressTargetToken))
+			sn = s
+		} else if err := m.repository.Save(ctx, m.withChain(sn)); err != nil {
+			return fmt.Errorf("error saving target %s: %s", sn.Name, err)
+		}
+		if err := m.apply(sn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *TargetManager) Get(ctx context.Context, name string) (domain.Sniper, error) {
+	return m.repository.Get(ctx, name)
+}
+
+func (m *TargetManager) List(ctx context.Context) ([]domain.Sniper, error) {
+	return m.repository.List(ctx)
+}
+
+// Add a new target for the instance with its name. The instance must have no target.
+func (m *TargetManager) Add(ctx context.Context, sn domain.Sniper) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	if err := m.validate(sn); err != nil {
+		return err
+	}
+	if _, err := m.repository.Get(ctx, sn.Name); err == nil {
+		return fmt.Errorf("target %s already exists", sn.Name)
+	}
+	return m.save(ctx, sn)
+}
+
+// Update the target of an instance. It stays armed or disarmed as it was.
+func (m *TargetManager) Update(ctx context.Context, sn domain.Sniper) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	if err := m.validate(sn); err != nil {
+		return err
+	}
+	old, err := m.repository.Get(ctx, sn.Name)
+	if err != nil {
+		return fmt.Errorf("error getting target %s: %s", sn.Name, err)
+	}
+
+	sn.Armed = old.Armed
+	if sn.Reveal == nil && sameAddress(sn.AddressTrigger, old.AddressTrigger) &&
+		sameAddress(sn.AddressTargetToken, old.AddressTargetToken) &&
+		sameAddress(sn.AddressTargetPaired, old.AddressTargetPaired) {
+		// the order committed to the trigger is still valid for the same trigger / pair
+		sn.Reveal = old.Reveal
+	}
+	return m.save(ctx, sn)
+}
+
+// Arm the target, so its instance snipes it
+func (m *TargetManager) Arm(ctx context.Context, name string) error {
+	return m.setArmed(ctx, name, true)
+}
+
+// Disarm the target, so its instance ignores it until armed again
+func (m *TargetManager) Disarm(ctx context.Context, name string) error {
+	return m.setArmed(ctx, name, false)
+}
+
+// Delete the target. Its instance stays idle until a new target is added.
+func (m *TargetManager) Delete(ctx context.Context, name string) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	sn, err := m.repository.Get(ctx, name)
+	if err != nil {
+		return fmt.Errorf("error getting target %s: %s", name, err)
+	}
+	if err := m.repository.Delete(ctx, name); err != nil {
+		return fmt.Errorf("error deleting target %s: %s", name, err)
+	}
+	sn.Armed = false
+	return m.apply(sn)
+}
+
+// RealizeProfit of the target (eg. after selling what it bought), reinvesting the share of the policy into its budget.
+// It returns how much was reinvested.
+func (m *TargetManager) RealizeProfit(ctx context.Context, name string, profit *big.Int) (*big.Int, error) {
+	if profit == nil || profit.Sign() < 0 {
+		return nil, fmt.Errorf("invalid profit %v", profit)
+	}
+
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	sn, err := m.repository.Get(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("error getting target %s: %s", name, err)
+	}
+	budget, added := m.reinvest.Reinvest(sn.Budget, profit)
+	log.Info(fmt.Sprintf("target %s realized a profit of %s wei, reinvesting %s wei", name, profit.String(), added.String()))
+	if added.Sign() == 0 {
+		return added, nil
+	}
+	sn.Budget = budget
+	return added, m.save(ctx, sn)
+}
+
+func (m *TargetManager) setArmed(ctx context.Context, name string, armed bool) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	sn, err := m.repository.Get(ctx, name)
+	if err != nil {
+		return fmt.Errorf("error getting target %s: %s", name, err)
+	}
+	sn.Armed = armed
+	return m.save(ctx, sn)
+}
+
+func (m *TargetManager) save(ctx context.Context, sn domain.Sniper) error {
+	sn = m.withChain(sn)
+	prev, err := m.repository.Get(ctx, sn.Name)
+	stored := err == nil
+	if err := m.repository.Save(ctx, sn); err != nil {
+		return fmt.Errorf("error saving target %s: %s", sn.Name, err)
+	}
+	if err := m.apply(sn); err != nil {
+		m.rollback(ctx, sn, prev, stored)
+		return err
+	}
+	return nil
+}
+
+// rollback a target some runner couldn't take (eg. its orders can't be presigned), so the repository and the runners
+// keep the previous one. A target that wasn't stored before is deleted, leaving its instance idle.
+func (m *TargetManager) rollback(ctx context.Context, sn domain.Sniper, prev domain.Sniper, stored bool) {
+	var err error
+	if stored {
+		err = m.repository.Save(ctx, prev)
+	} else {
+		err = m.repository.Delete(ctx, sn.Name)
+		prev = sn
+		prev.Armed = false
+	}
+	if err != nil {
+		log.Error(fmt.Sprintf("error rolling back the stored target of %s: %s", sn.Name, err))
+	}
+
+	prev = m.withChain(prev)
+	for _, r := range m.runners[sn.Name] {
+		if err := r.SetTarget(prev); err != nil {
+			log.Error(fmt.Sprintf("error rolling back the target of %s: %s", sn.Name, err))
+		}
+	}
+}
+
+func (m *TargetManager) apply(sn domain.Sniper) error {
+	sn = m.withChain(sn)
+	for _, r := range m.runners[sn.Name] {
+		if err := r.SetTarget(sn); err != nil {
+			return fmt.Errorf("error setting target of %s: %s", sn.Name, err)
+		}
+	}
+	log.Info(fmt.Sprintf("target of %s is %s (armed: %t)", sn.Name, sn.AddressTargetToken, sn.Armed))
+	return nil
+}
+
+func (m *TargetManager) withChain(sn domain.Sniper) domain.Sniper {
+	sn.ChainID = m.chainID
+	if sn.MinimumLiquidity == nil {
+		sn.MinimumLiquidity = new(big.Int)
+	}
+	return sn
+}
+
+func (m *TargetManager) validate(sn domain.Sniper) error {
+	if _, ok := m.runners[sn.Name]; !ok {
+		return fmt.Errorf("there's no sniper instance named '%s' to run the target", sn.Name)
+	}
+	for _, a := range []string{sn.AddressTrigger, sn.AddressTargetToken, sn.AddressTargetPaired} {
+		if !common.IsHexAddress(a) {
+			return fmt.Errorf("invalid address '%s'", a)
+		}
+	}
+	if !sn.BuyMode.Valid() {
+		return fmt.Errorf("invalid buy mode '%s', must be %s or %s", sn.BuyMode, domain.BuyModeExactIn, domain.BuyModeExactOut)
+	}
+	return nil
+}
+
+func sameAddress(a, b string) bool {
+	return common.HexToAddress(a) == common.HexToAddress(b)
+}
@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type (
+	fakeTargetsRepository struct {
+		targets map[string]domain.Sniper
+	}
+
+	// fakeTargetsRunner failing to take the targets of the token it rejects
+	fakeTargetsRunner struct {
+		rejects string
+		targets []domain.Sniper
+	}
+)
+
+func (f *fakeTargetsRepository) Get(_ context.Context, name string) (domain.Sniper, error) {
+	sn, ok := f.targets[name]
+	if !ok {
+		return sn, errors.New("not found")
+	}
+	return sn, nil
+}
+
+func (f *fakeTargetsRepository) List(context.Context) ([]domain.Sniper, error) {
+	var l []domain.Sniper
+	for _, sn := range f.targets {
+		l = append(l, sn)
+	}
+	return l, nil
+}
+
+func (f *fakeTargetsRepository) Save(_ context.Context, sn domain.Sniper) error {
+	f.targets[sn.Name] = sn
+	return nil
+}
+
+func (f *fakeTargetsRepository) Delete(_ context.Context, name string) error {
+	delete(f.targets, name)
+	return nil
+}
+
+func (f *fakeTargetsRunner) SetTarget(sn domain.Sniper) error {
+	if sn.AddressTargetToken == f.rejects {
+		return errors.New("can't presign")
+	}
+	f.targets = append(f.targets, sn)
+	return nil
+}
+
+func (f *fakeTargetsRunner) current() domain.Sniper {
+	return f.targets[len(f.targets)-1]
+}
+
+func newTargetsTestSniper(token string) domain.Sniper {
+	return domain.Sniper{
+		Name:                "ax-50",
+		AddressTrigger:      "0x0000000000000000000000000000000000000001",
+		AddressTargetToken:  token,
+		AddressTargetPaired: "0x0000000000000000000000000000000000000002",
+		BuyMode:             domain.BuyModeExactIn,
+		Armed:               true,
+	}
+}
+
+func TestTargetManager_RunnerFailure(t *testing.T) {
+	const (
+		tokenA = "0x000000000000000000000000000000000000000A"
+		tokenB = "0x000000000000000000000000000000000000000B"
+	)
+
+	tests := []struct {
+		name string
+		// stored target before the change, none if empty
+		stored string
+		change func(*TargetManager, domain.Sniper) error
+		// expected target of the repository and the runners after the failure, none if empty
+		expectToken string
+		expectArmed bool
+	}{
+		{
+			name:   "update keeps the previous target",
+			stored: tokenA,
+			change: func(m *TargetManager, sn domain.Sniper) error {
+				return m.Update(context.Background(), sn)
+			},
+			expectToken: tokenA,
+			expectArmed: true,
+		},
+		{
+			name: "add leaves the instance idle",
+			change: func(m *TargetManager, sn domain.Sniper) error {
+				return m.Add(context.Background(), sn)
+			},
+			expectToken: tokenB,
+			expectArmed: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeTargetsRepository{targets: map[string]domain.Sniper{}}
+			ok, failing := &fakeTargetsRunner{}, &fakeTargetsRunner{rejects: tokenB}
+			m := NewTargetManager(repo, big.NewInt(56), domain.ReinvestPolicy{})
+			m.Register("ax-50", ok, failing)
+			if tt.stored != "" {
+				if err := m.Add(context.Background(), newTargetsTestSniper(tt.stored)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := tt.change(m, newTargetsTestSniper(tokenB)); err == nil {
+				t.Fatalf("expected the runner failure")
+			}
+
+			sn, err := repo.Get(context.Background(), "ax-50")
+			if tt.stored == "" && err == nil {
+				t.Fatalf("expected no stored target, got %s", sn.AddressTargetToken)
+			}
+			if tt.stored != "" && (err != nil || sn.AddressTargetToken != tt.stored) {
+				t.Fatalf("expected stored target %s, got %s (%v)", tt.stored, sn.AddressTargetToken, err)
+			}
+			if c := ok.current(); c.AddressTargetToken != tt.expectToken || c.Armed != tt.expectArmed {
+				t.Fatalf("expected runner target %s (armed: %t), got %s (armed: %t)", tt.expectToken, tt.expectArmed, c.AddressTargetToken, c.Armed)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package usecase
 import (
 	"context"
 	"fmt"
+	"runtime/debug"
 
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
@@ -14,6 +15,10 @@ type (
 
 		monitor    transactionClassifierMonitor
 		strategies map[[4]byte]TransactionClassifierStrategy
+		// routes of other contracts than the router (eg. the v3 position manager), with their own strategies
+		routes map[string]map[[4]byte]TransactionClassifierStrategy
+		// deploys of contracts, nil ignores them
+		deploys TransactionClassifierStrategy
 	}
 
 	transactionClassifierMonitor  func(ctx context.Context, tx *types.Transaction)
@@ -30,20 +35,37 @@ func NewTransactionClassifier(
 		routerAddr: raddr,
 		monitor:    m,
 		strategies: s,
+		routes:     make(map[string]map[[4]byte]TransactionClassifierStrategy),
 	}
 }
 
+// Route the calls to the contract with the given address to the given strategies, as the ones to the router.
+// It must be done before classifying any tx.
+func (u *TransactionClassifier) Route(addr string, s map[[4]byte]TransactionClassifierStrategy) {
+	u.routes[addr] = s
+}
+
+// Deploys of contracts go to the given strategy (eg. tokens adding liquidity in their constructor). It must be done
+// before classifying any tx.
+func (u *TransactionClassifier) Deploys(s TransactionClassifierStrategy) {
+	u.deploys = s
+}
+
 func (u *TransactionClassifier) Classify(ctx context.Context, tx *types.Transaction) error {
 	if tx.To() == nil {
 		log.Trace("tx is a contract deploy: " + tx.Hash().String())
+		if u.deploys != nil {
+			return u.deploys(ctx, tx)
+		}
 		return nil
 	}
 
 	u.monitor(ctx, tx)
 
-	if tx.To().Hex() == u.routerAddr && len(tx.Data()) >= 4 {
+	to := tx.To().Hex()
+	if data := tx.Data(); to == u.routerAddr && len(data) >= 4 {
 		txFunctionHash := [4]byte{}
-		copy(txFunctionHash[:], tx.Data()[:4])
+		copy(txFunctionHash[:], data[:4])
 
 		if h, ok := u.strategies[txFunctionHash]; ok {
 			return h(ctx, tx)
@@ -51,7 +73,52 @@ func (u *TransactionClassifier) Classify(ctx context.Context, tx *types.Transact
 		log.Debug("found contract call to provided router address but not to a method we are looking for: " + tx.Hash().String())
 		return nil
 	}
+	if s, ok := u.routes[to]; ok && len(tx.Data()) >= 4 {
+		txFunctionHash := [4]byte{}
+		copy(txFunctionHash[:], tx.Data()[:4])
+
+		if h, ok := s[txFunctionHash]; ok {
+			return h(ctx, tx)
+		}
+		log.Trace(fmt.Sprintf("tx %s to %s isn't to a method we are looking for", tx.Hash().String(), to))
+		return nil
+	}
 
 	log.Trace(fmt.Sprintf("tx %s doesn't apply", tx.Hash().String()))
 	return nil
 }
+
+// FanOut of strategies for the same tx. They run concurrently, so independent sniper instances never wait on each other.
+func FanOut(s ...TransactionClassifierStrategy) TransactionClassifierStrategy {
+	if len(s) == 1 {
+		return s[0]
+	}
+
+	return func(ctx context.Context, tx *types.Transaction) error {
+		errs := make(chan error, len(s))
+		for _, st := range s {
+			go func(st TransactionClassifierStrategy) {
+				err := fmt.Errorf("strategy panicked for tx %s", tx.Hash().String())
+				defer func() { errs <- err }()
+				defer func() {
+					if r := recover(); r != nil {
+						log.Error(fmt.Sprintf("panic recovered: %s %s", fmt.Errorf("%s", r), debug.Stack()))
+					}
+				}()
+				err = st(ctx, tx)
+			}(st)
+		}
+
+		var err error
+		for range s {
+			if e := <-errs; e != nil {
+				if err == nil {
+					err = e
+				} else {
+					err = fmt.Errorf("%s: %s", err, e)
+				}
+			}
+		}
+		return err
+	}
+}
@@ -0,0 +1,167 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+type (
+	// TransactionLanes splits txs in two lanes:
+	// - A fast lane for txs sent to an address we watch (eg. router / token), which are candidates for a snipe.
+	//   These are handled right away by the caller.
+	// - A slow lane for everything else, handled asynchronously by a bounded pool of workers. If the slow lane
+	//   can't keep up (eg. a mempool flood) its txs are dropped, so they never delay the evaluation of candidates.
+	// Contract deploys take the fast lane too if they are watched, as they may create the token we snipe.
+	// While shedding load (see Shedding) only txs to the router take the fast lane, the rest are dropped right away.
+	// Txs to the router of disarmed targets are dropped by their strategies before doing any work.
+	// The tokens of the targets are watched as they change, see Target.
+	TransactionLanes struct {
+		router  common.Address
+		watched map[common.Address]struct{}
+		deploys bool
+
+		// targets are the tokens watched of each sniper instance by name, and targeted the set of them (a
+		// map[common.Address]struct{}) swapped on every change so dispatching never locks
+		targets  map[string]common.Address
+		targeted atomic.Value
+		mut      *sync.Mutex
+
+		fast transactionLanesHandler
+		slow transactionLanesHandler
+
+		queue chan transactionLanesEntry
+	}
+
+	transactionLanesHandler func(context.Context, *types.Transaction) error
+
+	transactionLanesEntry struct {
+		ctx context.Context
+		tx  *types.Transaction
+	}
+
+	// TransactionLanesTarget watches the token of the target of a sniper instance
+	TransactionLanesTarget struct {
+		lanes *TransactionLanes
+		name  string
+	}
+)
+
+func NewTransactionLanes(
+	fast, slow transactionLanesHandler,
+	slowWorkers, slowQueue int,
+	router string,
+	watched ...string,
+) *TransactionLanes {
+
+	if slowWorkers <= 0 {
+		panic("slow workers > 0")
+	}
+
+	w := make(map[common.Address]struct{}, len(watched))
+	for _, v := range watched {
+		w[common.HexToAddress(v)] = struct{}{}
+	}
+
+	l := &TransactionLanes{
+		router:  common.HexToAddress(router),
+		watched: w,
+		targets: make(map[string]common.Address),
+		mut:     new(sync.Mutex),
+		fast:    fast,
+		slow:    slow,
+		queue:   make(chan transactionLanesEntry, slowQueue),
+	}
+	l.targeted.Store(map[common.Address]struct{}{})
+	for i := 0; i < slowWorkers; i++ {
+		go l.consumeSlowLane()
+	}
+	return l
+}
+
+// WatchDeploys of contracts, taking them in the fast lane. It must be done before dispatching any tx.
+func (l *TransactionLanes) WatchDeploys() {
+	l.deploys = true
+}
+
+// Target of the sniper instance with the given name, whose token is watched. Register it as a runner of the instance
+// so it follows its changes.
+func (l *TransactionLanes) Target(name string) *TransactionLanesTarget {
+	return &TransactionLanesTarget{
+		lanes: l,
+		name:  name,
+	}
+}
+
+// SetTarget of the instance, watching its token instead of the one of its previous target
+func (t *TransactionLanesTarget) SetTarget(sn domain.Sniper) error {
+	t.lanes.mut.Lock()
+	defer t.lanes.mut.Unlock()
+
+	t.lanes.targets[t.name] = common.HexToAddress(sn.AddressTargetToken)
+	targeted := make(map[common.Address]struct{}, len(t.lanes.targets))
+	for _, a := range t.lanes.targets {
+		targeted[a] = struct{}{}
+	}
+	t.lanes.targeted.Store(targeted)
+	return nil
+}
+
+// Dispatch the tx to its lane. Only the fast lane errors are returned, the slow lane ones are logged.
+func (l *TransactionLanes) Dispatch(ctx context.Context, tx *types.Transaction) error {
+	shedding := IsShedding(ctx)
+	if to := tx.To(); to != nil {
+		if *to == l.router {
+			return l.fast(ctx, tx)
+		}
+		if l.isWatched(*to) && !shedding {
+			return l.fast(ctx, tx)
+		}
+	} else if l.deploys && !shedding {
+		return l.fast(ctx, tx)
+	}
+
+	if shedding {
+		log.Trace(fmt.Sprintf("shedding load, dropping tx %s", tx.Hash().String()))
+		return nil
+	}
+	select {
+	case l.queue <- transactionLanesEntry{ctx: ctx, tx: tx}:
+	default:
+		log.Trace(fmt.Sprintf("slow lane full, dropping tx %s", tx.Hash().String()))
+	}
+	return nil
+}
+
+func (l *TransactionLanes) isWatched(a common.Address) bool {
+	if _, ok := l.watched[a]; ok {
+		return true
+	}
+	_, ok := l.targeted.Load().(map[common.Address]struct{})[a]
+	return ok
+}
+
+func (l *TransactionLanes) consumeSlowLane() {
+	for e := range l.queue {
+		l.handleSlow(e)
+	}
+}
+
+func (l *TransactionLanes) handleSlow(e transactionLanesEntry) {
+	defer func() {
+		if err := recover(); err != nil {
+			log.Error(fmt.Sprintf("panic recovered: %s %s", fmt.Errorf("%s", err), debug.Stack()))
+		}
+	}()
+	if err := l.slow(e.ctx, e.tx); err != nil {
+		log.Error(err.Error())
+	}
+}
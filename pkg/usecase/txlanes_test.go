@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"context"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/saantiaguilera/liquidity-sniper/pkg/domain"
+)
+
+func TestTransactionLanes_Dispatch(t *testing.T) {
+	router := common.HexToAddress("0x10ED43C718714eb63d5aA57B78B54704E256024E")
+	token := common.HexToAddress("0x0E09FaBB73Bd3Ade0a17ECC321fD13a19e81cE82")
+	other := common.HexToAddress("0x55d398326f99059fF775485246999027B3197955")
+
+	tests := []struct {
+		name       string
+		to         common.Address
+		shedding   bool
+		expectFast int32
+		expectSlow int32
+	}{
+		{"router", router, false, 1, 0},
+		{"watched", token, false, 1, 0},
+		{"other", other, false, 0, 1},
+		{"router while shedding", router, true, 1, 0},
+		{"watched while shedding", token, true, 0, 0},
+		{"other while shedding", other, true, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fast, slow int32
+			l := NewTransactionLanes(
+				func(context.Context, *types.Transaction) error { atomic.AddInt32(&fast, 1); return nil },
+				func(context.Context, *types.Transaction) error { atomic.AddInt32(&slow, 1); return nil },
+				1, 10,
+				router.Hex(),
+				token.Hex(),
+			)
+			ctx := context.Background()
+			if tt.shedding {
+				ctx = Shedding(ctx)
+			}
+
+			to := tt.to
+			if err := l.Dispatch(ctx, types.NewTx(&types.LegacyTx{GasPrice: big.NewInt(1), To: &to})); err != nil {
+				t.Fatal(err)
+			}
+			deadline := time.Now().Add(time.Second)
+			for atomic.LoadInt32(&slow) < tt.expectSlow && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			if f, s := atomic.LoadInt32(&fast), atomic.LoadInt32(&slow); f != tt.expectFast || s != tt.expectSlow {
+				t.Fatalf("expected %d fast and %d slow, got %d and %d", tt.expectFast, tt.expectSlow, f, s)
+			}
+		})
+	}
+}
+
+func TestTransactionLanes_WatchDeploys(t *testing.T) {
+	router := common.HexToAddress("0x10ED43C718714eb63d5aA57B78B54704E256024E")
+	var fast int32
+	l := NewTransactionLanes(
+		func(context.Context, *types.Transaction) error { atomic.AddInt32(&fast, 1); return nil },
+		func(context.Context, *types.Transaction) error { return nil },
+		1, 10,
+		router.Hex(),
+	)
+	deploy := types.NewTx(&types.LegacyTx{GasPrice: big.NewInt(1)})
+
+	if err := l.Dispatch(context.Background(), deploy); err != nil {
+		t.Fatal(err)
+	}
+	if f := atomic.LoadInt32(&fast); f != 0 {
+		t.Fatalf("expected deploys not watched to take the slow lane, got %d fast", f)
+	}
+	l.WatchDeploys()
+	if err := l.Dispatch(context.Background(), deploy); err != nil {
+		t.Fatal(err)
+	}
+	if f := atomic.LoadInt32(&fast); f != 1 {
+		t.Fatalf("expected watched deploys to take the fast lane, got %d fast", f)
+	}
+}
+
+func TestTransactionLanes_Target(t *testing.T) {
+	router := common.HexToAddress("0x10ED43C718714eb63d5aA57B78B54704E256024E")
+	old := common.HexToAddress("0x0E09FaBB73Bd3Ade0a17ECC321fD13a19e81cE82")
+	current := common.HexToAddress("0x55d398326f99059fF775485246999027B3197955")
+	var fast int32
+	l := NewTransactionLanes(
+		func(context.Context, *types.Transaction) error { atomic.AddInt32(&fast, 1); return nil },
+		func(context.Context, *types.Transaction) error { return nil },
+		1, 10,
+		router.Hex(),
+	)
+	dispatch := func(to common.Address) int32 {
+		before := atomic.LoadInt32(&fast)
+		if err := l.Dispatch(context.Background(), types.NewTx(&types.LegacyTx{GasPrice: big.NewInt(1), To: &to})); err != nil {
+			t.Fatal(err)
+		}
+		return atomic.LoadInt32(&fast) - before
+	}
+
+	target := l.Target("ax-50")
+	if err := target.SetTarget(domain.Sniper{Name: "ax-50", AddressTargetToken: old.Hex()}); err != nil {
+		t.Fatal(err)
+	}
+	if f := dispatch(old); f != 1 {
+		t.Fatalf("expected the target token to take the fast lane, got %d fast", f)
+	}
+	if err := target.SetTarget(domain.Sniper{Name: "ax-50", AddressTargetToken: current.Hex()}); err != nil {
+		t.Fatal(err)
+	}
+	if f := dispatch(current); f != 1 {
+		t.Fatalf("expected the new target token to take the fast lane, got %d fast", f)
+	}
+	if f := dispatch(old); f != 0 {
+		t.Fatalf("expected the previous target token to take the slow lane, got %d fast", f)
+	}
+}
@@ -0,0 +1,142 @@
+import {
+    chain, swarm, accounts, contract, token
+} from '../config/local.json';
+import * as fs from 'fs';
+import { ethers } from "ethers";
+import { BigNumber } from '@ethersproject/bignumber';
+import * as readline from 'readline';
+import { exit } from 'process';
+
+const { path } = swarm;
+const { admin } = accounts;
+
+// sellSlippage is the % below the quote we accept for each sell, tokens with a max wallet usually tax sells too
+const sellSlippage = 15;
+
+const bscProvider = new ethers.providers.JsonRpcProvider(
+    chain.nodes.configure,
+    {
+        chainId: chain.id,
+        name: chain.name,
+    }
+)
+
+let rl = readline.createInterface({
+    input: process.stdin,
+    output: process.stdout
+});
+
+interface Bee {
+    readonly pk: string
+    readonly addr: string
+}
+
+const erc20Abi = [
+    "function symbol() view returns (string)",
+    "function decimals() view returns (uint8)",
+    "function balanceOf(address who) public view returns (uint256)",
+    "function approve(address spender, uint256 amount) external returns (bool)",
+]
+
+const routerAbi = [
+    "function getAmountsOut(uint amountIn, address[] memory path) public view returns (uint[] memory amounts)",
+    "function swapExactTokensForETHSupportingFeeOnTransferTokens(uint amountIn, uint amountOutMin, address[] calldata path, address to, uint deadline) external",
+]
+
+// sellPath of the token back into BNB, through the paired token if it isn't WBNB
+function sellPath(): Array<string> {
+    if (token.pair_address.toLowerCase() == token.wbnb.toLowerCase()) {
+        return [token.address, token.wbnb]
+    }
+    return [token.address, token.pair_address, token.wbnb]
+}
+
+// sell all the tokens of the bee, sending the BNB to the admin wallet. Bees sell one after the other, as each sell
+// moves the price of the next one.
+async function sell(me: ethers.Wallet, bee: Bee, balance: BigNumber): Promise<void> {
+    const beeWallet = new ethers.Wallet(bee.pk, bscProvider)
+    const erc20 = new ethers.Contract(token.address, erc20Abi, beeWallet)
+    const router = new ethers.Contract(contract.router, routerAbi, beeWallet)
+    const gasPrice = await bscProvider.getGasPrice()
+
+    const approval = await erc20.approve(contract.router, balance, { gasPrice: gasPrice })
+    let receipt = await bscProvider.waitForTransaction(approval.hash);
+    if (receipt.status != 1) {
+        console.log(`  [WARNING] Approval ${approval.hash} failed at ${bee.addr}: ${JSON.stringify(receipt)}`)
+        return
+    }
+
+    const amounts: Array<BigNumber> = await router.getAmountsOut(balance, sellPath())
+    const minOut = amounts[amounts.length - 1].mul(100 - sellSlippage).div(100)
+    const tx = await router.swapExactTokensForETHSupportingFeeOnTransferTokens(
+        balance,
+        minOut,
+        sellPath(),
+        me.address,
+        Math.floor(Date.now() / 1000) + 120,
+        { gasPrice: gasPrice },
+    )
+    console.log(`  Tx selling for bee ${bee.addr}: ${tx.hash}`)
+    receipt = await bscProvider.waitForTransaction(tx.hash);
+    if (receipt.status != 1) {
+        console.log(`  [WARNING] Tx ${tx.hash} failed at ${bee.addr}: ${JSON.stringify(receipt)}`)
+    }
+}
+
+async function consolidateAll(holders: Array<[Bee, BigNumber]>): Promise<void> {
+    console.log("\n> Starting consolidation..")
+
+    const me = new ethers.Wallet(admin, bscProvider)
+    console.log(`  Owner wallet: ${me.address}`)
+    console.log(`  Owner wallet balance: ${ethers.utils.formatEther(await me.getBalance())} BNB`)
+
+    for (const [bee, balance] of holders) {
+        await sell(me, bee, balance)
+    }
+
+    console.log(`  New owner wallet balance: ${ethers.utils.formatEther(await me.getBalance())} BNB`)
+    console.log("\n> Consolidation finished. Run refund-swarm to get back the BNB left for gas.")
+}
+
+// checkConsolidation of the tokens a split order left across the swarm (see order.max_wallet)
+async function checkConsolidation(): Promise<void> {
+    if (!fs.existsSync(path)) {
+        console.log(`> Bee book doesn't exist`)
+        return
+    }
+
+    const book: Array<Bee> = JSON.parse(fs.readFileSync(path).toString())
+    const erc20 = new ethers.Contract(token.address, erc20Abi, bscProvider)
+    const tokenSymbol = await erc20.symbol()
+    const tokenDecimals = await erc20.decimals()
+
+    console.log(`> Looking in bee book for ${tokenSymbol}`)
+    const holders: Array<[Bee, BigNumber]> = []
+    let total = BigNumber.from(0)
+    for (const bee of book) {
+        const balance: BigNumber = await erc20.balanceOf(bee.addr)
+        if (balance.gt(0)) {
+            console.log(`  Account ${bee.addr} holds ${ethers.utils.formatUnits(balance, tokenDecimals)} ${tokenSymbol}`)
+            holders.push([bee, balance])
+            total = total.add(balance)
+        }
+    }
+
+    if (holders.length == 0) {
+        console.log(`No ${tokenSymbol} found.`)
+        exit(0)
+    }
+
+    rl.question(`\n> Found ${ethers.utils.formatUnits(total, tokenDecimals)} ${tokenSymbol} across ${holders.length} wallets. Sell them all into the admin wallet? [y/n]: `, async (answer) => {
+        switch(answer.toLowerCase()) {
+          case 'y':
+            await consolidateAll(holders)
+            break;
+          default:
+            console.log('  Consolidation canceled.');
+        }
+        rl.close();
+    });
+}
+
+checkConsolidation()
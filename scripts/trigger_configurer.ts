@@ -1,6 +1,7 @@
 import { 
-    chain, order, contract, token, accounts
+    chain, order, contract, token, accounts, sniper, swarm, previewer
 } from '../config/local.json';
+import * as fs from 'fs';
 import { ethers } from "ethers";
 import { BigNumber } from '@ethersproject/bignumber';
 import * as readline from 'readline';
@@ -8,7 +9,19 @@ import { TransactionRequest } from '@ethersproject/abstract-provider';
 
 const orderSize = order.size;
 const minimumTokens = order.expected_tokens;
+const maxWallet: number = (order as any).max_wallet || 0;
+const jitterPercentage: number = (order as any).jitter || 0;
+// exactOut orders buy exactly the expected tokens for at most the order size, instead of spending the whole size
+const exactOut = (order as any).mode == 'exact_out';
 const { admin } = accounts;
+// swapFeeBps the v2 pairs of the router charge, pancake's if it isn't configured. ax-50 simulates with the very same
+const swapFeeBps = Math.round(((contract as any).swap_fee || 0.25) * 100);
+// splitMargin is the share we keep each leg below the max wallet, as the liquidity added may not be the quoted one
+const splitMargin = 0.1;
+// where the jitter seed of the trigger is stored for ax-50, see readJitterSeed
+const jitterSeedPath = `./config/jitter_${contract.trigger.toLowerCase()}.json`;
+// tokenDecimals of the token to buy, read before configuring. Not every token has 18 (eg. 9 is common)
+let tokenDecimals = 18;
 
 const bscProvider = new ethers.providers.JsonRpcProvider(
     chain.nodes.configure,
@@ -27,6 +40,7 @@ async function applyConfiguration(
     token: ethers.Contract, 
     pair: string,
     orderAmount: BigNumber,
+    minTokens: BigNumber,
     trigger: ethers.Contract,
     triggerAdminWallet: ethers.Wallet,
     gasPrice: BigNumber,
@@ -37,30 +51,142 @@ async function applyConfiguration(
     console.log(`  Admin balance: ${((await triggerAdminWallet.getBalance()).div(BigNumber.from(10).pow(14)).toNumber() / 10000).toFixed(3)} BNB`)
     console.log(`  Trigger contract: ${contract.trigger}`)
 
-    // minTokens can have up to 3 decimal places in floating point in case token has low supply
-    const minTokens = BigNumber.from(minimumTokens * 1000).mul(BigNumber.from(10).pow(15))
+    let hash: string
+    if (sniper.commit_reveal.enabled) {
+        // only the hash of the order goes on-chain, ax-50 reveals it when sniping
+        const commitment = ethers.utils.solidityKeccak256(
+            ['address', 'uint256', 'address', 'uint256', 'bytes32'],
+            [pair, orderAmount, token.address, minTokens, sniper.commit_reveal.salt],
+        )
+        console.log(`  Committing order: ${commitment}`)
+        const tx = await (exactOut ? trigger.commitSnipeExactOut : trigger.commitSnipe)(
+            commitment,
+            {
+                from: triggerAdminWallet.address,
+                gasPrice: gasPrice,
+            }
+        )
+        hash = tx.hash
+    } else {
+        const tx = await (exactOut ? trigger.configureSnipeExactOut : trigger.configureSnipe)(
+            pair,
+            orderAmount,
+            token.address,
+            minTokens,
+            {
+                from: triggerAdminWallet.address,
+                gasPrice: gasPrice,
+            }
+        )
+        hash = tx.hash
+    }
 
-    const { hash } = await trigger.configureSnipe(
-        pair,
-        orderAmount,
-        token.address,
-        minTokens,
+    console.log(`\n> Trigger configuration submitted: ${hash}`)
+    const receipt = await bscProvider.waitForTransaction(hash);
+    if (receipt.status != 1) {
+        console.log(` [ERROR] Tx ${hash} failed: ${JSON.stringify(receipt)}`)
+        return false
+    }
+    console.log(`  Applied configuration succesfully.`)
+    return true
+}
+
+// quoteLeg is how many tokens (in wei) the first leg of an order split in legs buys. It's the biggest of them, as the
+// following ones buy at a worse price. The pair is quoted if it already has liquidity, else the liquidity of the previewer
+// is assumed (paired with WBNB).
+async function quoteLeg(orderAmount: BigNumber, legs: number): Promise<BigNumber> {
+    const legIn = orderAmount.div(legs)
+    const path = token.pair_address.toLowerCase() == token.wbnb.toLowerCase() ?
+        [token.wbnb, token.address] : [token.wbnb, token.pair_address, token.address]
+    const routerAbi = [
+        "function getAmountsOut(uint amountIn, address[] memory path) public view returns (uint[] memory amounts)",
+    ]
+    const router = new ethers.Contract(contract.router, routerAbi, bscProvider)
+    try {
+        const amounts: Array<BigNumber> = await router.getAmountsOut(legIn, path)
+        return amounts[amounts.length - 1]
+    } catch (e) {
+        // no pair or no liquidity yet, the usual case before the launch
+    }
+
+    const e15 = BigNumber.from(10).pow(15)
+    const rsvIn = BigNumber.from(Math.round(previewer.liquidity_in_bnb * 1000)).mul(e15)
+    const rsvOut = parseTokens(previewer.liquidity_in_token)
+    const inWithFee = legIn.mul(10000 - swapFeeBps)
+    return inWithFee.mul(rsvOut).div(rsvIn.mul(10000).add(inWithFee))
+}
+
+// parseTokens amount (up to 3 decimal places) into the smallest unit of the token to buy. ax-50 does the very same, so
+// the order it reveals is the one configured here
+function parseTokens(amount: number): BigNumber {
+    return BigNumber.from(Math.round(amount * 1000)).mul(BigNumber.from(10).pow(tokenDecimals)).div(1000)
+}
+
+// splitRecipients of the order, so each of them buys less than the max wallet of the token (with a safety margin). They
+// are the first bees of the swarm, as ax-50 already manages their nonces to exit the position later on. Empty if there's
+// no max wallet or the order fits in one. Exact out orders buy the very same tokens in each leg, so there's nothing to quote.
+async function splitRecipients(orderAmount: BigNumber, minTokens: BigNumber, maxWalletAmount: BigNumber): Promise<Array<string> | null> {
+    if (maxWalletAmount.isZero()) {
+        return []
+    }
+    const bees: Array<{ addr: string }> = JSON.parse(fs.readFileSync(swarm.path).toString())
+    const capped = maxWalletAmount.mul(Math.round((1 - splitMargin) * 1000)).div(1000)
+    for (let legs = 1; legs <= bees.length; legs++) {
+        const leg = exactOut ? minTokens.div(legs).add(minTokens.mod(legs)) : await quoteLeg(orderAmount, legs)
+        console.log(`  Split in ${legs}: first leg buys ${ethers.utils.formatUnits(leg, tokenDecimals)} tokens`)
+        if (leg.lte(capped)) {
+            return legs < 2 ? [] : bees.slice(0, legs).map(b => b.addr)
+        }
+    }
+    console.log(`  [ERROR] The order buys more than ${bees.length} wallets (the swarm) can hold with a max wallet of ${maxWallet}`)
+    return null
+}
+
+async function applyRecipients(
+    orderAmount: BigNumber,
+    minTokens: BigNumber,
+    trigger: ethers.Contract,
+    triggerAdminWallet: ethers.Wallet,
+    gasPrice: BigNumber,
+): Promise<boolean> {
+    const maxWalletAmount = parseTokens(maxWallet)
+    const recipients = await splitRecipients(orderAmount, minTokens, maxWalletAmount)
+    if (recipients === null) {
+        return false
+    }
+    if (recipients.length > 0) {
+        console.log(`\n> Splitting order across ${recipients.length} wallets (max wallet ${maxWallet})`)
+        recipients.forEach(r => console.log(`  ${r}`))
+    }
+
+    // the trigger checks every leg against the max wallet, if the liquidity is way off our quote the snipe reverts
+    const tx = await trigger.configureRecipients(
+        recipients,
+        recipients.length > 0 ? maxWalletAmount : 0,
         {
             from: triggerAdminWallet.address,
             gasPrice: gasPrice,
         }
     )
-
-    console.log(`\n> Trigger configuration submitted: ${hash}`)
-    const receipt = await bscProvider.waitForTransaction(hash);
+    const receipt = await bscProvider.waitForTransaction(tx.hash);
     if (receipt.status != 1) {
-        console.log(` [ERROR] Tx ${hash} failed: ${JSON.stringify(receipt)}`)
+        console.log(` [ERROR] Tx ${tx.hash} failed: ${JSON.stringify(receipt)}`)
         return false
     }
-    console.log(`  Applied configuration succesfully.`)
     return true
 }
 
+// jitter the amounts of the order by the same factor, picked by the seed in ±jitter%. ax-50 picks it the same way
+// (see domain.OrderJitter), so a committed order is revealed with the same amounts.
+function jitter(seed: ethers.utils.BytesLike, ...amounts: Array<BigNumber>): Array<BigNumber> {
+    const bps = Math.min(Math.round(jitterPercentage * 100), 9999)
+    if (bps <= 0) {
+        return amounts
+    }
+    const factor = BigNumber.from(ethers.utils.sha256(seed)).mod(2 * bps + 1).add(10000 - bps)
+    return amounts.map(a => a.mul(factor).div(10000))
+}
+
 async function supplyTrigger(
     orderAmount: BigNumber,
     trigger: ethers.Contract,
@@ -107,15 +233,35 @@ async function configureTrigger(token: ethers.Contract, pair: string): Promise<v
     const triggerAdminWallet = new ethers.Wallet(admin, bscProvider)
     const triggerAbi = [
         "function configureSnipe(address _tokenPaired, uint _amountIn, address _tknToBuy, uint _amountOutMin) external returns(bool)",
+        "function commitSnipe(bytes32 _commitment) external returns(bool)",
+        "function configureSnipeExactOut(address _tokenPaired, uint _amountInMax, address _tknToBuy, uint _amountOut) external returns(bool)",
+        "function commitSnipeExactOut(bytes32 _commitment) external returns(bool)",
+        "function configureRecipients(address[] _recipients, uint _maxWallet) external returns(bool)",
     ]
     const trigger = new ethers.Contract(contract.trigger, triggerAbi, triggerAdminWallet)
-    const orderAmount = BigNumber.from(orderSize * 1000).mul(BigNumber.from(10).pow(15)) // orderSize can have up to 3 decimal places
+    // orderSize and minimumTokens can have up to 3 decimal places
+    let orderAmount = BigNumber.from(orderSize * 1000).mul(BigNumber.from(10).pow(15))
+    let minTokens = parseTokens(minimumTokens)
+    if (jitterPercentage > 0) {
+        // a new seed each time, so the order never repeats. ax-50 reads it to know the order we configured
+        const seed = ethers.utils.hexlify(ethers.utils.randomBytes(32));
+        [orderAmount, minTokens] = jitter(seed, orderAmount, minTokens)
+        console.log(`  Jittered order: ${ethers.utils.formatEther(orderAmount)} BNB for at least ${ethers.utils.formatUnits(minTokens, tokenDecimals)} tokens`)
+        fs.writeFileSync(jitterSeedPath, JSON.stringify({ seed: seed }))
+    }
     const gasPrice = await bscProvider.getGasPrice()
 
-    let ok = await applyConfiguration(
+    let ok = await applyRecipients(orderAmount, minTokens, trigger, triggerAdminWallet, gasPrice)
+    if (!ok) {
+        console.log('[ERROR] Halting.')
+        return
+    }
+
+    ok = await applyConfiguration(
         token,
         pair,
         orderAmount,
+        minTokens,
         trigger,
         triggerAdminWallet,
         gasPrice,
@@ -135,14 +281,24 @@ async function configureTrigger(token: ethers.Contract, pair: string): Promise<v
 async function promptTrigger(): Promise<void> {
     const erc20Abi = [
         "function symbol() view returns (string)",
+        "function decimals() view returns (uint8)",
     ]
     const erc20 = new ethers.Contract(token.address, erc20Abi, bscProvider)
     const tokenSymbol = await erc20.symbol()
+    tokenDecimals = await erc20.decimals()
 
     console.log('> Preparing to configure trigger')
     console.log(`  Token to buy: ${erc20.address}`)
-    console.log(`  Order size: ${orderSize} BNB`)
-    console.log(`  Min buy: ${minimumTokens} ${tokenSymbol}`)
+    if (exactOut) {
+        console.log(`  Order size: at most ${orderSize} BNB`)
+        console.log(`  Exact buy: ${minimumTokens} ${tokenSymbol} (${tokenDecimals} decimals)`)
+    } else {
+        console.log(`  Order size: ${orderSize} BNB`)
+        console.log(`  Min buy: ${minimumTokens} ${tokenSymbol} (${tokenDecimals} decimals)`)
+    }
+    if (maxWallet > 0) {
+        console.log(`  Max wallet: ${maxWallet} ${tokenSymbol}`)
+    }
     console.log('[WARNING] Configuring a trigger will REMOVE any existing ones. Make sure the previous trigger has been already used.')
 
     rl.question(`\n> Configure new trigger? [y/n]: `, async (answer) => {